@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/rdb"
+)
+
+// prints keys of RDB file as json, one key per line
+func main() {
+	path := flag.String("file", "build/redis/data/dump.rdb", "path to RDB file")
+	flag.Parse()
+
+	file, err := os.Open(*path)
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	encoder := json.NewEncoder(out)
+	if err := rdb.Parse(file, func(entry *rdb.Entry) error {
+		return encoder.Encode(entry)
+	}); err != nil {
+		out.Flush()
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -10,7 +10,7 @@ require (
 	github.com/go-redis/redis/v8 v8.4.2
 	github.com/go-redis/redismock/v8 v8.0.0
 	github.com/gorilla/sessions v1.2.1
-	github.com/joho/godotenv v1.3.0 // indirect
+	github.com/joho/godotenv v1.3.0
 	github.com/stretchr/testify v1.6.1
 	golang.org/x/crypto v0.0.0-20201208171446-5f87f3452ae9
 	gopkg.in/boj/redistore.v1 v1.0.0-20160128113310-fc113767cd6b
@@ -18,7 +18,9 @@ func (r *router) ListHandler(c *gin.Context) {
 	type payload struct {
 		Key   interface{}   `json:"key" binding:"required"`
 		Value []interface{} `json:"value"`
-		TTL   int           `json:"ttl"`
+		// ttl and expire_at are forwarded as they are and validated by the server
+		TTL      json.RawMessage `json:"ttl,omitempty"`
+		ExpireAt json.RawMessage `json:"expire_at,omitempty"`
 	}
 
 	type request struct {
@@ -68,9 +70,10 @@ func (r *router) ListHandler(c *gin.Context) {
 
 func (r *router) StringHandler(c *gin.Context) {
 	type payload struct {
-		Key   interface{} `json:"key" binding:"required"`
-		Value string      `json:"value"`
-		TTL   int         `json:"ttl"`
+		Key      interface{}     `json:"key" binding:"required"`
+		Value    string          `json:"value"`
+		TTL      json.RawMessage `json:"ttl,omitempty"`
+		ExpireAt json.RawMessage `json:"expire_at,omitempty"`
 	}
 
 	type request struct {
@@ -120,9 +123,10 @@ func (r *router) StringHandler(c *gin.Context) {
 
 func (r *router) MapHandler(c *gin.Context) {
 	type payload struct {
-		Key   interface{}            `json:"key" binding:"required"`
-		Value map[string]interface{} `json:"value"`
-		TTL   int                    `json:"ttl"`
+		Key      interface{}            `json:"key" binding:"required"`
+		Value    map[string]interface{} `json:"value"`
+		TTL      json.RawMessage        `json:"ttl,omitempty"`
+		ExpireAt json.RawMessage        `json:"expire_at,omitempty"`
 	}
 
 	type request struct {
@@ -1,24 +1,569 @@
 package models
 
-// SetHashRequest ...
+import "encoding/json"
+
+// SetHashRequest - values keep their JSON types, with raw they are stored as plain strings like in redis
 type SetHashRequest struct {
 	Key   interface{}            `json:"key" binding:"required"`
 	Value map[string]interface{} `json:"value" binding:"required"`
-	TTL   int                    `json:"ttl"`
+	Raw   bool                   `json:"raw"`
+	Expiration
 }
 
 // SetListRequest ...
 type SetListRequest struct {
 	Key   interface{}   `json:"key" binding:"required"`
 	Value []interface{} `json:"value" binding:"required"`
-	TTL   int           `json:"ttl"`
+	Expiration
 }
 
-// SetStringRequest ...
+// SetStringRequest - value may be empty, options are the same as of SET
 type SetStringRequest struct {
 	Key   interface{} `json:"key" binding:"required"`
-	Value string      `json:"value" binding:"required"`
-	TTL   int         `json:"ttl"`
+	Value *string     `json:"value" binding:"required"`
+	SetOptions
+}
+
+// SetOptions - options of SET. Time to live is set by one of TTL, EXPIRE_AT, EXAT in unix seconds, PXAT in
+// unix milliseconds or KEEPTTL, without them time to live of the key is discarded.
+type SetOptions struct {
+	Expiration
+	ExAt    int64 `json:"exat"`
+	PxAt    int64 `json:"pxat"`
+	KeepTTL bool  `json:"keepttl"`
+	// NX - set only if the key does not exist, XX - only if it exists
+	NX bool `json:"nx"`
+	XX bool `json:"xx"`
+	// Get - the previous value is returned instead of OK
+	Get bool `json:"get"`
+}
+
+// GetExOptions - time to live set by GETEX, the same as of SET, or PERSIST which removes it. Without options
+// GETEX is the same as GET.
+type GetExOptions struct {
+	Expiration
+	ExAt    int64 `json:"exat"`
+	PxAt    int64 `json:"pxat"`
+	Persist bool  `json:"persist"`
+}
+
+// GetExRequest ...
+type GetExRequest struct {
+	Key interface{} `json:"key" binding:"required"`
+	GetExOptions
+}
+
+// ExpireOptions - new time to live of the key: TTL, EXPIRE_AT, EXAT in unix seconds or PXAT in unix milliseconds.
+// Time in the past removes the key.
+type ExpireOptions struct {
+	Expiration
+	ExAt int64 `json:"exat"`
+	PxAt int64 `json:"pxat"`
+}
+
+// ExpireRequest ...
+type ExpireRequest struct {
+	Key interface{} `json:"key" binding:"required"`
+	ExpireOptions
+}
+
+// ExistsQuery - the same key given several times is counted several times, like in redis
+type ExistsQuery struct {
+	Keys []string `form:"keys" binding:"required"`
+}
+
+// StringValueRequest - value appended by APPEND or set by GETSET
+type StringValueRequest struct {
+	Key   interface{} `json:"key" binding:"required"`
+	Value *string     `json:"value" binding:"required"`
+}
+
+// SetRangeRequest - value written by SETRANGE at the offset
+type SetRangeRequest struct {
+	Key    interface{} `json:"key" binding:"required"`
+	Offset int64       `json:"offset"`
+	Value  *string     `json:"value" binding:"required"`
+}
+
+// GetRangeQuery - substring between start and end inclusive, negative offsets are counted from the end
+type GetRangeQuery struct {
+	Key   string `form:"key" binding:"required"`
+	Start *int64 `form:"start" binding:"required"`
+	End   *int64 `form:"end" binding:"required"`
+}
+
+// IncrRequest - increment of the integer stored by the key, INCR and DECR do not use it
+type IncrRequest struct {
+	Key       interface{} `json:"key" binding:"required"`
+	Increment *int64      `json:"increment"`
+}
+
+// IncrByFloatRequest - increment of the float stored by the key
+type IncrByFloatRequest struct {
+	Key       interface{} `json:"key" binding:"required"`
+	Increment *float64    `json:"increment" binding:"required"`
+}
+
+// SetMembersRequest - members added to or removed from the set
+type SetMembersRequest struct {
+	Key     interface{} `json:"key" binding:"required"`
+	Members []string    `json:"members" binding:"required"`
+}
+
+// SetStoreRequest - result of the operation on sets stored by keys is saved in destination
+type SetStoreRequest struct {
+	Destination string   `json:"destination" binding:"required"`
+	Keys        []string `json:"keys" binding:"required"`
+}
+
+// ZMember - member of sorted set with its score
+type ZMember struct {
+	Member string  `json:"member" binding:"required"`
+	Score  float64 `json:"score"`
+}
+
+// ZAddOptions - conditions of update of sorted set, the same as flags of ZADD
+type ZAddOptions struct {
+	// only add new members
+	NX bool `json:"nx"`
+	// only update existing members
+	XX bool `json:"xx"`
+	// only update existing members if the new score is greater or less than the current one
+	GT bool `json:"gt"`
+	LT bool `json:"lt"`
+	// number of changed members is returned instead of number of added ones
+	CH bool `json:"ch"`
+}
+
+// ZAddRequest - with incr the score of the only member is incremented and the new score is returned
+type ZAddRequest struct {
+	Key     interface{} `json:"key" binding:"required"`
+	Members []ZMember   `json:"members" binding:"required,dive"`
+	Incr    bool        `json:"incr"`
+	ZAddOptions
+}
+
+// ZRemRequest ...
+type ZRemRequest struct {
+	Key     interface{} `json:"key" binding:"required"`
+	Members []string    `json:"members" binding:"required"`
+}
+
+// ZPopRequest - count members with the lowest or the highest scores are removed, 1 by default
+type ZPopRequest struct {
+	Key   interface{} `json:"key" binding:"required"`
+	Count int64       `json:"count"`
+}
+
+// ZRangeQuery - members between start and stop by index (default), score or lex. Bounds of scores and lex
+// have the same syntax as in redis, e.g. (1.5, -inf, [a or +. With rev start is the highest bound.
+type ZRangeQuery struct {
+	Start string `form:"start" binding:"required"`
+	Stop  string `form:"stop" binding:"required"`
+	By    string `form:"by"`
+	Rev   bool   `form:"rev"`
+	// members skipped and number of returned members for ranges by score and lex, 0 count - no limit
+	Offset int64 `form:"offset"`
+	Count  int64 `form:"count"`
+}
+
+// ZStore - sorted sets combined by ZUNION and ZINTER, scores are multiplied by weights and
+// aggregated by sum (default), min or max
+type ZStore struct {
+	Keys      []string  `form:"keys" json:"keys" binding:"required"`
+	Weights   []float64 `form:"weights" json:"weights"`
+	Aggregate string    `form:"aggregate" json:"aggregate"`
+}
+
+// StreamEntry - entry of stream, values of fields are typed the same way as elements of lists
+type StreamEntry struct {
+	ID     string                 `json:"id"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// XTrim - entries are removed until the stream has at most maxlen entries or while their ids are less than minid
+type XTrim struct {
+	MaxLen *int64 `json:"maxlen"`
+	MinID  string `json:"minid"`
+}
+
+// XAddRequest - id is generated unless it is given, the stream is trimmed after the entry is added
+type XAddRequest struct {
+	Key    interface{}            `json:"key" binding:"required"`
+	ID     string                 `json:"id"`
+	Fields map[string]interface{} `json:"fields" binding:"required"`
+	XTrim
+}
+
+// XTrimRequest ...
+type XTrimRequest struct {
+	Key interface{} `json:"key" binding:"required"`
+	XTrim
+}
+
+// XRangeQuery - bounds are ids, "-" or "+", "(" before the id makes the bound exclusive. With XREVRANGE
+// start is the highest bound. 0 count - no limit
+type XRangeQuery struct {
+	Start string `form:"start"`
+	End   string `form:"end"`
+	Count int64  `form:"count"`
+}
+
+// XReadQuery - entries of streams with ids greater than ids of the same position, "$" is the last id of the stream
+type XReadQuery struct {
+	Keys  []string `form:"keys" binding:"required"`
+	IDs   []string `form:"ids" binding:"required"`
+	Count int64    `form:"count"`
+}
+
+// XStream - entries read from the stream
+type XStream struct {
+	Stream  string        `json:"stream"`
+	Entries []StreamEntry `json:"entries"`
+}
+
+// XGroupCreateRequest - the group starts after id, "$" (default) is the last id of the stream. With mkstream
+// empty stream is created if there is no such key.
+type XGroupCreateRequest struct {
+	Key      interface{} `json:"key" binding:"required"`
+	Group    string      `json:"group" binding:"required"`
+	ID       string      `json:"id"`
+	MkStream bool        `json:"mkstream"`
+}
+
+// XReadGroupRequest - ">" (default) reads entries never delivered to the group, other ids read entries
+// pending for the consumer. With noack read entries are not added to pending entries list.
+type XReadGroupRequest struct {
+	Group    string   `json:"group" binding:"required"`
+	Consumer string   `json:"consumer" binding:"required"`
+	Keys     []string `json:"keys" binding:"required"`
+	IDs      []string `json:"ids"`
+	Count    int64    `json:"count"`
+	NoAck    bool     `json:"noack"`
+}
+
+// XAckRequest ...
+type XAckRequest struct {
+	Key   interface{} `json:"key" binding:"required"`
+	Group string      `json:"group" binding:"required"`
+	IDs   []string    `json:"ids" binding:"required"`
+}
+
+// XPending - summary of pending entries of the group, consumers with numbers of their pending entries
+type XPending struct {
+	Count     int64            `json:"count"`
+	Lower     string           `json:"lower"`
+	Higher    string           `json:"higher"`
+	Consumers map[string]int64 `json:"consumers"`
+}
+
+// XPendingQuery - pending entries between start and end, optionally of one consumer
+type XPendingQuery struct {
+	Group    string `form:"group" binding:"required"`
+	Start    string `form:"start"`
+	End      string `form:"end"`
+	Count    int64  `form:"count"`
+	Consumer string `form:"consumer"`
+}
+
+// XPendingEntry - idle is time in milliseconds since the last delivery
+type XPendingEntry struct {
+	ID         string `json:"id"`
+	Consumer   string `json:"consumer"`
+	Idle       int64  `json:"idle"`
+	RetryCount int64  `json:"retry_count"`
+}
+
+// XClaim - pending entries idle for at least min_idle milliseconds are transferred to the consumer
+type XClaim struct {
+	Group    string   `json:"group" binding:"required"`
+	Consumer string   `json:"consumer" binding:"required"`
+	MinIdle  int64    `json:"min_idle"`
+	IDs      []string `json:"ids" binding:"required"`
+}
+
+// XClaimRequest ...
+type XClaimRequest struct {
+	Key interface{} `json:"key" binding:"required"`
+	XClaim
+}
+
+// XAutoClaim - the same as XClaim, but pending entries are scanned starting from start ("0-0" by default),
+// count is 100 by default
+type XAutoClaim struct {
+	Group    string `json:"group" binding:"required"`
+	Consumer string `json:"consumer" binding:"required"`
+	MinIdle  int64  `json:"min_idle"`
+	Start    string `json:"start"`
+	Count    int64  `json:"count"`
+}
+
+// XAutoClaimRequest ...
+type XAutoClaimRequest struct {
+	Key interface{} `json:"key" binding:"required"`
+	XAutoClaim
+}
+
+// XAutoClaimResult - claimed entries, next is the id to continue scanning with, "0-0" when the scan is complete
+type XAutoClaimResult struct {
+	Next    string        `json:"next"`
+	Entries []StreamEntry `json:"entries"`
+}
+
+// PublishRequest ...
+type PublishRequest struct {
+	Channel string `json:"channel" binding:"required"`
+	Message string `json:"message"`
+}
+
+// SubscribeQuery - channels of SUBSCRIBE or patterns of PSUBSCRIBE
+type SubscribeQuery struct {
+	Channels []string `form:"channels" binding:"required"`
+}
+
+// Message - message received by the subscriber, pattern is set when the message matched the pattern subscription
+type Message struct {
+	Channel string `json:"channel"`
+	Pattern string `json:"pattern,omitempty"`
+	Payload string `json:"message"`
+}
+
+// TxCommand - command of transaction with its arguments, e.g. {"command":"hset","args":["user:1","name","Ivan"]}
+type TxCommand struct {
+	Command string   `json:"command" binding:"required"`
+	Args    []string `json:"args"`
+}
+
+// TxRequest - commands executed atomically. The transaction is aborted if watched keys are modified by other
+// clients before it is executed.
+type TxRequest struct {
+	Watch    []string    `json:"watch" binding:"dive,required"`
+	Commands []TxCommand `json:"commands" binding:"required,min=1,dive"`
+}
+
+// TxResult - result of the command of transaction, failed command does not stop the following ones, like in redis
+type TxResult struct {
+	Result interface{} `json:"result"`
+	Error  string      `json:"error"`
+}
+
+// HDelRequest - fields removed from the hash
+type HDelRequest struct {
+	Key    interface{} `json:"key" binding:"required"`
+	Fields []string    `json:"fields" binding:"required,min=1"`
+}
+
+// HFieldQuery - ?key=name&field=role
+type HFieldQuery struct {
+	Key   string `form:"key" binding:"required"`
+	Field string `form:"field" binding:"required"`
+}
+
+// HMGetQuery - ?key=name&fields=role&fields=age, null is returned for the missing field
+type HMGetQuery struct {
+	Key    string   `form:"key" binding:"required"`
+	Fields []string `form:"fields" binding:"required"`
+	Raw    bool     `form:"raw"`
+}
+
+// HSetNXRequest - the field is set only if the hash has no such field. Value is kept as JSON, so that null
+// is told from the missing value.
+type HSetNXRequest struct {
+	Key   interface{}     `json:"key" binding:"required"`
+	Field string          `json:"field" binding:"required"`
+	Value json.RawMessage `json:"value" binding:"required"`
+	Raw   bool            `json:"raw"`
+}
+
+// HIncrByRequest - increment of the integer stored by the field, the missing field is created typed unless
+// raw is set
+type HIncrByRequest struct {
+	Key       interface{} `json:"key" binding:"required"`
+	Field     string      `json:"field" binding:"required"`
+	Increment *int64      `json:"increment" binding:"required"`
+	Raw       bool        `json:"raw"`
+}
+
+// HIncrByFloatRequest - increment of the float stored by the field, see HIncrByRequest
+type HIncrByFloatRequest struct {
+	Key       interface{} `json:"key" binding:"required"`
+	Field     string      `json:"field" binding:"required"`
+	Increment *float64    `json:"increment" binding:"required"`
+	Raw       bool        `json:"raw"`
+}
+
+// ListPushRequest - values are pushed one by one, so that LPUSH reverses their order
+type ListPushRequest struct {
+	Key   interface{}   `json:"key" binding:"required"`
+	Value []interface{} `json:"value" binding:"required,min=1"`
+}
+
+// ListPopRequest - without count one element is popped and returned as is, with count the list of elements
+// is returned
+type ListPopRequest struct {
+	Key   interface{} `json:"key" binding:"required"`
+	Count int64       `json:"count"`
+}
+
+// LInsertRequest - value is inserted before (default) or after the first element equal to pivot
+type LInsertRequest struct {
+	Key   interface{} `json:"key" binding:"required"`
+	After bool        `json:"after"`
+	Pivot interface{} `json:"pivot" binding:"required"`
+	Value interface{} `json:"value" binding:"required"`
+}
+
+// LRemRequest - count elements equal to value are removed from the head, negative count removes them
+// from the tail, 0 removes all of them
+type LRemRequest struct {
+	Key   interface{} `json:"key" binding:"required"`
+	Count int64       `json:"count"`
+	Value interface{} `json:"value" binding:"required"`
+}
+
+// LTrimRequest - only elements between start and stop are kept
+type LTrimRequest struct {
+	Key   interface{} `json:"key" binding:"required"`
+	Start int64       `json:"start"`
+	Stop  int64       `json:"stop"`
+}
+
+// LIndexQuery - negative index is counted from the tail
+type LIndexQuery struct {
+	Key   string `form:"key" binding:"required"`
+	Index *int64 `form:"index" binding:"required"`
+}
+
+// LPosArgs - rank is the number of the first returned match, negative rank searches from the tail, 0 rank is
+// rejected like by redis. Without count only one index is returned, 0 count returns all of them. With maxlen
+// only the first maxlen elements are compared.
+type LPosArgs struct {
+	Rank   *int64 `json:"rank"`
+	Count  *int64 `json:"count"`
+	MaxLen int64  `json:"maxlen"`
+}
+
+// LPosRequest ...
+type LPosRequest struct {
+	Key   interface{} `json:"key" binding:"required"`
+	Value interface{} `json:"value" binding:"required"`
+	LPosArgs
+}
+
+// LMoveRequest - the element is moved from one end ("left" or "right") of source to one end of destination
+type LMoveRequest struct {
+	Source      string `json:"source" binding:"required"`
+	Destination string `json:"destination" binding:"required"`
+	From        string `json:"from" binding:"required,oneof=left right"`
+	To          string `json:"to" binding:"required,oneof=left right"`
+}
+
+// BPopRequest - the element is popped from the first non-empty list, otherwise the request waits until an
+// element is pushed to any of keys. Timeout is in seconds, 0 waits until the client disconnects.
+type BPopRequest struct {
+	Keys    []string `json:"keys" binding:"required,min=1,dive,required"`
+	Timeout float64  `json:"timeout" binding:"min=0"`
+}
+
+// BLMoveRequest - LMoveRequest waiting for the element of source the same way as BPopRequest
+type BLMoveRequest struct {
+	LMoveRequest
+	Timeout float64 `json:"timeout" binding:"min=0"`
+}
+
+// BPopResult - element popped by BLPOP or BRPOP and the key of its list
+type BPopResult struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// JSONSetOptions - NX sets the value only if the path matches nothing, XX only if it matches
+type JSONSetOptions struct {
+	NX bool `json:"nx"`
+	XX bool `json:"xx"`
+}
+
+// JSONSetRequest - value is any JSON, the path is the root of the document by default
+type JSONSetRequest struct {
+	Key   interface{}     `json:"key" binding:"required"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value" binding:"required"`
+	JSONSetOptions
+}
+
+// JSONGetQuery - ?key=doc&path=$.name&path=$.age, without path the whole document is returned
+type JSONGetQuery struct {
+	Key   string   `form:"key" binding:"required"`
+	Paths []string `form:"path"`
+}
+
+// JSONPathQuery - key and the path of JSON.OBJKEYS
+type JSONPathQuery struct {
+	Key  string `form:"key" binding:"required"`
+	Path string `form:"path"`
+}
+
+// JSONDelRequest - without path the key is removed
+type JSONDelRequest struct {
+	Key  interface{} `json:"key" binding:"required"`
+	Path string      `json:"path"`
+}
+
+// JSONArrAppendRequest - values are appended to arrays matched by the path
+type JSONArrAppendRequest struct {
+	Key    interface{}       `json:"key" binding:"required"`
+	Path   string            `json:"path"`
+	Values []json.RawMessage `json:"values" binding:"required,min=1"`
+}
+
+// JSONNumIncrByRequest - integer increment of integer gives integer, otherwise the result is float
+type JSONNumIncrByRequest struct {
+	Key       interface{} `json:"key" binding:"required"`
+	Path      string      `json:"path" binding:"required"`
+	Increment json.Number `json:"increment" binding:"required"`
+}
+
+// ScanQuery - page of keys starting from the cursor, 0 - from the beginning. Count is the number of keys
+// examined by one call (10 by default), match and type filter them after that, so the page may be empty
+// while the next cursor is not 0.
+type ScanQuery struct {
+	Cursor uint64 `form:"cursor"`
+	Match  string `form:"match"`
+	Count  int64  `form:"count"`
+	Type   string `form:"type"`
+}
+
+// KeyScanQuery - page of fields of hash or members of set or sorted set, the same as ScanQuery
+type KeyScanQuery struct {
+	Key    string `form:"key" binding:"required"`
+	Cursor uint64 `form:"cursor"`
+	Match  string `form:"match"`
+	Count  int64  `form:"count"`
+	Raw    bool   `form:"raw"`
+}
+
+// ScanResult - the iteration is complete when cursor is 0
+type ScanResult struct {
+	Cursor uint64   `json:"cursor"`
+	Keys   []string `json:"keys"`
+}
+
+// HScanResult ...
+type HScanResult struct {
+	Cursor uint64                 `json:"cursor"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// SScanResult ...
+type SScanResult struct {
+	Cursor  uint64   `json:"cursor"`
+	Members []string `json:"members"`
+}
+
+// ZScanResult ...
+type ZScanResult struct {
+	Cursor  uint64    `json:"cursor"`
+	Members []ZMember `json:"members"`
 }
 
 // ListElement - элемент массива для идентификации типа данных
@@ -32,3 +577,16 @@ type User struct {
 	Login    string `json:"login" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
+
+// SaveStatus - result of the last saving of snapshot
+type SaveStatus struct {
+	InProgress bool `json:"in_progress"`
+	// unix time of the last successful save
+	LastSave int64 `json:"last_save"`
+	// duration of the last save in milliseconds
+	Duration int64 `json:"duration_ms"`
+	// size of the file in bytes, it is known only for native engine
+	Size    int64  `json:"size"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
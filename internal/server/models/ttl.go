@@ -0,0 +1,93 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ttlUnits - units of TTL given as a string, the longest suffix goes first
+var ttlUnits = []struct {
+	suffix string
+	unit   time.Duration
+}{
+	{suffix: "ms", unit: time.Millisecond},
+	{suffix: "s", unit: time.Second},
+	{suffix: "m", unit: time.Minute},
+	{suffix: "h", unit: time.Hour},
+}
+
+// TTL - time to live given as a number of minutes, like before units were supported, or as a string with
+// unit ms, s, m or h, e.g. "1500ms", "30s" or "2h"
+type TTL time.Duration
+
+// Duration ...
+func (t TTL) Duration() time.Duration {
+	return time.Duration(t)
+}
+
+// UnmarshalJSON - number of minutes or a string with unit
+func (t *TTL) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*t = 0
+		return nil
+	}
+
+	if len(data) == 0 || data[0] != '"' {
+		minutes, err := strconv.ParseInt(string(data), 10, 64)
+		if err != nil {
+			return fmt.Errorf("ttl has to be integer number of minutes or a string with unit ms, s, m or h, got %s", data)
+		}
+		return t.set(minutes, time.Minute, string(data))
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	for _, unit := range ttlUnits {
+		if !strings.HasSuffix(value, unit.suffix) {
+			continue
+		}
+
+		number := strings.TrimSuffix(value, unit.suffix)
+		// the sign is not allowed, so that "-1s" is not mistaken for valid time to live
+		if number == "" || number[0] < '0' || number[0] > '9' {
+			break
+		}
+
+		n, err := strconv.ParseInt(number, 10, 64)
+		if err != nil {
+			break
+		}
+		return t.set(n, unit.unit, value)
+	}
+
+	return fmt.Errorf("ttl has to be integer number of minutes or a string with unit ms, s, m or h, got %s", data)
+}
+
+// MarshalJSON - milliseconds with unit, so that the value is read back without loss
+func (t TTL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatInt(int64(t.Duration()/time.Millisecond), 10) + "ms")
+}
+
+func (t *TTL) set(n int64, unit time.Duration, value string) error {
+	if n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit) {
+		return fmt.Errorf("ttl %s is out of range", value)
+	}
+
+	*t = TTL(time.Duration(n) * unit)
+	return nil
+}
+
+// Expiration - time to live of the written key: TTL or absolute ExpireAt in RFC 3339, e.g.
+// "2021-01-01T00:00:00Z". Only one of them may be set, without them the key does not expire.
+type Expiration struct {
+	TTL      TTL        `json:"ttl"`
+	ExpireAt *time.Time `json:"expire_at"`
+}
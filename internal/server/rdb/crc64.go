@@ -0,0 +1,31 @@
+package rdb
+
+// crc64Table - table of CRC-64-Jones with reflected input and output, the variant used by redis.
+// hash/crc64 can't be used because it inverts the value before and after calculation.
+var crc64Table = makeCRC64Table(0x95AC9329AC4BC9B5)
+
+func makeCRC64Table(poly uint64) *[256]uint64 {
+	table := new([256]uint64)
+	for i := range table {
+		crc := uint64(i)
+		for j := 0; j < 8; j++ {
+			if crc&1 == 1 {
+				crc = crc>>1 ^ poly
+			} else {
+				crc >>= 1
+			}
+		}
+		table[i] = crc
+	}
+
+	return table
+}
+
+// crc64 - updates checksum with bytes of p
+func crc64(crc uint64, p []byte) uint64 {
+	for _, b := range p {
+		crc = crc64Table[byte(crc)^b] ^ crc>>8
+	}
+
+	return crc
+}
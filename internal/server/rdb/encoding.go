@@ -0,0 +1,367 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// parseZiplist - returns elements of ziplist, compact encoding of small lists and hashes used before redis 7.0
+func parseZiplist(buf []byte) ([]string, error) {
+	if len(buf) < 11 {
+		return nil, fmt.Errorf("%w: ziplist is too short", ErrBadFile)
+	}
+
+	// zlbytes, zltail and zllen are skipped, the end of the list is marked by 0xFF
+	res := []string{}
+	for i := 10; ; {
+		if i >= len(buf) {
+			return nil, fmt.Errorf("%w: ziplist is not terminated", ErrBadFile)
+		}
+		if buf[i] == 0xFF {
+			return res, nil
+		}
+
+		// length of the previous entry
+		if buf[i] == 0xFE {
+			i += 5
+		} else {
+			i++
+		}
+		if i >= len(buf) {
+			return nil, fmt.Errorf("%w: ziplist entry is out of list", ErrBadFile)
+		}
+
+		el, n, err := parseZiplistEntry(buf[i:])
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, el)
+		i += n
+	}
+}
+
+// parseZiplistEntry - returns value of the entry and number of bytes used by its encoding and data
+func parseZiplistEntry(buf []byte) (string, int, error) {
+	enc := buf[0]
+
+	switch enc >> 6 {
+	case 0:
+		return sliceString(buf, 1, int(enc&0x3f))
+	case 1:
+		if len(buf) < 2 {
+			return "", 0, fmt.Errorf("%w: ziplist entry is out of list", ErrBadFile)
+		}
+		return sliceString(buf, 2, int(enc&0x3f)<<8|int(buf[1]))
+	case 2:
+		if len(buf) < 5 {
+			return "", 0, fmt.Errorf("%w: ziplist entry is out of list", ErrBadFile)
+		}
+		return sliceString(buf, 5, int(binary.BigEndian.Uint32(buf[1:5])))
+	}
+
+	var size int
+	switch enc {
+	case 0xC0:
+		size = 2
+	case 0xD0:
+		size = 4
+	case 0xE0:
+		size = 8
+	case 0xF0:
+		size = 3
+	case 0xFE:
+		size = 1
+	default:
+		// 4 bit immediate integer 0..12 is stored as 1..13
+		if enc >= 0xF1 && enc <= 0xFD {
+			return strconv.Itoa(int(enc&0x0f) - 1), 1, nil
+		}
+		return "", 0, fmt.Errorf("%w: unknown ziplist encoding 0x%x", ErrBadFile, enc)
+	}
+
+	if len(buf) < 1+size {
+		return "", 0, fmt.Errorf("%w: ziplist entry is out of list", ErrBadFile)
+	}
+
+	return strconv.FormatInt(littleEndianInt(buf[1:1+size]), 10), 1 + size, nil
+}
+
+// parseListpack - returns elements of listpack, compact encoding of small lists and hashes since redis 7.0
+func parseListpack(buf []byte) ([]string, error) {
+	if len(buf) < 7 {
+		return nil, fmt.Errorf("%w: listpack is too short", ErrBadFile)
+	}
+
+	// total bytes and number of elements are skipped, the end of the list is marked by 0xFF
+	res := []string{}
+	for i := 6; ; {
+		if i >= len(buf) {
+			return nil, fmt.Errorf("%w: listpack is not terminated", ErrBadFile)
+		}
+		if buf[i] == 0xFF {
+			return res, nil
+		}
+
+		el, n, err := parseListpackEntry(buf[i:])
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, el)
+		// entry is followed by its length used to iterate backwards
+		i += n + backlenSize(n)
+	}
+}
+
+// parseListpackEntry - returns value of the entry and number of bytes used by its encoding and data
+func parseListpackEntry(buf []byte) (string, int, error) {
+	enc := buf[0]
+
+	switch {
+	// 7 bit unsigned integer
+	case enc>>7 == 0:
+		return strconv.Itoa(int(enc)), 1, nil
+	// string up to 63 bytes
+	case enc>>6 == 2:
+		return sliceString(buf, 1, int(enc&0x3f))
+	// 13 bit signed integer
+	case enc>>5 == 6:
+		if len(buf) < 2 {
+			return "", 0, fmt.Errorf("%w: listpack entry is out of list", ErrBadFile)
+		}
+		value := int(enc&0x1f)<<8 | int(buf[1])
+		if value >= 1<<12 {
+			value -= 1 << 13
+		}
+		return strconv.Itoa(value), 2, nil
+	// string up to 4095 bytes
+	case enc>>4 == 0xE:
+		if len(buf) < 2 {
+			return "", 0, fmt.Errorf("%w: listpack entry is out of list", ErrBadFile)
+		}
+		return sliceString(buf, 2, int(enc&0x0f)<<8|int(buf[1]))
+	case enc == 0xF0:
+		if len(buf) < 5 {
+			return "", 0, fmt.Errorf("%w: listpack entry is out of list", ErrBadFile)
+		}
+		return sliceString(buf, 5, int(binary.LittleEndian.Uint32(buf[1:5])))
+	}
+
+	var size int
+	switch enc {
+	case 0xF1:
+		size = 2
+	case 0xF2:
+		size = 3
+	case 0xF3:
+		size = 4
+	case 0xF4:
+		size = 8
+	default:
+		return "", 0, fmt.Errorf("%w: unknown listpack encoding 0x%x", ErrBadFile, enc)
+	}
+
+	if len(buf) < 1+size {
+		return "", 0, fmt.Errorf("%w: listpack entry is out of list", ErrBadFile)
+	}
+
+	return strconv.FormatInt(littleEndianInt(buf[1:1+size]), 10), 1 + size, nil
+}
+
+// backlenSize - number of bytes used to store length of listpack entry, the same bounds as in redis
+func backlenSize(n int) int {
+	switch {
+	case n <= 127:
+		return 1
+	case n < 16383:
+		return 2
+	case n < 2097151:
+		return 3
+	case n < 268435455:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// listpack - builds listpack, elements are appended to the end
+type listpack struct {
+	buf []byte
+	n   int
+}
+
+// appendInt - integers are stored with the smallest encoding
+func (lp *listpack) appendInt(value int64) {
+	var entry []byte
+	switch {
+	case value >= 0 && value <= 127:
+		entry = []byte{byte(value)}
+	case value >= -4096 && value <= 4095:
+		entry = []byte{0xC0 | byte(uint64(value)>>8&0x1f), byte(value)}
+	case value >= math.MinInt16 && value <= math.MaxInt16:
+		entry = []byte{0xF1, byte(value), byte(value >> 8)}
+	case value >= -1<<23 && value < 1<<23:
+		entry = []byte{0xF2, byte(value), byte(value >> 8), byte(value >> 16)}
+	case value >= math.MinInt32 && value <= math.MaxInt32:
+		entry = make([]byte, 5)
+		entry[0] = 0xF3
+		binary.LittleEndian.PutUint32(entry[1:], uint32(value))
+	default:
+		entry = make([]byte, 9)
+		entry[0] = 0xF4
+		binary.LittleEndian.PutUint64(entry[1:], uint64(value))
+	}
+
+	lp.append(entry)
+}
+
+func (lp *listpack) appendString(value string) {
+	var entry []byte
+	switch n := len(value); {
+	case n < 64:
+		entry = []byte{0x80 | byte(n)}
+	case n < 4096:
+		entry = []byte{0xE0 | byte(n>>8), byte(n)}
+	default:
+		entry = make([]byte, 5)
+		entry[0] = 0xF0
+		binary.LittleEndian.PutUint32(entry[1:], uint32(n))
+	}
+
+	lp.append(append(entry, value...))
+}
+
+// append - entry is followed by its length, the most significant 7 bits are stored first
+func (lp *listpack) append(entry []byte) {
+	lp.buf = append(lp.buf, entry...)
+
+	size := backlenSize(len(entry))
+	for i := size - 1; i >= 0; i-- {
+		b := byte(len(entry) >> (7 * uint(i)) & 127)
+		if i != size-1 {
+			b |= 128
+		}
+		lp.buf = append(lp.buf, b)
+	}
+	lp.n++
+}
+
+// bytes - header with total number of bytes and number of elements, elements and the end marker
+func (lp *listpack) bytes() []byte {
+	res := make([]byte, 6, len(lp.buf)+7)
+	binary.LittleEndian.PutUint32(res, uint32(len(lp.buf)+7))
+
+	n := lp.n
+	if n > 0xFFFF {
+		n = 0xFFFF
+	}
+	binary.LittleEndian.PutUint16(res[4:], uint16(n))
+
+	res = append(res, lp.buf...)
+	return append(res, 0xFF)
+}
+
+// parseZipmap - returns keys and values of zipmap, encoding of small hashes used before redis 2.6
+func parseZipmap(buf []byte) ([]string, error) {
+	if len(buf) < 2 {
+		return nil, fmt.Errorf("%w: zipmap is too short", ErrBadFile)
+	}
+
+	res := []string{}
+	for i := 1; ; {
+		if i >= len(buf) {
+			return nil, fmt.Errorf("%w: zipmap is not terminated", ErrBadFile)
+		}
+		if buf[i] == 0xFF {
+			if len(res)%2 != 0 {
+				return nil, fmt.Errorf("%w: zipmap has key without value", ErrBadFile)
+			}
+			return res, nil
+		}
+
+		// length is stored in one byte or in 4 bytes after 0xFE
+		n := int(buf[i])
+		i++
+		if n == 0xFE {
+			if i+4 > len(buf) {
+				return nil, fmt.Errorf("%w: zipmap entry is out of map", ErrBadFile)
+			}
+			n = int(binary.LittleEndian.Uint32(buf[i : i+4]))
+			i += 4
+		}
+
+		// values are followed by number of free bytes left for updates
+		var free int
+		if len(res)%2 == 1 {
+			if i >= len(buf) {
+				return nil, fmt.Errorf("%w: zipmap entry is out of map", ErrBadFile)
+			}
+			free = int(buf[i])
+			i++
+		}
+
+		if i+n > len(buf) {
+			return nil, fmt.Errorf("%w: zipmap entry is out of map", ErrBadFile)
+		}
+		res = append(res, string(buf[i:i+n]))
+		i += n + free
+	}
+}
+
+// parseIntset - returns elements of intset, encoding of small sets of integers
+func parseIntset(buf []byte) ([]string, error) {
+	if len(buf) < 8 {
+		return nil, fmt.Errorf("%w: intset is too short", ErrBadFile)
+	}
+
+	size := int(binary.LittleEndian.Uint32(buf[0:4]))
+	n := int(binary.LittleEndian.Uint32(buf[4:8]))
+	if size != 2 && size != 4 && size != 8 {
+		return nil, fmt.Errorf("%w: unknown intset encoding %d", ErrBadFile, size)
+	}
+	if len(buf) != 8+size*n {
+		return nil, fmt.Errorf("%w: intset has wrong length", ErrBadFile)
+	}
+
+	res := make([]string, n)
+	for i := range res {
+		offset := 8 + i*size
+		res[i] = strconv.FormatInt(littleEndianInt(buf[offset:offset+size]), 10)
+	}
+
+	return res, nil
+}
+
+// parseScore - score of zset member stored as string, e.g. 1.5, inf or -inf
+func parseScore(value string) (float64, error) {
+	score, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid score %s", ErrBadFile, value)
+	}
+
+	return score, nil
+}
+
+// sliceString - returns string of length n which starts at offset and total number of used bytes
+func sliceString(buf []byte, offset, n int) (string, int, error) {
+	if offset+n > len(buf) {
+		return "", 0, fmt.Errorf("%w: string is out of list", ErrBadFile)
+	}
+
+	return string(buf[offset : offset+n]), offset + n, nil
+}
+
+// littleEndianInt - signed integer of 1-8 bytes
+func littleEndianInt(buf []byte) int64 {
+	var value uint64
+	for i := len(buf) - 1; i >= 0; i-- {
+		value = value<<8 | uint64(buf[i])
+	}
+
+	// sign is extended from the highest bit
+	shift := uint(64 - 8*len(buf))
+	return int64(value<<shift) >> shift
+}
@@ -0,0 +1,53 @@
+package rdb
+
+import "fmt"
+
+// lzfDecompress - restores string compressed by LZF, length of the result is stored in the file
+func lzfDecompress(in []byte, length int) ([]byte, error) {
+	out := make([]byte, 0, length)
+
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+
+		// literal run of ctrl+1 bytes
+		if ctrl < 1<<5 {
+			if i+ctrl+1 > len(in) {
+				return nil, fmt.Errorf("%w: LZF literal is out of input", ErrBadFile)
+			}
+			out = append(out, in[i:i+ctrl+1]...)
+			i += ctrl + 1
+			continue
+		}
+
+		// back reference
+		n := ctrl >> 5
+		if n == 7 {
+			if i >= len(in) {
+				return nil, fmt.Errorf("%w: LZF reference is out of input", ErrBadFile)
+			}
+			n += int(in[i])
+			i++
+		}
+		if i >= len(in) {
+			return nil, fmt.Errorf("%w: LZF reference is out of input", ErrBadFile)
+		}
+		ref := len(out) - (ctrl&0x1f)<<8 - int(in[i]) - 1
+		i++
+
+		if ref < 0 {
+			return nil, fmt.Errorf("%w: LZF reference is out of output", ErrBadFile)
+		}
+
+		// reference may overlap the copied bytes, so they are copied one by one
+		for j := 0; j < n+2; j++ {
+			out = append(out, out[ref+j])
+		}
+	}
+
+	if len(out) != length {
+		return nil, fmt.Errorf("%w: LZF string has length %d instead of %d", ErrBadFile, len(out), length)
+	}
+
+	return out, nil
+}
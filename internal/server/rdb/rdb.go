@@ -0,0 +1,97 @@
+package rdb
+
+import "errors"
+
+// opcodes of RDB file
+const (
+	opFunction2    = 0xF5
+	opFunction     = 0xF6
+	opModuleAux    = 0xF7
+	opIdle         = 0xF8
+	opFreq         = 0xF9
+	opAux          = 0xFA
+	opResizeDB     = 0xFB
+	opExpireTimeMs = 0xFC
+	opExpireTime   = 0xFD
+	opSelectDB     = 0xFE
+	opEOF          = 0xFF
+)
+
+// types of values
+const (
+	typeString           = 0
+	typeList             = 1
+	typeSet              = 2
+	typeZSet             = 3
+	typeHash             = 4
+	typeZSet2            = 5
+	typeHashZipmap       = 9
+	typeListZiplist      = 10
+	typeSetIntset        = 11
+	typeZSetZiplist      = 12
+	typeHashZiplist      = 13
+	typeListQuicklist    = 14
+	typeStreamListpacks  = 15
+	typeHashListpack     = 16
+	typeZSetListpack     = 17
+	typeListQuicklist2   = 18
+	typeStreamListpacks2 = 19
+	typeStreamListpacks3 = 21
+)
+
+// special encodings of strings
+const (
+	encInt8  = 0
+	encInt16 = 1
+	encInt32 = 2
+	encLZF   = 3
+)
+
+// containers of quicklist nodes
+const (
+	containerPlain  = 1
+	containerPacked = 2
+)
+
+const (
+	// magic - every RDB file starts with it
+	magic = "REDIS"
+	// minVersion - files of older versions have no checksum and use obsolete encodings
+	minVersion = 1
+	// maxVersion - the latest version which can be read, redis 7.0
+	maxVersion = 10
+	// checksumVersion - files starting from this version end with CRC64 checksum
+	checksumVersion = 5
+	// maxStringLen - the same limit as proto-max-bulk-len in redis, protects from corrupted lengths
+	maxStringLen = 512 * 1024 * 1024
+)
+
+// Types of entries, the same names are returned by TYPE command
+const (
+	TypeString = "string"
+	TypeHash   = "hash"
+	TypeList   = "list"
+	TypeSet    = "set"
+	TypeZSet   = "zset"
+	TypeStream = "stream"
+)
+
+var (
+	// ErrBadFile - returned when the file is not RDB or is corrupted
+	ErrBadFile = errors.New("Bad RDB file")
+	// ErrChecksum - returned when checksum at the end of the file does not match its content
+	ErrChecksum = errors.New("Wrong RDB checksum")
+)
+
+// Entry - key read from RDB file
+type Entry struct {
+	DB  int    `json:"db"`
+	Key string `json:"key"`
+	// TypeString, TypeHash, TypeList, TypeSet, TypeZSet or TypeStream
+	Type string `json:"type"`
+	// string, map[string]string, []string (list and set), map[string]float64 (scores of members of zset)
+	// or *Stream depending on the type
+	Value interface{} `json:"value"`
+	// unix time in milliseconds, 0 - key does not expire
+	ExpireAt int64 `json:"expire_at,omitempty"`
+}
@@ -0,0 +1,254 @@
+package rdb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const dumpPath = "../../../build/redis/data/dump.rdb"
+
+func TestReadDump(t *testing.T) {
+	dump, err := ioutil.ReadFile(dumpPath)
+	assert.NoError(t, err)
+
+	reader := NewReader(bytes.NewReader(dump))
+	entries := map[string]*Entry{}
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		entries[entry.Key] = entry
+	}
+
+	assert.Equal(t, 9, reader.Version())
+	assert.Equal(t, "6.0.9", reader.Aux()["redis-ver"])
+	assert.Len(t, entries, 10)
+
+	assert.Equal(t, &Entry{Key: "user:ivan", Type: TypeString, Value: "lapshin"}, entries["user:ivan"])
+	assert.Equal(t, &Entry{
+		Key:      "user:4",
+		Type:     TypeHash,
+		Value:    map[string]string{"name": "Ivan", "lastname": "Lapshin"},
+		ExpireAt: 1609019628218,
+	}, entries["user:4"])
+	assert.Equal(t, &Entry{
+		Key:  "list:1",
+		Type: TypeList,
+		Value: []string{
+			`{"Dtype":"string","Data":"ivan"}`,
+			`{"Dtype":"float64","Data":"1.00000"}`,
+			`{"Dtype":"float64","Data":"3.20000"}`,
+		},
+	}, entries["list:1"])
+	assert.Len(t, entries["key"].Value, 12)
+}
+
+func TestReadDumpErrors(t *testing.T) {
+	dump, err := ioutil.ReadFile(dumpPath)
+	assert.NoError(t, err)
+
+	type testCase struct {
+		name  string
+		input []byte
+		err   error
+	}
+
+	corrupted := append([]byte{}, dump...)
+	corrupted[len(corrupted)-1]++
+
+	tCases := []testCase{
+		{name: "Checksum", input: corrupted, err: ErrChecksum},
+		{name: "Truncated", input: dump[:200], err: io.ErrUnexpectedEOF},
+		{name: "Signature", input: []byte("RODIS0009"), err: ErrBadFile},
+		{name: "Version", input: []byte("REDIS0042"), err: ErrBadFile},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Parse(bytes.NewReader(tc.input), func(entry *Entry) error {
+				return nil
+			})
+			assert.True(t, errors.Is(err, tc.err), err)
+		})
+	}
+}
+
+func TestParseEncodings(t *testing.T) {
+	type testCase struct {
+		name   string
+		parse  func([]byte) ([]string, error)
+		input  []byte
+		output []string
+	}
+
+	tCases := []testCase{
+		{
+			name:  "Ziplist",
+			parse: parseZiplist,
+			input: []byte{
+				0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+				0x00, 0x02, 'a', 'b',
+				0x04, 0xF3,
+				0x02, 0xFE, 0xFF,
+				0x03, 0xC0, 0x00, 0x01,
+				0xFF,
+			},
+			output: []string{"ab", "2", "-1", "256"},
+		},
+		{
+			name:  "Listpack",
+			parse: parseListpack,
+			input: []byte{
+				0, 0, 0, 0, 0, 0,
+				0x05, 0x01,
+				0x82, 'a', 'b', 0x03,
+				0xDF, 0xFF, 0x02,
+				0xF1, 0x00, 0x80, 0x03,
+				0xFF,
+			},
+			output: []string{"5", "ab", "-1", "-32768"},
+		},
+		{
+			name:  "Zipmap",
+			parse: parseZipmap,
+			input: []byte{
+				0x01,
+				0x04, 'n', 'a', 'm', 'e',
+				0x04, 0x01, 'I', 'v', 'a', 'n', 0x00,
+				0xFF,
+			},
+			output: []string{"name", "Ivan"},
+		},
+		{
+			name:  "Intset",
+			parse: parseIntset,
+			input: []byte{
+				0x02, 0x00, 0x00, 0x00,
+				0x03, 0x00, 0x00, 0x00,
+				0xFF, 0xFF, 0x05, 0x00, 0x00, 0x01,
+			},
+			output: []string{"-1", "5", "256"},
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := tc.parse(tc.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.output, output)
+		})
+	}
+}
+
+func TestLZF(t *testing.T) {
+	// literal "ab" followed by back reference of 4 bytes to "ab"
+	output, err := lzfDecompress([]byte{0x01, 'a', 'b', 0x40, 0x01}, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, "ababab", string(output))
+
+	_, err = lzfDecompress([]byte{0x01, 'a', 'b', 0x40, 0x05}, 6)
+	assert.True(t, errors.Is(err, ErrBadFile))
+}
+
+func TestCRC64(t *testing.T) {
+	assert.Equal(t, uint64(0xe9c6d914c4b8d9ca), crc64(0, []byte("123456789")))
+}
+
+func TestWriteRead(t *testing.T) {
+	long := string(bytes.Repeat([]byte("a"), 20000))
+
+	// entries with the same and different fields are split to several listpacks
+	stream := &Stream{
+		LastID: StreamID{Ms: 1609019628218, Seq: 300},
+		Groups: []StreamGroup{
+			{
+				Name:   "workers",
+				LastID: StreamID{Ms: 1609019628218, Seq: 2},
+				Pending: []StreamPending{
+					{ID: StreamID{Ms: 1609019628217, Seq: 5}, Consumer: "ivan", DeliveryTime: 1609019628300, DeliveryCount: 2},
+					{ID: StreamID{Ms: 1609019628218, Seq: 2}, Consumer: "petr", DeliveryTime: 1609019628400, DeliveryCount: 1},
+				},
+				Consumers: []StreamConsumer{
+					{Name: "ivan", SeenTime: 1609019628300},
+					{Name: "petr", SeenTime: 1609019628400},
+				},
+			},
+			{Name: "empty", Pending: []StreamPending{}, Consumers: []StreamConsumer{}},
+		},
+	}
+	stream.Entries = append(stream.Entries, StreamEntry{
+		ID:     StreamID{Ms: 1609019628217, Seq: 5},
+		Fields: []string{"name", "ivan", "age", "25"},
+	})
+	for i := 0; i < 150; i++ {
+		fields := []string{"name", long[:i], "age", "-1"}
+		if i%3 == 0 {
+			fields = []string{"lastname", "lapshin"}
+		}
+		stream.Entries = append(stream.Entries, StreamEntry{
+			ID:     StreamID{Ms: 1609019628218 + uint64(i/10), Seq: uint64(i % 10)},
+			Fields: fields,
+		})
+	}
+
+	entries := []*Entry{
+		{Key: "user:ivan", Type: TypeString, Value: "lapshin"},
+		{Key: "long", Type: TypeString, Value: long, ExpireAt: 1609019628218},
+		{Key: "user:1", Type: TypeHash, Value: map[string]string{"name": "Ivan", "lastname": "Lapshin"}},
+		{Key: "list:1", Type: TypeList, Value: []string{`{"Dtype":"string","Data":"ivan"}`, ""}},
+		{Key: "roles", Type: TypeSet, Value: []string{"admin", "user"}},
+		{Key: "scores", Type: TypeZSet, Value: map[string]float64{"ivan": 1.5, "petr": -2}},
+		{Key: "events", Type: TypeStream, Value: stream},
+		{Key: "empty", Type: TypeStream, Value: &Stream{Entries: []StreamEntry{}, Groups: []StreamGroup{}}},
+		{DB: 1, Key: "user:ivan", Type: TypeString, Value: "petrov"},
+	}
+
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+	assert.NoError(t, w.WriteHeader(map[string]string{"redis-ver": "6.0.9"}))
+	for _, entry := range entries {
+		assert.NoError(t, w.WriteEntry(entry))
+	}
+	assert.NoError(t, w.WriteEnd())
+	assert.Equal(t, int64(buf.Len()), w.Size())
+
+	reader := NewReader(bytes.NewReader(buf.Bytes()))
+	for _, expected := range entries {
+		entry, err := reader.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, entry)
+	}
+
+	_, err := reader.Next()
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, "6.0.9", reader.Aux()["redis-ver"])
+}
+
+func TestReadZSet(t *testing.T) {
+	// scores of RDB versions before 8 are stored as strings, infinities have special lengths
+	input := []byte{
+		0x03,
+		0x04, 'i', 'v', 'a', 'n', 0x03, '1', '.', '5',
+		0x04, 'p', 'e', 't', 'r', 0xFE,
+		0x04, 'o', 'l', 'e', 'g', 0xFF,
+	}
+
+	reader := NewReader(bytes.NewReader(input))
+	value, err := reader.readZSet(false)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"ivan": 1.5, "petr": math.Inf(1), "oleg": math.Inf(-1)}, value)
+
+	reader = NewReader(bytes.NewReader([]byte{0x01, 0x04, 'i', 'v', 'a', 'n', 0x01, 'a'}))
+	_, err = reader.readZSet(false)
+	assert.True(t, errors.Is(err, ErrBadFile))
+}
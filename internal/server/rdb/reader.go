@@ -0,0 +1,581 @@
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Reader - reads keys from RDB file one by one, so that big files are not kept in memory
+type Reader struct {
+	r   *bufio.Reader
+	crc uint64
+	// version of the file, it is known after the header is read
+	version int
+	// auxiliary fields, e.g. redis-ver or ctime
+	aux      map[string]string
+	db       int
+	expireAt int64
+	started  bool
+	finished bool
+}
+
+// NewReader - helper to init reader
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r:   bufio.NewReader(r),
+		aux: make(map[string]string),
+	}
+}
+
+// Version - version of RDB format, it is known after the first call of Next
+func (r *Reader) Version() int {
+	return r.version
+}
+
+// Aux - auxiliary fields of the file read so far, they are stored before the keys
+func (r *Reader) Aux() map[string]string {
+	return r.aux
+}
+
+// Next - returns the next key, io.EOF is returned after the last key when checksum is valid
+func (r *Reader) Next() (*Entry, error) {
+	if r.finished {
+		return nil, io.EOF
+	}
+
+	if !r.started {
+		if err := r.readHeader(); err != nil {
+			return nil, err
+		}
+		r.started = true
+	}
+
+	for {
+		opcode, err := r.readByte()
+		if err != nil {
+			return nil, unexpected(err)
+		}
+
+		switch opcode {
+		case opEOF:
+			if err := r.readChecksum(); err != nil {
+				return nil, err
+			}
+			r.finished = true
+			return nil, io.EOF
+		case opSelectDB:
+			db, err := r.readLength()
+			if err != nil {
+				return nil, err
+			}
+			r.db = int(db)
+		case opResizeDB:
+			// sizes of hash tables are hints for preallocation
+			if _, err := r.readLength(); err != nil {
+				return nil, err
+			}
+			if _, err := r.readLength(); err != nil {
+				return nil, err
+			}
+		case opAux:
+			key, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			value, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			r.aux[key] = value
+		case opExpireTime:
+			buf, err := r.readFull(4)
+			if err != nil {
+				return nil, err
+			}
+			r.expireAt = int64(binary.LittleEndian.Uint32(buf)) * 1000
+		case opExpireTimeMs:
+			buf, err := r.readFull(8)
+			if err != nil {
+				return nil, err
+			}
+			r.expireAt = int64(binary.LittleEndian.Uint64(buf))
+		case opIdle:
+			// LRU idle time is not restored
+			if _, err := r.readLength(); err != nil {
+				return nil, err
+			}
+		case opFreq:
+			// LFU counter is not restored
+			if _, err := r.readByte(); err != nil {
+				return nil, unexpected(err)
+			}
+		case opModuleAux, opFunction, opFunction2:
+			return nil, fmt.Errorf("%w: modules and functions are not supported", ErrBadFile)
+		default:
+			entry, err := r.readEntry(opcode)
+			if err != nil {
+				return nil, err
+			}
+			return entry, nil
+		}
+	}
+}
+
+// Parse - calls handler for every key of the file
+func Parse(r io.Reader, handler func(entry *Entry) error) error {
+	reader := NewReader(r)
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := handler(entry); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *Reader) readHeader() error {
+	buf, err := r.readFull(9)
+	if err != nil {
+		return err
+	}
+
+	if string(buf[:5]) != magic {
+		return fmt.Errorf("%w: no REDIS signature", ErrBadFile)
+	}
+
+	version, err := strconv.Atoi(string(buf[5:]))
+	if err != nil {
+		return fmt.Errorf("%w: invalid version %s", ErrBadFile, buf[5:])
+	}
+	if version < minVersion || version > maxVersion {
+		return fmt.Errorf("%w: version %d is not supported", ErrBadFile, version)
+	}
+
+	r.version = version
+	return nil
+}
+
+func (r *Reader) readChecksum() error {
+	if r.version < checksumVersion {
+		return nil
+	}
+
+	// checksum itself is not a part of checksummed content
+	expected := r.crc
+	buf, err := r.readFull(8)
+	if err != nil {
+		return err
+	}
+
+	// zero checksum means that it was disabled by rdbchecksum no
+	checksum := binary.LittleEndian.Uint64(buf)
+	if checksum != 0 && checksum != expected {
+		return ErrChecksum
+	}
+
+	return nil
+}
+
+// readEntry - reads key and value of the given type, expiration read before is applied to the key
+func (r *Reader) readEntry(valueType byte) (*Entry, error) {
+	key, err := r.readString()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{
+		DB:       r.db,
+		Key:      key,
+		ExpireAt: r.expireAt,
+	}
+	r.expireAt = 0
+
+	switch valueType {
+	case typeString:
+		entry.Type = TypeString
+		entry.Value, err = r.readString()
+	case typeList:
+		entry.Type = TypeList
+		entry.Value, err = r.readStrings()
+	case typeListZiplist:
+		entry.Type = TypeList
+		entry.Value, err = r.readEncoded(parseZiplist)
+	case typeListQuicklist:
+		entry.Type = TypeList
+		entry.Value, err = r.readQuicklist(false)
+	case typeListQuicklist2:
+		entry.Type = TypeList
+		entry.Value, err = r.readQuicklist(true)
+	case typeHash:
+		entry.Type = TypeHash
+		entry.Value, err = r.readHash()
+	case typeHashZipmap:
+		entry.Type = TypeHash
+		entry.Value, err = r.readEncodedHash(parseZipmap)
+	case typeHashZiplist:
+		entry.Type = TypeHash
+		entry.Value, err = r.readEncodedHash(parseZiplist)
+	case typeHashListpack:
+		entry.Type = TypeHash
+		entry.Value, err = r.readEncodedHash(parseListpack)
+	case typeSet:
+		entry.Type = TypeSet
+		entry.Value, err = r.readStrings()
+	case typeSetIntset:
+		entry.Type = TypeSet
+		entry.Value, err = r.readEncoded(parseIntset)
+	case typeZSet, typeZSet2:
+		entry.Type = TypeZSet
+		entry.Value, err = r.readZSet(valueType == typeZSet2)
+	case typeZSetZiplist:
+		entry.Type = TypeZSet
+		entry.Value, err = r.readEncodedZSet(parseZiplist)
+	case typeZSetListpack:
+		entry.Type = TypeZSet
+		entry.Value, err = r.readEncodedZSet(parseListpack)
+	case typeStreamListpacks, typeStreamListpacks2, typeStreamListpacks3:
+		entry.Type = TypeStream
+		entry.Value, err = r.readStream(valueType)
+	default:
+		return nil, fmt.Errorf("%w: unknown type %d of key %s", ErrBadFile, valueType, key)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// readStrings - reads number of elements and the elements
+func (r *Reader) readStrings() ([]string, error) {
+	n, err := r.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	// n is not used for preallocation, corrupted file may have any length
+	res := []string{}
+	for i := uint64(0); i < n; i++ {
+		el, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, el)
+	}
+
+	return res, nil
+}
+
+// readHash - reads number of fields and pairs of field and value
+func (r *Reader) readHash() (map[string]string, error) {
+	n, err := r.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]string{}
+	for i := uint64(0); i < n; i++ {
+		field, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		res[field] = value
+	}
+
+	return res, nil
+}
+
+// readZSet - reads number of members and pairs of member and score. Scores are stored as binary doubles
+// since RDB version 8 and as strings before.
+func (r *Reader) readZSet(binaryScores bool) (map[string]float64, error) {
+	n, err := r.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]float64{}
+	for i := uint64(0); i < n; i++ {
+		member, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+
+		var score float64
+		if binaryScores {
+			buf, err := r.readFull(8)
+			if err != nil {
+				return nil, err
+			}
+			score = math.Float64frombits(binary.LittleEndian.Uint64(buf))
+		} else if score, err = r.readScore(); err != nil {
+			return nil, err
+		}
+
+		res[member] = score
+	}
+
+	return res, nil
+}
+
+// readScore - score stored as string with one byte length, special lengths are used for NaN and infinities
+func (r *Reader) readScore() (float64, error) {
+	length, err := r.readByte()
+	if err != nil {
+		return 0, unexpected(err)
+	}
+
+	switch length {
+	case 253:
+		return math.NaN(), nil
+	case 254:
+		return math.Inf(1), nil
+	case 255:
+		return math.Inf(-1), nil
+	}
+
+	buf, err := r.readFull(int(length))
+	if err != nil {
+		return 0, err
+	}
+
+	return parseScore(string(buf))
+}
+
+// readQuicklist - reads list stored as linked list of ziplists or, since redis 7.0, of listpacks
+func (r *Reader) readQuicklist(listpack bool) ([]string, error) {
+	n, err := r.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{}
+	for i := uint64(0); i < n; i++ {
+		container := uint64(containerPacked)
+		if listpack {
+			if container, err = r.readLength(); err != nil {
+				return nil, err
+			}
+		}
+
+		// big elements are stored in separate nodes as is
+		if container == containerPlain {
+			el, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, el)
+			continue
+		}
+
+		parse := parseZiplist
+		if listpack {
+			parse = parseListpack
+		}
+
+		node, err := r.readEncoded(parse)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, node...)
+	}
+
+	return res, nil
+}
+
+// readEncoded - reads string which contains compact encoding of small collection
+func (r *Reader) readEncoded(parse func([]byte) ([]string, error)) ([]string, error) {
+	buf, err := r.readString()
+	if err != nil {
+		return nil, err
+	}
+
+	return parse([]byte(buf))
+}
+
+func (r *Reader) readEncodedHash(parse func([]byte) ([]string, error)) (map[string]string, error) {
+	pairs, err := r.readEncoded(parse)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("%w: hash has field without value", ErrBadFile)
+	}
+
+	res := make(map[string]string, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		res[pairs[i]] = pairs[i+1]
+	}
+
+	return res, nil
+}
+
+func (r *Reader) readEncodedZSet(parse func([]byte) ([]string, error)) (map[string]float64, error) {
+	pairs, err := r.readEncoded(parse)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("%w: zset has member without score", ErrBadFile)
+	}
+
+	res := make(map[string]float64, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		score, err := parseScore(pairs[i+1])
+		if err != nil {
+			return nil, err
+		}
+		res[pairs[i]] = score
+	}
+
+	return res, nil
+}
+
+// readString - reads string which may be stored as integer or compressed by LZF
+func (r *Reader) readString() (string, error) {
+	length, encoded, err := r.readLengthOrEncoding()
+	if err != nil {
+		return "", err
+	}
+
+	if !encoded {
+		buf, err := r.readFull(int(length))
+		if err != nil {
+			return "", err
+		}
+		return string(buf), nil
+	}
+
+	switch length {
+	case encInt8, encInt16, encInt32:
+		buf, err := r.readFull(1 << length)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(littleEndianInt(buf), 10), nil
+	case encLZF:
+		compressed, err := r.readLength()
+		if err != nil {
+			return "", err
+		}
+		uncompressed, err := r.readLength()
+		if err != nil {
+			return "", err
+		}
+
+		buf, err := r.readFull(int(compressed))
+		if err != nil {
+			return "", err
+		}
+
+		res, err := lzfDecompress(buf, int(uncompressed))
+		if err != nil {
+			return "", err
+		}
+		return string(res), nil
+	default:
+		return "", fmt.Errorf("%w: unknown string encoding %d", ErrBadFile, length)
+	}
+}
+
+// readLength - reads length, special encodings are not allowed
+func (r *Reader) readLength() (uint64, error) {
+	length, encoded, err := r.readLengthOrEncoding()
+	if err != nil {
+		return 0, err
+	}
+
+	if encoded {
+		return 0, fmt.Errorf("%w: length is expected, got encoding %d", ErrBadFile, length)
+	}
+
+	return length, nil
+}
+
+// readLengthOrEncoding - two highest bits of the first byte define how length is stored,
+// 11 means that it is not length but special encoding of the following string
+func (r *Reader) readLengthOrEncoding() (uint64, bool, error) {
+	first, err := r.readByte()
+	if err != nil {
+		return 0, false, unexpected(err)
+	}
+
+	switch first >> 6 {
+	case 0:
+		return uint64(first & 0x3f), false, nil
+	case 1:
+		next, err := r.readByte()
+		if err != nil {
+			return 0, false, unexpected(err)
+		}
+		return uint64(first&0x3f)<<8 | uint64(next), false, nil
+	case 3:
+		return uint64(first & 0x3f), true, nil
+	}
+
+	switch first {
+	case 0x80:
+		buf, err := r.readFull(4)
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(binary.BigEndian.Uint32(buf)), false, nil
+	case 0x81:
+		buf, err := r.readFull(8)
+		if err != nil {
+			return 0, false, err
+		}
+		return binary.BigEndian.Uint64(buf), false, nil
+	default:
+		return 0, false, fmt.Errorf("%w: unknown length encoding 0x%x", ErrBadFile, first)
+	}
+}
+
+func (r *Reader) readByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	r.crc = crc64(r.crc, []byte{b})
+	return b, nil
+}
+
+func (r *Reader) readFull(n int) ([]byte, error) {
+	if n < 0 || n > maxStringLen {
+		return nil, fmt.Errorf("%w: invalid length %d", ErrBadFile, n)
+	}
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		return nil, unexpected(err)
+	}
+
+	r.crc = crc64(r.crc, buf)
+	return buf, nil
+}
+
+// unexpected - the file can't end in the middle of the key
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
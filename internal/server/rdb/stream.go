@@ -0,0 +1,493 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// flags of entries of stream listpack
+const (
+	streamItemDeleted    = 1
+	streamItemSameFields = 2
+)
+
+// streamNodeMaxEntries - entries of stream are written to listpacks of this size, the default of
+// stream-node-max-entries of redis
+const streamNodeMaxEntries = 100
+
+// StreamID - id of stream entry, unix time in milliseconds and sequence number
+type StreamID struct {
+	Ms  uint64 `json:"ms"`
+	Seq uint64 `json:"seq"`
+}
+
+// Stream - value of stream key
+type Stream struct {
+	Entries []StreamEntry `json:"entries"`
+	// the greatest id ever added, it may be greater than id of the last entry
+	LastID StreamID      `json:"last_id"`
+	Groups []StreamGroup `json:"groups"`
+}
+
+// StreamEntry - fields of the entry are stored as pairs of field and value
+type StreamEntry struct {
+	ID     StreamID `json:"id"`
+	Fields []string `json:"fields"`
+}
+
+// StreamGroup - consumer group, pending entries are delivered to consumers but not acknowledged
+type StreamGroup struct {
+	Name      string           `json:"name"`
+	LastID    StreamID         `json:"last_id"`
+	Pending   []StreamPending  `json:"pending"`
+	Consumers []StreamConsumer `json:"consumers"`
+}
+
+// StreamPending - entry of pending entries list, delivery time is unix time in milliseconds
+type StreamPending struct {
+	ID            StreamID `json:"id"`
+	Consumer      string   `json:"consumer"`
+	DeliveryTime  int64    `json:"delivery_time"`
+	DeliveryCount uint64   `json:"delivery_count"`
+}
+
+// StreamConsumer - seen time is unix time in milliseconds of the last interaction
+type StreamConsumer struct {
+	Name     string `json:"name"`
+	SeenTime int64  `json:"seen_time"`
+}
+
+// readStream - reads listpacks with entries followed by metadata and consumer groups. Listpacks of
+// redis 7.0 streams are followed by additional metadata, which is skipped.
+func (r *Reader) readStream(valueType byte) (*Stream, error) {
+	nodes, err := r.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Stream{
+		Entries: []StreamEntry{},
+		Groups:  []StreamGroup{},
+	}
+	for i := uint64(0); i < nodes; i++ {
+		key, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		if len(key) != 16 {
+			return nil, fmt.Errorf("%w: stream node key has length %d", ErrBadFile, len(key))
+		}
+
+		lp, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+
+		elements, err := parseListpack([]byte(lp))
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := parseStreamNode(decodeStreamID([]byte(key)), elements)
+		if err != nil {
+			return nil, err
+		}
+		s.Entries = append(s.Entries, entries...)
+	}
+
+	// number of entries
+	if _, err := r.readLength(); err != nil {
+		return nil, err
+	}
+	if s.LastID, err = r.readStreamID(); err != nil {
+		return nil, err
+	}
+
+	if valueType != typeStreamListpacks {
+		// first id, max deleted id and number of added entries
+		for i := 0; i < 5; i++ {
+			if _, err := r.readLength(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	groups, err := r.readLength()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < groups; i++ {
+		group, err := r.readStreamGroup(valueType)
+		if err != nil {
+			return nil, err
+		}
+		s.Groups = append(s.Groups, *group)
+	}
+
+	return s, nil
+}
+
+func (r *Reader) readStreamGroup(valueType byte) (*StreamGroup, error) {
+	var err error
+	group := &StreamGroup{
+		Pending:   []StreamPending{},
+		Consumers: []StreamConsumer{},
+	}
+
+	if group.Name, err = r.readString(); err != nil {
+		return nil, err
+	}
+	if group.LastID, err = r.readStreamID(); err != nil {
+		return nil, err
+	}
+	if valueType != typeStreamListpacks {
+		// number of entries read by the group
+		if _, err := r.readLength(); err != nil {
+			return nil, err
+		}
+	}
+
+	pending, err := r.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[StreamID]int, pending)
+	for i := uint64(0); i < pending; i++ {
+		buf, err := r.readFull(24)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := StreamPending{
+			ID:           decodeStreamID(buf[:16]),
+			DeliveryTime: int64(binary.LittleEndian.Uint64(buf[16:])),
+		}
+		if entry.DeliveryCount, err = r.readLength(); err != nil {
+			return nil, err
+		}
+
+		index[entry.ID] = len(group.Pending)
+		group.Pending = append(group.Pending, entry)
+	}
+
+	consumers, err := r.readLength()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < consumers; i++ {
+		consumer := StreamConsumer{}
+		if consumer.Name, err = r.readString(); err != nil {
+			return nil, err
+		}
+
+		size := 8
+		if valueType == typeStreamListpacks3 {
+			// active time
+			size = 16
+		}
+		buf, err := r.readFull(size)
+		if err != nil {
+			return nil, err
+		}
+		consumer.SeenTime = int64(binary.LittleEndian.Uint64(buf[:8]))
+
+		// pending entries of the consumer refer to entries of the group
+		pending, err := r.readLength()
+		if err != nil {
+			return nil, err
+		}
+		for j := uint64(0); j < pending; j++ {
+			buf, err := r.readFull(16)
+			if err != nil {
+				return nil, err
+			}
+
+			k, ok := index[decodeStreamID(buf)]
+			if !ok {
+				return nil, fmt.Errorf("%w: pending entry of consumer %s is not in the group", ErrBadFile, consumer.Name)
+			}
+			group.Pending[k].Consumer = consumer.Name
+		}
+
+		group.Consumers = append(group.Consumers, consumer)
+	}
+
+	return group, nil
+}
+
+func (r *Reader) readStreamID() (StreamID, error) {
+	var id StreamID
+	var err error
+	if id.Ms, err = r.readLength(); err != nil {
+		return id, err
+	}
+	if id.Seq, err = r.readLength(); err != nil {
+		return id, err
+	}
+
+	return id, nil
+}
+
+// parseStreamNode - elements of listpack start with the master entry: number of valid and deleted entries
+// and fields of the first entry. Ids of entries are stored as differences with the master id, the same
+// fields as in the master entry are not repeated.
+func parseStreamNode(master StreamID, elements []string) ([]StreamEntry, error) {
+	p := &streamNodeParser{elements: elements}
+
+	// number of valid and deleted entries
+	p.nextInt()
+	p.nextInt()
+	n := p.nextInt()
+	if n < 0 || n > int64(len(elements)) {
+		return nil, fmt.Errorf("%w: invalid number of fields %d in stream listpack", ErrBadFile, n)
+	}
+
+	masterFields := make([]string, n)
+	for i := range masterFields {
+		masterFields[i] = p.next()
+	}
+	// end of master entry
+	p.nextInt()
+
+	res := []StreamEntry{}
+	for p.err == nil && p.pos < len(elements) {
+		flags := p.nextInt()
+		entry := StreamEntry{
+			ID: StreamID{
+				Ms:  master.Ms + uint64(p.nextInt()),
+				Seq: master.Seq + uint64(p.nextInt()),
+			},
+		}
+
+		if flags&streamItemSameFields != 0 {
+			for _, field := range masterFields {
+				entry.Fields = append(entry.Fields, field, p.next())
+			}
+		} else {
+			n := p.nextInt()
+			for i := int64(0); i < n*2 && p.err == nil; i++ {
+				entry.Fields = append(entry.Fields, p.next())
+			}
+		}
+		// number of elements of the entry used to iterate backwards
+		p.nextInt()
+
+		if flags&streamItemDeleted == 0 {
+			res = append(res, entry)
+		}
+	}
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return res, nil
+}
+
+// streamNodeParser - reads elements of stream listpack one by one, the first error is kept
+type streamNodeParser struct {
+	elements []string
+	pos      int
+	err      error
+}
+
+func (p *streamNodeParser) next() string {
+	if p.err != nil {
+		return ""
+	}
+	if p.pos >= len(p.elements) {
+		p.err = fmt.Errorf("%w: stream entry is out of listpack", ErrBadFile)
+		return ""
+	}
+
+	p.pos++
+	return p.elements[p.pos-1]
+}
+
+func (p *streamNodeParser) nextInt() int64 {
+	value := p.next()
+	if p.err != nil {
+		return 0
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		p.err = fmt.Errorf("%w: invalid integer %s in stream listpack", ErrBadFile, value)
+	}
+
+	return n
+}
+
+// writeStream - entries are written to listpacks of streamNodeMaxEntries entries
+func (w *Writer) writeStream(s *Stream) error {
+	nodes := (len(s.Entries) + streamNodeMaxEntries - 1) / streamNodeMaxEntries
+	if err := w.writeLength(uint64(nodes)); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(s.Entries); i += streamNodeMaxEntries {
+		end := i + streamNodeMaxEntries
+		if end > len(s.Entries) {
+			end = len(s.Entries)
+		}
+
+		entries := s.Entries[i:end]
+		if err := w.writeString(string(encodeStreamID(entries[0].ID))); err != nil {
+			return err
+		}
+		if err := w.writeString(string(streamNode(entries))); err != nil {
+			return err
+		}
+	}
+
+	if err := w.writeLength(uint64(len(s.Entries))); err != nil {
+		return err
+	}
+	if err := w.writeStreamID(s.LastID); err != nil {
+		return err
+	}
+
+	if err := w.writeLength(uint64(len(s.Groups))); err != nil {
+		return err
+	}
+	for _, group := range s.Groups {
+		if err := w.writeStreamGroup(group); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *Writer) writeStreamGroup(group StreamGroup) error {
+	if err := w.writeString(group.Name); err != nil {
+		return err
+	}
+	if err := w.writeStreamID(group.LastID); err != nil {
+		return err
+	}
+
+	if err := w.writeLength(uint64(len(group.Pending))); err != nil {
+		return err
+	}
+	for _, entry := range group.Pending {
+		buf := make([]byte, 24)
+		copy(buf, encodeStreamID(entry.ID))
+		binary.LittleEndian.PutUint64(buf[16:], uint64(entry.DeliveryTime))
+		if err := w.write(buf); err != nil {
+			return err
+		}
+		if err := w.writeLength(entry.DeliveryCount); err != nil {
+			return err
+		}
+	}
+
+	if err := w.writeLength(uint64(len(group.Consumers))); err != nil {
+		return err
+	}
+	for _, consumer := range group.Consumers {
+		if err := w.writeString(consumer.Name); err != nil {
+			return err
+		}
+
+		buf := make([]byte, 8)
+		binary.LittleEndian.PutUint64(buf, uint64(consumer.SeenTime))
+		if err := w.write(buf); err != nil {
+			return err
+		}
+
+		var ids [][]byte
+		for _, entry := range group.Pending {
+			if entry.Consumer == consumer.Name {
+				ids = append(ids, encodeStreamID(entry.ID))
+			}
+		}
+
+		if err := w.writeLength(uint64(len(ids))); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := w.write(id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *Writer) writeStreamID(id StreamID) error {
+	if err := w.writeLength(id.Ms); err != nil {
+		return err
+	}
+
+	return w.writeLength(id.Seq)
+}
+
+// streamNode - listpack with master entry made of fields of the first entry, entries with the same fields
+// store only values
+func streamNode(entries []StreamEntry) []byte {
+	master := entries[0]
+	masterFields := make([]string, 0, len(master.Fields)/2)
+	for i := 0; i < len(master.Fields); i += 2 {
+		masterFields = append(masterFields, master.Fields[i])
+	}
+
+	lp := &listpack{}
+	lp.appendInt(int64(len(entries)))
+	lp.appendInt(0)
+	lp.appendInt(int64(len(masterFields)))
+	for _, field := range masterFields {
+		lp.appendString(field)
+	}
+	lp.appendInt(0)
+
+	for _, entry := range entries {
+		sameFields := len(entry.Fields) == len(master.Fields)
+		for i := 0; sameFields && i < len(entry.Fields); i += 2 {
+			sameFields = entry.Fields[i] == master.Fields[i]
+		}
+
+		flags := int64(0)
+		if sameFields {
+			flags = streamItemSameFields
+		}
+		lp.appendInt(flags)
+		lp.appendInt(int64(entry.ID.Ms - master.ID.Ms))
+		lp.appendInt(int64(entry.ID.Seq - master.ID.Seq))
+
+		if sameFields {
+			for i := 1; i < len(entry.Fields); i += 2 {
+				lp.appendString(entry.Fields[i])
+			}
+			lp.appendInt(int64(3 + len(masterFields)))
+			continue
+		}
+
+		lp.appendInt(int64(len(entry.Fields) / 2))
+		for _, value := range entry.Fields {
+			lp.appendString(value)
+		}
+		lp.appendInt(int64(4 + len(entry.Fields)))
+	}
+
+	return lp.bytes()
+}
+
+// encodeStreamID - ids are stored as 128 bit big endian numbers, so that they are ordered as bytes
+func encodeStreamID(id StreamID) []byte {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf, id.Ms)
+	binary.BigEndian.PutUint64(buf[8:], id.Seq)
+
+	return buf
+}
+
+func decodeStreamID(buf []byte) StreamID {
+	return StreamID{
+		Ms:  binary.BigEndian.Uint64(buf[:8]),
+		Seq: binary.BigEndian.Uint64(buf[8:16]),
+	}
+}
@@ -0,0 +1,235 @@
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// writeVersion - version of written files, the one used by redis 6.0, so that redis can load them
+const writeVersion = 9
+
+// Writer - writes keys to RDB file, header has to be written first and end of the file last
+type Writer struct {
+	w   *bufio.Writer
+	crc uint64
+	// number of written bytes
+	size int64
+	db   int
+}
+
+// NewWriter - helper to init writer
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w:  bufio.NewWriter(w),
+		db: -1,
+	}
+}
+
+// Size - number of bytes written so far
+func (w *Writer) Size() int64 {
+	return w.size
+}
+
+// WriteHeader - writes signature, version and auxiliary fields, e.g. redis-ver or ctime
+func (w *Writer) WriteHeader(aux map[string]string) error {
+	if err := w.write([]byte(fmt.Sprintf("%s%04d", magic, writeVersion))); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(aux))
+	for key := range aux {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := w.write([]byte{opAux}); err != nil {
+			return err
+		}
+		if err := w.writeString(key); err != nil {
+			return err
+		}
+		if err := w.writeString(aux[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteEntry - writes key with its value and expiration. Keys of one database have to be written together.
+func (w *Writer) WriteEntry(entry *Entry) error {
+	if entry.DB != w.db {
+		if err := w.write([]byte{opSelectDB}); err != nil {
+			return err
+		}
+		if err := w.writeLength(uint64(entry.DB)); err != nil {
+			return err
+		}
+		w.db = entry.DB
+	}
+
+	if entry.ExpireAt != 0 {
+		buf := make([]byte, 9)
+		buf[0] = opExpireTimeMs
+		binary.LittleEndian.PutUint64(buf[1:], uint64(entry.ExpireAt))
+		if err := w.write(buf); err != nil {
+			return err
+		}
+	}
+
+	switch entry.Type {
+	case TypeString:
+		value, ok := entry.Value.(string)
+		if !ok {
+			break
+		}
+		if err := w.writeKey(typeString, entry.Key); err != nil {
+			return err
+		}
+		return w.writeString(value)
+	case TypeHash:
+		value, ok := entry.Value.(map[string]string)
+		if !ok {
+			break
+		}
+		if err := w.writeKey(typeHash, entry.Key); err != nil {
+			return err
+		}
+		if err := w.writeLength(uint64(len(value))); err != nil {
+			return err
+		}
+
+		for field, val := range value {
+			if err := w.writeString(field); err != nil {
+				return err
+			}
+			if err := w.writeString(val); err != nil {
+				return err
+			}
+		}
+		return nil
+	case TypeList, TypeSet:
+		value, ok := entry.Value.([]string)
+		if !ok {
+			break
+		}
+
+		valueType := byte(typeList)
+		if entry.Type == TypeSet {
+			valueType = typeSet
+		}
+		if err := w.writeKey(valueType, entry.Key); err != nil {
+			return err
+		}
+		if err := w.writeLength(uint64(len(value))); err != nil {
+			return err
+		}
+
+		for _, el := range value {
+			if err := w.writeString(el); err != nil {
+				return err
+			}
+		}
+		return nil
+	case TypeZSet:
+		value, ok := entry.Value.(map[string]float64)
+		if !ok {
+			break
+		}
+		if err := w.writeKey(typeZSet2, entry.Key); err != nil {
+			return err
+		}
+		if err := w.writeLength(uint64(len(value))); err != nil {
+			return err
+		}
+
+		buf := make([]byte, 8)
+		for member, score := range value {
+			if err := w.writeString(member); err != nil {
+				return err
+			}
+			binary.LittleEndian.PutUint64(buf, math.Float64bits(score))
+			if err := w.write(buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	case TypeStream:
+		value, ok := entry.Value.(*Stream)
+		if !ok {
+			break
+		}
+		if err := w.writeKey(typeStreamListpacks, entry.Key); err != nil {
+			return err
+		}
+		return w.writeStream(value)
+	}
+
+	return fmt.Errorf("Can't write %s %T of key %s to RDB", entry.Type, entry.Value, entry.Key)
+}
+
+// WriteEnd - writes end of the file with checksum and flushes buffered data
+func (w *Writer) WriteEnd() error {
+	if err := w.write([]byte{opEOF}); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, w.crc)
+	if err := w.write(buf); err != nil {
+		return err
+	}
+
+	return w.w.Flush()
+}
+
+func (w *Writer) writeKey(valueType byte, key string) error {
+	if err := w.write([]byte{valueType}); err != nil {
+		return err
+	}
+
+	return w.writeString(key)
+}
+
+// writeString - strings are written as is, without integer encoding and compression
+func (w *Writer) writeString(value string) error {
+	if err := w.writeLength(uint64(len(value))); err != nil {
+		return err
+	}
+
+	return w.write([]byte(value))
+}
+
+func (w *Writer) writeLength(length uint64) error {
+	switch {
+	case length < 1<<6:
+		return w.write([]byte{byte(length)})
+	case length < 1<<14:
+		return w.write([]byte{byte(length>>8) | 0x40, byte(length)})
+	case length <= 0xFFFFFFFF:
+		buf := make([]byte, 5)
+		buf[0] = 0x80
+		binary.BigEndian.PutUint32(buf[1:], uint32(length))
+		return w.write(buf)
+	default:
+		buf := make([]byte, 9)
+		buf[0] = 0x81
+		binary.BigEndian.PutUint64(buf[1:], length)
+		return w.write(buf)
+	}
+}
+
+func (w *Writer) write(p []byte) error {
+	if _, err := w.w.Write(p); err != nil {
+		return err
+	}
+
+	w.crc = crc64(w.crc, p)
+	w.size += int64(len(p))
+	return nil
+}
@@ -0,0 +1,150 @@
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxBulkLen - the same limit as proto-max-bulk-len in redis
+	maxBulkLen = 512 * 1024 * 1024
+	// maxArrayLen - maximum number of arguments in one command
+	maxArrayLen = 1024 * 1024
+	// maxInlineLen - the same limit of inline commands as in redis
+	maxInlineLen = 64 * 1024
+	// bulkChunk - bulk string is read by chunks, so that memory is allocated only for the data which is
+	// actually received, not for the length declared by the client
+	bulkChunk = 64 * 1024
+)
+
+// ErrProtocol - returned when client sends something which is not RESP
+var ErrProtocol = errors.New("Protocol error")
+
+// Reader - reads commands sent by clients in RESP
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader - helper to init reader
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r: bufio.NewReader(r),
+	}
+}
+
+// Buffered - number of bytes which may be read without blocking
+func (r *Reader) Buffered() int {
+	return r.r.Buffered()
+}
+
+// Wait - blocks until something is sent by the client or the connection is closed, nothing is consumed
+func (r *Reader) Wait() error {
+	_, err := r.r.Peek(1)
+	return err
+}
+
+// ReadCommand - reads command as array of bulk strings or as inline command separated by spaces
+func (r *Reader) ReadCommand() ([]string, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 {
+		return []string{}, nil
+	}
+
+	if line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	n, err := parseLen(line[1:], maxArrayLen)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(line) == 0 || line[0] != '$' {
+			return nil, fmt.Errorf("%w: expected '$', got '%s'", ErrProtocol, line)
+		}
+
+		arg, err := r.readBulk(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	return args, nil
+}
+
+func (r *Reader) readBulk(header string) (string, error) {
+	n, err := parseLen(header, maxBulkLen)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if n < bulkChunk {
+		b.Grow(n)
+	} else {
+		b.Grow(bulkChunk)
+	}
+	if _, err := io.CopyN(&b, r.r, int64(n)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+
+	crlf := make([]byte, 2)
+	if _, err := io.ReadFull(r.r, crlf); err != nil {
+		return "", err
+	}
+	if crlf[0] != '\r' || crlf[1] != '\n' {
+		return "", fmt.Errorf("%w: bulk string is not terminated by CRLF", ErrProtocol)
+	}
+
+	return b.String(), nil
+}
+
+// readLine - line of inline command or header of RESP, it may not be longer than maxInlineLen
+func (r *Reader) readLine() (string, error) {
+	var line []byte
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		if len(line)+len(chunk) > maxInlineLen {
+			return "", fmt.Errorf("%w: too big inline request", ErrProtocol)
+		}
+		line = append(line, chunk...)
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		break
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(string(line), "\n"), "\r"), nil
+}
+
+func parseLen(value string, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > max {
+		return 0, fmt.Errorf("%w: invalid length '%s'", ErrProtocol, value)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,132 @@
+package resp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCommand(t *testing.T) {
+	type testCase struct {
+		name    string
+		input   string
+		args    []string
+		isError bool
+	}
+
+	tCases := []testCase{
+		{
+			name:  "Multibulk",
+			input: "*3\r\n$3\r\nSET\r\n$4\r\nuser\r\n$9\r\nIvan\r\nLap\r\n",
+			args:  []string{"SET", "user", "Ivan\r\nLap"},
+		},
+		{
+			name:  "Inline",
+			input: "PING  hello\r\n",
+			args:  []string{"PING", "hello"},
+		},
+		{
+			name:    "Not bulk string",
+			input:   "*1\r\n:1\r\n",
+			isError: true,
+		},
+		{
+			name:    "Invalid length",
+			input:   "*1\r\n$-5\r\n",
+			isError: true,
+		},
+		{
+			name:    "Too big inline request",
+			input:   strings.Repeat("a", maxInlineLen+1) + "\r\n",
+			isError: true,
+		},
+		{
+			name:    "Bulk string without CRLF",
+			input:   "*1\r\n$4\r\nPINGxx",
+			isError: true,
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			args, err := NewReader(strings.NewReader(tc.input)).ReadCommand()
+			if tc.isError {
+				assert.True(t, errors.Is(err, ErrProtocol))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.args, args)
+			}
+		})
+	}
+
+	// memory is not allocated for the length declared in the header before the data is received
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := NewReader(strings.NewReader("*1\r\n$536870912\r\nPING")).ReadCommand()
+	runtime.ReadMemStats(&after)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1024*1024))
+}
+
+func TestWriteValue(t *testing.T) {
+	type testCase struct {
+		name   string
+		value  interface{}
+		output string
+	}
+
+	tCases := []testCase{
+		{name: "Simple string", value: SimpleString("OK"), output: "+OK\r\n"},
+		{name: "Error", value: Error("ERR bad\r\nline"), output: "-ERR bad  line\r\n"},
+		{name: "Bulk string", value: "Ivan", output: "$4\r\nIvan\r\n"},
+		{name: "Null", value: nil, output: "$-1\r\n"},
+		{name: "Integer", value: int64(-10), output: ":-10\r\n"},
+		{name: "Array", value: []string{"a", "bc"}, output: "*2\r\n$1\r\na\r\n$2\r\nbc\r\n"},
+		{name: "Hash", value: map[string]string{"b": "2", "a": "1"}, output: "*4\r\n$1\r\na\r\n$1\r\n1\r\n$1\r\nb\r\n$1\r\n2\r\n"},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := NewWriter(buf)
+
+			assert.NoError(t, w.WriteValue(tc.value))
+			assert.NoError(t, w.Flush())
+			assert.Equal(t, tc.output, buf.String())
+		})
+	}
+}
+
+func TestWriteValueRESP3(t *testing.T) {
+	type testCase struct {
+		name   string
+		value  interface{}
+		output string
+	}
+
+	tCases := []testCase{
+		{name: "Null", value: nil, output: "_\r\n"},
+		{name: "Double", value: 3.5, output: ",3.5\r\n"},
+		{name: "Boolean", value: true, output: "#t\r\n"},
+		{name: "Set", value: Set{"a"}, output: "~1\r\n$1\r\na\r\n"},
+		{name: "Push", value: Push{"message", int64(1)}, output: ">2\r\n$7\r\nmessage\r\n:1\r\n"},
+		{name: "Map", value: map[string]interface{}{"age": int64(21)}, output: "%1\r\n$3\r\nage\r\n:21\r\n"},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := NewWriter(buf)
+			w.SetProtocol(RESP3)
+
+			assert.NoError(t, w.WriteValue(tc.value))
+			assert.NoError(t, w.Flush())
+			assert.Equal(t, tc.output, buf.String())
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package resp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// RESP2 - protocol used by default
+	RESP2 = 2
+	// RESP3 - protocol negotiated by HELLO 3, has maps, sets, doubles, nulls and push messages
+	RESP3 = 3
+)
+
+// lineReplacer - simple strings and errors can't contain line breaks
+var lineReplacer = strings.NewReplacer("\r", " ", "\n", " ")
+
+// SimpleString - value written as simple string (+OK) instead of bulk string
+type SimpleString string
+
+// Error - value written as error reply
+type Error string
+
+// Set - unordered collection, written as set in RESP3 and as array in RESP2
+type Set []string
+
+// Push - out of band message (e.g. pub/sub), written as push in RESP3 and as array in RESP2
+type Push []interface{}
+
+// Writer - writes replies in RESP
+type Writer struct {
+	w     *bufio.Writer
+	proto int
+}
+
+// NewWriter - helper to init writer
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w:     bufio.NewWriter(w),
+		proto: RESP2,
+	}
+}
+
+// SetProtocol - switches version of protocol used for the next replies
+func (w *Writer) SetProtocol(proto int) {
+	w.proto = proto
+}
+
+// Protocol - version of protocol used for replies
+func (w *Writer) Protocol() int {
+	return w.proto
+}
+
+// Flush - sends buffered replies to the client
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// WriteSimpleString ...
+func (w *Writer) WriteSimpleString(value string) error {
+	return w.writeLine('+', lineReplacer.Replace(value))
+}
+
+// WriteError - writes error, message has to start with error code, e.g. ERR or WRONGTYPE
+func (w *Writer) WriteError(message string) error {
+	return w.writeLine('-', lineReplacer.Replace(message))
+}
+
+// WriteInteger ...
+func (w *Writer) WriteInteger(value int64) error {
+	return w.writeLine(':', strconv.FormatInt(value, 10))
+}
+
+// WriteBulkString ...
+func (w *Writer) WriteBulkString(value string) error {
+	if err := w.writeLine('$', strconv.Itoa(len(value))); err != nil {
+		return err
+	}
+
+	if _, err := w.w.WriteString(value); err != nil {
+		return err
+	}
+
+	_, err := w.w.WriteString("\r\n")
+	return err
+}
+
+// WriteNull - writes absence of value
+func (w *Writer) WriteNull() error {
+	if w.proto == RESP3 {
+		return w.writeLine('_', "")
+	}
+
+	return w.writeLine('$', "-1")
+}
+
+// WriteDouble - writes double in RESP3, in RESP2 it is sent as bulk string
+func (w *Writer) WriteDouble(value float64) error {
+	var formatted string
+	switch {
+	case math.IsInf(value, 1):
+		formatted = "inf"
+	case math.IsInf(value, -1):
+		formatted = "-inf"
+	case math.IsNaN(value):
+		formatted = "nan"
+	default:
+		formatted = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	if w.proto == RESP3 {
+		return w.writeLine(',', formatted)
+	}
+
+	return w.WriteBulkString(formatted)
+}
+
+// WriteBoolean - writes boolean in RESP3, in RESP2 it is sent as integer
+func (w *Writer) WriteBoolean(value bool) error {
+	if w.proto == RESP3 {
+		if value {
+			return w.writeLine('#', "t")
+		}
+		return w.writeLine('#', "f")
+	}
+
+	if value {
+		return w.WriteInteger(1)
+	}
+	return w.WriteInteger(0)
+}
+
+// WriteArrayHeader - writes length of array, elements have to be written next
+func (w *Writer) WriteArrayHeader(n int) error {
+	return w.writeLine('*', strconv.Itoa(n))
+}
+
+// WriteMapHeader - writes number of pairs in map, keys and values have to be written next.
+// In RESP2 map is sent as flat array of keys and values.
+func (w *Writer) WriteMapHeader(n int) error {
+	if w.proto == RESP3 {
+		return w.writeLine('%', strconv.Itoa(n))
+	}
+
+	return w.WriteArrayHeader(n * 2)
+}
+
+// WriteSetHeader - writes number of elements in set, in RESP2 set is sent as array
+func (w *Writer) WriteSetHeader(n int) error {
+	if w.proto == RESP3 {
+		return w.writeLine('~', strconv.Itoa(n))
+	}
+
+	return w.WriteArrayHeader(n)
+}
+
+// WritePushHeader - writes number of elements in push message, in RESP2 it is sent as array
+func (w *Writer) WritePushHeader(n int) error {
+	if w.proto == RESP3 {
+		return w.writeLine('>', strconv.Itoa(n))
+	}
+
+	return w.WriteArrayHeader(n)
+}
+
+// WriteCommand - writes command as array of bulk strings, the way clients send it
+func (w *Writer) WriteCommand(args ...string) error {
+	if err := w.WriteArrayHeader(len(args)); err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		if err := w.WriteBulkString(arg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteValue - writes go value choosing the suitable RESP type
+func (w *Writer) WriteValue(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return w.WriteNull()
+	case SimpleString:
+		return w.WriteSimpleString(string(v))
+	case Error:
+		return w.WriteError(string(v))
+	case error:
+		return w.WriteError(v.Error())
+	case string:
+		return w.WriteBulkString(v)
+	case []byte:
+		return w.WriteBulkString(string(v))
+	case int:
+		return w.WriteInteger(int64(v))
+	case int64:
+		return w.WriteInteger(v)
+	case bool:
+		return w.WriteBoolean(v)
+	case float64:
+		return w.WriteDouble(v)
+	case []string:
+		if err := w.WriteArrayHeader(len(v)); err != nil {
+			return err
+		}
+		return w.writeStrings(v)
+	case Set:
+		if err := w.WriteSetHeader(len(v)); err != nil {
+			return err
+		}
+		return w.writeStrings(v)
+	case []interface{}:
+		if err := w.WriteArrayHeader(len(v)); err != nil {
+			return err
+		}
+		return w.writeValues(v)
+	case Push:
+		if err := w.WritePushHeader(len(v)); err != nil {
+			return err
+		}
+		return w.writeValues(v)
+	case map[string]string:
+		if err := w.WriteMapHeader(len(v)); err != nil {
+			return err
+		}
+		for _, key := range sortedKeys(v) {
+			if err := w.WriteBulkString(key); err != nil {
+				return err
+			}
+			if err := w.WriteBulkString(v[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		if err := w.WriteMapHeader(len(v)); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := w.WriteBulkString(key); err != nil {
+				return err
+			}
+			if err := w.WriteValue(v[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("Can't write %T as RESP", value)
+	}
+}
+
+func (w *Writer) writeStrings(values []string) error {
+	for _, el := range values {
+		if err := w.WriteBulkString(el); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *Writer) writeValues(values []interface{}) error {
+	for _, el := range values {
+		if err := w.WriteValue(el); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *Writer) writeLine(prefix byte, value string) error {
+	if err := w.w.WriteByte(prefix); err != nil {
+		return err
+	}
+
+	if _, err := w.w.WriteString(value); err != nil {
+		return err
+	}
+
+	_, err := w.w.WriteString("\r\n")
+	return err
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
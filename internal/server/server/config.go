@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/store"
 )
 
 // Config ...
@@ -13,8 +15,31 @@ type Config struct {
 	sessionKey                      string
 	sessionMaxNumberIDLEConnections int
 	sessionName                     string
+	storageEngine                   string
+	respPort                        string
+	appendOnly                      bool
+	appendFilename                  string
+	appendFsync                     string
+	dbFilename                      string
+	activeExpireHz                  int
+	activeExpireEffort              int
+	maxmemory                       int64
+	maxmemoryPolicy                 string
+	maxmemorySamples                int
+	pubsubBuffer                    int
+	notifyKeyspaceEvents            string
+	keysLimit                       int
 }
 
+const (
+	// engineRedis - data is stored in external redis
+	engineRedis = "redis"
+	// engineNative - data is stored in memory of the server by store.Native
+	engineNative = "native"
+	// defaultKeysLimit - maximum number of keys returned by /keys unless KEYS_LIMIT is set
+	defaultKeysLimit = 1000
+)
+
 // NewConfig - helper to init config
 func NewConfig() (*Config, error) {
 	serverPort, exists := os.LookupEnv("SERVER_PORT")
@@ -22,8 +47,16 @@ func NewConfig() (*Config, error) {
 		return nil, fmt.Errorf("No SERVER_PORT in .env")
 	}
 
-	redisAddr, exists := os.LookupEnv("REDIS_ADDR")
+	storageEngine, exists := os.LookupEnv("STORAGE_ENGINE")
 	if !exists {
+		storageEngine = engineRedis
+	}
+	if storageEngine != engineRedis && storageEngine != engineNative {
+		return nil, fmt.Errorf("Unknown STORAGE_ENGINE %s, available: %s/%s", storageEngine, engineRedis, engineNative)
+	}
+
+	redisAddr, exists := os.LookupEnv("REDIS_ADDR")
+	if !exists && storageEngine == engineRedis {
 		return nil, fmt.Errorf("No REDIS_ADDR in .env")
 	}
 
@@ -41,11 +74,115 @@ func NewConfig() (*Config, error) {
 		return nil, err
 	}
 
+	// RESP listener is optional, it is started only when the port is set
+	respPort, _ := os.LookupEnv("RESP_PORT")
+
+	// append only file is used by native engine only, external redis has its own persistence
+	appendOnly := false
+	if value, exists := os.LookupEnv("APPENDONLY"); exists {
+		switch value {
+		case "yes":
+			appendOnly = true
+		case "no":
+		default:
+			return nil, fmt.Errorf("APPENDONLY has to be yes or no, got %s", value)
+		}
+	}
+
+	appendFilename, exists := os.LookupEnv("APPENDFILENAME")
+	if !exists {
+		appendFilename = "appendonly.aof"
+	}
+
+	appendFsync, exists := os.LookupEnv("APPENDFSYNC")
+	if !exists {
+		appendFsync = store.FsyncEverySec
+	}
+
+	// RDB file is written by SAVE and BGSAVE of native engine and loaded on start, when append only file is disabled
+	dbFilename, exists := os.LookupEnv("DBFILENAME")
+	if !exists {
+		dbFilename = "dump.rdb"
+	}
+
+	// active expiration of native engine, the same defaults as hz and active-expire-effort of redis
+	activeExpireHz, err := intEnv("ACTIVE_EXPIRE_HZ", 10)
+	if err != nil {
+		return nil, err
+	}
+
+	activeExpireEffort, err := intEnv("ACTIVE_EXPIRE_EFFORT", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// memory limit of native engine, it is not set by default
+	var maxmemory int64
+	if value, exists := os.LookupEnv("MAXMEMORY"); exists {
+		if maxmemory, err = store.ParseMemory(value); err != nil {
+			return nil, err
+		}
+	}
+
+	maxmemoryPolicy, exists := os.LookupEnv("MAXMEMORY_POLICY")
+	if !exists {
+		maxmemoryPolicy = store.PolicyNoEviction
+	}
+
+	maxmemorySamples, err := intEnv("MAXMEMORY_SAMPLES", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	// subscriber which does not read messages is dropped when its buffer is full
+	pubsubBuffer, err := intEnv("PUBSUB_BUFFER", store.DefaultPubSubBuffer)
+	if err != nil {
+		return nil, err
+	}
+
+	// keyspace events are disabled by default, like in redis. External redis keeps its own setting unless it is set.
+	notifyKeyspaceEvents, _ := os.LookupEnv("NOTIFY_KEYSPACE_EVENTS")
+
+	// /keys rejects patterns matching more keys, 0 - no limit
+	keysLimit, err := intEnv("KEYS_LIMIT", defaultKeysLimit)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
 		serverPort:                      serverPort,
 		redisAddr:                       redisAddr,
 		sessionMaxNumberIDLEConnections: imaxIDLEconn,
 		sessionKey:                      sessionKey,
 		sessionName:                     "auth",
+		storageEngine:                   storageEngine,
+		respPort:                        respPort,
+		appendOnly:                      appendOnly,
+		appendFilename:                  appendFilename,
+		appendFsync:                     appendFsync,
+		dbFilename:                      dbFilename,
+		activeExpireHz:                  activeExpireHz,
+		activeExpireEffort:              activeExpireEffort,
+		maxmemory:                       maxmemory,
+		maxmemoryPolicy:                 maxmemoryPolicy,
+		maxmemorySamples:                maxmemorySamples,
+		pubsubBuffer:                    pubsubBuffer,
+		notifyKeyspaceEvents:            notifyKeyspaceEvents,
+		keysLimit:                       keysLimit,
 	}, nil
 }
+
+// intEnv - integer value of the variable, default value is used when it is not set
+func intEnv(name string, defaultValue int) (int, error) {
+	value, exists := os.LookupEnv(name)
+	if !exists {
+		return defaultValue, nil
+	}
+
+	res, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s has to be integer: %w", name, err)
+	}
+
+	return res, nil
+}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/Vysogota99/redis-implementation/internal/server/store"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/sessions"
@@ -22,15 +23,32 @@ func (r *router) setHashHandler(c *gin.Context) {
 	}
 
 	data := &models.SetHashRequest{}
-	if err := c.ShouldBindJSON(data); err != nil {
+	if err := bindElements(c, data); err != nil {
 		respond(c, http.StatusBadRequest, "", err.Error())
 		return
 	}
 
-	err := r.redis.SetHash(c, key.(string), data.Value, data.TTL)
+	values, err := store.HashValues(data.Value, data.Raw)
+	if err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	version, ok, err := r.ifMatch(c, key.(string))
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	if ok {
+		version, err = r.redis.SetHashCAS(c, key.(string), values, data.Expiration, version)
+		setVersion(c, version)
+	} else {
+		err = r.redis.SetHash(c, key.(string), values, data.Expiration)
+	}
 	if err != nil {
 		log.Println(err)
-		respond(c, http.StatusInternalServerError, "", err.Error())
+		respond(c, errorStatus(err), "", err.Error())
 		return
 	}
 
@@ -50,10 +68,36 @@ func (r *router) setStringHandler(c *gin.Context) {
 		return
 	}
 
-	result, err := r.redis.SetString(c, key.(string), data.Value, data.TTL)
+	version, ok, err := r.ifMatch(c, key.(string))
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	// plain SET keeps the former behaviour, compare-and-set is not combined with options
+	plain := data.SetOptions == models.SetOptions{Expiration: data.Expiration} && *data.Value != ""
+	if ok && !plain {
+		respond(c, http.StatusBadRequest, "", "ERR If-Match is not supported with empty value or options of SET")
+		return
+	}
+
+	result := "OK"
+	switch {
+	case ok:
+		version, err = r.redis.SetStringCAS(c, key.(string), *data.Value, data.Expiration, version)
+		setVersion(c, version)
+	case plain:
+		result, err = r.redis.SetString(c, key.(string), *data.Value, data.Expiration)
+	default:
+		result, err = r.redis.SetArgs(c, key.(string), *data.Value, data.SetOptions)
+	}
+	if err == redis.Nil {
+		respond(c, http.StatusNoContent, "", err.Error())
+		return
+	}
 	if err != nil {
 		log.Println(err)
-		respond(c, http.StatusInternalServerError, "", err.Error())
+		respond(c, errorStatus(err), "", err.Error())
 		return
 	}
 	respond(c, http.StatusOK, result, "")
@@ -67,15 +111,27 @@ func (r *router) setListHandler(c *gin.Context) {
 	}
 
 	data := &models.SetListRequest{}
-	if err := c.ShouldBindJSON(data); err != nil {
+	if err := bindElements(c, data); err != nil {
 		respond(c, http.StatusUnprocessableEntity, "", err.Error())
 		return
 	}
+	data.Value = listElements(data.Value)
+
+	version, ok, err := r.ifMatch(c, key.(string))
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
 
-	err := r.redis.SetList(c, key.(string), data.Value, data.TTL)
+	if ok {
+		version, err = r.redis.SetListCAS(c, key.(string), data.Value, data.Expiration, version)
+		setVersion(c, version)
+	} else {
+		err = r.redis.SetList(c, key.(string), data.Value, data.Expiration)
+	}
 	if err != nil {
 		log.Println(err)
-		respond(c, http.StatusInternalServerError, "", err.Error())
+		respond(c, errorStatus(err), "", err.Error())
 		return
 	}
 
@@ -89,6 +145,10 @@ func (r *router) getHashHandler(c *gin.Context) {
 		return
 	}
 
+	if !r.etag(c, key) {
+		return
+	}
+
 	result, err := r.redis.GetHash(c, key)
 	if err != nil {
 		if err == redis.Nil {
@@ -100,7 +160,11 @@ func (r *router) getHashHandler(c *gin.Context) {
 		return
 	}
 
-	respond(c, http.StatusOK, result, "")
+	if rawQuery(c) {
+		respond(c, http.StatusOK, result, "")
+		return
+	}
+	respond(c, http.StatusOK, store.DecodeHashValues(result), "")
 }
 
 func (r *router) getStringHandler(c *gin.Context) {
@@ -110,6 +174,10 @@ func (r *router) getStringHandler(c *gin.Context) {
 		return
 	}
 
+	if !r.etag(c, key) {
+		return
+	}
+
 	result, err := r.redis.GetString(c, key)
 	if err != nil {
 		if err == redis.Nil {
@@ -124,19 +192,9 @@ func (r *router) getStringHandler(c *gin.Context) {
 	respond(c, http.StatusOK, result, "")
 }
 
-func (r *router) keysHandler(c *gin.Context) {
-	pattern := c.Query("pattern")
-	if pattern == "" {
-		respond(c, http.StatusBadRequest, "", "No field pattern in get query")
-		return
-	}
-	result, err := r.redis.GetKeys(c, pattern)
+func (r *router) infoHandler(c *gin.Context) {
+	result, err := r.redis.Info(c, c.Query("section"))
 	if err != nil {
-		if err == redis.Nil {
-			respond(c, http.StatusNoContent, "", err.Error())
-			return
-		}
-
 		respond(c, http.StatusInternalServerError, "", err.Error())
 		return
 	}
@@ -151,6 +209,10 @@ func (r *router) getListHandler(c *gin.Context) {
 		return
 	}
 
+	if !r.etag(c, key) {
+		return
+	}
+
 	result, err := r.redis.GetList(c, key)
 	if err != nil {
 		if err == redis.Nil {
@@ -242,9 +304,9 @@ func (r *router) signupHandler(c *gin.Context) {
 		"password": hashPassword(req.Password),
 	}
 
-	err = r.redis.SetHash(context.Background(), userKey, userCreate, 0)
+	err = r.redis.SetHash(context.Background(), userKey, userCreate, models.Expiration{})
 	if err != nil {
-		respond(c, http.StatusInternalServerError, "", err.Error())
+		respond(c, errorStatus(err), "", err.Error())
 		return
 	}
 
@@ -280,8 +342,12 @@ func (r *router) hGetHandler(c *gin.Context) {
 	}
 
 	field := c.Query("field")
-	if key == "" {
-		respond(c, http.StatusBadRequest, "", "No field key in get query")
+	if field == "" {
+		respond(c, http.StatusBadRequest, "", "No field field in get query")
+		return
+	}
+
+	if !r.etag(c, key) {
 		return
 	}
 
@@ -296,7 +362,11 @@ func (r *router) hGetHandler(c *gin.Context) {
 		return
 	}
 
-	respond(c, http.StatusOK, result, "")
+	if rawQuery(c) {
+		respond(c, http.StatusOK, result, "")
+		return
+	}
+	respond(c, http.StatusOK, store.DecodeHashValue(result), "")
 }
 
 func (r *router) hSetHandler(c *gin.Context) {
@@ -307,15 +377,33 @@ func (r *router) hSetHandler(c *gin.Context) {
 	}
 
 	data := &models.SetHashRequest{}
-	if err := c.ShouldBindJSON(data); err != nil {
+	if err := bindElements(c, data); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	values, err := store.HashValues(data.Value, data.Raw)
+	if err != nil {
 		respond(c, http.StatusBadRequest, "", err.Error())
 		return
 	}
 
-	res, err := r.redis.HSet(c, key.(string), data.Value)
+	version, ok, err := r.ifMatch(c, key.(string))
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	var res int64
+	if ok {
+		res, version, err = r.redis.HSetCAS(c, key.(string), values, version)
+		setVersion(c, version)
+	} else {
+		res, err = r.redis.HSet(c, key.(string), values)
+	}
 	if err != nil {
 		log.Println(err)
-		respond(c, http.StatusInternalServerError, "", err.Error())
+		respond(c, errorStatus(err), "", err.Error())
 		return
 	}
 
@@ -353,6 +441,10 @@ func (r *router) lRangeHandler(c *gin.Context) {
 		return
 	}
 
+	if !r.etag(c, key) {
+		return
+	}
+
 	result, err := r.redis.LRange(context.Background(), key, startInt, stopInt)
 	if err != nil {
 		if err == redis.Nil {
@@ -385,19 +477,32 @@ func (r *router) lSetHandler(c *gin.Context) {
 		key: key.(string),
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := bindElements(c, &req); err != nil {
 		respond(c, http.StatusBadRequest, "", err.Error())
 		return
 	}
+	req.Value = listElement(req.Value)
 
-	result, err := r.redis.LSet(context.Background(), req.key, req.Index, req.Value)
+	version, ok, err := r.ifMatch(c, req.key)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	result := "OK"
+	if ok {
+		version, err = r.redis.LSetCAS(context.Background(), req.key, req.Index, req.Value, version)
+		setVersion(c, version)
+	} else {
+		result, err = r.redis.LSet(context.Background(), req.key, req.Index, req.Value)
+	}
 	if err != nil {
 		if err == redis.Nil {
 			respond(c, http.StatusNoContent, "", err.Error())
 			return
 		}
 
-		respond(c, http.StatusInternalServerError, "", err.Error())
+		respond(c, errorStatus(err), "", err.Error())
 		return
 	}
 
@@ -406,11 +511,62 @@ func (r *router) lSetHandler(c *gin.Context) {
 
 func (r *router) saveHandler(c *gin.Context) {
 	if err := r.redis.Save(context.Background()); err != nil {
+		if err == store.ErrSaveInProgress {
+			respond(c, http.StatusConflict, "", err.Error())
+			return
+		}
+
+		respond(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+
+	r.saveStatusHandler(c)
+}
+
+func (r *router) bgSaveHandler(c *gin.Context) {
+	if err := r.redis.BGSave(context.Background()); err != nil {
+		if err == store.ErrSaveInProgress {
+			respond(c, http.StatusConflict, "", err.Error())
+			return
+		}
+
+		respond(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusAccepted, "Background saving started", "")
+}
+
+func (r *router) saveStatusHandler(c *gin.Context) {
+	status, err := r.redis.SaveStatus(context.Background())
+	if err != nil {
 		respond(c, http.StatusInternalServerError, "", err.Error())
 		return
 	}
 
-	respond(c, http.StatusOK, "Файл dump.rdb в папке [project name]/build/redis/data", "")
+	respond(c, http.StatusOK, status, "")
+}
+
+// errorStatus - http status of the error returned by the store
+func errorStatus(err error) int {
+	if store.IsOOM(err) {
+		// memory limit is reached and the store is not allowed to evict keys
+		return http.StatusInsufficientStorage
+	}
+	if store.IsWrongType(err) || store.IsBusyGroup(err) || store.IsTxAborted(err) {
+		return http.StatusConflict
+	}
+	if store.IsVersionMismatch(err) {
+		return http.StatusPreconditionFailed
+	}
+	if store.IsNoGroup(err) {
+		return http.StatusNotFound
+	}
+	if store.IsInvalidArgument(err) {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
 }
 
 func respond(c *gin.Context, code int, result interface{}, err string) {
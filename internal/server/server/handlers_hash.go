@@ -0,0 +1,212 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/Vysogota99/redis-implementation/internal/server/store"
+	"github.com/gin-gonic/gin"
+)
+
+func (r *router) hDelHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.HDelRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.HDel(c, key.(string), data.Fields)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// hExistsHandler - ?key=name&field=role
+func (r *router) hExistsHandler(c *gin.Context) {
+	query := models.HFieldQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.HExists(c, query.Key, query.Field)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) hLenHandler(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		respond(c, http.StatusBadRequest, "", "No field key in get query")
+		return
+	}
+
+	result, err := r.redis.HLen(c, key)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// hKeysHandler - HKEYS or HVALS
+func (r *router) hKeysHandler(values bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.Query("key")
+		if key == "" {
+			respond(c, http.StatusBadRequest, "", "No field key in get query")
+			return
+		}
+
+		var result []string
+		var err error
+		if values {
+			result, err = r.redis.HVals(c, key)
+		} else {
+			result, err = r.redis.HKeys(c, key)
+		}
+		if err != nil {
+			respond(c, errorStatus(err), "", err.Error())
+			return
+		}
+
+		if !values || rawQuery(c) {
+			respond(c, http.StatusOK, result, "")
+			return
+		}
+
+		decoded := make([]interface{}, len(result))
+		for i, val := range result {
+			decoded[i] = store.DecodeHashValue(val)
+		}
+		respond(c, http.StatusOK, decoded, "")
+	}
+}
+
+// hMGetHandler - ?key=name&fields=role&fields=age
+func (r *router) hMGetHandler(c *gin.Context) {
+	query := models.HMGetQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.HMGet(c, query.Key, query.Fields)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	if !query.Raw {
+		for i, val := range result {
+			if str, ok := val.(string); ok {
+				result[i] = store.DecodeHashValue(str)
+			}
+		}
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) hSetNXHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.HSetNXRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data.Value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	values, err := store.HashValues(map[string]interface{}{data.Field: value}, data.Raw)
+	if err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.HSetNX(c, key.(string), data.Field, values[data.Field])
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) hIncrByHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.HIncrByRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.HIncrBy(c, key.(string), data.Field, *data.Increment, data.Raw)
+	if err != nil {
+		respond(c, counterStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) hIncrByFloatHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.HIncrByFloatRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.HIncrByFloat(c, key.(string), data.Field, *data.Increment, data.Raw)
+	if err != nil {
+		respond(c, counterStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// rawQuery - ?raw=true returns values of the hash as they are stored
+func rawQuery(c *gin.Context) bool {
+	raw, _ := strconv.ParseBool(c.Query("raw"))
+	return raw
+}
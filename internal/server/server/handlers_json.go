@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// jsonSetHandler - 204 if the value is not set because of nx or xx
+func (r *router) jsonSetHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.JSONSetRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.JSONSet(c, key.(string), data.Path, string(data.Value), data.JSONSetOptions)
+	respondValue(c, result, err)
+}
+
+// jsonGetHandler - ?key=doc&path=$.name
+func (r *router) jsonGetHandler(c *gin.Context) {
+	query := models.JSONGetQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.JSONGet(c, query.Key, query.Paths)
+	respondJSON(c, result, err)
+}
+
+func (r *router) jsonDelHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.JSONDelRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.JSONDel(c, key.(string), data.Path)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) jsonArrAppendHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.JSONArrAppendRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	values := make([]string, len(data.Values))
+	for i, val := range data.Values {
+		values[i] = string(val)
+	}
+
+	result, err := r.redis.JSONArrAppend(c, key.(string), data.Path, values)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) jsonNumIncrByHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.JSONNumIncrByRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.JSONNumIncrBy(c, key.(string), data.Path, data.Increment.String())
+	respondJSON(c, result, err)
+}
+
+// jsonObjKeysHandler - ?key=doc&path=$.address, 204 if there is no such key
+func (r *router) jsonObjKeysHandler(c *gin.Context) {
+	query := models.JSONPathQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.JSONObjKeys(c, query.Key, query.Path)
+	if err == redis.Nil {
+		respond(c, http.StatusNoContent, "", err.Error())
+		return
+	}
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// respondJSON - serialized JSON is embedded into the response as is, so that numbers are not rounded. 204 if
+// there is no such key.
+func respondJSON(c *gin.Context, result string, err error) {
+	if err == redis.Nil {
+		respond(c, http.StatusNoContent, "", err.Error())
+		return
+	}
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, json.RawMessage(result), "")
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/gin-gonic/gin"
+)
+
+// typeHandler - ?key=user, "none" if there is no such key
+func (r *router) typeHandler(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		respond(c, http.StatusBadRequest, "", "No field key in get query")
+		return
+	}
+
+	result, err := r.redis.Type(c, key)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// ttlHandler - TTL in seconds or PTTL in milliseconds, -1 if the key does not expire, -2 if there is no such key
+func (r *router) ttlHandler(ms bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.Query("key")
+		if key == "" {
+			respond(c, http.StatusBadRequest, "", "No field key in get query")
+			return
+		}
+
+		var result int64
+		var err error
+		if ms {
+			result, err = r.redis.PTTL(c, key)
+		} else {
+			result, err = r.redis.TTL(c, key)
+		}
+		if err != nil {
+			respond(c, errorStatus(err), "", err.Error())
+			return
+		}
+
+		respond(c, http.StatusOK, result, "")
+	}
+}
+
+func (r *router) expireHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.ExpireRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.Expire(c, key.(string), data.ExpireOptions)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) persistHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	result, err := r.redis.Persist(c, key.(string))
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// existsHandler - ?keys=user&keys=name, number of existing keys
+func (r *router) existsHandler(c *gin.Context) {
+	query := models.ExistsQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.Exists(c, query.Keys)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
@@ -0,0 +1,338 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-redis/redis/v8"
+)
+
+// pushHandler - LPUSH or RPUSH
+func (r *router) pushHandler(left bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, exists := c.Get("key")
+		if !exists {
+			respond(c, http.StatusInternalServerError, "", "No key in context")
+			return
+		}
+
+		data := &models.ListPushRequest{}
+		if err := bindElements(c, data); err != nil {
+			respond(c, http.StatusUnprocessableEntity, "", err.Error())
+			return
+		}
+
+		values := listElements(data.Value)
+
+		var result int64
+		var err error
+		if left {
+			result, err = r.redis.LPush(c, key.(string), values)
+		} else {
+			result, err = r.redis.RPush(c, key.(string), values)
+		}
+		if err != nil {
+			respond(c, errorStatus(err), "", err.Error())
+			return
+		}
+
+		respond(c, http.StatusOK, result, "")
+	}
+}
+
+// popHandler - LPOP or RPOP, 204 if there is no such key
+func (r *router) popHandler(left bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, exists := c.Get("key")
+		if !exists {
+			respond(c, http.StatusInternalServerError, "", "No key in context")
+			return
+		}
+
+		data := &models.ListPopRequest{}
+		if err := c.ShouldBindJSON(data); err != nil {
+			respond(c, http.StatusUnprocessableEntity, "", err.Error())
+			return
+		}
+
+		count := data.Count
+		if count == 0 {
+			count = 1
+		}
+
+		var result []interface{}
+		var err error
+		if left {
+			result, err = r.redis.LPop(c, key.(string), count)
+		} else {
+			result, err = r.redis.RPop(c, key.(string), count)
+		}
+		if err == redis.Nil {
+			respond(c, http.StatusNoContent, "", err.Error())
+			return
+		}
+		if err != nil {
+			respond(c, errorStatus(err), "", err.Error())
+			return
+		}
+
+		if data.Count == 0 {
+			respond(c, http.StatusOK, result[0], "")
+			return
+		}
+		respond(c, http.StatusOK, result, "")
+	}
+}
+
+func (r *router) lLenHandler(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		respond(c, http.StatusBadRequest, "", "No field key in get query")
+		return
+	}
+
+	result, err := r.redis.LLen(c, key)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// lIndexHandler - ?key=name&index=-1, 204 if the index is out of range
+func (r *router) lIndexHandler(c *gin.Context) {
+	query := models.LIndexQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.LIndex(c, query.Key, *query.Index)
+	if err == redis.Nil {
+		respond(c, http.StatusNoContent, "", err.Error())
+		return
+	}
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) lInsertHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.LInsertRequest{}
+	if err := bindElements(c, data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.LInsert(c, key.(string), !data.After, listElement(data.Pivot), listElement(data.Value))
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) lRemHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.LRemRequest{}
+	if err := bindElements(c, data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.LRem(c, key.(string), data.Count, listElement(data.Value))
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) lTrimHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.LTrimRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	if err := r.redis.LTrim(c, key.(string), data.Start, data.Stop); err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, "OK", "")
+}
+
+// lPosHandler - without count responds with the index or 204 if there is no such element
+func (r *router) lPosHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.LPosRequest{}
+	if err := bindElements(c, data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.LPos(c, key.(string), listElement(data.Value), data.LPosArgs)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	if data.Count != nil {
+		respond(c, http.StatusOK, result, "")
+		return
+	}
+	if len(result) == 0 {
+		respond(c, http.StatusNoContent, "", redis.Nil.Error())
+		return
+	}
+	respond(c, http.StatusOK, result[0], "")
+}
+
+// lMoveHandler - responds with the moved element, 204 if there is no source
+func (r *router) lMoveHandler(c *gin.Context) {
+	data := &models.LMoveRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.LMove(c, data.Source, data.Destination, data.From, data.To)
+	if err == redis.Nil {
+		respond(c, http.StatusNoContent, "", err.Error())
+		return
+	}
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// bPopHandler - the request is held until the element is popped or the timeout elapses, disconnect of the
+// client cancels the request context and unblocks it
+func (r *router) bPopHandler(left bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data := &models.BPopRequest{}
+		if err := c.ShouldBindJSON(data); err != nil {
+			respond(c, http.StatusUnprocessableEntity, "", err.Error())
+			return
+		}
+
+		ctx := c.Request.Context()
+		var result *models.BPopResult
+		var err error
+		if left {
+			result, err = r.redis.BLPop(ctx, data.Keys, seconds(data.Timeout))
+		} else {
+			result, err = r.redis.BRPop(ctx, data.Keys, seconds(data.Timeout))
+		}
+		respondBlocked(c, result, err)
+	}
+}
+
+func (r *router) bLMoveHandler(c *gin.Context) {
+	data := &models.BLMoveRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.BLMove(c.Request.Context(), data.Source, data.Destination, data.From, data.To, seconds(data.Timeout))
+	respondBlocked(c, result, err)
+}
+
+// respondBlocked - timeout of the blocking command is 204. The element popped by native engine is written even
+// if the client is gone meanwhile, redis engine returns the element to the list and the error of the context.
+// Errors are not written to the gone client.
+func respondBlocked(c *gin.Context, result interface{}, err error) {
+	if err != nil && c.Request.Context().Err() != nil {
+		c.Abort()
+		return
+	}
+	if err == redis.Nil {
+		respond(c, http.StatusNoContent, "", err.Error())
+		return
+	}
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// seconds - timeout of blocking commands is given in seconds, like in redis
+func seconds(timeout float64) time.Duration {
+	return time.Duration(timeout * float64(time.Second))
+}
+
+// bindElements - the same as ShouldBindJSON, but numbers are decoded as json.Number, so that listElement
+// may tell integers from floats
+func bindElements(c *gin.Context, obj interface{}) error {
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(obj); err != nil {
+		return err
+	}
+
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// listElement - integer is stored as int64 and other numbers as float64, so that their types are kept by
+// models.ListElement. Numbers nested into maps are serialized as they are written.
+func listElement(value interface{}) interface{} {
+	number, ok := value.(json.Number)
+	if !ok {
+		return value
+	}
+
+	if integer, err := number.Int64(); err == nil {
+		return integer
+	}
+
+	float, _ := number.Float64()
+	return float
+}
+
+func listElements(values []interface{}) []interface{} {
+	res := make([]interface{}, len(values))
+	for i, value := range values {
+		res[i] = listElement(value)
+	}
+
+	return res
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/Vysogota99/redis-implementation/internal/server/store"
+	"github.com/gin-gonic/gin"
+)
+
+// publishHandler - responds with number of subscribers which received the message
+func (r *router) publishHandler(c *gin.Context) {
+	data := &models.PublishRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.Publish(c, data.Channel, data.Message)
+	if err != nil {
+		log.Println(err)
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// subscribeHandler - SUBSCRIBE or PSUBSCRIBE with patterns, ?channels=news&channels=sport. Every channel is
+// confirmed by subscribe event, then messages are sent as server-sent events until the client disconnects.
+// The subscriber which does not read messages is dropped after error event.
+func (r *router) subscribeHandler(patterns bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := models.SubscribeQuery{}
+		if err := c.ShouldBindQuery(&query); err != nil {
+			respond(c, http.StatusBadRequest, "", err.Error())
+			return
+		}
+
+		var sub store.Subscription
+		var err error
+		event := "subscribe"
+		if patterns {
+			sub, err = r.redis.Subscribe(c, nil, query.Channels)
+			event = "psubscribe"
+		} else {
+			sub, err = r.redis.Subscribe(c, query.Channels, nil)
+		}
+		if err != nil {
+			log.Println(err)
+			respond(c, errorStatus(err), "", err.Error())
+			return
+		}
+		defer sub.Close()
+
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+		for i, channel := range query.Channels {
+			c.SSEvent(event, gin.H{"channel": channel, "count": i + 1})
+		}
+		c.Writer.Flush()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case message, ok := <-sub.Messages():
+				if !ok {
+					if err := sub.Err(); err != nil {
+						c.SSEvent("error", err.Error())
+					}
+					return false
+				}
+
+				if message.Pattern != "" {
+					c.SSEvent("pmessage", message)
+				} else {
+					c.SSEvent("message", message)
+				}
+				return true
+			case <-c.Request.Context().Done():
+				return false
+			}
+		})
+	}
+}
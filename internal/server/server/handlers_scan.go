@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/Vysogota99/redis-implementation/internal/server/store"
+	"github.com/gin-gonic/gin"
+)
+
+// keysScanCount - number of keys examined by one SCAN of /keys
+const keysScanCount = 1000
+
+// limitedKeys - engine which finds keys in one walk of the keyspace stopping after the limit, e.g. store.Native
+type limitedKeys interface {
+	GetKeysLimit(ctx context.Context, pattern string, limit int) ([]string, error)
+}
+
+// keysHandler - ?pattern=user:*, keys are collected by SCAN, so that redis is not blocked by KEYS, or by
+// one walk of the engine implementing limitedKeys. More than keysLimit keys are rejected with 422, /scan
+// has to be used for them.
+func (r *router) keysHandler(c *gin.Context) {
+	pattern := c.Query("pattern")
+	if pattern == "" {
+		respond(c, http.StatusBadRequest, "", "No field pattern in get query")
+		return
+	}
+
+	var result []string
+	var err error
+	if engine, ok := r.redis.(limitedKeys); ok {
+		limit := 0
+		if r.keysLimit > 0 {
+			limit = r.keysLimit + 1
+		}
+		result, err = engine.GetKeysLimit(c, pattern, limit)
+	} else {
+		result, err = r.scanKeys(c, pattern)
+	}
+	if err != nil {
+		respond(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+
+	if r.keysLimit > 0 && len(result) > r.keysLimit {
+		respond(c, http.StatusUnprocessableEntity, "", fmt.Sprintf("More than %d keys match the pattern, use /scan", r.keysLimit))
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// scanKeys - keys matching the pattern collected by SCAN, it stops after more than keysLimit keys are found
+func (r *router) scanKeys(ctx context.Context, pattern string) ([]string, error) {
+	// SCAN may return the same key more than once
+	seen := map[string]struct{}{}
+	result := []string{}
+	var cursor uint64
+	for {
+		keys, next, err := r.redis.Scan(ctx, cursor, pattern, keysScanCount, "")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				result = append(result, key)
+			}
+		}
+
+		if next == 0 || (r.keysLimit > 0 && len(result) > r.keysLimit) {
+			return result, nil
+		}
+		cursor = next
+	}
+}
+
+// scanHandler - ?cursor=0&match=user:*&count=100&type=hash
+func (r *router) scanHandler(c *gin.Context) {
+	query := models.ScanQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	keys, next, err := r.redis.Scan(c, query.Cursor, query.Match, query.Count, query.Type)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, models.ScanResult{Cursor: next, Keys: keys}, "")
+}
+
+// hScanHandler - ?key=user&cursor=0&match=r*&count=100
+func (r *router) hScanHandler(c *gin.Context) {
+	query := models.KeyScanQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	pairs, next, err := r.redis.HScan(c, query.Key, query.Cursor, query.Match, query.Count)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	fields := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if query.Raw {
+			fields[pairs[i]] = pairs[i+1]
+		} else {
+			fields[pairs[i]] = store.DecodeHashValue(pairs[i+1])
+		}
+	}
+
+	respond(c, http.StatusOK, models.HScanResult{Cursor: next, Fields: fields}, "")
+}
+
+// sScanHandler - ?key=roles&cursor=0&match=a*&count=100
+func (r *router) sScanHandler(c *gin.Context) {
+	query := models.KeyScanQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	members, next, err := r.redis.SScan(c, query.Key, query.Cursor, query.Match, query.Count)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, models.SScanResult{Cursor: next, Members: members}, "")
+}
+
+// zScanHandler - ?key=rating&cursor=0&match=i*&count=100
+func (r *router) zScanHandler(c *gin.Context) {
+	query := models.KeyScanQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	pairs, next, err := r.redis.ZScan(c, query.Key, query.Cursor, query.Match, query.Count)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	members := make([]models.ZMember, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		score, err := strconv.ParseFloat(pairs[i+1], 64)
+		if err != nil {
+			respond(c, http.StatusInternalServerError, "", err.Error())
+			return
+		}
+		members = append(members, models.ZMember{Member: pairs[i], Score: score})
+	}
+
+	respond(c, http.StatusOK, models.ZScanResult{Cursor: next, Members: members}, "")
+}
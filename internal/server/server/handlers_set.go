@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (r *router) sAddHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.SetMembersRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.SAdd(c, key.(string), data.Members)
+	if err != nil {
+		log.Println(err)
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) sRemHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.SetMembersRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.SRem(c, key.(string), data.Members)
+	if err != nil {
+		log.Println(err)
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) sMembersHandler(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		respond(c, http.StatusBadRequest, "", "No field key in get query")
+		return
+	}
+
+	result, err := r.redis.SMembers(c, key)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) sIsMemberHandler(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		respond(c, http.StatusBadRequest, "", "No field key in get query")
+		return
+	}
+
+	member, exists := c.GetQuery("member")
+	if !exists {
+		respond(c, http.StatusBadRequest, "", "No field member in get query")
+		return
+	}
+
+	result, err := r.redis.SIsMember(c, key, member)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) sCardHandler(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		respond(c, http.StatusBadRequest, "", "No field key in get query")
+		return
+	}
+
+	result, err := r.redis.SCard(c, key)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// setOperationHandler - SINTER, SUNION and SDIFF of sets passed as ?keys=a&keys=b
+func (r *router) setOperationHandler(operation func(ctx context.Context, keys []string) ([]string, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys := c.QueryArray("keys")
+		if len(keys) == 0 {
+			respond(c, http.StatusBadRequest, "", "No field keys in get query")
+			return
+		}
+
+		result, err := operation(c, keys)
+		if err != nil {
+			respond(c, errorStatus(err), "", err.Error())
+			return
+		}
+
+		respond(c, http.StatusOK, result, "")
+	}
+}
+
+// setStoreHandler - SINTERSTORE, SUNIONSTORE and SDIFFSTORE, responds with cardinality of destination
+func (r *router) setStoreHandler(operation func(ctx context.Context, destination string, keys []string) (int64, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data := &models.SetStoreRequest{}
+		if err := c.ShouldBindJSON(data); err != nil {
+			respond(c, http.StatusBadRequest, "", err.Error())
+			return
+		}
+
+		result, err := operation(c, data.Destination, data.Keys)
+		if err != nil {
+			log.Println(err)
+			respond(c, errorStatus(err), "", err.Error())
+			return
+		}
+
+		respond(c, http.StatusOK, result, "")
+	}
+}
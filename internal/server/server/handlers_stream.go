@@ -0,0 +1,263 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/gin-gonic/gin"
+)
+
+// xAddHandler - responds with id of the added entry
+func (r *router) xAddHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.XAddRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.XAdd(c, key.(string), data.ID, data.Fields, data.XTrim)
+	if err != nil {
+		log.Println(err)
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// xRangeHandler - XRANGE and XREVRANGE, ?key=events&start=-&end=+, see models.XRangeQuery for the other parameters
+func (r *router) xRangeHandler(operation func(ctx context.Context, key string, query models.XRangeQuery) ([]models.StreamEntry, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.Query("key")
+		if key == "" {
+			respond(c, http.StatusBadRequest, "", "No field key in get query")
+			return
+		}
+
+		query := models.XRangeQuery{}
+		if err := c.ShouldBindQuery(&query); err != nil {
+			respond(c, http.StatusBadRequest, "", err.Error())
+			return
+		}
+
+		result, err := operation(c, key, query)
+		if err != nil {
+			respond(c, errorStatus(err), "", err.Error())
+			return
+		}
+
+		respond(c, http.StatusOK, result, "")
+	}
+}
+
+func (r *router) xLenHandler(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		respond(c, http.StatusBadRequest, "", "No field key in get query")
+		return
+	}
+
+	result, err := r.redis.XLen(c, key)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// xTrimHandler - responds with number of removed entries
+func (r *router) xTrimHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.XTrimRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.XTrim(c, key.(string), data.XTrim)
+	if err != nil {
+		log.Println(err)
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// xReadHandler - ?keys=a&keys=b&ids=0&ids=$, responds only with streams which have new entries
+func (r *router) xReadHandler(c *gin.Context) {
+	query := models.XReadQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.XRead(c, query)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) xGroupCreateHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.XGroupCreateRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	if err := r.redis.XGroupCreate(c, key.(string), data.Group, data.ID, data.MkStream); err != nil {
+		log.Println(err)
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusCreated, "success", "")
+}
+
+// xReadGroupHandler - keys are passed in the body, so that there is no key middleware
+func (r *router) xReadGroupHandler(c *gin.Context) {
+	data := models.XReadGroupRequest{}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.XReadGroup(c, data)
+	if err != nil {
+		log.Println(err)
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// xAckHandler - responds with number of acknowledged entries
+func (r *router) xAckHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.XAckRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.XAck(c, key.(string), data.Group, data.IDs)
+	if err != nil {
+		log.Println(err)
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// xPendingHandler - ?key=events&group=workers responds with summary of pending entries, with start, end, count
+// or consumer it responds with the pending entries themselves
+func (r *router) xPendingHandler(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		respond(c, http.StatusBadRequest, "", "No field key in get query")
+		return
+	}
+
+	query := models.XPendingQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	if query.Start == "" && query.End == "" && query.Count == 0 && query.Consumer == "" {
+		result, err := r.redis.XPending(c, key, query.Group)
+		if err != nil {
+			respond(c, errorStatus(err), "", err.Error())
+			return
+		}
+
+		respond(c, http.StatusOK, result, "")
+		return
+	}
+
+	result, err := r.redis.XPendingExt(c, key, query)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// xClaimHandler - responds with claimed entries
+func (r *router) xClaimHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.XClaimRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.XClaim(c, key.(string), data.XClaim)
+	if err != nil {
+		log.Println(err)
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// xAutoClaimHandler - responds with claimed entries and the id to continue with
+func (r *router) xAutoClaimHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.XAutoClaimRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.XAutoClaim(c, key.(string), data.XAutoClaim)
+	if err != nil {
+		log.Println(err)
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/Vysogota99/redis-implementation/internal/server/store"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// incrHandler - INCR and DECR or INCRBY and DECRBY with increment from the request
+func (r *router) incrHandler(by bool, sign int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, exists := c.Get("key")
+		if !exists {
+			respond(c, http.StatusInternalServerError, "", "No key in context")
+			return
+		}
+
+		data := &models.IncrRequest{}
+		if err := c.ShouldBindJSON(data); err != nil {
+			respond(c, http.StatusUnprocessableEntity, "", err.Error())
+			return
+		}
+
+		increment := int64(1)
+		if by {
+			if data.Increment == nil {
+				respond(c, http.StatusUnprocessableEntity, "", "No field increment in request")
+				return
+			}
+			increment = *data.Increment
+		}
+
+		if sign < 0 {
+			if increment == math.MinInt64 {
+				respond(c, http.StatusUnprocessableEntity, "", "ERR decrement would overflow")
+				return
+			}
+			increment = -increment
+		}
+
+		result, err := r.redis.IncrBy(c, key.(string), increment)
+		if err != nil {
+			respond(c, counterStatus(err), "", err.Error())
+			return
+		}
+
+		respond(c, http.StatusOK, result, "")
+	}
+}
+
+func (r *router) incrByFloatHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.IncrByFloatRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.IncrByFloat(c, key.(string), *data.Increment)
+	if err != nil {
+		respond(c, counterStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// counterStatus - the key holding not a number conflicts with the increment, the result out of range
+// can't be processed
+func counterStatus(err error) int {
+	if store.IsNotNumber(err) {
+		return http.StatusConflict
+	}
+	if store.IsOverflow(err) {
+		return http.StatusUnprocessableEntity
+	}
+
+	return errorStatus(err)
+}
+
+func (r *router) appendHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.StringValueRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.Append(c, key.(string), *data.Value)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) strLenHandler(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		respond(c, http.StatusBadRequest, "", "No field key in get query")
+		return
+	}
+
+	result, err := r.redis.StrLen(c, key)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// getRangeHandler - ?key=name&start=0&end=-1
+func (r *router) getRangeHandler(c *gin.Context) {
+	query := models.GetRangeQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.GetRange(c, query.Key, *query.Start, *query.End)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) setRangeHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.SetRangeRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.SetRange(c, key.(string), data.Offset, *data.Value)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// getSetHandler - responds with the previous value, 204 if there was no value
+func (r *router) getSetHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.StringValueRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.GetSet(c, key.(string), *data.Value)
+	respondValue(c, result, err)
+}
+
+func (r *router) getDelHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	result, err := r.redis.GetDel(c, key.(string))
+	respondValue(c, result, err)
+}
+
+func (r *router) getExHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.GetExRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusUnprocessableEntity, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.GetEx(c, key.(string), data.GetExOptions)
+	respondValue(c, result, err)
+}
+
+// respondValue - value of the string, 204 if there is no such key
+func respondValue(c *gin.Context, result string, err error) {
+	if err == redis.Nil {
+		respond(c, http.StatusNoContent, "", err.Error())
+		return
+	}
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
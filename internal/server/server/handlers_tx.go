@@ -0,0 +1,28 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/gin-gonic/gin"
+)
+
+// txHandler - executes commands atomically, responds with result or error of every command. Invalid transaction
+// is rejected before anything is executed, aborted transaction responds with 409.
+func (r *router) txHandler(c *gin.Context) {
+	data := &models.TxRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.Tx(c, data.Watch, data.Commands)
+	if err != nil {
+		log.Println(err)
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/store"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// etag - sets ETag to the version of the key, nothing is set for missing key. The version is read before the
+// value, so that the value is never older than the ETag: the write with such ETag may be rejected needlessly,
+// but it never overwrites changes the client has not seen.
+func (r *router) etag(c *gin.Context, key string) bool {
+	version, err := r.redis.Version(c, key)
+	if err == redis.Nil {
+		return true
+	}
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return false
+	}
+
+	c.Header("ETag", formatETag(version))
+	return true
+}
+
+// ifMatch - version of the key expected by If-Match, false if the header is not set. "*" matches the current
+// version of the existing key. ETag which is not given by the server, e.g. weak one, never matches.
+func (r *router) ifMatch(c *gin.Context, key string) (int64, bool, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return 0, false, nil
+	}
+
+	if header == "*" {
+		version, err := r.redis.Version(c, key)
+		if err == redis.Nil {
+			return 0, true, store.ErrVersionMismatch
+		}
+		return version, true, err
+	}
+
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, true, store.ErrVersionMismatch
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil {
+		return 0, true, store.ErrVersionMismatch
+	}
+
+	return version, true, nil
+}
+
+// setVersion - ETag of the key written by compare-and-set, the key removed by the write has no version
+func setVersion(c *gin.Context, version int64) {
+	if version > 0 {
+		c.Header("ETag", formatETag(version))
+	}
+}
+
+// formatETag - strong ETag, e.g. "5"
+func formatETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// zAddHandler - with incr responds with the new score of the member, or 204 if it is not updated because of options
+func (r *router) zAddHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.ZAddRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	if !data.Incr {
+		result, err := r.redis.ZAdd(c, key.(string), data.Members, data.ZAddOptions)
+		if err != nil {
+			log.Println(err)
+			respond(c, errorStatus(err), "", err.Error())
+			return
+		}
+
+		respond(c, http.StatusOK, result, "")
+		return
+	}
+
+	if len(data.Members) != 1 {
+		respond(c, http.StatusBadRequest, "", "Incr supports a single member")
+		return
+	}
+
+	result, err := r.redis.ZAddIncr(c, key.(string), data.Members[0], data.ZAddOptions)
+	if err != nil {
+		if err == redis.Nil {
+			respond(c, http.StatusNoContent, "", err.Error())
+			return
+		}
+
+		log.Println(err)
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) zRemHandler(c *gin.Context) {
+	key, exists := c.Get("key")
+	if !exists {
+		respond(c, http.StatusInternalServerError, "", "No key in context")
+		return
+	}
+
+	data := &models.ZRemRequest{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.ZRem(c, key.(string), data.Members)
+	if err != nil {
+		log.Println(err)
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) zScoreHandler(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		respond(c, http.StatusBadRequest, "", "No field key in get query")
+		return
+	}
+
+	member, exists := c.GetQuery("member")
+	if !exists {
+		respond(c, http.StatusBadRequest, "", "No field member in get query")
+		return
+	}
+
+	result, err := r.redis.ZScore(c, key, member)
+	if err != nil {
+		if err == redis.Nil {
+			respond(c, http.StatusNoContent, "", err.Error())
+			return
+		}
+
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+func (r *router) zRankHandler(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		respond(c, http.StatusBadRequest, "", "No field key in get query")
+		return
+	}
+
+	member, exists := c.GetQuery("member")
+	if !exists {
+		respond(c, http.StatusBadRequest, "", "No field member in get query")
+		return
+	}
+
+	result, err := r.redis.ZRank(c, key, member)
+	if err != nil {
+		if err == redis.Nil {
+			respond(c, http.StatusNoContent, "", err.Error())
+			return
+		}
+
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// zRangeHandler - ?key=scores&start=0&stop=-1, see models.ZRangeQuery for the other parameters
+func (r *router) zRangeHandler(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		respond(c, http.StatusBadRequest, "", "No field key in get query")
+		return
+	}
+
+	query := models.ZRangeQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respond(c, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	result, err := r.redis.ZRange(c, key, query)
+	if err != nil {
+		respond(c, errorStatus(err), "", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, result, "")
+}
+
+// zPopHandler - ZPOPMIN and ZPOPMAX, responds with removed members and their scores
+func (r *router) zPopHandler(pop func(ctx context.Context, key string, count int64) ([]models.ZMember, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, exists := c.Get("key")
+		if !exists {
+			respond(c, http.StatusInternalServerError, "", "No key in context")
+			return
+		}
+
+		data := &models.ZPopRequest{}
+		if err := c.ShouldBindJSON(data); err != nil {
+			respond(c, http.StatusBadRequest, "", err.Error())
+			return
+		}
+		if data.Count == 0 {
+			data.Count = 1
+		}
+
+		result, err := pop(c, key.(string), data.Count)
+		if err != nil {
+			log.Println(err)
+			respond(c, errorStatus(err), "", err.Error())
+			return
+		}
+
+		respond(c, http.StatusOK, result, "")
+	}
+}
+
+// zCombineHandler - ZUNION and ZINTER of sorted sets passed as ?keys=a&keys=b
+func (r *router) zCombineHandler(operation func(ctx context.Context, store models.ZStore) ([]models.ZMember, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		store := models.ZStore{}
+		if err := c.ShouldBindQuery(&store); err != nil {
+			respond(c, http.StatusBadRequest, "", err.Error())
+			return
+		}
+
+		result, err := operation(c, store)
+		if err != nil {
+			respond(c, errorStatus(err), "", err.Error())
+			return
+		}
+
+		respond(c, http.StatusOK, result, "")
+	}
+}
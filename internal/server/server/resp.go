@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/resp"
+	"github.com/Vysogota99/redis-implementation/internal/server/store"
+)
+
+// respServer - accepts connections of redis clients (redis-cli, go-redis) and executes their commands
+// on the same store as router
+type respServer struct {
+	addr     string
+	redis    store.RedisImpl
+	listener net.Listener
+	lastID   int64
+}
+
+// respConn - connection of one client
+type respConn struct {
+	ctx    context.Context
+	conn   net.Conn
+	reader *resp.Reader
+	// replies and messages of subscriptions are written by different goroutines
+	mu     sync.Mutex
+	writer *resp.Writer
+	redis  store.RedisImpl
+	id     int64
+	name   string
+	// subscriptions of the connection by channel and by pattern
+	channels map[string]store.Subscription
+	patterns map[string]store.Subscription
+}
+
+// respReplies - several replies to one command, e.g. confirmation of every channel of SUBSCRIBE
+type respReplies []interface{}
+
+// newRespServer - helper to init RESP listener
+func newRespServer(addr string, redis store.RedisImpl) *respServer {
+	return &respServer{
+		addr:  addr,
+		redis: redis,
+	}
+}
+
+// listen - opens the port, connections are served in background
+func (s *respServer) listen() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	s.listener = listener
+	go s.serve()
+
+	return nil
+}
+
+// close - stops accepting new connections
+func (s *respServer) close() error {
+	if s.listener == nil {
+		return nil
+	}
+
+	return s.listener.Close()
+}
+
+func (s *respServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *respServer) handle(conn net.Conn) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer conn.Close()
+
+	c := &respConn{
+		ctx:    ctx,
+		conn:   conn,
+		reader: resp.NewReader(conn),
+		writer: resp.NewWriter(conn),
+		redis:  s.redis,
+		id:     atomic.AddInt64(&s.lastID, 1),
+
+		channels: make(map[string]store.Subscription),
+		patterns: make(map[string]store.Subscription),
+	}
+	defer c.unsubscribeAll()
+
+	for {
+		args, err := c.reader.ReadCommand()
+		if err != nil {
+			if errors.Is(err, resp.ErrProtocol) {
+				c.write(resp.Error(fmt.Sprintf("ERR %s", err.Error())), true)
+			} else if err != io.EOF {
+				log.Println(err)
+			}
+			return
+		}
+
+		if len(args) == 0 {
+			continue
+		}
+
+		// replies to pipelined commands are sent together
+		quit := strings.ToLower(args[0]) == "quit"
+		if err := c.write(c.exec(args), quit || c.reader.Buffered() == 0); err != nil {
+			log.Println(err)
+			return
+		}
+
+		if quit {
+			return
+		}
+	}
+}
+
+// block - executes the blocking command with the context, which is cancelled when the client disconnects.
+// The connection is peeked meanwhile, the peek is interrupted by the read deadline after the command.
+func (c *respConn) block(command func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		// the next command sent by the client is read after this one, like by redis
+		var netErr net.Error
+		if err := c.reader.Wait(); err != nil && !(errors.As(err, &netErr) && netErr.Timeout()) {
+			cancel()
+		}
+	}()
+
+	err := command(ctx)
+
+	if deadlineErr := c.conn.SetReadDeadline(time.Now()); deadlineErr != nil {
+		return deadlineErr
+	}
+	<-done
+	if deadlineErr := c.conn.SetReadDeadline(time.Time{}); deadlineErr != nil {
+		return deadlineErr
+	}
+
+	return err
+}
+
+// write - writes the reply, it is sent to the client immediately if flush is set
+func (c *respConn) write(value interface{}, flush bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	replies, ok := value.(respReplies)
+	if !ok {
+		replies = respReplies{value}
+	}
+
+	for _, reply := range replies {
+		if err := c.writer.WriteValue(reply); err != nil {
+			return err
+		}
+	}
+
+	if !flush {
+		return nil
+	}
+
+	return c.writer.Flush()
+}
+
+// exec - finds command by name, checks number of arguments and executes it
+func (c *respConn) exec(args []string) interface{} {
+	name := strings.ToLower(args[0])
+	cmd, ok := respCommands[name]
+	if !ok {
+		return resp.Error(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+
+	if (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity) {
+		return resp.Error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+	}
+
+	// RESP2 connection with subscriptions is used only for messages, RESP3 pushes them out of band
+	if c.subscribed() && c.writer.Protocol() == resp.RESP2 && !respSubscribedCommands[name] {
+		return resp.Error(fmt.Sprintf("ERR Can't execute '%s': only (P)SUBSCRIBE / (P)UNSUBSCRIBE / PING / QUIT are allowed in this context", name))
+	}
+
+	result, err := cmd.handler(c, args[1:])
+	if err != nil {
+		return respError(err)
+	}
+
+	return result
+}
+
+// respError - converts error to RESP error reply, errors of redis already start with error code
+func respError(err error) resp.Error {
+	message := err.Error()
+	if code := strings.SplitN(message, " ", 2)[0]; code != "" && code == strings.ToUpper(code) && len(code) > 1 {
+		return resp.Error(message)
+	}
+
+	return resp.Error(fmt.Sprintf("ERR %s", message))
+}
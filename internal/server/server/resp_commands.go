@@ -0,0 +1,1289 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/Vysogota99/redis-implementation/internal/server/resp"
+	"github.com/Vysogota99/redis-implementation/internal/server/store"
+	"github.com/go-redis/redis/v8"
+)
+
+// respCommand - command supported by RESP listener
+type respCommand struct {
+	handler func(c *respConn, args []string) (interface{}, error)
+	// number of arguments including the name of command, negative value means "at least"
+	arity int
+}
+
+// respServerVersion - version of redis which behavior is implemented
+const respServerVersion = store.RedisVersion
+
+var (
+	respOK = resp.SimpleString("OK")
+
+	errSyntax   = errors.New("ERR syntax error")
+	errNotInt   = errors.New("ERR value is not an integer or out of range")
+	errHashArg  = errors.New("ERR wrong number of arguments for 'hset' command")
+	errNoProto  = errors.New("NOPROTO unsupported protocol version")
+	errNoFloat  = errors.New("ERR value is not a valid float")
+	errLexWith  = errors.New("ERR syntax error, WITHSCORES not supported in combination with BYLEX")
+	errExpire   = errors.New("ERR invalid expire time")
+	errPositive = errors.New("ERR value is out of range, must be positive")
+	errTimeout  = errors.New("ERR timeout is not a float or out of range")
+)
+
+var respCommands map[string]respCommand
+
+func init() {
+	respCommands = map[string]respCommand{
+		"ping":           {handler: respPing, arity: -1},
+		"echo":           {handler: respEcho, arity: 2},
+		"quit":           {handler: respQuit, arity: 1},
+		"command":        {handler: respCommandInfo, arity: -1},
+		"hello":          {handler: respHello, arity: -1},
+		"get":            {handler: respGet, arity: 2},
+		"set":            {handler: respSet, arity: -3},
+		"getset":         {handler: respGetSet, arity: 3},
+		"getdel":         {handler: respGetDel, arity: 2},
+		"getex":          {handler: respGetEx, arity: -2},
+		"append":         {handler: respAppend, arity: 3},
+		"strlen":         {handler: respStrLen, arity: 2},
+		"getrange":       {handler: respGetRange, arity: 4},
+		"setrange":       {handler: respSetRange, arity: 4},
+		"incr":           {handler: respIncr, arity: 2},
+		"decr":           {handler: respDecr, arity: 2},
+		"incrby":         {handler: respIncrBy, arity: 3},
+		"decrby":         {handler: respDecrBy, arity: 3},
+		"incrbyfloat":    {handler: respIncrByFloat, arity: 3},
+		"hgetall":        {handler: respHGetAll, arity: 2},
+		"hget":           {handler: respHGet, arity: 3},
+		"hset":           {handler: respHSet, arity: -4},
+		"hdel":           {handler: respHDel, arity: -3},
+		"hexists":        {handler: respHExists, arity: 3},
+		"hlen":           {handler: respHLen, arity: 2},
+		"hkeys":          {handler: respHKeys, arity: 2},
+		"hvals":          {handler: respHVals, arity: 2},
+		"hmget":          {handler: respHMGet, arity: -3},
+		"hsetnx":         {handler: respHSetNX, arity: 4},
+		"hincrby":        {handler: respHIncrBy, arity: 4},
+		"hincrbyfloat":   {handler: respHIncrByFloat, arity: 4},
+		"hscan":          {handler: respHScan, arity: -3},
+		"rpush":          {handler: respRPush, arity: -3},
+		"lrange":         {handler: respLRange, arity: 4},
+		"lset":           {handler: respLSet, arity: 4},
+		"lpush":          {handler: respLPush, arity: -3},
+		"lpop":           {handler: respLPop, arity: -2},
+		"rpop":           {handler: respRPop, arity: -2},
+		"llen":           {handler: respLLen, arity: 2},
+		"lindex":         {handler: respLIndex, arity: 3},
+		"linsert":        {handler: respLInsert, arity: 5},
+		"lrem":           {handler: respLRem, arity: 4},
+		"ltrim":          {handler: respLTrim, arity: 4},
+		"lpos":           {handler: respLPos, arity: -3},
+		"lmove":          {handler: respLMove, arity: 5},
+		"blpop":          {handler: respBLPop, arity: -3},
+		"brpop":          {handler: respBRPop, arity: -3},
+		"blmove":         {handler: respBLMove, arity: 6},
+		"sadd":           {handler: respSAdd, arity: -3},
+		"srem":           {handler: respSRem, arity: -3},
+		"smembers":       {handler: respSMembers, arity: 2},
+		"sismember":      {handler: respSIsMember, arity: 3},
+		"scard":          {handler: respSCard, arity: 2},
+		"sinter":         {handler: respSInter, arity: -2},
+		"sunion":         {handler: respSUnion, arity: -2},
+		"sdiff":          {handler: respSDiff, arity: -2},
+		"sinterstore":    {handler: respSInterStore, arity: -3},
+		"sunionstore":    {handler: respSUnionStore, arity: -3},
+		"sdiffstore":     {handler: respSDiffStore, arity: -3},
+		"sscan":          {handler: respSScan, arity: -3},
+		"zadd":           {handler: respZAdd, arity: -4},
+		"zrem":           {handler: respZRem, arity: -3},
+		"zscore":         {handler: respZScore, arity: 3},
+		"zrank":          {handler: respZRank, arity: 3},
+		"zrange":         {handler: respZRange, arity: -4},
+		"zpopmin":        {handler: respZPopMin, arity: -2},
+		"zpopmax":        {handler: respZPopMax, arity: -2},
+		"zunion":         {handler: respZUnion, arity: -3},
+		"zinter":         {handler: respZInter, arity: -3},
+		"zscan":          {handler: respZScan, arity: -3},
+		"xadd":           {handler: respXAdd, arity: -5},
+		"xrange":         {handler: respXRange, arity: -4},
+		"xrevrange":      {handler: respXRevRange, arity: -4},
+		"xlen":           {handler: respXLen, arity: 2},
+		"xtrim":          {handler: respXTrim, arity: -4},
+		"xread":          {handler: respXRead, arity: -4},
+		"xgroup":         {handler: respXGroup, arity: -2},
+		"xreadgroup":     {handler: respXReadGroup, arity: -7},
+		"xack":           {handler: respXAck, arity: -4},
+		"xpending":       {handler: respXPending, arity: -3},
+		"xclaim":         {handler: respXClaim, arity: -6},
+		"xautoclaim":     {handler: respXAutoClaim, arity: -6},
+		"json.set":       {handler: respJSONSet, arity: -4},
+		"json.get":       {handler: respJSONGet, arity: -2},
+		"json.del":       {handler: respJSONDel, arity: -2},
+		"json.arrappend": {handler: respJSONArrAppend, arity: -4},
+		"json.numincrby": {handler: respJSONNumIncrBy, arity: 4},
+		"json.objkeys":   {handler: respJSONObjKeys, arity: -2},
+		"publish":        {handler: respPublish, arity: 3},
+		"subscribe":      {handler: respSubscribe, arity: -2},
+		"psubscribe":     {handler: respPSubscribe, arity: -2},
+		"unsubscribe":    {handler: respUnsubscribe, arity: -1},
+		"punsubscribe":   {handler: respPUnsubscribe, arity: -1},
+		"config":         {handler: respConfig, arity: -3},
+		"keys":           {handler: respKeys, arity: 2},
+		"scan":           {handler: respScan, arity: -2},
+		"del":            {handler: respDel, arity: -2},
+		"exists":         {handler: respExists, arity: -2},
+		"type":           {handler: respType, arity: 2},
+		"ttl":            {handler: respTTL, arity: 2},
+		"pttl":           {handler: respPTTL, arity: 2},
+		"expire":         {handler: respExpire, arity: 3},
+		"pexpire":        {handler: respPExpire, arity: 3},
+		"expireat":       {handler: respExpireUnix, arity: 3},
+		"pexpireat":      {handler: respPExpireUnix, arity: 3},
+		"persist":        {handler: respPersist, arity: 2},
+		"save":           {handler: respSave, arity: 1},
+		"bgsave":         {handler: respBGSave, arity: -1},
+		"lastsave":       {handler: respLastSave, arity: 1},
+		"info":           {handler: respInfo, arity: -1},
+	}
+}
+
+func respPing(c *respConn, args []string) (interface{}, error) {
+	// RESP2 connection with subscriptions replies in the same format as messages
+	if c.subscribed() && c.writer.Protocol() == resp.RESP2 {
+		message := ""
+		if len(args) > 0 {
+			message = args[0]
+		}
+		return []interface{}{"pong", message}, nil
+	}
+
+	if len(args) == 0 {
+		return resp.SimpleString("PONG"), nil
+	}
+
+	return args[0], nil
+}
+
+func respEcho(c *respConn, args []string) (interface{}, error) {
+	return args[0], nil
+}
+
+func respQuit(c *respConn, args []string) (interface{}, error) {
+	return respOK, nil
+}
+
+// respCommandInfo - redis-cli asks for documentation of commands on start, there is nothing to tell
+func respCommandInfo(c *respConn, args []string) (interface{}, error) {
+	return []interface{}{}, nil
+}
+
+// respHello - switches protocol of the connection: HELLO [protover [AUTH username password] [SETNAME clientname]]
+func respHello(c *respConn, args []string) (interface{}, error) {
+	proto := c.writer.Protocol()
+	if len(args) > 0 {
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, errors.New("ERR Protocol version is not an integer or out of range")
+		}
+		if version != resp.RESP2 && version != resp.RESP3 {
+			return nil, errNoProto
+		}
+		proto = version
+		args = args[1:]
+	}
+
+	name := c.name
+	for len(args) > 0 {
+		switch {
+		// there are no users and passwords, so any credentials are accepted like by redis without requirepass
+		case strings.ToLower(args[0]) == "auth" && len(args) >= 3:
+			args = args[3:]
+		case strings.ToLower(args[0]) == "setname" && len(args) >= 2:
+			name = args[1]
+			args = args[2:]
+		default:
+			return nil, errSyntax
+		}
+	}
+
+	c.name = name
+	c.mu.Lock()
+	c.writer.SetProtocol(proto)
+	c.mu.Unlock()
+
+	return map[string]interface{}{
+		"server":  "redis",
+		"version": respServerVersion,
+		"proto":   int64(proto),
+		"id":      c.id,
+		"mode":    "standalone",
+		"role":    "master",
+		"modules": []interface{}{},
+	}, nil
+}
+
+func respGet(c *respConn, args []string) (interface{}, error) {
+	res, err := c.redis.GetString(c.ctx, args[0])
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	return res, err
+}
+
+// respSet - SET key value [NX | XX] [GET] [EX seconds | PX milliseconds | EXAT unix-time-seconds |
+// PXAT unix-time-milliseconds | KEEPTTL]
+func respSet(c *respConn, args []string) (interface{}, error) {
+	opts := models.SetOptions{}
+	for i := 2; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "nx":
+			opts.NX = true
+		case "xx":
+			opts.XX = true
+		case "get":
+			opts.Get = true
+		case "keepttl":
+			opts.KeepTTL = true
+		case "ex", "px", "exat", "pxat":
+			if i+1 >= len(args) || opts.TTL != 0 || opts.ExAt != 0 || opts.PxAt != 0 {
+				return nil, errSyntax
+			}
+			exp, err := respExpireOptions(args[i], args[i+1])
+			if err != nil {
+				return nil, err
+			}
+			opts.Expiration, opts.ExAt, opts.PxAt = exp.Expiration, exp.ExAt, exp.PxAt
+			i++
+		default:
+			return nil, errSyntax
+		}
+	}
+
+	res, err := c.redis.SetArgs(c.ctx, args[0], args[1], opts)
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.Get {
+		return res, nil
+	}
+
+	return resp.SimpleString(res), nil
+}
+
+func respGetSet(c *respConn, args []string) (interface{}, error) {
+	return respValue(c.redis.GetSet(c.ctx, args[0], args[1]))
+}
+
+func respGetDel(c *respConn, args []string) (interface{}, error) {
+	return respValue(c.redis.GetDel(c.ctx, args[0]))
+}
+
+// respGetEx - GETEX key [EX seconds | PX milliseconds | EXAT unix-time-seconds | PXAT unix-time-milliseconds |
+// PERSIST]
+func respGetEx(c *respConn, args []string) (interface{}, error) {
+	opts := models.GetExOptions{}
+	switch {
+	case len(args) == 2 && strings.ToLower(args[1]) == "persist":
+		opts.Persist = true
+	case len(args) == 3:
+		exp, err := respExpireOptions(args[1], args[2])
+		if err != nil {
+			return nil, err
+		}
+		opts.Expiration, opts.ExAt, opts.PxAt = exp.Expiration, exp.ExAt, exp.PxAt
+	case len(args) != 1:
+		return nil, errSyntax
+	}
+
+	return respValue(c.redis.GetEx(c.ctx, args[0], opts))
+}
+
+func respAppend(c *respConn, args []string) (interface{}, error) {
+	return c.redis.Append(c.ctx, args[0], args[1])
+}
+
+func respStrLen(c *respConn, args []string) (interface{}, error) {
+	return c.redis.StrLen(c.ctx, args[0])
+}
+
+func respGetRange(c *respConn, args []string) (interface{}, error) {
+	start, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+
+	end, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+
+	return c.redis.GetRange(c.ctx, args[0], start, end)
+}
+
+func respSetRange(c *respConn, args []string) (interface{}, error) {
+	offset, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+
+	return c.redis.SetRange(c.ctx, args[0], offset, args[2])
+}
+
+// respExpireOptions - EX and PX are passed as TTL, the same way as ttl of HTTP requests, EXAT and PXAT as
+// unix time
+func respExpireOptions(option, value string) (models.ExpireOptions, error) {
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return models.ExpireOptions{}, errNotInt
+	}
+	if v <= 0 {
+		return models.ExpireOptions{}, errExpire
+	}
+
+	switch strings.ToLower(option) {
+	case "ex":
+		if v > math.MaxInt64/int64(time.Second) {
+			return models.ExpireOptions{}, errExpire
+		}
+		return models.ExpireOptions{Expiration: models.Expiration{TTL: models.TTL(time.Duration(v) * time.Second)}}, nil
+	case "px":
+		if v > math.MaxInt64/int64(time.Millisecond) {
+			return models.ExpireOptions{}, errExpire
+		}
+		return models.ExpireOptions{Expiration: models.Expiration{TTL: models.TTL(time.Duration(v) * time.Millisecond)}}, nil
+	case "exat":
+		return models.ExpireOptions{ExAt: v}, nil
+	case "pxat":
+		return models.ExpireOptions{PxAt: v}, nil
+	}
+
+	return models.ExpireOptions{}, errSyntax
+}
+
+// respValue - value of the string or null reply if there is no such key
+func respValue(value string, err error) (interface{}, error) {
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func respIncr(c *respConn, args []string) (interface{}, error) {
+	return c.redis.IncrBy(c.ctx, args[0], 1)
+}
+
+func respDecr(c *respConn, args []string) (interface{}, error) {
+	return c.redis.IncrBy(c.ctx, args[0], -1)
+}
+
+func respIncrBy(c *respConn, args []string) (interface{}, error) {
+	increment, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+
+	return c.redis.IncrBy(c.ctx, args[0], increment)
+}
+
+func respDecrBy(c *respConn, args []string) (interface{}, error) {
+	decrement, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+	if decrement == math.MinInt64 {
+		return nil, errors.New("ERR decrement would overflow")
+	}
+
+	return c.redis.IncrBy(c.ctx, args[0], -decrement)
+}
+
+// respIncrByFloat - redis replies with bulk string even in RESP3
+func respIncrByFloat(c *respConn, args []string) (interface{}, error) {
+	increment, err := strconv.ParseFloat(args[1], 64)
+	if err != nil || math.IsNaN(increment) || math.IsInf(increment, 0) {
+		return nil, errNoFloat
+	}
+
+	res, err := c.redis.IncrByFloat(c.ctx, args[0], increment)
+	if err != nil {
+		return nil, err
+	}
+
+	return strconv.FormatFloat(res, 'f', -1, 64), nil
+}
+
+func respHGetAll(c *respConn, args []string) (interface{}, error) {
+	return c.redis.GetHash(c.ctx, args[0])
+}
+
+func respHGet(c *respConn, args []string) (interface{}, error) {
+	res, err := c.redis.HGet(c.ctx, args[0], args[1])
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	return res, err
+}
+
+func respHSet(c *respConn, args []string) (interface{}, error) {
+	if len(args)%2 != 1 {
+		return nil, errHashArg
+	}
+
+	values := make(map[string]interface{}, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		values[args[i]] = args[i+1]
+	}
+
+	return c.redis.HSet(c.ctx, args[0], values)
+}
+
+func respHDel(c *respConn, args []string) (interface{}, error) {
+	return c.redis.HDel(c.ctx, args[0], args[1:])
+}
+
+func respHExists(c *respConn, args []string) (interface{}, error) {
+	res, err := c.redis.HExists(c.ctx, args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return boolInt(res), nil
+}
+
+func respHLen(c *respConn, args []string) (interface{}, error) {
+	return c.redis.HLen(c.ctx, args[0])
+}
+
+func respHKeys(c *respConn, args []string) (interface{}, error) {
+	return c.redis.HKeys(c.ctx, args[0])
+}
+
+func respHVals(c *respConn, args []string) (interface{}, error) {
+	return c.redis.HVals(c.ctx, args[0])
+}
+
+func respHMGet(c *respConn, args []string) (interface{}, error) {
+	return c.redis.HMGet(c.ctx, args[0], args[1:])
+}
+
+func respHSetNX(c *respConn, args []string) (interface{}, error) {
+	res, err := c.redis.HSetNX(c.ctx, args[0], args[1], args[2])
+	if err != nil {
+		return nil, err
+	}
+
+	return boolInt(res), nil
+}
+
+func respHIncrBy(c *respConn, args []string) (interface{}, error) {
+	increment, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+
+	return c.redis.HIncrBy(c.ctx, args[0], args[1], increment, true)
+}
+
+// respHIncrByFloat - redis replies with bulk string even in RESP3
+func respHIncrByFloat(c *respConn, args []string) (interface{}, error) {
+	increment, err := strconv.ParseFloat(args[2], 64)
+	if err != nil || math.IsNaN(increment) || math.IsInf(increment, 0) {
+		return nil, errNoFloat
+	}
+
+	res, err := c.redis.HIncrByFloat(c.ctx, args[0], args[1], increment, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return strconv.FormatFloat(res, 'f', -1, 64), nil
+}
+
+// boolInt - redis replies to HEXISTS and HSETNX with integer even in RESP3
+func boolInt(value bool) int64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func respRPush(c *respConn, args []string) (interface{}, error) {
+	return c.redis.RPush(c.ctx, args[0], stringValues(args[1:]))
+}
+
+func respLPush(c *respConn, args []string) (interface{}, error) {
+	return c.redis.LPush(c.ctx, args[0], stringValues(args[1:]))
+}
+
+func respLPop(c *respConn, args []string) (interface{}, error) {
+	return respPop(c, args, c.redis.LPop)
+}
+
+func respRPop(c *respConn, args []string) (interface{}, error) {
+	return respPop(c, args, c.redis.RPop)
+}
+
+// respPop - LPOP or RPOP key [count], without count the element is returned as is
+func respPop(c *respConn, args []string, pop func(ctx context.Context, key string, count int64) ([]interface{}, error)) (interface{}, error) {
+	if len(args) > 2 {
+		return nil, errSyntax
+	}
+
+	count := int64(1)
+	if len(args) == 2 {
+		var err error
+		if count, err = strconv.ParseInt(args[1], 10, 64); err != nil || count <= 0 {
+			return nil, errPositive
+		}
+	}
+
+	list, err := pop(c.ctx, args[0], count)
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == 1 {
+		return respElement(c, list[0])
+	}
+	if c.writer.Protocol() == resp.RESP3 {
+		return list, nil
+	}
+
+	return formatList(list)
+}
+
+func respLLen(c *respConn, args []string) (interface{}, error) {
+	return c.redis.LLen(c.ctx, args[0])
+}
+
+func respLIndex(c *respConn, args []string) (interface{}, error) {
+	index, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+
+	el, err := c.redis.LIndex(c.ctx, args[0], index)
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return respElement(c, el)
+}
+
+// respLInsert - LINSERT key BEFORE|AFTER pivot element
+func respLInsert(c *respConn, args []string) (interface{}, error) {
+	switch strings.ToLower(args[1]) {
+	case "before":
+		return c.redis.LInsert(c.ctx, args[0], true, args[2], args[3])
+	case "after":
+		return c.redis.LInsert(c.ctx, args[0], false, args[2], args[3])
+	}
+
+	return nil, errSyntax
+}
+
+func respLRem(c *respConn, args []string) (interface{}, error) {
+	count, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+
+	return c.redis.LRem(c.ctx, args[0], count, args[2])
+}
+
+func respLTrim(c *respConn, args []string) (interface{}, error) {
+	start, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+
+	stop, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+
+	if err := c.redis.LTrim(c.ctx, args[0], start, stop); err != nil {
+		return nil, err
+	}
+
+	return respOK, nil
+}
+
+// respLPos - LPOS key element [RANK rank] [COUNT num-matches] [MAXLEN len]
+func respLPos(c *respConn, args []string) (interface{}, error) {
+	if len(args)%2 != 0 {
+		return nil, errSyntax
+	}
+
+	lpos := models.LPosArgs{}
+	for i := 2; i < len(args); i += 2 {
+		value, err := strconv.ParseInt(args[i+1], 10, 64)
+		if err != nil {
+			return nil, errNotInt
+		}
+
+		switch strings.ToLower(args[i]) {
+		case "rank":
+			lpos.Rank = &value
+		case "count":
+			lpos.Count = &value
+		case "maxlen":
+			lpos.MaxLen = value
+		default:
+			return nil, errSyntax
+		}
+	}
+
+	res, err := c.redis.LPos(c.ctx, args[0], args[1], lpos)
+	if err != nil {
+		return nil, err
+	}
+
+	if lpos.Count != nil {
+		positions := make([]interface{}, len(res))
+		for i, pos := range res {
+			positions[i] = pos
+		}
+		return positions, nil
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+
+	return res[0], nil
+}
+
+// respLMove - LMOVE source destination LEFT|RIGHT LEFT|RIGHT
+func respLMove(c *respConn, args []string) (interface{}, error) {
+	el, err := c.redis.LMove(c.ctx, args[0], args[1], strings.ToLower(args[2]), strings.ToLower(args[3]))
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return respElement(c, el)
+}
+
+func respBLPop(c *respConn, args []string) (interface{}, error) {
+	return respBPop(c, args, c.redis.BLPop)
+}
+
+func respBRPop(c *respConn, args []string) (interface{}, error) {
+	return respBPop(c, args, c.redis.BRPop)
+}
+
+// respBPop - BLPOP or BRPOP key [key ...] timeout, the reply is the key and the element. Disconnect of the
+// client unblocks the command, see respConn.block.
+func respBPop(c *respConn, args []string, pop func(ctx context.Context, keys []string, timeout time.Duration) (*models.BPopResult, error)) (interface{}, error) {
+	timeout, err := respTimeout(args[len(args)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	var res *models.BPopResult
+	err = c.block(func(ctx context.Context) error {
+		res, err = pop(ctx, args[:len(args)-1], timeout)
+		return err
+	})
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	el, err := respElement(c, res.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return []interface{}{res.Key, el}, nil
+}
+
+func respBLMove(c *respConn, args []string) (interface{}, error) {
+	timeout, err := respTimeout(args[4])
+	if err != nil {
+		return nil, err
+	}
+
+	var el interface{}
+	err = c.block(func(ctx context.Context) error {
+		el, err = c.redis.BLMove(ctx, args[0], args[1], strings.ToLower(args[2]), strings.ToLower(args[3]), timeout)
+		return err
+	})
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return respElement(c, el)
+}
+
+// respTimeout - timeout of blocking commands in seconds
+func respTimeout(arg string) (time.Duration, error) {
+	timeout, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(timeout) || math.IsInf(timeout, 0) {
+		return 0, errTimeout
+	}
+
+	return time.Duration(timeout * float64(time.Second)), nil
+}
+
+// respElement - element of the list keeps its type in RESP3 and is formatted as string in RESP2
+func respElement(c *respConn, el interface{}) (interface{}, error) {
+	if c.writer.Protocol() == resp.RESP3 {
+		return el, nil
+	}
+
+	res, err := formatList([]interface{}{el})
+	if err != nil {
+		return nil, err
+	}
+
+	return res[0], nil
+}
+
+func stringValues(args []string) []interface{} {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg
+	}
+
+	return values
+}
+
+func respLRange(c *respConn, args []string) (interface{}, error) {
+	start, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+
+	stop, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+
+	list, err := c.redis.LRange(c.ctx, args[0], start, stop)
+	if err != nil {
+		return nil, err
+	}
+
+	// RESP3 keeps types of elements: integers, doubles and maps
+	if c.writer.Protocol() == resp.RESP3 {
+		return list, nil
+	}
+
+	return formatList(list)
+}
+
+func respLSet(c *respConn, args []string) (interface{}, error) {
+	index, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+
+	res, err := c.redis.LSet(c.ctx, args[0], index, args[2])
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.SimpleString(res), nil
+}
+
+func respSAdd(c *respConn, args []string) (interface{}, error) {
+	return c.redis.SAdd(c.ctx, args[0], args[1:])
+}
+
+func respSRem(c *respConn, args []string) (interface{}, error) {
+	return c.redis.SRem(c.ctx, args[0], args[1:])
+}
+
+func respSMembers(c *respConn, args []string) (interface{}, error) {
+	return respSetResult(c.redis.SMembers(c.ctx, args[0]))
+}
+
+// respSIsMember - redis replies with integer even in RESP3
+func respSIsMember(c *respConn, args []string) (interface{}, error) {
+	res, err := c.redis.SIsMember(c.ctx, args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if res {
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
+
+func respSCard(c *respConn, args []string) (interface{}, error) {
+	return c.redis.SCard(c.ctx, args[0])
+}
+
+func respSInter(c *respConn, args []string) (interface{}, error) {
+	return respSetResult(c.redis.SInter(c.ctx, args))
+}
+
+func respSUnion(c *respConn, args []string) (interface{}, error) {
+	return respSetResult(c.redis.SUnion(c.ctx, args))
+}
+
+func respSDiff(c *respConn, args []string) (interface{}, error) {
+	return respSetResult(c.redis.SDiff(c.ctx, args))
+}
+
+func respSInterStore(c *respConn, args []string) (interface{}, error) {
+	return c.redis.SInterStore(c.ctx, args[0], args[1:])
+}
+
+func respSUnionStore(c *respConn, args []string) (interface{}, error) {
+	return c.redis.SUnionStore(c.ctx, args[0], args[1:])
+}
+
+func respSDiffStore(c *respConn, args []string) (interface{}, error) {
+	return c.redis.SDiffStore(c.ctx, args[0], args[1:])
+}
+
+// respSetResult - members are sent as RESP3 set, RESP2 clients receive an array
+func respSetResult(members []string, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Set(members), nil
+}
+
+// respZAdd - ZADD key [NX|XX] [GT|LT] [CH] [INCR] score member [score member ...]
+func respZAdd(c *respConn, args []string) (interface{}, error) {
+	var opts models.ZAddOptions
+	var incr bool
+
+	i := 1
+flags:
+	for ; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "nx":
+			opts.NX = true
+		case "xx":
+			opts.XX = true
+		case "gt":
+			opts.GT = true
+		case "lt":
+			opts.LT = true
+		case "ch":
+			opts.CH = true
+		case "incr":
+			incr = true
+		default:
+			break flags
+		}
+	}
+
+	pairs := args[i:]
+	if len(pairs) == 0 || len(pairs)%2 != 0 {
+		return nil, errSyntax
+	}
+
+	members := make([]models.ZMember, 0, len(pairs)/2)
+	for j := 0; j < len(pairs); j += 2 {
+		score, err := strconv.ParseFloat(pairs[j], 64)
+		if err != nil {
+			return nil, errNoFloat
+		}
+		members = append(members, models.ZMember{Member: pairs[j+1], Score: score})
+	}
+
+	if !incr {
+		return c.redis.ZAdd(c.ctx, args[0], members, opts)
+	}
+
+	if len(members) != 1 {
+		return nil, errors.New("ERR INCR option supports a single increment-element pair")
+	}
+
+	res, err := c.redis.ZAddIncr(c.ctx, args[0], members[0], opts)
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	return res, err
+}
+
+func respZRem(c *respConn, args []string) (interface{}, error) {
+	return c.redis.ZRem(c.ctx, args[0], args[1:])
+}
+
+func respZScore(c *respConn, args []string) (interface{}, error) {
+	res, err := c.redis.ZScore(c.ctx, args[0], args[1])
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	return res, err
+}
+
+func respZRank(c *respConn, args []string) (interface{}, error) {
+	res, err := c.redis.ZRank(c.ctx, args[0], args[1])
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	return res, err
+}
+
+// respZRange - ZRANGE key start stop [BYSCORE|BYLEX] [REV] [LIMIT offset count] [WITHSCORES] of redis 6.2
+func respZRange(c *respConn, args []string) (interface{}, error) {
+	query := models.ZRangeQuery{Start: args[1], Stop: args[2]}
+	var withScores, limit bool
+
+	for i := 3; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "byscore":
+			query.By = store.ZRangeByScore
+		case "bylex":
+			query.By = store.ZRangeByLex
+		case "rev":
+			query.Rev = true
+		case "withscores":
+			withScores = true
+		case "limit":
+			if i+2 >= len(args) {
+				return nil, errSyntax
+			}
+
+			var err error
+			if query.Offset, err = strconv.ParseInt(args[i+1], 10, 64); err != nil {
+				return nil, errNotInt
+			}
+			if query.Count, err = strconv.ParseInt(args[i+2], 10, 64); err != nil {
+				return nil, errNotInt
+			}
+			limit = true
+			i += 2
+		default:
+			return nil, errSyntax
+		}
+	}
+
+	if withScores && query.By == store.ZRangeByLex {
+		return nil, errLexWith
+	}
+
+	// negative count means no limit, zero count returns nothing
+	switch {
+	case limit && query.Count == 0 && query.By != "":
+		return []interface{}{}, nil
+	case query.Count < 0:
+		query.Count = 0
+	}
+
+	members, err := c.redis.ZRange(c.ctx, args[0], query)
+	if err != nil {
+		return nil, err
+	}
+
+	return respZMembers(members, withScores), nil
+}
+
+func respZPopMin(c *respConn, args []string) (interface{}, error) {
+	count, err := parseZPopCount(args)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := c.redis.ZPopMin(c.ctx, args[0], count)
+	if err != nil {
+		return nil, err
+	}
+
+	return respZMembers(members, true), nil
+}
+
+func respZPopMax(c *respConn, args []string) (interface{}, error) {
+	count, err := parseZPopCount(args)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := c.redis.ZPopMax(c.ctx, args[0], count)
+	if err != nil {
+		return nil, err
+	}
+
+	return respZMembers(members, true), nil
+}
+
+func respZUnion(c *respConn, args []string) (interface{}, error) {
+	zstore, withScores, err := parseZStore(args)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := c.redis.ZUnion(c.ctx, zstore)
+	if err != nil {
+		return nil, err
+	}
+
+	return respZMembers(members, withScores), nil
+}
+
+func respZInter(c *respConn, args []string) (interface{}, error) {
+	zstore, withScores, err := parseZStore(args)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := c.redis.ZInter(c.ctx, zstore)
+	if err != nil {
+		return nil, err
+	}
+
+	return respZMembers(members, withScores), nil
+}
+
+// parseZStore - numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE SUM|MIN|MAX] [WITHSCORES]
+func parseZStore(args []string) (models.ZStore, bool, error) {
+	var zstore models.ZStore
+	var withScores bool
+
+	numKeys, err := strconv.Atoi(args[0])
+	if err != nil {
+		return zstore, false, errNotInt
+	}
+	if numKeys < 1 || numKeys >= len(args) {
+		return zstore, false, errSyntax
+	}
+	zstore.Keys = args[1 : numKeys+1]
+
+	for i := numKeys + 1; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "weights":
+			if i+numKeys >= len(args) {
+				return zstore, false, errSyntax
+			}
+			for _, arg := range args[i+1 : i+numKeys+1] {
+				weight, err := strconv.ParseFloat(arg, 64)
+				if err != nil {
+					return zstore, false, errors.New("ERR weight value is not a float")
+				}
+				zstore.Weights = append(zstore.Weights, weight)
+			}
+			i += numKeys
+		case "aggregate":
+			if i+1 >= len(args) {
+				return zstore, false, errSyntax
+			}
+			zstore.Aggregate = args[i+1]
+			i++
+		case "withscores":
+			withScores = true
+		default:
+			return zstore, false, errSyntax
+		}
+	}
+
+	return zstore, withScores, nil
+}
+
+// parseZPopCount - key [count], one member is popped by default
+func parseZPopCount(args []string) (int64, error) {
+	switch len(args) {
+	case 1:
+		return 1, nil
+	case 2:
+		count, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return 0, errNotInt
+		}
+		return count, nil
+	}
+
+	return 0, errSyntax
+}
+
+// respZMembers - members are followed by their scores with WITHSCORES, scores are sent as doubles in RESP3
+func respZMembers(members []models.ZMember, withScores bool) []interface{} {
+	res := make([]interface{}, 0, len(members)*2)
+	for _, member := range members {
+		res = append(res, member.Member)
+		if withScores {
+			res = append(res, member.Score)
+		}
+	}
+
+	return res
+}
+
+func respPublish(c *respConn, args []string) (interface{}, error) {
+	return c.redis.Publish(c.ctx, args[0], args[1])
+}
+
+func respKeys(c *respConn, args []string) (interface{}, error) {
+	return c.redis.GetKeys(c.ctx, args[0])
+}
+
+func respDel(c *respConn, args []string) (interface{}, error) {
+	var deleted int64
+	for _, key := range args {
+		res, err := c.redis.Delete(c.ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		deleted += res
+	}
+
+	return deleted, nil
+}
+
+func respSave(c *respConn, args []string) (interface{}, error) {
+	if err := c.redis.Save(c.ctx); err != nil {
+		return nil, err
+	}
+
+	return respOK, nil
+}
+
+// respBGSave - BGSAVE [SCHEDULE], saving is never scheduled because only one saving may run at a time
+func respBGSave(c *respConn, args []string) (interface{}, error) {
+	if len(args) > 1 || (len(args) == 1 && strings.ToLower(args[0]) != "schedule") {
+		return nil, errSyntax
+	}
+
+	if err := c.redis.BGSave(c.ctx); err != nil {
+		return nil, err
+	}
+
+	return resp.SimpleString("Background saving started"), nil
+}
+
+func respLastSave(c *respConn, args []string) (interface{}, error) {
+	status, err := c.redis.SaveStatus(c.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return status.LastSave, nil
+}
+
+// respInfo - INFO [section], fields are sent as lines "name:value" sorted by name
+func respInfo(c *respConn, args []string) (interface{}, error) {
+	if len(args) > 1 {
+		return nil, errSyntax
+	}
+
+	var section string
+	if len(args) == 1 {
+		section = args[0]
+	}
+
+	info, err := c.redis.Info(c.ctx, section)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(info))
+	for name := range info {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+
+	var res strings.Builder
+	for _, name := range fields {
+		res.WriteString(name)
+		res.WriteByte(':')
+		res.WriteString(info[name])
+		res.WriteString("\r\n")
+	}
+
+	return res.String(), nil
+}
+
+// respConfig - CONFIG GET pattern and CONFIG SET parameter value, only notify-keyspace-events is supported
+func respConfig(c *respConn, args []string) (interface{}, error) {
+	const notifyParameter = "notify-keyspace-events"
+
+	switch strings.ToLower(args[0]) {
+	case "get":
+		if len(args) != 2 {
+			return nil, errSyntax
+		}
+
+		if matched, _ := path.Match(strings.ToLower(args[1]), notifyParameter); !matched {
+			return []interface{}{}, nil
+		}
+
+		value, err := c.redis.NotifyKeyspaceEvents(c.ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{notifyParameter, value}, nil
+	case "set":
+		if len(args) != 3 {
+			return nil, errSyntax
+		}
+
+		if strings.ToLower(args[1]) != notifyParameter {
+			return nil, fmt.Errorf("ERR Unsupported CONFIG parameter: %s", args[1])
+		}
+
+		if err := c.redis.SetNotifyKeyspaceEvents(c.ctx, args[2]); err != nil {
+			return nil, err
+		}
+		return respOK, nil
+	}
+
+	return nil, fmt.Errorf("ERR Unknown subcommand or wrong number of arguments for '%s'. Try CONFIG HELP.", args[0])
+}
+
+// formatList - typed elements of list are sent as strings, maps are serialized to json
+func formatList(list []interface{}) ([]string, error) {
+	res := make([]string, len(list))
+	for i, el := range list {
+		switch v := el.(type) {
+		case string:
+			res[i] = v
+		case int64:
+			res[i] = strconv.FormatInt(v, 10)
+		case float64:
+			res[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			serialized, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = string(serialized)
+		}
+	}
+
+	return res, nil
+}
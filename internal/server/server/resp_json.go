@@ -0,0 +1,78 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// respJSONSet - JSON.SET key path value [NX|XX]
+func respJSONSet(c *respConn, args []string) (interface{}, error) {
+	opts := models.JSONSetOptions{}
+	switch {
+	case len(args) == 4 && strings.ToLower(args[3]) == "nx":
+		opts.NX = true
+	case len(args) == 4 && strings.ToLower(args[3]) == "xx":
+		opts.XX = true
+	case len(args) != 3:
+		return nil, errSyntax
+	}
+
+	_, err := c.redis.JSONSet(c.ctx, args[0], args[1], args[2], opts)
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return respOK, nil
+}
+
+// respJSONGet - JSON.GET key [path ...]
+func respJSONGet(c *respConn, args []string) (interface{}, error) {
+	return respValue(c.redis.JSONGet(c.ctx, args[0], args[1:]))
+}
+
+// respJSONDel - JSON.DEL key [path]
+func respJSONDel(c *respConn, args []string) (interface{}, error) {
+	if len(args) > 2 {
+		return nil, errSyntax
+	}
+
+	return c.redis.JSONDel(c.ctx, args[0], respJSONPath(args))
+}
+
+// respJSONArrAppend - JSON.ARRAPPEND key path value [value ...]
+func respJSONArrAppend(c *respConn, args []string) (interface{}, error) {
+	return c.redis.JSONArrAppend(c.ctx, args[0], args[1], args[2:])
+}
+
+// respJSONNumIncrBy - JSON.NUMINCRBY key path number, the result is bulk string with JSON
+func respJSONNumIncrBy(c *respConn, args []string) (interface{}, error) {
+	return c.redis.JSONNumIncrBy(c.ctx, args[0], args[1], args[2])
+}
+
+// respJSONObjKeys - JSON.OBJKEYS key [path]
+func respJSONObjKeys(c *respConn, args []string) (interface{}, error) {
+	if len(args) > 2 {
+		return nil, errSyntax
+	}
+
+	res, err := c.redis.JSONObjKeys(c.ctx, args[0], respJSONPath(args))
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	return res, err
+}
+
+// respJSONPath - the optional path after the key, the root by default
+func respJSONPath(args []string) string {
+	if len(args) > 1 {
+		return args[1]
+	}
+
+	return ""
+}
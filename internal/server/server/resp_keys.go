@@ -0,0 +1,78 @@
+package server
+
+import (
+	"strconv"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/Vysogota99/redis-implementation/internal/server/resp"
+)
+
+func respType(c *respConn, args []string) (interface{}, error) {
+	res, err := c.redis.Type(c.ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.SimpleString(res), nil
+}
+
+func respTTL(c *respConn, args []string) (interface{}, error) {
+	return c.redis.TTL(c.ctx, args[0])
+}
+
+func respPTTL(c *respConn, args []string) (interface{}, error) {
+	return c.redis.PTTL(c.ctx, args[0])
+}
+
+func respExpire(c *respConn, args []string) (interface{}, error) {
+	return respSetExpire(c, args, "ex")
+}
+
+func respPExpire(c *respConn, args []string) (interface{}, error) {
+	return respSetExpire(c, args, "px")
+}
+
+func respExpireUnix(c *respConn, args []string) (interface{}, error) {
+	return respSetExpire(c, args, "exat")
+}
+
+func respPExpireUnix(c *respConn, args []string) (interface{}, error) {
+	return respSetExpire(c, args, "pxat")
+}
+
+// respSetExpire - EXPIRE, PEXPIRE, EXPIREAT or PEXPIREAT key time, the time is passed the same way as the
+// option of SET. Non positive time is in the past and removes the key.
+func respSetExpire(c *respConn, args []string, option string) (interface{}, error) {
+	v, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+
+	// 0 means that the option is not set, so that 1 is used as the time in the past
+	opts := models.ExpireOptions{PxAt: 1}
+	if v > 0 {
+		if opts, err = respExpireOptions(option, args[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := c.redis.Expire(c.ctx, args[0], opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return boolInt(res), nil
+}
+
+func respPersist(c *respConn, args []string) (interface{}, error) {
+	res, err := c.redis.Persist(c.ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return boolInt(res), nil
+}
+
+func respExists(c *respConn, args []string) (interface{}, error) {
+	return c.redis.Exists(c.ctx, args)
+}
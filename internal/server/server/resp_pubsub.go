@@ -0,0 +1,138 @@
+package server
+
+import (
+	"log"
+	"sort"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/resp"
+	"github.com/Vysogota99/redis-implementation/internal/server/store"
+)
+
+// respSubscribedCommands - commands allowed on RESP2 connection with subscriptions
+var respSubscribedCommands = map[string]bool{
+	"subscribe":    true,
+	"psubscribe":   true,
+	"unsubscribe":  true,
+	"punsubscribe": true,
+	"ping":         true,
+	"quit":         true,
+}
+
+// respSubscribe - SUBSCRIBE channel [channel ...], every channel is confirmed by its own reply
+func respSubscribe(c *respConn, args []string) (interface{}, error) {
+	return c.subscribe(args, false)
+}
+
+// respPSubscribe - PSUBSCRIBE pattern [pattern ...]
+func respPSubscribe(c *respConn, args []string) (interface{}, error) {
+	return c.subscribe(args, true)
+}
+
+// respUnsubscribe - UNSUBSCRIBE [channel ...], without channels the connection is unsubscribed from all of them
+func respUnsubscribe(c *respConn, args []string) (interface{}, error) {
+	return c.unsubscribe(args, false), nil
+}
+
+// respPUnsubscribe - PUNSUBSCRIBE [pattern ...]
+func respPUnsubscribe(c *respConn, args []string) (interface{}, error) {
+	return c.unsubscribe(args, true), nil
+}
+
+// subscribed - checks if the connection has subscriptions
+func (c *respConn) subscribed() bool {
+	return len(c.channels)+len(c.patterns) > 0
+}
+
+// subscribe - every channel or pattern has its own subscription, so that it can be closed separately
+func (c *respConn) subscribe(names []string, patterns bool) (interface{}, error) {
+	subs, kind := c.channels, "subscribe"
+	if patterns {
+		subs, kind = c.patterns, "psubscribe"
+	}
+
+	replies := respReplies{}
+	for _, name := range names {
+		if _, ok := subs[name]; !ok {
+			var sub store.Subscription
+			var err error
+			if patterns {
+				sub, err = c.redis.Subscribe(c.ctx, nil, []string{name})
+			} else {
+				sub, err = c.redis.Subscribe(c.ctx, []string{name}, nil)
+			}
+			if err != nil {
+				return append(replies, respError(err)), nil
+			}
+
+			subs[name] = sub
+			go c.forward(sub)
+		}
+
+		replies = append(replies, resp.Push{kind, name, int64(len(c.channels) + len(c.patterns))})
+	}
+
+	return replies, nil
+}
+
+// unsubscribe - closes subscriptions to names or to all channels or patterns if names are not given
+func (c *respConn) unsubscribe(names []string, patterns bool) respReplies {
+	subs, kind := c.channels, "unsubscribe"
+	if patterns {
+		subs, kind = c.patterns, "punsubscribe"
+	}
+
+	if len(names) == 0 {
+		for name := range subs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	// redis confirms unsubscribing even if there are no subscriptions
+	if len(names) == 0 {
+		return respReplies{resp.Push{kind, nil, int64(len(c.channels) + len(c.patterns))}}
+	}
+
+	replies := respReplies{}
+	for _, name := range names {
+		if sub, ok := subs[name]; ok {
+			sub.Close()
+			delete(subs, name)
+		}
+
+		replies = append(replies, resp.Push{kind, name, int64(len(c.channels) + len(c.patterns))})
+	}
+
+	return replies
+}
+
+// unsubscribeAll - closes subscriptions when the connection is closed
+func (c *respConn) unsubscribeAll() {
+	for _, sub := range c.channels {
+		sub.Close()
+	}
+	for _, sub := range c.patterns {
+		sub.Close()
+	}
+}
+
+// forward - writes messages of the subscription to the client until the subscription is closed. The client
+// which does not read messages is disconnected, like by client-output-buffer-limit of redis.
+func (c *respConn) forward(sub store.Subscription) {
+	for message := range sub.Messages() {
+		reply := resp.Push{"message", message.Channel, message.Payload}
+		if message.Pattern != "" {
+			reply = resp.Push{"pmessage", message.Pattern, message.Channel, message.Payload}
+		}
+
+		if err := c.write(reply, true); err != nil {
+			c.conn.Close()
+			return
+		}
+	}
+
+	if err := sub.Err(); err != nil {
+		log.Printf("Client %d is disconnected: %s", c.id, err)
+		c.conn.Close()
+	}
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var errCursor = errors.New("ERR invalid cursor")
+
+// respScan - SCAN cursor [MATCH pattern] [COUNT count] [TYPE type]
+func respScan(c *respConn, args []string) (interface{}, error) {
+	cursor, match, count, keyType, err := parseScan(args, true)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, next, err := c.redis.Scan(c.ctx, cursor, match, count, keyType)
+	if err != nil {
+		return nil, err
+	}
+
+	return []interface{}{strconv.FormatUint(next, 10), keys}, nil
+}
+
+func respHScan(c *respConn, args []string) (interface{}, error) {
+	return respKeyScan(c, args, c.redis.HScan)
+}
+
+func respSScan(c *respConn, args []string) (interface{}, error) {
+	return respKeyScan(c, args, c.redis.SScan)
+}
+
+func respZScan(c *respConn, args []string) (interface{}, error) {
+	return respKeyScan(c, args, c.redis.ZScan)
+}
+
+// respKeyScan - HSCAN, SSCAN or ZSCAN key cursor [MATCH pattern] [COUNT count]
+func respKeyScan(c *respConn, args []string, scan func(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error)) (interface{}, error) {
+	cursor, match, count, _, err := parseScan(args[1:], false)
+	if err != nil {
+		return nil, err
+	}
+
+	elements, next, err := scan(c.ctx, args[0], cursor, match, count)
+	if err != nil {
+		return nil, err
+	}
+
+	return []interface{}{strconv.FormatUint(next, 10), elements}, nil
+}
+
+// parseScan - cursor and options of SCAN starting from args[0], TYPE is allowed only for keys
+func parseScan(args []string, withType bool) (uint64, string, int64, string, error) {
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return 0, "", 0, "", errCursor
+	}
+
+	var match, keyType string
+	var count int64
+	for i := 1; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return 0, "", 0, "", errSyntax
+		}
+
+		switch strings.ToLower(args[i]) {
+		case "match":
+			match = args[i+1]
+		case "count":
+			if count, err = strconv.ParseInt(args[i+1], 10, 64); err != nil {
+				return 0, "", 0, "", errNotInt
+			}
+			if count < 1 {
+				return 0, "", 0, "", errSyntax
+			}
+		case "type":
+			if !withType {
+				return 0, "", 0, "", errSyntax
+			}
+			keyType = args[i+1]
+		default:
+			return 0, "", 0, "", errSyntax
+		}
+		i++
+	}
+
+	return cursor, match, count, keyType, nil
+}
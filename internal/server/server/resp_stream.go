@@ -0,0 +1,468 @@
+package server
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/Vysogota99/redis-implementation/internal/server/resp"
+)
+
+var errXAddArgs = errors.New("ERR wrong number of arguments for 'xadd' command")
+
+// respXAdd - XADD key [MAXLEN|MINID [=|~] threshold] id|* field value [field value ...]. Approximate trimming is
+// exact, repeated fields keep the last value.
+func respXAdd(c *respConn, args []string) (interface{}, error) {
+	trim, i, err := parseXTrim(args, 1, false)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := args[i:]
+	if len(pairs) < 3 || len(pairs)%2 != 1 {
+		return nil, errXAddArgs
+	}
+
+	fields := make(map[string]interface{}, len(pairs)/2)
+	for j := 1; j < len(pairs); j += 2 {
+		fields[pairs[j]] = pairs[j+1]
+	}
+
+	return c.redis.XAdd(c.ctx, args[0], pairs[0], fields, trim)
+}
+
+// respXRange - XRANGE key start end [COUNT count]
+func respXRange(c *respConn, args []string) (interface{}, error) {
+	query, err := parseXRange(args)
+	if err != nil || query == nil {
+		return nil, err
+	}
+
+	entries, err := c.redis.XRange(c.ctx, args[0], *query)
+	if err != nil {
+		return nil, err
+	}
+
+	return respStreamEntries(c, entries)
+}
+
+// respXRevRange - XREVRANGE key end start [COUNT count]
+func respXRevRange(c *respConn, args []string) (interface{}, error) {
+	query, err := parseXRange(args)
+	if err != nil || query == nil {
+		return nil, err
+	}
+
+	entries, err := c.redis.XRevRange(c.ctx, args[0], *query)
+	if err != nil {
+		return nil, err
+	}
+
+	return respStreamEntries(c, entries)
+}
+
+func respXLen(c *respConn, args []string) (interface{}, error) {
+	return c.redis.XLen(c.ctx, args[0])
+}
+
+// respXTrim - XTRIM key MAXLEN|MINID [=|~] threshold
+func respXTrim(c *respConn, args []string) (interface{}, error) {
+	trim, i, err := parseXTrim(args, 1, true)
+	if err != nil {
+		return nil, err
+	}
+	if i != len(args) {
+		return nil, errSyntax
+	}
+
+	return c.redis.XTrim(c.ctx, args[0], trim)
+}
+
+// respXRead - XREAD [COUNT count] [BLOCK milliseconds] STREAMS key [key ...] id [id ...]. The command does not
+// wait for new entries, BLOCK is accepted for compatibility with clients.
+func respXRead(c *respConn, args []string) (interface{}, error) {
+	var query models.XReadQuery
+
+	i := 0
+	for ; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "count", "block":
+			if i+1 >= len(args) {
+				return nil, errSyntax
+			}
+			value, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				return nil, errNotInt
+			}
+			if strings.ToLower(args[i]) == "count" {
+				query.Count = value
+			}
+			i++
+			continue
+		case "streams":
+			query.Keys, query.IDs = splitStreams(args[i+1:])
+		default:
+			return nil, errSyntax
+		}
+		break
+	}
+
+	if query.Keys == nil {
+		return nil, errSyntax
+	}
+
+	streams, err := c.redis.XRead(c.ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return respXStreams(c, streams)
+}
+
+// respXGroup - only XGROUP CREATE key group id|$ [MKSTREAM] is supported
+func respXGroup(c *respConn, args []string) (interface{}, error) {
+	if strings.ToLower(args[0]) != "create" {
+		return nil, errors.New("ERR Unknown subcommand or wrong number of arguments for '" + args[0] + "'. Try XGROUP HELP.")
+	}
+
+	var mkstream bool
+	switch len(args) {
+	case 4:
+	case 5:
+		if strings.ToLower(args[4]) != "mkstream" {
+			return nil, errSyntax
+		}
+		mkstream = true
+	default:
+		return nil, errSyntax
+	}
+
+	if err := c.redis.XGroupCreate(c.ctx, args[1], args[2], args[3], mkstream); err != nil {
+		return nil, err
+	}
+
+	return respOK, nil
+}
+
+// respXReadGroup - XREADGROUP GROUP group consumer [COUNT count] [BLOCK milliseconds] [NOACK] STREAMS key [key ...]
+// id [id ...]. As well as XREAD it does not wait for new entries.
+func respXReadGroup(c *respConn, args []string) (interface{}, error) {
+	if strings.ToLower(args[0]) != "group" {
+		return nil, errSyntax
+	}
+	request := models.XReadGroupRequest{Group: args[1], Consumer: args[2]}
+
+	i := 3
+	for ; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "count", "block":
+			if i+1 >= len(args) {
+				return nil, errSyntax
+			}
+			value, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				return nil, errNotInt
+			}
+			if strings.ToLower(args[i]) == "count" {
+				request.Count = value
+			}
+			i++
+			continue
+		case "noack":
+			request.NoAck = true
+			continue
+		case "streams":
+			request.Keys, request.IDs = splitStreams(args[i+1:])
+		default:
+			return nil, errSyntax
+		}
+		break
+	}
+
+	if request.Keys == nil {
+		return nil, errSyntax
+	}
+
+	streams, err := c.redis.XReadGroup(c.ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return respXStreams(c, streams)
+}
+
+func respXAck(c *respConn, args []string) (interface{}, error) {
+	return c.redis.XAck(c.ctx, args[0], args[1], args[2:])
+}
+
+// respXPending - XPENDING key group [start end count [consumer]]
+func respXPending(c *respConn, args []string) (interface{}, error) {
+	if len(args) == 2 {
+		pending, err := c.redis.XPending(c.ctx, args[0], args[1])
+		if err != nil {
+			return nil, err
+		}
+		if pending.Count == 0 {
+			return []interface{}{int64(0), nil, nil, nil}, nil
+		}
+
+		names := make([]string, 0, len(pending.Consumers))
+		for name := range pending.Consumers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		consumers := make([]interface{}, len(names))
+		for i, name := range names {
+			consumers[i] = []interface{}{name, strconv.FormatInt(pending.Consumers[name], 10)}
+		}
+
+		return []interface{}{pending.Count, pending.Lower, pending.Higher, consumers}, nil
+	}
+
+	if len(args) != 5 && len(args) != 6 {
+		return nil, errSyntax
+	}
+
+	query := models.XPendingQuery{Group: args[1], Start: args[2], End: args[3]}
+	count, err := strconv.ParseInt(args[4], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+	if count <= 0 {
+		return []interface{}{}, nil
+	}
+	query.Count = count
+	if len(args) == 6 {
+		query.Consumer = args[5]
+	}
+
+	entries, err := c.redis.XPendingExt(c.ctx, args[0], query)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		res[i] = []interface{}{entry.ID, entry.Consumer, entry.Idle, entry.RetryCount}
+	}
+
+	return res, nil
+}
+
+// respXClaim - XCLAIM key group consumer min-idle-time id [id ...] [JUSTID], the other options are not supported
+func respXClaim(c *respConn, args []string) (interface{}, error) {
+	minIdle, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		return nil, errors.New("ERR Invalid min-idle-time argument for XCLAIM")
+	}
+
+	ids := args[4:]
+	justID := strings.ToLower(ids[len(ids)-1]) == "justid"
+	if justID {
+		ids = ids[:len(ids)-1]
+	}
+
+	entries, err := c.redis.XClaim(c.ctx, args[0], models.XClaim{Group: args[1], Consumer: args[2], MinIdle: minIdle, IDs: ids})
+	if err != nil {
+		return nil, err
+	}
+
+	if justID {
+		return respStreamIDs(entries), nil
+	}
+	return respStreamEntries(c, entries)
+}
+
+// respXAutoClaim - XAUTOCLAIM key group consumer min-idle-time start [COUNT count] [JUSTID] of redis 6.2
+func respXAutoClaim(c *respConn, args []string) (interface{}, error) {
+	minIdle, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		return nil, errors.New("ERR Invalid min-idle-time argument for XAUTOCLAIM")
+	}
+
+	claim := models.XAutoClaim{Group: args[1], Consumer: args[2], MinIdle: minIdle, Start: args[4]}
+	var justID bool
+	for i := 5; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "count":
+			if i+1 >= len(args) {
+				return nil, errSyntax
+			}
+			if claim.Count, err = strconv.ParseInt(args[i+1], 10, 64); err != nil || claim.Count <= 0 {
+				return nil, errors.New("ERR COUNT must be > 0")
+			}
+			i++
+		case "justid":
+			justID = true
+		default:
+			return nil, errSyntax
+		}
+	}
+
+	result, err := c.redis.XAutoClaim(c.ctx, args[0], claim)
+	if err != nil {
+		return nil, err
+	}
+
+	if justID {
+		return []interface{}{result.Next, respStreamIDs(result.Entries)}, nil
+	}
+
+	entries, err := respStreamEntries(c, result.Entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return []interface{}{result.Next, entries}, nil
+}
+
+// parseXTrim - MAXLEN|MINID [=|~] threshold starting from args[i], returns index of the next argument.
+// Without required the strategy is optional.
+func parseXTrim(args []string, i int, required bool) (models.XTrim, int, error) {
+	var trim models.XTrim
+	if i >= len(args) {
+		return trim, i, errSyntax
+	}
+
+	strategy := strings.ToLower(args[i])
+	if strategy != "maxlen" && strategy != "minid" {
+		if required {
+			return trim, i, errSyntax
+		}
+		return trim, i, nil
+	}
+
+	i++
+	if i < len(args) && (args[i] == "=" || args[i] == "~") {
+		i++
+	}
+	if i >= len(args) {
+		return trim, i, errSyntax
+	}
+
+	if strategy == "minid" {
+		trim.MinID = args[i]
+		return trim, i + 1, nil
+	}
+
+	maxLen, err := strconv.ParseInt(args[i], 10, 64)
+	if err != nil {
+		return trim, i, errNotInt
+	}
+	trim.MaxLen = &maxLen
+
+	return trim, i + 1, nil
+}
+
+// parseXRange - key start end [COUNT count], nil query means that nothing has to be returned
+func parseXRange(args []string) (*models.XRangeQuery, error) {
+	query := &models.XRangeQuery{Start: args[1], End: args[2]}
+
+	switch len(args) {
+	case 3:
+		return query, nil
+	case 5:
+		if strings.ToLower(args[3]) != "count" {
+			return nil, errSyntax
+		}
+	default:
+		return nil, errSyntax
+	}
+
+	count, err := strconv.ParseInt(args[4], 10, 64)
+	if err != nil {
+		return nil, errNotInt
+	}
+	// the same as redis does, zero or negative count returns nothing
+	if count <= 0 {
+		return nil, nil
+	}
+	query.Count = count
+
+	return query, nil
+}
+
+// splitStreams - the first half of arguments after STREAMS are keys, the second one are ids
+func splitStreams(args []string) ([]string, []string) {
+	if len(args) == 0 || len(args)%2 != 0 {
+		return []string{}, args
+	}
+
+	return args[:len(args)/2], args[len(args)/2:]
+}
+
+// respStreamEntries - entries are pairs of id and array of fields with values, fields are sorted by name. Values
+// keep their types in RESP3. Entries deleted from the stream are null.
+func respStreamEntries(c *respConn, entries []models.StreamEntry) ([]interface{}, error) {
+	res := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		if entry.Fields == nil {
+			continue
+		}
+
+		names := make([]string, 0, len(entry.Fields))
+		for field := range entry.Fields {
+			names = append(names, field)
+		}
+		sort.Strings(names)
+
+		values := make([]interface{}, len(names))
+		for j, name := range names {
+			values[j] = entry.Fields[name]
+		}
+		if c.writer.Protocol() != resp.RESP3 {
+			formatted, err := formatList(values)
+			if err != nil {
+				return nil, err
+			}
+			for j := range formatted {
+				values[j] = formatted[j]
+			}
+		}
+
+		fields := make([]interface{}, 0, len(names)*2)
+		for j, name := range names {
+			fields = append(fields, name, values[j])
+		}
+		res[i] = []interface{}{entry.ID, fields}
+	}
+
+	return res, nil
+}
+
+func respStreamIDs(entries []models.StreamEntry) []string {
+	res := make([]string, len(entries))
+	for i, entry := range entries {
+		res[i] = entry.ID
+	}
+
+	return res
+}
+
+// respXStreams - reply of XREAD and XREADGROUP, streams are map in RESP3 and pairs of key and entries in RESP2.
+// Null is returned if there are no entries.
+func respXStreams(c *respConn, streams []models.XStream) (interface{}, error) {
+	if len(streams) == 0 {
+		return nil, nil
+	}
+
+	res := make([]interface{}, len(streams))
+	byKey := make(map[string]interface{}, len(streams))
+	for i, stream := range streams {
+		entries, err := respStreamEntries(c, stream.Entries)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = []interface{}{stream.Stream, entries}
+		byKey[stream.Stream] = entries
+	}
+
+	if c.writer.Protocol() == resp.RESP3 {
+		return byKey, nil
+	}
+
+	return res, nil
+}
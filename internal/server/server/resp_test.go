@@ -0,0 +1,617 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/Vysogota99/redis-implementation/internal/server/store"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRespClient(t *testing.T) (*redis.Client, func()) {
+	s := newRespServer("127.0.0.1:0", store.NewNative())
+	assert.NoError(t, s.listen())
+
+	client := redis.NewClient(&redis.Options{
+		Addr: s.listener.Addr().String(),
+	})
+
+	return client, func() {
+		client.Close()
+		s.close()
+	}
+}
+
+func TestRespStrings(t *testing.T) {
+	client, closeFn := newTestRespClient(t)
+	defer closeFn()
+
+	ctx := context.Background()
+
+	assert.Equal(t, "PONG", client.Ping(ctx).Val())
+
+	res, err := client.Set(ctx, "user:1", "Ivan", 0).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", res)
+
+	value, err := client.Get(ctx, "user:1").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "Ivan", value)
+
+	_, err = client.Get(ctx, "user:2").Result()
+	assert.Equal(t, redis.Nil, err)
+
+	deleted, err := client.Del(ctx, "user:1", "user:2").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
+
+func TestRespCounters(t *testing.T) {
+	client, closeFn := newTestRespClient(t)
+	defer closeFn()
+
+	ctx := context.Background()
+
+	assert.Equal(t, int64(1), client.Incr(ctx, "counter").Val())
+	assert.Equal(t, int64(11), client.IncrBy(ctx, "counter", 10).Val())
+	assert.Equal(t, int64(10), client.Decr(ctx, "counter").Val())
+	assert.Equal(t, int64(5), client.DecrBy(ctx, "counter", 5).Val())
+	assert.Equal(t, 5.5, client.IncrByFloat(ctx, "counter", 0.5).Val())
+
+	// the float is not an integer anymore
+	err := client.Incr(ctx, "counter").Err()
+	assert.EqualError(t, err, "ERR value is not an integer or out of range")
+
+	client.Set(ctx, "max", "9223372036854775807", 0)
+	err = client.Incr(ctx, "max").Err()
+	assert.EqualError(t, err, "ERR increment or decrement would overflow")
+}
+
+func TestRespStringOptions(t *testing.T) {
+	client, closeFn := newTestRespClient(t)
+	defer closeFn()
+
+	ctx := context.Background()
+
+	assert.True(t, client.SetNX(ctx, "lock", "1", time.Minute).Val())
+	assert.False(t, client.SetNX(ctx, "lock", "2", time.Minute).Val())
+
+	old, err := client.Do(ctx, "SET", "lock", "2", "GET", "KEEPTTL").Text()
+	assert.NoError(t, err)
+	assert.Equal(t, "1", old)
+
+	err = client.Do(ctx, "SET", "lock", "3", "NX", "XX").Err()
+	assert.EqualError(t, err, "ERR syntax error")
+
+	err = client.Do(ctx, "SET", "lock", "3", "EX", "0").Err()
+	assert.EqualError(t, err, "ERR invalid expire time")
+
+	// EX and PX are passed as ttl, the same way as ttl of HTTP requests
+	assert.NoError(t, client.Do(ctx, "SET", "session", "1", "PX", "1500").Err())
+	assert.InDelta(t, 1500*time.Millisecond, client.PTTL(ctx, "session").Val(), float64(time.Second))
+	assert.NoError(t, client.Do(ctx, "GETEX", "session", "EX", "30").Err())
+	assert.InDelta(t, 30*time.Second, client.PTTL(ctx, "session").Val(), float64(time.Second))
+
+	err = client.Do(ctx, "SET", "session", "1", "EX", "10", "PX", "10").Err()
+	assert.EqualError(t, err, "ERR syntax error")
+
+	err = client.Do(ctx, "SET", "session", "1", "EX", "9223372036854775807").Err()
+	assert.EqualError(t, err, "ERR invalid expire time")
+
+	assert.Equal(t, int64(5), client.Append(ctx, "name", "Hello").Val())
+	assert.Equal(t, int64(11), client.Append(ctx, "name", " World").Val())
+	assert.Equal(t, int64(11), client.StrLen(ctx, "name").Val())
+	assert.Equal(t, "World", client.GetRange(ctx, "name", -5, -1).Val())
+	assert.Equal(t, int64(11), client.SetRange(ctx, "name", 6, "Redis").Val())
+	assert.Equal(t, "Hello Redis", client.GetSet(ctx, "name", "Ivan").Val())
+
+	value, err := client.Do(ctx, "GETEX", "name", "PERSIST").Text()
+	assert.NoError(t, err)
+	assert.Equal(t, "Ivan", value)
+
+	value, err = client.Do(ctx, "GETDEL", "name").Text()
+	assert.NoError(t, err)
+	assert.Equal(t, "Ivan", value)
+
+	err = client.Do(ctx, "GETDEL", "name").Err()
+	assert.Equal(t, redis.Nil, err)
+}
+
+func TestRespHashAndList(t *testing.T) {
+	client, closeFn := newTestRespClient(t)
+	defer closeFn()
+
+	ctx := context.Background()
+
+	added, err := client.HSet(ctx, "user:1", "name", "Ivan", "age", 21).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), added)
+
+	hash, err := client.HGetAll(ctx, "user:1").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "Ivan", "age": "21"}, hash)
+
+	length, err := client.RPush(ctx, "list:1", "a", "b", "c").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), length)
+
+	assert.Equal(t, "OK", client.LSet(ctx, "list:1", 1, "d").Val())
+
+	list, err := client.LRange(ctx, "list:1", 0, -1).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "d", "c"}, list)
+
+	keys, err := client.Keys(ctx, "*").Result()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:1", "list:1"}, keys)
+
+	_, err = client.Get(ctx, "list:1").Result()
+	assert.EqualError(t, err, store.ErrWrongType.Error())
+
+	_, err = client.Do(ctx, "unknown").Result()
+	assert.EqualError(t, err, "ERR unknown command 'unknown'")
+
+	_, err = client.Do(ctx, "get").Result()
+	assert.EqualError(t, err, "ERR wrong number of arguments for 'get' command")
+}
+
+func TestRespLists(t *testing.T) {
+	client, closeFn := newTestRespClient(t)
+	defer closeFn()
+
+	ctx := context.Background()
+
+	assert.Equal(t, int64(3), client.RPush(ctx, "list", "a", "b", "c").Val())
+	assert.Equal(t, int64(5), client.LPush(ctx, "list", "y", "x").Val())
+	assert.Equal(t, int64(5), client.LLen(ctx, "list").Val())
+	assert.Equal(t, "c", client.LIndex(ctx, "list", -1).Val())
+	assert.Equal(t, int64(6), client.LInsertAfter(ctx, "list", "a", "b").Val())
+	assert.Equal(t, []int64{3, 4}, client.LPosCount(ctx, "list", "b", 0, redis.LPosArgs{}).Val())
+	assert.Equal(t, int64(1), client.LRem(ctx, "list", -1, "b").Val())
+	assert.Equal(t, "x", client.LPop(ctx, "list").Val())
+
+	popped, err := client.Do(ctx, "RPOP", "list", "2").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"c", "b"}, popped)
+
+	assert.NoError(t, client.LTrim(ctx, "list", 0, 0).Err())
+
+	moved, err := client.Do(ctx, "LMOVE", "list", "other", "LEFT", "RIGHT").Text()
+	assert.NoError(t, err)
+	assert.Equal(t, "y", moved)
+
+	err = client.Do(ctx, "LMOVE", "list", "other", "LEFT", "RIGHT").Err()
+	assert.Equal(t, redis.Nil, err)
+
+	err = client.Do(ctx, "LPOS", "other", "y", "RANK", "0").Err()
+	assert.Error(t, err)
+}
+
+func TestRespHashes(t *testing.T) {
+	client, closeFn := newTestRespClient(t)
+	defer closeFn()
+
+	ctx := context.Background()
+
+	assert.Equal(t, int64(2), client.HSet(ctx, "user", "name", "ivan", "age", "21").Val())
+	assert.True(t, client.HExists(ctx, "user", "age").Val())
+	assert.Equal(t, int64(2), client.HLen(ctx, "user").Val())
+	assert.Equal(t, []string{"age", "name"}, client.HKeys(ctx, "user").Val())
+	assert.Equal(t, []string{"21", "ivan"}, client.HVals(ctx, "user").Val())
+	assert.Equal(t, []interface{}{"ivan", nil}, client.HMGet(ctx, "user", "name", "city").Val())
+	assert.False(t, client.HSetNX(ctx, "user", "name", "petr").Val())
+	assert.Equal(t, int64(22), client.HIncrBy(ctx, "user", "age", 1).Val())
+	assert.Equal(t, 22.5, client.HIncrByFloat(ctx, "user", "age", 0.5).Val())
+	assert.Equal(t, int64(1), client.HDel(ctx, "user", "age", "city").Val())
+
+	err := client.HIncrBy(ctx, "user", "name", 1).Err()
+	assert.EqualError(t, err, "ERR hash value is not an integer")
+}
+
+func TestRespJSON(t *testing.T) {
+	client, closeFn := newTestRespClient(t)
+	defer closeFn()
+
+	ctx := context.Background()
+
+	assert.Equal(t, "OK", client.Do(ctx, "JSON.SET", "doc", "$", `{"name":"ivan","age":21,"tags":["a"]}`).Val())
+	assert.Equal(t, redis.Nil, client.Do(ctx, "JSON.SET", "doc", "$.name", `"petr"`, "NX").Err())
+	assert.Equal(t, `{"age":21,"name":"ivan","tags":["a"]}`, client.Do(ctx, "JSON.GET", "doc").Val())
+	assert.Equal(t, `["ivan"]`, client.Do(ctx, "JSON.GET", "doc", "$.name").Val())
+	assert.Equal(t, "22", client.Do(ctx, "JSON.NUMINCRBY", "doc", ".age", "1").Val())
+	assert.Equal(t, []interface{}{int64(3)}, client.Do(ctx, "JSON.ARRAPPEND", "doc", "$.tags", `"b"`, `"c"`).Val())
+	assert.Equal(t, []interface{}{"age", "name", "tags"}, client.Do(ctx, "JSON.OBJKEYS", "doc").Val())
+	assert.Equal(t, int64(1), client.Do(ctx, "JSON.DEL", "doc", "$.tags[0]").Val())
+	assert.Equal(t, `["b","c"]`, client.Do(ctx, "JSON.GET", "doc", ".tags").Val())
+	assert.Equal(t, redis.Nil, client.Do(ctx, "JSON.GET", "missing").Err())
+
+	err := client.Do(ctx, "JSON.NUMINCRBY", "doc", ".name", "1").Err()
+	assert.EqualError(t, err, "ERR wrong type of path value - expected a number but found string")
+
+	err = client.Do(ctx, "JSON.SET", "doc", "$", "{}", "NX", "XX").Err()
+	assert.EqualError(t, err, "ERR syntax error")
+
+	assert.Equal(t, int64(1), client.Do(ctx, "JSON.DEL", "doc").Val())
+	assert.Empty(t, client.Keys(ctx, "doc").Val())
+}
+
+func TestRespBlockingLists(t *testing.T) {
+	client, closeFn := newTestRespClient(t)
+	defer closeFn()
+
+	ctx := context.Background()
+
+	assert.Equal(t, int64(1), client.RPush(ctx, "list", "a").Val())
+	assert.Equal(t, []string{"list", "a"}, client.BLPop(ctx, time.Second, "missing", "list").Val())
+
+	err := client.Do(ctx, "BRPOP", "list", "0.01").Err()
+	assert.Equal(t, redis.Nil, err)
+
+	err = client.Do(ctx, "BRPOP", "list", "soon").Err()
+	assert.EqualError(t, err, "ERR timeout is not a float or out of range")
+
+	// the element is pushed by another connection while the command is blocked
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		client.RPush(ctx, "list", "b")
+	}()
+
+	moved, err := client.Do(ctx, "BLMOVE", "list", "other", "RIGHT", "LEFT", "0").Text()
+	assert.NoError(t, err)
+	assert.Equal(t, "b", moved)
+	assert.Equal(t, []string{"b"}, client.LRange(ctx, "other", 0, -1).Val())
+
+	// the client disconnects while the command is blocked, so that the pushed element is not popped for it
+	conn, err := net.Dial("tcp", client.Options().Addr)
+	assert.NoError(t, err)
+	fmt.Fprint(conn, "BLPOP queue 0\r\n")
+	time.Sleep(50 * time.Millisecond)
+	conn.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, int64(1), client.LPush(ctx, "queue", "c").Val())
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int64(1), client.LLen(ctx, "queue").Val())
+}
+
+func TestRespHello(t *testing.T) {
+	native := store.NewNative()
+	err := native.SetList(context.Background(), "list:1", []interface{}{21, 3.5, map[string]interface{}{"name": "Ivan"}}, models.Expiration{})
+	assert.NoError(t, err)
+
+	s := newRespServer("127.0.0.1:0", native)
+	assert.NoError(t, s.listen())
+	defer s.close()
+
+	conn, err := net.Dial("tcp", s.listener.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	fmt.Fprint(conn, "HELLO 4\r\n")
+	assert.Equal(t, "-NOPROTO unsupported protocol version\r\n", readReply(t, reader, 1))
+
+	fmt.Fprint(conn, "HELLO 3 SETNAME test\r\n")
+	assert.Equal(t, "%7\r\n", readReply(t, reader, 1))
+	// pairs of server, version, proto, id, mode, role and modules
+	readReply(t, reader, 25)
+
+	fmt.Fprint(conn, "LRANGE list:1 0 -1\r\n")
+	assert.Equal(t, "*3\r\n:21\r\n,3.5\r\n%1\r\n$4\r\nname\r\n$4\r\nIvan\r\n", readReply(t, reader, 8))
+
+	fmt.Fprint(conn, "GET missing\r\n")
+	assert.Equal(t, "_\r\n", readReply(t, reader, 1))
+}
+
+// readReply - reads given number of lines of reply
+func readReply(t *testing.T, reader *bufio.Reader, lines int) string {
+	reply := ""
+	for i := 0; i < lines; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		reply += line
+	}
+
+	return reply
+}
+
+func TestRespSets(t *testing.T) {
+	client, closeFn := newTestRespClient(t)
+	defer closeFn()
+
+	ctx := context.Background()
+
+	added, err := client.SAdd(ctx, "roles:1", "admin", "user").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), added)
+	_, err = client.SAdd(ctx, "roles:2", "user", "guest").Result()
+	assert.NoError(t, err)
+
+	members, err := client.SMembers(ctx, "roles:1").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"admin", "user"}, members)
+
+	assert.True(t, client.SIsMember(ctx, "roles:1", "admin").Val())
+	assert.Equal(t, []string{"user"}, client.SInter(ctx, "roles:1", "roles:2").Val())
+	assert.Equal(t, int64(3), client.SUnionStore(ctx, "all", "roles:1", "roles:2").Val())
+	assert.Equal(t, int64(3), client.SCard(ctx, "all").Val())
+
+	_, err = client.SAdd(ctx, "user:1", "admin").Result()
+	assert.NoError(t, err)
+	_, err = client.Get(ctx, "user:1").Result()
+	assert.EqualError(t, err, store.ErrWrongType.Error())
+}
+
+func TestRespZSets(t *testing.T) {
+	client, closeFn := newTestRespClient(t)
+	defer closeFn()
+
+	ctx := context.Background()
+
+	added, err := client.ZAdd(ctx, "scores", &redis.Z{Score: 1.5, Member: "ivan"}, &redis.Z{Score: 2, Member: "petr"}).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), added)
+
+	score, err := client.ZIncrXX(ctx, "scores", &redis.Z{Score: 1, Member: "ivan"}).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, score)
+
+	_, err = client.ZIncrNX(ctx, "scores", &redis.Z{Score: 1, Member: "ivan"}).Result()
+	assert.Equal(t, redis.Nil, err)
+
+	changed, err := client.Do(ctx, "zadd", "scores", "gt", "ch", "1", "petr", "3", "oleg").Int64()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), changed)
+
+	assert.Equal(t, int64(1), client.ZRank(ctx, "scores", "ivan").Val())
+	assert.Equal(t, 2.5, client.ZScore(ctx, "scores", "ivan").Val())
+
+	members, err := client.Do(ctx, "zrange", "scores", "+inf", "(2", "byscore", "rev", "withscores").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"oleg", "3", "ivan", "2.5"}, members)
+
+	_, err = client.Do(ctx, "zrange", "scores", "-", "+", "bylex", "withscores").Result()
+	assert.Error(t, err)
+
+	members, err = client.Do(ctx, "zunion", "2", "scores", "missing", "weights", "2", "1").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"petr", "ivan", "oleg"}, members)
+
+	popped, err := client.ZPopMin(ctx, "scores", 2).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Score: 2, Member: "petr"}, {Score: 2.5, Member: "ivan"}}, popped)
+
+	_, err = client.Do(ctx, "zadd", "scores", "nx", "xx", "1", "ivan").Result()
+	assert.Error(t, err)
+}
+
+func TestRespStreams(t *testing.T) {
+	client, closeFn := newTestRespClient(t)
+	defer closeFn()
+
+	ctx := context.Background()
+
+	for _, id := range []string{"1-0", "2-0", "3-0"} {
+		added, err := client.XAdd(ctx, &redis.XAddArgs{Stream: "events", ID: id, Values: []string{"name", "ivan", "age", "25"}}).Result()
+		assert.NoError(t, err)
+		assert.Equal(t, id, added)
+	}
+
+	_, err := client.XAdd(ctx, &redis.XAddArgs{Stream: "events", ID: "1-0", Values: []string{"name", "petr"}}).Result()
+	assert.Error(t, err)
+
+	messages, err := client.XRangeN(ctx, "events", "(1-0", "+", 1).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.XMessage{{ID: "2-0", Values: map[string]interface{}{"name": "ivan", "age": "25"}}}, messages)
+
+	messages, err = client.XRevRange(ctx, "events", "+", "-").Result()
+	assert.NoError(t, err)
+	assert.Len(t, messages, 3)
+	assert.Equal(t, "3-0", messages[0].ID)
+
+	streams, err := client.XRead(ctx, &redis.XReadArgs{Streams: []string{"events", "2-0"}, Block: -1}).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "events", streams[0].Stream)
+	assert.Len(t, streams[0].Messages, 1)
+
+	_, err = client.XRead(ctx, &redis.XReadArgs{Streams: []string{"events", "$"}, Block: -1}).Result()
+	assert.Equal(t, redis.Nil, err)
+
+	assert.Equal(t, "OK", client.XGroupCreate(ctx, "events", "workers", "0").Val())
+	_, err = client.XGroupCreate(ctx, "events", "workers", "0").Result()
+	assert.EqualError(t, err, "BUSYGROUP Consumer Group name already exists")
+
+	streams, err = client.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "workers", Consumer: "ivan", Streams: []string{"events", ">"}, Count: 2, Block: -1}).Result()
+	assert.NoError(t, err)
+	assert.Len(t, streams[0].Messages, 2)
+
+	pending, err := client.XPending(ctx, "events", "workers").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, &redis.XPending{Count: 2, Lower: "1-0", Higher: "2-0", Consumers: map[string]int64{"ivan": 2}}, pending)
+
+	ids, err := client.XClaimJustID(ctx, &redis.XClaimArgs{Stream: "events", Group: "workers", Consumer: "petr", Messages: []string{"1-0"}}).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1-0"}, ids)
+
+	ext, err := client.XPendingExt(ctx, &redis.XPendingExtArgs{Stream: "events", Group: "workers", Start: "-", End: "+", Count: 10, Consumer: "petr"}).Result()
+	assert.NoError(t, err)
+	assert.Len(t, ext, 1)
+	assert.Equal(t, "1-0", ext[0].ID)
+
+	assert.Equal(t, int64(1), client.XAck(ctx, "events", "workers", "1-0").Val())
+
+	claimed, err := client.Do(ctx, "xautoclaim", "events", "workers", "oleg", "0", "0-0", "count", "1").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"0-0", []interface{}{[]interface{}{"2-0", []interface{}{"age", "25", "name", "ivan"}}}}, claimed)
+
+	assert.Equal(t, int64(2), client.XTrim(ctx, "events", 1).Val())
+	assert.Equal(t, int64(1), client.XLen(ctx, "events").Val())
+
+	_, err = client.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "missing", Consumer: "ivan", Streams: []string{"events", ">"}, Block: -1}).Result()
+	assert.Error(t, err)
+}
+
+func TestRespPublish(t *testing.T) {
+	native := store.NewNative()
+	s := newRespServer("127.0.0.1:0", native)
+	assert.NoError(t, s.listen())
+	defer s.close()
+
+	client := redis.NewClient(&redis.Options{
+		Addr: s.listener.Addr().String(),
+	})
+	defer client.Close()
+
+	sub, err := native.Subscribe(context.Background(), []string{"news"}, nil)
+	assert.NoError(t, err)
+	defer sub.Close()
+
+	receivers, err := client.Publish(context.Background(), "news", "hello").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), receivers)
+	assert.Equal(t, "hello", (<-sub.Messages()).Payload)
+}
+
+func TestRespKeyspaceNotifications(t *testing.T) {
+	client, closeFn := newTestRespClient(t)
+	defer closeFn()
+
+	ctx := context.Background()
+
+	_, err := client.ConfigSet(ctx, "notify-keyspace-events", "KEq").Result()
+	assert.Error(t, err)
+	assert.Equal(t, "OK", client.ConfigSet(ctx, "notify-keyspace-events", "E$g").Val())
+	assert.Equal(t, []interface{}{"notify-keyspace-events", "g$E"}, client.ConfigGet(ctx, "notify-*").Val())
+
+	pubsub := client.PSubscribe(ctx, "__keyevent@0__:*")
+	defer pubsub.Close()
+	_, err = pubsub.Receive(ctx)
+	assert.NoError(t, err)
+
+	assert.NoError(t, client.Set(ctx, "user:1", "Ivan", 0).Err())
+	assert.NoError(t, client.HSet(ctx, "user:2", "name", "Ivan").Err())
+	assert.NoError(t, client.Del(ctx, "user:1").Err())
+
+	// hash events are not enabled
+	for _, event := range []string{"set", "del"} {
+		msg, err := pubsub.ReceiveMessage(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "__keyevent@0__:"+event, msg.Channel)
+		assert.Equal(t, "__keyevent@0__:*", msg.Pattern)
+		assert.Equal(t, "user:1", msg.Payload)
+	}
+
+	// only pub/sub commands are allowed on RESP2 connection with subscriptions
+	conn, err := net.Dial("tcp", client.Options().Addr)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	fmt.Fprint(conn, "*2\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n*2\r\n$3\r\nget\r\n$4\r\nuser\r\n*1\r\n$4\r\nping\r\n*1\r\n$11\r\nunsubscribe\r\n")
+	for _, line := range []string{
+		"*3", "$9", "subscribe", "$4", "news", ":1",
+		"-ERR Can't execute 'get': only (P)SUBSCRIBE / (P)UNSUBSCRIBE / PING / QUIT are allowed in this context",
+		"*2", "$4", "pong", "$0", "",
+		"*3", "$11", "unsubscribe", "$4", "news", ":0",
+	} {
+		res, err := reader.ReadString('\n')
+		assert.NoError(t, err)
+		assert.Equal(t, line+"\r\n", res)
+	}
+}
+
+func TestRespScan(t *testing.T) {
+	client, closeFn := newTestRespClient(t)
+	defer closeFn()
+
+	ctx := context.Background()
+
+	assert.NoError(t, client.Set(ctx, "name", "ivan", 0).Err())
+	assert.NoError(t, client.HSet(ctx, "user", "role", "admin").Err())
+	assert.NoError(t, client.SAdd(ctx, "roles", "admin", "user").Err())
+	assert.NoError(t, client.ZAdd(ctx, "rating", &redis.Z{Member: "ivan", Score: 1.5}).Err())
+
+	keys := []string{}
+	iter := client.Scan(ctx, 0, "", 1).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	assert.NoError(t, iter.Err())
+	assert.ElementsMatch(t, []string{"name", "user", "roles", "rating"}, keys)
+
+	assert.Equal(t, []interface{}{"0", []interface{}{"user"}}, client.Do(ctx, "SCAN", "0", "COUNT", "100", "TYPE", "hash").Val())
+
+	fields, cursor, err := client.HScan(ctx, "user", 0, "r*", 0).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), cursor)
+	assert.Equal(t, []string{"role", "admin"}, fields)
+
+	members, _, err := client.SScan(ctx, "roles", 0, "a*", 10).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, members)
+
+	scores, _, err := client.ZScan(ctx, "rating", 0, "", 10).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ivan", "1.5"}, scores)
+
+	err = client.Do(ctx, "SCAN", "first").Err()
+	assert.EqualError(t, err, "ERR invalid cursor")
+
+	err = client.Do(ctx, "SCAN", "0", "COUNT", "0").Err()
+	assert.EqualError(t, err, "ERR syntax error")
+
+	err = client.Do(ctx, "HSCAN", "user", "0", "TYPE", "hash").Err()
+	assert.EqualError(t, err, "ERR syntax error")
+
+	err = client.Do(ctx, "SSCAN", "user", "0").Err()
+	assert.True(t, store.IsWrongType(err))
+}
+
+func TestRespKeys(t *testing.T) {
+	client, closeFn := newTestRespClient(t)
+	defer closeFn()
+
+	ctx := context.Background()
+
+	assert.NoError(t, client.Set(ctx, "name", "ivan", 0).Err())
+	assert.NoError(t, client.RPush(ctx, "list", "a").Err())
+
+	assert.Equal(t, "string", client.Type(ctx, "name").Val())
+	assert.Equal(t, "list", client.Type(ctx, "list").Val())
+	assert.Equal(t, "none", client.Type(ctx, "missing").Val())
+	assert.Equal(t, int64(2), client.Exists(ctx, "name", "list", "missing").Val())
+
+	assert.Equal(t, time.Duration(-1), client.TTL(ctx, "name").Val())
+	assert.Equal(t, time.Duration(-2), client.PTTL(ctx, "missing").Val())
+
+	assert.True(t, client.Expire(ctx, "name", 100*time.Second).Val())
+	assert.Equal(t, 100*time.Second, client.TTL(ctx, "name").Val())
+	assert.True(t, client.PExpire(ctx, "name", 5*time.Second).Val())
+	assert.InDelta(t, 5*time.Second, client.PTTL(ctx, "name").Val(), float64(time.Second))
+	assert.True(t, client.ExpireAt(ctx, "name", time.Now().Add(time.Hour)).Val())
+	assert.InDelta(t, time.Hour, client.TTL(ctx, "name").Val(), float64(time.Second))
+	assert.False(t, client.Expire(ctx, "missing", time.Second).Val())
+
+	assert.True(t, client.Persist(ctx, "name").Val())
+	assert.False(t, client.Persist(ctx, "name").Val())
+	assert.Equal(t, time.Duration(-1), client.TTL(ctx, "name").Val())
+
+	// non positive timeout removes the key
+	assert.Equal(t, int64(1), client.Do(ctx, "EXPIRE", "list", "0").Val())
+	assert.Equal(t, int64(0), client.Exists(ctx, "list").Val())
+	assert.True(t, client.PExpireAt(ctx, "name", time.Unix(1, 0)).Val())
+	assert.Equal(t, int64(0), client.Exists(ctx, "name").Val())
+
+	err := client.Do(ctx, "EXPIRE", "name", "soon").Err()
+	assert.EqualError(t, err, "ERR value is not an integer or out of range")
+}
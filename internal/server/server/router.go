@@ -13,6 +13,8 @@ type router struct {
 	redis        store.RedisImpl
 	sessionName  string
 	sessionStore sessions.Store
+	// maximum number of keys returned by /keys, 0 - no limit
+	keysLimit int
 }
 
 // newRouter - helper for initialization http
@@ -23,6 +25,7 @@ func newRouter(serverPort, sessionName string, redis store.RedisImpl, sessionSto
 		redis:        redis,
 		sessionName:  sessionName,
 		sessionStore: sessionStore,
+		keysLimit:    defaultKeysLimit,
 	}
 }
 
@@ -34,12 +37,38 @@ func (r *router) setup() *gin.Engine {
 		list.GET("/get", r.getListHandler)
 		list.GET("/lrange", r.lRangeHandler)
 		list.POST("/lset", r.keyToStringMiddleware(), r.lSetHandler)
+		list.POST("/lpush", r.keyToStringMiddleware(), r.pushHandler(true))
+		list.POST("/rpush", r.keyToStringMiddleware(), r.pushHandler(false))
+		list.POST("/lpop", r.keyToStringMiddleware(), r.popHandler(true))
+		list.POST("/rpop", r.keyToStringMiddleware(), r.popHandler(false))
+		list.GET("/llen", r.lLenHandler)
+		list.GET("/lindex", r.lIndexHandler)
+		list.POST("/linsert", r.keyToStringMiddleware(), r.lInsertHandler)
+		list.POST("/lrem", r.keyToStringMiddleware(), r.lRemHandler)
+		list.POST("/ltrim", r.keyToStringMiddleware(), r.lTrimHandler)
+		list.POST("/lpos", r.keyToStringMiddleware(), r.lPosHandler)
+		list.POST("/lmove", r.lMoveHandler)
+		list.POST("/blpop", r.bPopHandler(true))
+		list.POST("/brpop", r.bPopHandler(false))
+		list.POST("/blmove", r.bLMoveHandler)
 	}
 
 	str := r.router.Group("/string")
 	{
 		str.POST("/set", r.keyToStringMiddleware(), r.setStringHandler)
 		str.GET("/get", r.getStringHandler)
+		str.POST("/incr", r.keyToStringMiddleware(), r.incrHandler(false, 1))
+		str.POST("/decr", r.keyToStringMiddleware(), r.incrHandler(false, -1))
+		str.POST("/incrby", r.keyToStringMiddleware(), r.incrHandler(true, 1))
+		str.POST("/decrby", r.keyToStringMiddleware(), r.incrHandler(true, -1))
+		str.POST("/incrbyfloat", r.keyToStringMiddleware(), r.incrByFloatHandler)
+		str.POST("/append", r.keyToStringMiddleware(), r.appendHandler)
+		str.GET("/strlen", r.strLenHandler)
+		str.GET("/getrange", r.getRangeHandler)
+		str.POST("/setrange", r.keyToStringMiddleware(), r.setRangeHandler)
+		str.POST("/getset", r.keyToStringMiddleware(), r.getSetHandler)
+		str.POST("/getdel", r.keyToStringMiddleware(), r.getDelHandler)
+		str.POST("/getex", r.keyToStringMiddleware(), r.getExHandler)
 	}
 
 	hash := r.router.Group("/hash")
@@ -48,15 +77,99 @@ func (r *router) setup() *gin.Engine {
 		hash.GET("/get", r.getHashHandler)
 		hash.POST("/hset", r.keyToStringMiddleware(), r.hSetHandler)
 		hash.GET("/hget", r.hGetHandler)
+		hash.POST("/hdel", r.keyToStringMiddleware(), r.hDelHandler)
+		hash.GET("/hexists", r.hExistsHandler)
+		hash.GET("/hlen", r.hLenHandler)
+		hash.GET("/hkeys", r.hKeysHandler(false))
+		hash.GET("/hvals", r.hKeysHandler(true))
+		hash.GET("/hmget", r.hMGetHandler)
+		hash.POST("/hsetnx", r.keyToStringMiddleware(), r.hSetNXHandler)
+		hash.POST("/hincrby", r.keyToStringMiddleware(), r.hIncrByHandler)
+		hash.POST("/hincrbyfloat", r.keyToStringMiddleware(), r.hIncrByFloatHandler)
+		hash.GET("/scan", r.hScanHandler)
+	}
+
+	set := r.router.Group("/set")
+	{
+		set.POST("/add", r.keyToStringMiddleware(), r.sAddHandler)
+		set.POST("/rem", r.keyToStringMiddleware(), r.sRemHandler)
+		set.GET("/members", r.sMembersHandler)
+		set.GET("/ismember", r.sIsMemberHandler)
+		set.GET("/card", r.sCardHandler)
+		set.GET("/inter", r.setOperationHandler(r.redis.SInter))
+		set.GET("/union", r.setOperationHandler(r.redis.SUnion))
+		set.GET("/diff", r.setOperationHandler(r.redis.SDiff))
+		set.POST("/interstore", r.setStoreHandler(r.redis.SInterStore))
+		set.POST("/unionstore", r.setStoreHandler(r.redis.SUnionStore))
+		set.POST("/diffstore", r.setStoreHandler(r.redis.SDiffStore))
+		set.GET("/scan", r.sScanHandler)
+	}
+
+	zset := r.router.Group("/zset")
+	{
+		zset.POST("/add", r.keyToStringMiddleware(), r.zAddHandler)
+		zset.POST("/rem", r.keyToStringMiddleware(), r.zRemHandler)
+		zset.GET("/score", r.zScoreHandler)
+		zset.GET("/rank", r.zRankHandler)
+		zset.GET("/range", r.zRangeHandler)
+		zset.POST("/popmin", r.keyToStringMiddleware(), r.zPopHandler(r.redis.ZPopMin))
+		zset.POST("/popmax", r.keyToStringMiddleware(), r.zPopHandler(r.redis.ZPopMax))
+		zset.GET("/union", r.zCombineHandler(r.redis.ZUnion))
+		zset.GET("/inter", r.zCombineHandler(r.redis.ZInter))
+		zset.GET("/scan", r.zScanHandler)
+	}
+
+	stream := r.router.Group("/stream")
+	{
+		stream.POST("/add", r.keyToStringMiddleware(), r.xAddHandler)
+		stream.GET("/range", r.xRangeHandler(r.redis.XRange))
+		stream.GET("/revrange", r.xRangeHandler(r.redis.XRevRange))
+		stream.GET("/len", r.xLenHandler)
+		stream.POST("/trim", r.keyToStringMiddleware(), r.xTrimHandler)
+		stream.GET("/read", r.xReadHandler)
+		stream.POST("/group/create", r.keyToStringMiddleware(), r.xGroupCreateHandler)
+		stream.POST("/readgroup", r.xReadGroupHandler)
+		stream.POST("/ack", r.keyToStringMiddleware(), r.xAckHandler)
+		stream.GET("/pending", r.xPendingHandler)
+		stream.POST("/claim", r.keyToStringMiddleware(), r.xClaimHandler)
+		stream.POST("/autoclaim", r.keyToStringMiddleware(), r.xAutoClaimHandler)
+	}
+
+	doc := r.router.Group("/json")
+	{
+		doc.POST("/set", r.keyToStringMiddleware(), r.jsonSetHandler)
+		doc.GET("/get", r.jsonGetHandler)
+		doc.POST("/del", r.keyToStringMiddleware(), r.jsonDelHandler)
+		doc.POST("/arrappend", r.keyToStringMiddleware(), r.jsonArrAppendHandler)
+		doc.POST("/numincrby", r.keyToStringMiddleware(), r.jsonNumIncrByHandler)
+		doc.GET("/objkeys", r.jsonObjKeysHandler)
+	}
+
+	pubsub := r.router.Group("/pubsub")
+	{
+		pubsub.POST("/publish", r.publishHandler)
+		pubsub.GET("/subscribe", r.subscribeHandler(false))
+		pubsub.GET("/psubscribe", r.subscribeHandler(true))
 	}
 
 	r.router.GET("/keys", r.keysHandler)
+	r.router.GET("/scan", r.scanHandler)
 	r.router.POST("/del", r.keyToStringMiddleware(), r.deleteHandler)
+	r.router.GET("/type", r.typeHandler)
+	r.router.GET("/ttl", r.ttlHandler(false))
+	r.router.GET("/pttl", r.ttlHandler(true))
+	r.router.POST("/expire", r.keyToStringMiddleware(), r.expireHandler)
+	r.router.POST("/persist", r.keyToStringMiddleware(), r.persistHandler)
+	r.router.GET("/exists", r.existsHandler)
+	r.router.POST("/tx", r.txHandler)
 
 	r.router.POST("/login", r.loginHadler)
 	r.router.POST("/signup", r.signupHandler)
 	r.router.POST("/logout", r.authUserMiddleware(), r.logoutHandler)
 	r.router.POST("/save", r.saveHandler)
+	r.router.POST("/bgsave", r.bgSaveHandler)
+	r.router.GET("/save/status", r.saveStatusHandler)
+	r.router.GET("/info", r.infoHandler)
 
 	return r.router
 }
@@ -1,15 +1,19 @@
 package server
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Vysogota99/redis-implementation/internal/server/models"
 	"github.com/Vysogota99/redis-implementation/internal/server/store"
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -66,9 +70,10 @@ func TestSetStringHandler(t *testing.T) {
 	ts := httptest.NewServer(router.setup())
 	defer ts.Close()
 
+	value := "lapshin"
 	reqBody := models.SetStringRequest{
 		Key:   "user:1",
-		Value: "lapshin",
+		Value: &value,
 	}
 
 	data, err := json.Marshal(reqBody)
@@ -274,3 +279,1328 @@ func TestDeleteHandler(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 	resp.Body.Close()
 }
+
+func TestSaveHandler(t *testing.T) {
+	redis := store.NewMock()
+
+	router := newRouter(":3000", "auth", redis, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	resp, err := http.Post(fmt.Sprintf("%s/save", ts.URL), "application/json", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := struct {
+		Result models.SaveStatus `json:"result"`
+	}{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, models.SaveStatus{LastSave: 1609019059, Duration: 2000, Success: true}, body.Result)
+	resp.Body.Close()
+}
+
+func TestBGSaveHandler(t *testing.T) {
+	redis := store.NewMock()
+
+	router := newRouter(":3000", "auth", redis, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	resp, err := http.Post(fmt.Sprintf("%s/bgsave", ts.URL), "application/json", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/save/status", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestBGSaveInProgress(t *testing.T) {
+	native := store.NewNative()
+	native.SetDBFilename(fmt.Sprintf("%s/dump.rdb", t.TempDir()))
+
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	codes := []int{}
+	for i := 0; i < 2; i++ {
+		resp, err := http.Post(fmt.Sprintf("%s/bgsave", ts.URL), "application/json", nil)
+		assert.NoError(t, err)
+		codes = append(codes, resp.StatusCode)
+		resp.Body.Close()
+	}
+	assert.NoError(t, native.Close())
+
+	assert.Equal(t, http.StatusAccepted, codes[0])
+	assert.Contains(t, []int{http.StatusAccepted, http.StatusConflict}, codes[1])
+}
+
+func TestInfoHandler(t *testing.T) {
+	redis := store.NewMock()
+
+	router := newRouter(":3000", "auth", redis, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	resp, err := http.Get(fmt.Sprintf("%s/info?section=persistence", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := struct {
+		Result map[string]string `json:"result"`
+	}{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "ok", body.Result["rdb_last_bgsave_status"])
+	resp.Body.Close()
+}
+
+func TestOOMStatus(t *testing.T) {
+	native := store.NewNative()
+	assert.NoError(t, native.SetMaxMemory(1, store.PolicyNoEviction, 5))
+
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	value := "lapshin"
+	reqBody := models.SetStringRequest{
+		Key:   "user:1",
+		Value: &value,
+	}
+
+	data, err := json.Marshal(reqBody)
+	assert.NoError(t, err)
+
+	// the limit is checked before the write, so the first key is stored
+	for _, code := range []int{http.StatusOK, http.StatusInsufficientStorage} {
+		resp, err := http.Post(fmt.Sprintf("%s/string/set", ts.URL), "application/json", bytes.NewBuffer(data))
+		assert.NoError(t, err)
+		assert.Equal(t, code, resp.StatusCode)
+		resp.Body.Close()
+	}
+}
+
+func TestSetHandlers(t *testing.T) {
+	redis := store.NewMock()
+	router := newRouter(":3000", "auth", redis, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	reqBody := models.SetMembersRequest{
+		Key:     "roles",
+		Members: []string{"admin", "user"},
+	}
+
+	data, err := json.Marshal(reqBody)
+	assert.NoError(t, err)
+
+	resp, err := http.Post(fmt.Sprintf("%s/set/add", ts.URL), "application/json", bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/set/members?key=roles", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := struct {
+		Result []string `json:"result"`
+	}{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, []string{"admin", "user"}, body.Result)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/set/inter?keys=roles:1&keys=roles:2", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/set/inter", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	storeBody := models.SetStoreRequest{
+		Destination: "common",
+		Keys:        []string{"roles:1", "roles:2"},
+	}
+
+	data, err = json.Marshal(storeBody)
+	assert.NoError(t, err)
+
+	resp, err = http.Post(fmt.Sprintf("%s/set/interstore", ts.URL), "application/json", bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestWrongTypeStatus(t *testing.T) {
+	native := store.NewNative()
+	_, err := native.SetString(context.Background(), "roles", "admin", models.Expiration{})
+	assert.NoError(t, err)
+
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	resp, err := http.Get(fmt.Sprintf("%s/set/members?key=roles", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestZSetHandlers(t *testing.T) {
+	native := store.NewNative()
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	reqBody := models.ZAddRequest{
+		Key:     "scores",
+		Members: []models.ZMember{{Member: "ivan", Score: 1.5}, {Member: "petr", Score: 2}, {Member: "oleg", Score: 3}},
+	}
+
+	data, err := json.Marshal(reqBody)
+	assert.NoError(t, err)
+
+	resp, err := http.Post(fmt.Sprintf("%s/zset/add", ts.URL), "application/json", bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/zset/range?key=scores&start=(1.5&stop=%%2Binf&by=score", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := struct {
+		Result []models.ZMember `json:"result"`
+	}{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, []models.ZMember{{Member: "petr", Score: 2}, {Member: "oleg", Score: 3}}, body.Result)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/zset/rank?key=scores&member=oleg", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/zset/score?key=scores&member=anna", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/zset/range?key=scores&start=a&stop=1&by=score", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Post(fmt.Sprintf("%s/zset/popmax", ts.URL), "application/json", bytes.NewBufferString(`{"key": "scores"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, []models.ZMember{{Member: "oleg", Score: 3}}, body.Result)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/zset/union?keys=scores&keys=missing&weights=2&weights=1", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, []models.ZMember{{Member: "ivan", Score: 3}, {Member: "petr", Score: 4}}, body.Result)
+	resp.Body.Close()
+}
+
+func TestZSetHandlersMock(t *testing.T) {
+	redis := store.NewMock()
+	router := newRouter(":3000", "auth", redis, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	resp, err := http.Post(fmt.Sprintf("%s/zset/add", ts.URL), "application/json",
+		bytes.NewBufferString(`{"key": "scores", "members": [{"member": "ivan", "score": 1}], "incr": true, "nx": true}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Post(fmt.Sprintf("%s/zset/add", ts.URL), "application/json",
+		bytes.NewBufferString(`{"key": "scores", "members": [{"member": "ivan", "score": 1}], "nx": true, "xx": true}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/zset/inter?keys=a&keys=b&aggregate=max", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/zset/range?key=scores&start=0&stop=-1&rev=true", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestStreamHandlers(t *testing.T) {
+	native := store.NewNative()
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	for _, id := range []string{"1-0", "2-0"} {
+		resp, err := http.Post(fmt.Sprintf("%s/stream/add", ts.URL), "application/json",
+			bytes.NewBufferString(fmt.Sprintf(`{"key": "events", "id": "%s", "fields": {"name": "ivan", "age": 25}}`, id)))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	resp, err := http.Post(fmt.Sprintf("%s/stream/add", ts.URL), "application/json",
+		bytes.NewBufferString(`{"key": "events", "id": "1-0", "fields": {"name": "petr"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/stream/range?key=events&start=(1-0", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := struct {
+		Result []models.StreamEntry `json:"result"`
+	}{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, []models.StreamEntry{{ID: "2-0", Fields: map[string]interface{}{"name": "ivan", "age": float64(25)}}}, body.Result)
+	resp.Body.Close()
+
+	resp, err = http.Post(fmt.Sprintf("%s/stream/group/create", ts.URL), "application/json",
+		bytes.NewBufferString(`{"key": "events", "group": "workers", "id": "0"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Post(fmt.Sprintf("%s/stream/group/create", ts.URL), "application/json",
+		bytes.NewBufferString(`{"key": "events", "group": "workers"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Post(fmt.Sprintf("%s/stream/readgroup", ts.URL), "application/json",
+		bytes.NewBufferString(`{"group": "workers", "consumer": "ivan", "keys": ["events"], "count": 1}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	streams := struct {
+		Result []models.XStream `json:"result"`
+	}{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&streams))
+	assert.Len(t, streams.Result, 1)
+	assert.Equal(t, "1-0", streams.Result[0].Entries[0].ID)
+	resp.Body.Close()
+
+	resp, err = http.Post(fmt.Sprintf("%s/stream/readgroup", ts.URL), "application/json",
+		bytes.NewBufferString(`{"group": "missing", "consumer": "ivan", "keys": ["events"]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/stream/pending?key=events&group=workers", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	pending := struct {
+		Result models.XPending `json:"result"`
+	}{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&pending))
+	assert.Equal(t, models.XPending{Count: 1, Lower: "1-0", Higher: "1-0", Consumers: map[string]int64{"ivan": 1}}, pending.Result)
+	resp.Body.Close()
+
+	resp, err = http.Post(fmt.Sprintf("%s/stream/claim", ts.URL), "application/json",
+		bytes.NewBufferString(`{"key": "events", "group": "workers", "consumer": "petr", "ids": ["1-0"]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/stream/pending?key=events&group=workers&consumer=petr", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	entries := struct {
+		Result []models.XPendingEntry `json:"result"`
+	}{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&entries))
+	assert.Len(t, entries.Result, 1)
+	assert.Equal(t, int64(2), entries.Result[0].RetryCount)
+	resp.Body.Close()
+
+	resp, err = http.Post(fmt.Sprintf("%s/stream/ack", ts.URL), "application/json",
+		bytes.NewBufferString(`{"key": "events", "group": "workers", "ids": ["1-0"]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Post(fmt.Sprintf("%s/stream/trim", ts.URL), "application/json",
+		bytes.NewBufferString(`{"key": "events", "maxlen": 0}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/stream/len?key=events", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	length := struct {
+		Result int64 `json:"result"`
+	}{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&length))
+	assert.Zero(t, length.Result)
+	resp.Body.Close()
+}
+
+func TestStreamHandlersMock(t *testing.T) {
+	redis := store.NewMock()
+	router := newRouter(":3000", "auth", redis, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	resp, err := http.Post(fmt.Sprintf("%s/stream/add", ts.URL), "application/json",
+		bytes.NewBufferString(`{"key": "events", "fields": {"name": "ivan"}, "minid": "1609019628218"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/stream/revrange?key=events&count=1", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := struct {
+		Result []models.StreamEntry `json:"result"`
+	}{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, []models.StreamEntry{{ID: "1609019628218-0", Fields: map[string]interface{}{"name": "ivan", "age": float64(25)}}}, body.Result)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/stream/read?keys=events&ids=0", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/stream/read?keys=events&keys=other&ids=0", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Post(fmt.Sprintf("%s/stream/autoclaim", ts.URL), "application/json",
+		bytes.NewBufferString(`{"key": "events", "group": "workers", "consumer": "ivan", "start": "a"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestPubSubHandlers(t *testing.T) {
+	native := store.NewNative()
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/pubsub/psubscribe?channels=news.*", ts.URL), nil)
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	assert.Equal(t, "event:psubscribe\ndata:{\"channel\":\"news.*\",\"count\":1}\n\n", readEvent(t, reader))
+
+	publish, err := http.Post(fmt.Sprintf("%s/pubsub/publish", ts.URL), "application/json",
+		bytes.NewBufferString(`{"channel": "news.sport", "message": "hello"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, publish.StatusCode)
+
+	body := struct {
+		Result int64 `json:"result"`
+	}{}
+	assert.NoError(t, json.NewDecoder(publish.Body).Decode(&body))
+	assert.Equal(t, int64(1), body.Result)
+	publish.Body.Close()
+
+	assert.Equal(t, "event:pmessage\ndata:{\"channel\":\"news.sport\",\"pattern\":\"news.*\",\"message\":\"hello\"}\n\n", readEvent(t, reader))
+
+	// subscription is closed when the client disconnects
+	cancel()
+	resp.Body.Close()
+	assert.Eventually(t, func() bool {
+		receivers, err := native.Publish(context.Background(), "news.sport", "hello")
+		return err == nil && receivers == 0
+	}, time.Second, 10*time.Millisecond)
+
+	resp, err = http.Get(fmt.Sprintf("%s/pubsub/subscribe", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Post(fmt.Sprintf("%s/pubsub/publish", ts.URL), "application/json", bytes.NewBufferString(`{"message": "hello"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestPubSubHandlersMock(t *testing.T) {
+	redis := store.NewMock()
+	router := newRouter(":3000", "auth", redis, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	resp, err := http.Get(fmt.Sprintf("%s/pubsub/subscribe?channels=news&channels=sport", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent(t, reader)
+	assert.Equal(t, "event:subscribe\ndata:{\"channel\":\"sport\",\"count\":2}\n\n", readEvent(t, reader))
+
+	publish, err := http.Post(fmt.Sprintf("%s/pubsub/publish", ts.URL), "application/json",
+		bytes.NewBufferString(`{"channel": "sport", "message": "goal"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, publish.StatusCode)
+	publish.Body.Close()
+
+	assert.Equal(t, "event:message\ndata:{\"channel\":\"sport\",\"message\":\"goal\"}\n\n", readEvent(t, reader))
+}
+
+// readEvent - reads lines of server-sent event until the empty one
+func readEvent(t *testing.T, reader *bufio.Reader) string {
+	event := ""
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		event += line
+		if line == "\n" {
+			return event
+		}
+	}
+}
+
+func TestTxHandler(t *testing.T) {
+	native := store.NewNative()
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	type testCase struct {
+		name string
+		body string
+		code int
+	}
+
+	tCases := []testCase{
+		{
+			name: "Transaction",
+			body: `{"watch": ["user"], "commands": [{"command": "set", "args": ["user", "ivan"]}, {"command": "get", "args": ["user"]}]}`,
+			code: http.StatusOK,
+		},
+		{name: "Without commands", body: `{"commands": []}`, code: http.StatusBadRequest},
+		{name: "Empty watched key", body: `{"watch": [""], "commands": [{"command": "get", "args": ["user"]}]}`, code: http.StatusBadRequest},
+		{name: "Unknown command", body: `{"commands": [{"command": "flushall"}]}`, code: http.StatusBadRequest},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := http.Post(fmt.Sprintf("%s/tx", ts.URL), "application/json", bytes.NewBufferString(tc.body))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.code, resp.StatusCode)
+			resp.Body.Close()
+		})
+	}
+
+	value, err := native.GetString(context.Background(), "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "ivan", value)
+}
+
+func TestTxHandlerMock(t *testing.T) {
+	redis := store.NewMock()
+	router := newRouter(":3000", "auth", redis, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	resp, err := http.Post(fmt.Sprintf("%s/tx", ts.URL), "application/json",
+		bytes.NewBufferString(`{"commands": [{"command": "sadd", "args": ["roles", "admin"]}, {"command": "zadd", "args": ["scores", "1", "ivan"]}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := struct {
+		Result []models.TxResult `json:"result"`
+	}{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Len(t, body.Result, 2)
+	resp.Body.Close()
+}
+
+func TestIfMatch(t *testing.T) {
+	native := store.NewNative()
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	resp, err := http.Post(fmt.Sprintf("%s/hash/set", ts.URL), "application/json",
+		bytes.NewBufferString(`{"key": "user:1", "value": {"name": "Ivan"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/hash/get?key=user:1", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	etag := resp.Header.Get("ETag")
+	assert.NotEmpty(t, etag)
+	resp.Body.Close()
+
+	type testCase struct {
+		name    string
+		ifMatch string
+		code    int
+	}
+
+	tCases := []testCase{
+		{name: "Current version", ifMatch: etag, code: http.StatusOK},
+		// the version is changed by the previous write
+		{name: "Stale version", ifMatch: etag, code: http.StatusPreconditionFailed},
+		{name: "Unknown ETag", ifMatch: `W/"1"`, code: http.StatusPreconditionFailed},
+		{name: "Any version", ifMatch: "*", code: http.StatusOK},
+		{name: "Without If-Match", code: http.StatusOK},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/hash/hset", ts.URL),
+				bytes.NewBufferString(`{"key": "user:1", "value": {"name": "Petr"}}`))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.code, resp.StatusCode)
+			if tc.code == http.StatusOK && tc.ifMatch != "" {
+				assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+			}
+			resp.Body.Close()
+		})
+	}
+
+	// missing key never matches
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/string/set", ts.URL),
+		bytes.NewBufferString(`{"key": "name", "value": "Ivan"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", "*")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestIfMatchMock(t *testing.T) {
+	redis := store.NewMock()
+	router := newRouter(":3000", "auth", redis, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	resp, err := http.Get(fmt.Sprintf("%s/string/get?key=name", ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+	resp.Body.Close()
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/string/set", ts.URL),
+		bytes.NewBufferString(`{"key": "name", "value": "Ivan"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+	resp.Body.Close()
+}
+
+func TestCounterHandlers(t *testing.T) {
+	native := store.NewNative()
+	_, err := native.SetString(context.Background(), "name", "Ivan", models.Expiration{})
+	assert.NoError(t, err)
+	_, err = native.SetString(context.Background(), "max", "9223372036854775807", models.Expiration{})
+	assert.NoError(t, err)
+
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	type testCase struct {
+		name   string
+		path   string
+		body   string
+		code   int
+		result interface{}
+	}
+
+	tCases := []testCase{
+		{name: "Incr missing key", path: "/string/incr", body: `{"key": "counter"}`, code: http.StatusOK, result: float64(1)},
+		{name: "Incrby", path: "/string/incrby", body: `{"key": "counter", "increment": 10}`, code: http.StatusOK, result: float64(11)},
+		{name: "Decrby", path: "/string/decrby", body: `{"key": "counter", "increment": 20}`, code: http.StatusOK, result: float64(-9)},
+		{name: "Decr", path: "/string/decr", body: `{"key": "counter"}`, code: http.StatusOK, result: float64(-10)},
+		{name: "Incrbyfloat", path: "/string/incrbyfloat", body: `{"key": "counter", "increment": 0.25}`, code: http.StatusOK, result: -9.75},
+		{name: "Incrby without increment", path: "/string/incrby", body: `{"key": "counter"}`, code: http.StatusUnprocessableEntity},
+		{name: "Not an integer", path: "/string/incr", body: `{"key": "name"}`, code: http.StatusConflict},
+		{name: "Not a float", path: "/string/incrbyfloat", body: `{"key": "name", "increment": 1}`, code: http.StatusConflict},
+		{name: "Overflow", path: "/string/incr", body: `{"key": "max"}`, code: http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := http.Post(ts.URL+tc.path, "application/json", bytes.NewBufferString(tc.body))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.code, resp.StatusCode)
+
+			if tc.result != nil {
+				body := struct {
+					Result interface{} `json:"result"`
+				}{}
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.Equal(t, tc.result, body.Result)
+			}
+		})
+	}
+}
+
+func TestStringHandlers(t *testing.T) {
+	native := store.NewNative()
+	_, err := native.SAdd(context.Background(), "roles", []string{"admin"})
+	assert.NoError(t, err)
+
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	type testCase struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+		result interface{}
+	}
+
+	tCases := []testCase{
+		{name: "Set empty value", method: http.MethodPost, path: "/string/set", body: `{"key": "flag", "value": ""}`, code: http.StatusOK, result: "OK"},
+		{name: "Set NX existing key", method: http.MethodPost, path: "/string/set", body: `{"key": "flag", "value": "1", "nx": true}`, code: http.StatusNoContent},
+		{name: "Set XX with GET", method: http.MethodPost, path: "/string/set", body: `{"key": "flag", "value": "1", "xx": true, "get": true}`, code: http.StatusOK, result: ""},
+		{name: "Set NX and XX", method: http.MethodPost, path: "/string/set", body: `{"key": "flag", "value": "1", "nx": true, "xx": true}`, code: http.StatusBadRequest},
+		{name: "Set GET wrong type", method: http.MethodPost, path: "/string/set", body: `{"key": "roles", "value": "1", "get": true}`, code: http.StatusConflict},
+		{name: "Set without value", method: http.MethodPost, path: "/string/set", body: `{"key": "flag"}`, code: http.StatusUnprocessableEntity},
+		{name: "Append", method: http.MethodPost, path: "/string/append", body: `{"key": "name", "value": "Hello World"}`, code: http.StatusOK, result: float64(11)},
+		{name: "Strlen", method: http.MethodGet, path: "/string/strlen?key=name", code: http.StatusOK, result: float64(11)},
+		{name: "Getrange", method: http.MethodGet, path: "/string/getrange?key=name&start=-5&end=-1", code: http.StatusOK, result: "World"},
+		{name: "Getrange without end", method: http.MethodGet, path: "/string/getrange?key=name&start=0", code: http.StatusBadRequest},
+		{name: "Setrange", method: http.MethodPost, path: "/string/setrange", body: `{"key": "name", "offset": 6, "value": "Redis"}`, code: http.StatusOK, result: float64(11)},
+		{name: "Setrange negative offset", method: http.MethodPost, path: "/string/setrange", body: `{"key": "name", "offset": -1, "value": "a"}`, code: http.StatusBadRequest},
+		{name: "Getset", method: http.MethodPost, path: "/string/getset", body: `{"key": "name", "value": "Ivan"}`, code: http.StatusOK, result: "Hello Redis"},
+		{name: "Getex", method: http.MethodPost, path: "/string/getex", body: `{"key": "name", "ttl": 10}`, code: http.StatusOK, result: "Ivan"},
+		{name: "Getex two expirations", method: http.MethodPost, path: "/string/getex", body: `{"key": "name", "ttl": 10, "persist": true}`, code: http.StatusBadRequest},
+		{name: "Getdel", method: http.MethodPost, path: "/string/getdel", body: `{"key": "name"}`, code: http.StatusOK, result: "Ivan"},
+		{name: "Getdel missing key", method: http.MethodPost, path: "/string/getdel", body: `{"key": "name"}`, code: http.StatusNoContent},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, ts.URL+tc.path, bytes.NewBufferString(tc.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.code, resp.StatusCode)
+
+			if tc.result != nil {
+				body := struct {
+					Result interface{} `json:"result"`
+				}{}
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.Equal(t, tc.result, body.Result)
+			}
+		})
+	}
+}
+
+func TestListHandlers(t *testing.T) {
+	native := store.NewNative()
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	type testCase struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+		result interface{}
+	}
+
+	tCases := []testCase{
+		{name: "Rpush", method: http.MethodPost, path: "/list/rpush", body: `{"key": "list", "value": [1, 2.5, "ivan", {"age": 21}]}`, code: http.StatusOK, result: float64(4)},
+		{name: "Lpush", method: http.MethodPost, path: "/list/lpush", body: `{"key": "list", "value": ["b", "a"]}`, code: http.StatusOK, result: float64(6)},
+		{name: "Lpush without values", method: http.MethodPost, path: "/list/lpush", body: `{"key": "list", "value": []}`, code: http.StatusUnprocessableEntity},
+		{name: "Llen", method: http.MethodGet, path: "/list/llen?key=list", code: http.StatusOK, result: float64(6)},
+		{name: "Lindex", method: http.MethodGet, path: "/list/lindex?key=list&index=-1", code: http.StatusOK, result: map[string]interface{}{"age": float64(21)}},
+		{name: "Lindex out of range", method: http.MethodGet, path: "/list/lindex?key=list&index=10", code: http.StatusNoContent},
+		{name: "Lpos integer", method: http.MethodPost, path: "/list/lpos", body: `{"key": "list", "value": 1}`, code: http.StatusOK, result: float64(2)},
+		{name: "Lpos float", method: http.MethodPost, path: "/list/lpos", body: `{"key": "list", "value": 2.5, "count": 0}`, code: http.StatusOK, result: []interface{}{float64(3)}},
+		{name: "Lpos no match", method: http.MethodPost, path: "/list/lpos", body: `{"key": "list", "value": 2}`, code: http.StatusNoContent},
+		{name: "Lpos zero rank", method: http.MethodPost, path: "/list/lpos", body: `{"key": "list", "value": 1, "rank": 0}`, code: http.StatusBadRequest},
+		{name: "Lpos all matches", method: http.MethodPost, path: "/list/lpos", body: `{"key": "list", "value": 1, "rank": 1, "count": 0}`, code: http.StatusOK, result: []interface{}{float64(2)}},
+		{name: "Linsert", method: http.MethodPost, path: "/list/linsert", body: `{"key": "list", "pivot": 1, "value": 0, "after": true}`, code: http.StatusOK, result: float64(7)},
+		{name: "Lrem", method: http.MethodPost, path: "/list/lrem", body: `{"key": "list", "count": 0, "value": "ivan"}`, code: http.StatusOK, result: float64(1)},
+		{name: "Lpop", method: http.MethodPost, path: "/list/lpop", body: `{"key": "list"}`, code: http.StatusOK, result: "a"},
+		{name: "Rpop with count", method: http.MethodPost, path: "/list/rpop", body: `{"key": "list", "count": 2}`, code: http.StatusOK, result: []interface{}{map[string]interface{}{"age": float64(21)}, 2.5}},
+		{name: "Ltrim", method: http.MethodPost, path: "/list/ltrim", body: `{"key": "list", "start": 1, "stop": -1}`, code: http.StatusOK, result: "OK"},
+		{name: "Lmove", method: http.MethodPost, path: "/list/lmove", body: `{"source": "list", "destination": "other", "from": "right", "to": "left"}`, code: http.StatusOK, result: float64(0)},
+		{name: "Lmove bad direction", method: http.MethodPost, path: "/list/lmove", body: `{"source": "list", "destination": "other", "from": "up", "to": "left"}`, code: http.StatusUnprocessableEntity},
+		{name: "Get", method: http.MethodGet, path: "/list/get?key=list", code: http.StatusOK, result: []interface{}{float64(1)}},
+		{name: "Lpop missing key", method: http.MethodPost, path: "/list/lpop", body: `{"key": "missing"}`, code: http.StatusNoContent},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, ts.URL+tc.path, bytes.NewBufferString(tc.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.code, resp.StatusCode)
+
+			if tc.result != nil {
+				body := struct {
+					Result interface{} `json:"result"`
+				}{}
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.Equal(t, tc.result, body.Result)
+			}
+		})
+	}
+
+	// integers keep their type
+	list, err := native.GetList(context.Background(), "other")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(0)}, list)
+}
+
+func TestBlockingListHandlers(t *testing.T) {
+	native := store.NewNative()
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	ctx := context.Background()
+	_, err := native.RPush(ctx, "list", []interface{}{"a", int64(2)})
+	assert.NoError(t, err)
+
+	type testCase struct {
+		name   string
+		path   string
+		body   string
+		code   int
+		result interface{}
+	}
+
+	tCases := []testCase{
+		{name: "Blpop", path: "/list/blpop", body: `{"keys": ["missing", "list"], "timeout": 1}`, code: http.StatusOK, result: map[string]interface{}{"key": "list", "value": "a"}},
+		{name: "Blmove", path: "/list/blmove", body: `{"source": "list", "destination": "other", "from": "left", "to": "right", "timeout": 1}`, code: http.StatusOK, result: float64(2)},
+		{name: "Brpop timeout", path: "/list/brpop", body: `{"keys": ["list"], "timeout": 0.01}`, code: http.StatusNoContent},
+		{name: "Brpop negative timeout", path: "/list/brpop", body: `{"keys": ["list"], "timeout": -1}`, code: http.StatusUnprocessableEntity},
+		{name: "Blpop without keys", path: "/list/blpop", body: `{"keys": []}`, code: http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := http.Post(ts.URL+tc.path, "application/json", bytes.NewBufferString(tc.body))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.code, resp.StatusCode)
+
+			if tc.result != nil {
+				body := struct {
+					Result interface{} `json:"result"`
+				}{}
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.Equal(t, tc.result, body.Result)
+			}
+		})
+	}
+
+	// the request is held until the element is pushed
+	done := make(chan int)
+	go func() {
+		resp, err := http.Post(ts.URL+"/list/brpop", "application/json", bytes.NewBufferString(`{"keys": ["list"]}`))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		done <- resp.StatusCode
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	_, err = native.LPush(ctx, "list", []interface{}{"b"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, <-done)
+
+	// disconnect of the client ends the request
+	reqCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, ts.URL+"/list/blpop", bytes.NewBufferString(`{"keys": ["list"]}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	_, err = http.DefaultClient.Do(req)
+	assert.Error(t, err)
+
+	// the popped element is written even if the client is gone meanwhile, errors are not
+	gone, cancelGone := context.WithCancel(ctx)
+	cancelGone()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/list/blpop", nil).WithContext(gone)
+	respondBlocked(c, &models.BPopResult{Key: "list", Value: "b"}, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"value":"b"`)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/list/blpop", nil).WithContext(gone)
+	respondBlocked(c, nil, context.Canceled)
+	assert.True(t, c.IsAborted())
+	assert.Zero(t, w.Body.Len())
+}
+
+func TestHashHandlers(t *testing.T) {
+	native := store.NewNative()
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	_, err := native.HSet(context.Background(), "user", map[string]interface{}{"name": "ivan", "age": 21})
+	assert.NoError(t, err)
+
+	type testCase struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+		result interface{}
+	}
+
+	tCases := []testCase{
+		{name: "Hget without field", method: http.MethodGet, path: "/hash/hget?key=user", code: http.StatusBadRequest},
+		{name: "Hexists", method: http.MethodGet, path: "/hash/hexists?key=user&field=age", code: http.StatusOK, result: true},
+		{name: "Hexists missing field", method: http.MethodGet, path: "/hash/hexists?key=user&field=city", code: http.StatusOK, result: false},
+		{name: "Hlen", method: http.MethodGet, path: "/hash/hlen?key=user", code: http.StatusOK, result: float64(2)},
+		{name: "Hkeys", method: http.MethodGet, path: "/hash/hkeys?key=user", code: http.StatusOK, result: []interface{}{"age", "name"}},
+		{name: "Hvals", method: http.MethodGet, path: "/hash/hvals?key=user", code: http.StatusOK, result: []interface{}{"21", "ivan"}},
+		{name: "Hvals raw", method: http.MethodGet, path: "/hash/hvals?key=user&raw=true", code: http.StatusOK, result: []interface{}{"21", "ivan"}},
+		{name: "Hmget", method: http.MethodGet, path: "/hash/hmget?key=user&fields=name&fields=city", code: http.StatusOK, result: []interface{}{"ivan", nil}},
+		{name: "Hmget without fields", method: http.MethodGet, path: "/hash/hmget?key=user", code: http.StatusBadRequest},
+		{name: "Hsetnx", method: http.MethodPost, path: "/hash/hsetnx", body: `{"key": "user", "field": "city", "value": "moscow"}`, code: http.StatusOK, result: true},
+		{name: "Hsetnx existing field", method: http.MethodPost, path: "/hash/hsetnx", body: `{"key": "user", "field": "name", "value": "petr"}`, code: http.StatusOK, result: false},
+		{name: "Hsetnx without value", method: http.MethodPost, path: "/hash/hsetnx", body: `{"key": "user", "field": "role"}`, code: http.StatusUnprocessableEntity},
+		{name: "Hincrby", method: http.MethodPost, path: "/hash/hincrby", body: `{"key": "user", "field": "age", "increment": 1}`, code: http.StatusOK, result: float64(22)},
+		{name: "Hincrby not an integer", method: http.MethodPost, path: "/hash/hincrby", body: `{"key": "user", "field": "name", "increment": 1}`, code: http.StatusConflict},
+		{name: "Hincrbyfloat", method: http.MethodPost, path: "/hash/hincrbyfloat", body: `{"key": "user", "field": "age", "increment": 0.5}`, code: http.StatusOK, result: 22.5},
+		{name: "Hdel", method: http.MethodPost, path: "/hash/hdel", body: `{"key": "user", "fields": ["age", "city", "missing"]}`, code: http.StatusOK, result: float64(2)},
+		{name: "Hdel without fields", method: http.MethodPost, path: "/hash/hdel", body: `{"key": "user", "fields": []}`, code: http.StatusUnprocessableEntity},
+		{name: "Hget", method: http.MethodGet, path: "/hash/hget?key=user&field=name", code: http.StatusOK, result: "ivan"},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, ts.URL+tc.path, bytes.NewBufferString(tc.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.code, resp.StatusCode)
+
+			if tc.result != nil {
+				body := struct {
+					Result interface{} `json:"result"`
+				}{}
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.Equal(t, tc.result, body.Result)
+			}
+		})
+	}
+}
+
+func TestTypedHashHandlers(t *testing.T) {
+	native := store.NewNative()
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	type testCase struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+		// raw JSON of the result
+		result string
+	}
+
+	tCases := []testCase{
+		{
+			name:   "Set typed values",
+			method: http.MethodPost,
+			path:   "/hash/set",
+			body:   `{"key": "user", "value": {"age": 21, "id": 9007199254740993, "score": 2.5, "ratio": 1.0, "admin": false, "phone": null, "zip": "101000", "address": {"city": "moscow", "floors": [1, 2]}}}`,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "Get typed values",
+			method: http.MethodGet,
+			path:   "/hash/get?key=user",
+			code:   http.StatusOK,
+			result: `{"address":{"city":"moscow","floors":[1,2]},"admin":false,"age":21,"id":9007199254740993,"phone":null,"ratio":1.0,"score":2.5,"zip":"101000"}`,
+		},
+		{name: "Hget integer", method: http.MethodGet, path: "/hash/hget?key=user&field=age", code: http.StatusOK, result: `21`},
+		{name: "Hget string of digits", method: http.MethodGet, path: "/hash/hget?key=user&field=zip", code: http.StatusOK, result: `"101000"`},
+		{name: "Hget null", method: http.MethodGet, path: "/hash/hget?key=user&field=phone", code: http.StatusOK, result: `null`},
+		{name: "Hget object", method: http.MethodGet, path: "/hash/hget?key=user&field=address", code: http.StatusOK, result: `{"city":"moscow","floors":[1,2]}`},
+		{name: "Hget raw", method: http.MethodGet, path: "/hash/hget?key=user&field=zip&raw=true", code: http.StatusOK, result: `"{\"Dtype\":\"string\",\"Data\":\"101000\"}"`},
+		{name: "Hmget typed values", method: http.MethodGet, path: "/hash/hmget?key=user&fields=age&fields=admin&fields=missing", code: http.StatusOK, result: `[21,false,null]`},
+		{name: "Hsetnx null", method: http.MethodPost, path: "/hash/hsetnx", body: `{"key": "user", "field": "fax", "value": null}`, code: http.StatusOK, result: `true`},
+		{name: "Hget null set by hsetnx", method: http.MethodGet, path: "/hash/hget?key=user&field=fax", code: http.StatusOK, result: `null`},
+		{name: "Hincrby typed integer", method: http.MethodPost, path: "/hash/hincrby", body: `{"key": "user", "field": "age", "increment": 1}`, code: http.StatusOK, result: `22`},
+		{name: "Hget incremented integer", method: http.MethodGet, path: "/hash/hget?key=user&field=age&raw=true", code: http.StatusOK, result: `"{\"Dtype\":\"int64\",\"Data\":\"22\"}"`},
+		{name: "Hincrbyfloat typed float", method: http.MethodPost, path: "/hash/hincrbyfloat", body: `{"key": "user", "field": "score", "increment": 0.25}`, code: http.StatusOK, result: `2.75`},
+		{name: "Hget incremented float", method: http.MethodGet, path: "/hash/hget?key=user&field=score", code: http.StatusOK, result: `2.75`},
+		{name: "Hincrby typed string", method: http.MethodPost, path: "/hash/hincrby", body: `{"key": "user", "field": "zip", "increment": 1}`, code: http.StatusConflict},
+		{name: "Hincrby missing field", method: http.MethodPost, path: "/hash/hincrby", body: `{"key": "user", "field": "visits", "increment": 1}`, code: http.StatusOK, result: `1`},
+		{name: "Hget new counter", method: http.MethodGet, path: "/hash/hget?key=user&field=visits&raw=true", code: http.StatusOK, result: `"{\"Dtype\":\"int64\",\"Data\":\"1\"}"`},
+		{
+			name:   "Hset raw values",
+			method: http.MethodPost,
+			path:   "/hash/hset",
+			body:   `{"key": "legacy", "value": {"name": "ivan", "age": 21}, "raw": true}`,
+			code:   http.StatusOK,
+			result: `2`,
+		},
+		{name: "Get raw values", method: http.MethodGet, path: "/hash/get?key=legacy&raw=true", code: http.StatusOK, result: `{"age":"21","name":"ivan"}`},
+		{name: "Get raw values typed", method: http.MethodGet, path: "/hash/get?key=legacy", code: http.StatusOK, result: `{"age":"21","name":"ivan"}`},
+		{name: "Hincrby raw integer", method: http.MethodPost, path: "/hash/hincrby", body: `{"key": "legacy", "field": "age", "increment": 1}`, code: http.StatusOK, result: `22`},
+		{name: "Hsetnx raw", method: http.MethodPost, path: "/hash/hsetnx", body: `{"key": "legacy", "field": "city", "value": "moscow", "raw": true}`, code: http.StatusOK, result: `true`},
+		{name: "Hvals raw", method: http.MethodGet, path: "/hash/hvals?key=legacy&raw=true", code: http.StatusOK, result: `["22","moscow","ivan"]`},
+		{name: "Hmget raw", method: http.MethodGet, path: "/hash/hmget?key=legacy&fields=age&raw=true", code: http.StatusOK, result: `["22"]`},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, ts.URL+tc.path, bytes.NewBufferString(tc.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.code, resp.StatusCode)
+
+			if tc.result != "" {
+				body := struct {
+					Result json.RawMessage `json:"result"`
+				}{}
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.Equal(t, tc.result, string(body.Result))
+			}
+		})
+	}
+}
+
+func TestJSONHandlers(t *testing.T) {
+	native := store.NewNative()
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	type testCase struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+		// raw JSON of the result
+		result string
+	}
+
+	tCases := []testCase{
+		{
+			name:   "Set document",
+			method: http.MethodPost,
+			path:   "/json/set",
+			body:   `{"key": "user", "value": {"name": "ivan", "id": 9007199254740993, "address": {"city": "moscow"}, "tags": ["admin"]}}`,
+			code:   http.StatusOK,
+			result: `"OK"`,
+		},
+		{name: "Set without value", method: http.MethodPost, path: "/json/set", body: `{"key": "user", "path": "$.name"}`, code: http.StatusUnprocessableEntity},
+		{name: "Set nested field", method: http.MethodPost, path: "/json/set", body: `{"key": "user", "path": "$.address.city", "value": "kazan"}`, code: http.StatusOK, result: `"OK"`},
+		{name: "Set NX existing field", method: http.MethodPost, path: "/json/set", body: `{"key": "user", "path": "$.name", "value": "petr", "nx": true}`, code: http.StatusNoContent},
+		{name: "Set at invalid path", method: http.MethodPost, path: "/json/set", body: `{"key": "user", "path": "$.[0]", "value": 1}`, code: http.StatusBadRequest},
+		{name: "Set not at the root of missing key", method: http.MethodPost, path: "/json/set", body: `{"key": "other", "path": "$.a", "value": 1}`, code: http.StatusBadRequest},
+		{
+			name:   "Get document",
+			method: http.MethodGet,
+			path:   "/json/get?key=user",
+			code:   http.StatusOK,
+			result: `{"address":{"city":"kazan"},"id":9007199254740993,"name":"ivan","tags":["admin"]}`,
+		},
+		{name: "Get paths", method: http.MethodGet, path: "/json/get?key=user&path=.address.city&path=$.id", code: http.StatusOK, result: `{"$.id":[9007199254740993],".address.city":"kazan"}`},
+		{name: "Get missing key", method: http.MethodGet, path: "/json/get?key=missing", code: http.StatusNoContent},
+		{name: "Get without key", method: http.MethodGet, path: "/json/get", code: http.StatusBadRequest},
+		{name: "Numincrby", method: http.MethodPost, path: "/json/numincrby", body: `{"key": "user", "path": "$.id", "increment": 2}`, code: http.StatusOK, result: `[9007199254740995]`},
+		{name: "Numincrby not a number", method: http.MethodPost, path: "/json/numincrby", body: `{"key": "user", "path": ".name", "increment": 2}`, code: http.StatusBadRequest},
+		{name: "Arrappend", method: http.MethodPost, path: "/json/arrappend", body: `{"key": "user", "path": ".tags", "values": ["dev", {"level": 2}]}`, code: http.StatusOK, result: `3`},
+		{name: "Arrappend without values", method: http.MethodPost, path: "/json/arrappend", body: `{"key": "user", "path": ".tags", "values": []}`, code: http.StatusUnprocessableEntity},
+		{name: "Objkeys", method: http.MethodGet, path: "/json/objkeys?key=user", code: http.StatusOK, result: `["address","id","name","tags"]`},
+		{name: "Objkeys of missing key", method: http.MethodGet, path: "/json/objkeys?key=missing", code: http.StatusNoContent},
+		{name: "Del field", method: http.MethodPost, path: "/json/del", body: `{"key": "user", "path": "$.tags[0]"}`, code: http.StatusOK, result: `1`},
+		{name: "Get after del", method: http.MethodGet, path: "/json/get?key=user&path=$.tags", code: http.StatusOK, result: `[["dev",{"level":2}]]`},
+		{name: "Del document", method: http.MethodPost, path: "/json/del", body: `{"key": "user"}`, code: http.StatusOK, result: `1`},
+		{name: "Get deleted document", method: http.MethodGet, path: "/json/get?key=user", code: http.StatusNoContent},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, ts.URL+tc.path, bytes.NewBufferString(tc.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.code, resp.StatusCode)
+
+			if tc.result != "" {
+				body := struct {
+					Result json.RawMessage `json:"result"`
+				}{}
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.Equal(t, tc.result, string(body.Result))
+			}
+		})
+	}
+}
+
+func TestScanHandlers(t *testing.T) {
+	native := store.NewNative()
+	router := newRouter(":3000", "auth", native, nil)
+	router.keysLimit = 3
+
+	ctx := context.Background()
+	values, err := store.HashValues(map[string]interface{}{"role": "admin", "age": 25}, false)
+	assert.NoError(t, err)
+	_, err = native.HSet(ctx, "user", values)
+	assert.NoError(t, err)
+	_, err = native.SAdd(ctx, "roles", []string{"admin", "user"})
+	assert.NoError(t, err)
+	_, err = native.ZAdd(ctx, "rating", []models.ZMember{{Member: "ivan", Score: 1.5}}, models.ZAddOptions{})
+	assert.NoError(t, err)
+	_, err = native.SetString(ctx, "name", "ivan", models.Expiration{})
+	assert.NoError(t, err)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	type testCase struct {
+		name   string
+		path   string
+		code   int
+		result string
+	}
+
+	tCases := []testCase{
+		{name: "Scan match", path: "/scan?cursor=0&match=us*&count=100", code: http.StatusOK, result: `{"cursor":0,"keys":["user"]}`},
+		{name: "Scan type", path: "/scan?count=100&type=zset", code: http.StatusOK, result: `{"cursor":0,"keys":["rating"]}`},
+		{name: "Scan negative count", path: "/scan?count=-1", code: http.StatusBadRequest},
+		{name: "Scan invalid cursor", path: "/scan?cursor=first", code: http.StatusBadRequest},
+		{name: "Hscan", path: "/hash/scan?key=user", code: http.StatusOK, result: `{"cursor":0,"fields":{"age":25,"role":"admin"}}`},
+		{name: "Hscan raw", path: "/hash/scan?key=user&match=r*&raw=true", code: http.StatusOK, result: `{"cursor":0,"fields":{"role":"{\"Dtype\":\"string\",\"Data\":\"admin\"}"}}`},
+		{name: "Hscan without key", path: "/hash/scan", code: http.StatusBadRequest},
+		{name: "Sscan", path: "/set/scan?key=roles&match=a*", code: http.StatusOK, result: `{"cursor":0,"members":["admin"]}`},
+		{name: "Sscan of hash", path: "/set/scan?key=user", code: http.StatusConflict},
+		{name: "Zscan", path: "/zset/scan?key=rating&count=5", code: http.StatusOK, result: `{"cursor":0,"members":[{"member":"ivan","score":1.5}]}`},
+		{name: "Keys", path: "/keys?pattern=r*", code: http.StatusOK, result: `["roles","rating"]`},
+		{name: "Keys over limit", path: "/keys?pattern=*", code: http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := http.Get(ts.URL + tc.path)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.code, resp.StatusCode)
+
+			if tc.result != "" {
+				body := struct {
+					Result json.RawMessage `json:"result"`
+				}{}
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.Equal(t, tc.result, string(body.Result))
+			}
+		})
+	}
+}
+
+func TestKeyHandlers(t *testing.T) {
+	native := store.NewNative()
+	router := newRouter(":3000", "auth", native, nil)
+
+	ctx := context.Background()
+	assert.NoError(t, native.SetHash(ctx, "user", map[string]interface{}{"role": `"admin"`}, models.Expiration{}))
+	_, err := native.SetString(ctx, "name", "ivan", models.Expiration{})
+	assert.NoError(t, err)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	type testCase struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+		result string
+	}
+
+	tCases := []testCase{
+		{name: "Type", method: http.MethodGet, path: "/type?key=user", code: http.StatusOK, result: `"hash"`},
+		{name: "Type of missing key", method: http.MethodGet, path: "/type?key=missing", code: http.StatusOK, result: `"none"`},
+		{name: "Type without key", method: http.MethodGet, path: "/type", code: http.StatusBadRequest},
+		{name: "TTL without expiration", method: http.MethodGet, path: "/ttl?key=user", code: http.StatusOK, result: `-1`},
+		{name: "Expire", method: http.MethodPost, path: "/expire", body: `{"key": "user", "ttl": 2}`, code: http.StatusOK, result: `true`},
+		{name: "TTL", method: http.MethodGet, path: "/ttl?key=user", code: http.StatusOK, result: `120`},
+		{name: "Expire missing key", method: http.MethodPost, path: "/expire", body: `{"key": "missing", "ttl": 2}`, code: http.StatusOK, result: `false`},
+		{name: "Expire without time", method: http.MethodPost, path: "/expire", body: `{"key": "user"}`, code: http.StatusBadRequest},
+		{name: "Expire with negative time", method: http.MethodPost, path: "/expire", body: `{"key": "user", "exat": -1}`, code: http.StatusBadRequest},
+		{name: "Persist", method: http.MethodPost, path: "/persist", body: `{"key": "user"}`, code: http.StatusOK, result: `true`},
+		{name: "PTTL after persist", method: http.MethodGet, path: "/pttl?key=user", code: http.StatusOK, result: `-1`},
+		{name: "PTTL of missing key", method: http.MethodGet, path: "/pttl?key=missing", code: http.StatusOK, result: `-2`},
+		{name: "Exists", method: http.MethodGet, path: "/exists?keys=user&keys=name&keys=missing", code: http.StatusOK, result: `2`},
+		{name: "Exists without keys", method: http.MethodGet, path: "/exists", code: http.StatusBadRequest},
+		{name: "Expire in the past", method: http.MethodPost, path: "/expire", body: `{"key": "name", "pxat": 1}`, code: http.StatusOK, result: `true`},
+		{name: "Exists after expiration", method: http.MethodGet, path: "/exists?keys=name", code: http.StatusOK, result: `0`},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, ts.URL+tc.path, bytes.NewBufferString(tc.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.code, resp.StatusCode)
+
+			if tc.result != "" {
+				body := struct {
+					Result json.RawMessage `json:"result"`
+				}{}
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.Equal(t, tc.result, string(body.Result))
+			}
+		})
+	}
+}
+
+func TestExpirationHandlers(t *testing.T) {
+	native := store.NewNative()
+	router := newRouter(":3000", "auth", native, nil)
+
+	ts := httptest.NewServer(router.setup())
+	defer ts.Close()
+
+	expireAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	type testCase struct {
+		name string
+		path string
+		body string
+		code int
+		key  string
+		pttl int64
+	}
+
+	tCases := []testCase{
+		{name: "String with ttl in milliseconds", path: "/string/set", body: `{"key": "session", "value": "1", "ttl": "1500ms"}`, code: http.StatusOK, key: "session", pttl: 1500},
+		{name: "String with ttl in minutes", path: "/string/set", body: `{"key": "name", "value": "ivan", "ttl": 2}`, code: http.StatusOK, key: "name", pttl: 120000},
+		{name: "String with expire_at", path: "/string/set", body: `{"key": "token", "value": "1", "expire_at": "` + expireAt + `"}`, code: http.StatusOK, key: "token", pttl: 3600000},
+		{name: "Hash with ttl in seconds", path: "/hash/set", body: `{"key": "user", "value": {"name": "ivan"}, "ttl": "30s"}`, code: http.StatusOK, key: "user", pttl: 30000},
+		{name: "Hash with expire_at", path: "/hash/set", body: `{"key": "cart", "value": {"id": 1}, "expire_at": "` + expireAt + `"}`, code: http.StatusOK, key: "cart", pttl: 3600000},
+		{name: "List with ttl in hours", path: "/list/set", body: `{"key": "events", "value": [1], "ttl": "2h"}`, code: http.StatusOK, key: "events", pttl: 7200000},
+		{name: "Ttl with expire_at", path: "/list/set", body: `{"key": "queue", "value": [1], "ttl": "1s", "expire_at": "` + expireAt + `"}`, code: http.StatusBadRequest},
+		{name: "Negative ttl", path: "/hash/set", body: `{"key": "user", "value": {"name": "ivan"}, "ttl": -1}`, code: http.StatusBadRequest},
+		{name: "Unknown unit", path: "/string/set", body: `{"key": "name", "value": "ivan", "ttl": "1d"}`, code: http.StatusUnprocessableEntity},
+		{name: "Signed ttl", path: "/list/set", body: `{"key": "queue", "value": [1], "ttl": "-1s"}`, code: http.StatusUnprocessableEntity},
+		{name: "Expire with ttl in seconds", path: "/expire", body: `{"key": "name", "ttl": "10s"}`, code: http.StatusOK, key: "name", pttl: 10000},
+		{name: "Expire with expire_at", path: "/expire", body: `{"key": "user", "expire_at": "` + expireAt + `"}`, code: http.StatusOK, key: "user", pttl: 3600000},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := http.Post(ts.URL+tc.path, "application/json", bytes.NewBufferString(tc.body))
+			assert.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tc.code, resp.StatusCode)
+
+			if tc.key != "" {
+				pttl, err := native.PTTL(context.Background(), tc.key)
+				assert.NoError(t, err)
+				assert.InDelta(t, tc.pttl, pttl, 1000)
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package server
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
 	"github.com/Vysogota99/redis-implementation/internal/server/store"
 	"github.com/gorilla/sessions"
 
@@ -11,6 +16,7 @@ import (
 type Server struct {
 	conf         *Config
 	router       *router
+	resp         *respServer
 	redis        store.RedisImpl
 	sessionStore sessions.Store
 }
@@ -24,24 +30,84 @@ func NewServer(conf *Config) *Server {
 
 // Start - start the server
 func (s *Server) Start() error {
-	redis, err := store.New(s.conf.redisAddr)
-	if err != nil {
+	if err := s.initStore(); err != nil {
 		return err
 	}
 
-	s.redis = redis
-
 	if err := s.initSessionStore(); err != nil {
 		return err
 	}
 
+	if s.conf.respPort != "" {
+		s.resp = newRespServer(s.conf.respPort, s.redis)
+		if err := s.resp.listen(); err != nil {
+			return err
+		}
+		defer s.resp.close()
+	}
+
 	s.router = newRouter(s.conf.serverPort, s.conf.sessionName, s.redis, s.sessionStore)
+	s.router.keysLimit = s.conf.keysLimit
 	s.router.setup().Run(s.conf.serverPort)
 
 	return nil
 }
 
+func (s *Server) initStore() error {
+	if s.conf.storageEngine == engineNative {
+		native := store.NewNative()
+		native.SetDBFilename(s.conf.dbFilename)
+		if err := native.SetMaxMemory(s.conf.maxmemory, s.conf.maxmemoryPolicy, s.conf.maxmemorySamples); err != nil {
+			return err
+		}
+		if err := native.SetPubSubBuffer(s.conf.pubsubBuffer); err != nil {
+			return err
+		}
+		if err := native.SetNotifyKeyspaceEvents(context.Background(), s.conf.notifyKeyspaceEvents); err != nil {
+			return err
+		}
+
+		// append only file is more complete than snapshot, so snapshot is used only without it, like in redis
+		if s.conf.appendOnly {
+			if err := native.OpenAOF(s.conf.appendFilename, s.conf.appendFsync); err != nil {
+				return err
+			}
+		} else if err := loadRDB(native, s.conf.dbFilename); err != nil {
+			return err
+		}
+
+		if err := native.StartActiveExpire(s.conf.activeExpireHz, s.conf.activeExpireEffort); err != nil {
+			return err
+		}
+
+		s.redis = native
+		return nil
+	}
+
+	redis, err := store.New(s.conf.redisAddr)
+	if err != nil {
+		return err
+	}
+	if err := redis.SetPubSubBuffer(s.conf.pubsubBuffer); err != nil {
+		return err
+	}
+	if s.conf.notifyKeyspaceEvents != "" {
+		if err := redis.SetNotifyKeyspaceEvents(context.Background(), s.conf.notifyKeyspaceEvents); err != nil {
+			return err
+		}
+	}
+
+	s.redis = redis
+	return nil
+}
+
 func (s *Server) initSessionStore() error {
+	if s.conf.storageEngine == engineNative {
+		// there is no redis for sessions, so they are kept in signed cookies
+		s.sessionStore = sessions.NewCookieStore([]byte(s.conf.sessionKey))
+		return nil
+	}
+
 	store, err := redistore.NewRediStore(s.conf.sessionMaxNumberIDLEConnections, "tcp", s.conf.redisAddr, "", []byte(s.conf.sessionKey))
 	if err != nil {
 		return err
@@ -50,3 +116,23 @@ func (s *Server) initSessionStore() error {
 	s.sessionStore = store
 	return nil
 }
+
+// loadRDB - restores data of native engine from RDB file, if it exists
+func loadRDB(native *store.Native, path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	loaded, err := native.LoadRDB(file)
+	if err != nil {
+		return fmt.Errorf("Could not load %s: %w", path, err)
+	}
+
+	log.Printf("%d keys are loaded from %s", loaded, path)
+	return nil
+}
@@ -0,0 +1,234 @@
+package store
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/resp"
+)
+
+const (
+	// FsyncAlways - append only file is synced after every write, the slowest and the safest policy
+	FsyncAlways = "always"
+	// FsyncEverySec - append only file is synced once a second, up to one second of writes may be lost
+	FsyncEverySec = "everysec"
+	// FsyncNo - syncing is left to the operating system
+	FsyncNo = "no"
+)
+
+// aof - append only file, every write of the native engine is stored in it as a redis command
+type aof struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *resp.Writer
+	fsync  string
+	// there are writes which were not synced yet
+	dirty bool
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// OpenAOF - restores data from append only file and starts appending every write to it.
+// Has to be called before the engine is used.
+func (n *Native) OpenAOF(path, fsync string) error {
+	if fsync != FsyncAlways && fsync != FsyncEverySec && fsync != FsyncNo {
+		return fmt.Errorf("Unknown fsync policy %s, available: %s/%s/%s", fsync, FsyncAlways, FsyncEverySec, FsyncNo)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.aof != nil {
+		return fmt.Errorf("Append only file is already opened")
+	}
+
+	if err := n.loadAOF(path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	n.aof = &aof{
+		file:   file,
+		writer: resp.NewWriter(file),
+		fsync:  fsync,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	if fsync == FsyncEverySec {
+		go n.aof.syncLoop()
+	} else {
+		close(n.aof.done)
+	}
+
+	return nil
+}
+
+// Close - stops active expiration, waits for background saving, syncs and closes append only file
+func (n *Native) Close() error {
+	n.StopActiveExpire()
+	n.saving.Wait()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.aof == nil {
+		return nil
+	}
+
+	err := n.aof.close()
+	n.aof = nil
+	return err
+}
+
+// loadAOF - replays commands from the file. Incomplete command at the end of the file, which is left
+// when the server is stopped in the middle of write, is cut off.
+func (n *Native) loadAOF(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	counter := &countingReader{r: file}
+	reader := resp.NewReader(counter)
+
+	var loaded, valid int64
+	// commands of transaction are applied when its EXEC is read, transaction without EXEC is cut off
+	var multi [][]string
+	inMulti := false
+	for {
+		args, err := reader.ReadCommand()
+		if err == io.EOF && counter.n-int64(reader.Buffered()) == valid {
+			break
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			log.Printf("Append only file %s is truncated, %d bytes are discarded", path, counter.n-valid)
+			return os.Truncate(path, valid)
+		}
+		if err != nil {
+			return fmt.Errorf("Bad append only file %s at offset %d: %w", path, valid, err)
+		}
+
+		// blank lines and empty arrays have no command to apply
+		if len(args) == 0 {
+			valid = counter.n - int64(reader.Buffered())
+			continue
+		}
+
+		switch {
+		case strings.ToLower(args[0]) == "multi":
+			inMulti, multi = true, nil
+			continue
+		case inMulti && strings.ToLower(args[0]) != "exec":
+			multi = append(multi, args)
+			continue
+		case inMulti:
+			inMulti = false
+		default:
+			multi = [][]string{args}
+		}
+
+		for _, args := range multi {
+			if _, err := n.apply(args); err != nil {
+				return fmt.Errorf("Bad append only file %s at offset %d: %w", path, valid, err)
+			}
+			loaded++
+		}
+
+		valid = counter.n - int64(reader.Buffered())
+	}
+
+	log.Printf("%d commands are loaded from append only file %s", loaded, path)
+	return nil
+}
+
+func (a *aof) write(args []string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.writer.WriteCommand(args...); err != nil {
+		return err
+	}
+
+	if err := a.writer.Flush(); err != nil {
+		return err
+	}
+
+	if a.fsync == FsyncAlways {
+		return a.file.Sync()
+	}
+
+	a.dirty = true
+	return nil
+}
+
+func (a *aof) sync() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.dirty = false
+	return a.file.Sync()
+}
+
+func (a *aof) syncLoop() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			a.mu.Lock()
+			dirty := a.dirty
+			a.mu.Unlock()
+
+			if !dirty {
+				continue
+			}
+
+			if err := a.sync(); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
+
+func (a *aof) close() error {
+	close(a.stop)
+	<-a.done
+
+	if err := a.sync(); err != nil {
+		a.file.Close()
+		return err
+	}
+
+	return a.file.Close()
+}
+
+// countingReader - counts read bytes to know the offset of the last complete command
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
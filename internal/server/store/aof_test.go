@@ -0,0 +1,158 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAOFReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aof")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "appendonly.aof")
+
+	client := NewNative()
+	assert.NoError(t, client.OpenAOF(path, FsyncAlways))
+
+	_, err = client.SetString(context.Background(), "user:1", "Ivan", models.Expiration{TTL: models.TTL(10 * time.Minute)})
+	assert.NoError(t, err)
+	_, err = client.SetString(context.Background(), "user:2", "Petr", models.Expiration{})
+	assert.NoError(t, err)
+	assert.NoError(t, client.SetHash(context.Background(), "hash", map[string]interface{}{"name": "Ivan", "age": 20}, models.Expiration{}))
+	assert.NoError(t, client.SetList(context.Background(), "list", []interface{}{"a", int64(1), 2.5}, models.Expiration{}))
+	_, err = client.LSet(context.Background(), "list", 0, "b")
+	assert.NoError(t, err)
+	_, err = client.Delete(context.Background(), "user:2")
+	assert.NoError(t, err)
+	_, err = client.SAdd(context.Background(), "roles", []string{"admin", "user", "guest"})
+	assert.NoError(t, err)
+	_, err = client.SRem(context.Background(), "roles", []string{"guest"})
+	assert.NoError(t, err)
+	_, err = client.SDiffStore(context.Background(), "others", []string{"roles"})
+	assert.NoError(t, err)
+	_, err = client.ZAdd(context.Background(), "scores", []models.ZMember{{Member: "ivan", Score: 1.5}, {Member: "petr", Score: 2}}, models.ZAddOptions{})
+	assert.NoError(t, err)
+	_, err = client.ZAddIncr(context.Background(), "scores", models.ZMember{Member: "ivan", Score: 1}, models.ZAddOptions{GT: true})
+	assert.NoError(t, err)
+	_, err = client.ZPopMax(context.Background(), "scores", 1)
+	assert.NoError(t, err)
+	for _, id := range []string{"1-0", "2-0", "3-0"} {
+		_, err = client.XAdd(context.Background(), "events", id, map[string]interface{}{"name": "ivan", "age": int64(25)}, models.XTrim{})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, client.XGroupCreate(context.Background(), "events", "workers", "0", false))
+	_, err = client.XReadGroup(context.Background(), models.XReadGroupRequest{Group: "workers", Consumer: "ivan", Keys: []string{"events"}})
+	assert.NoError(t, err)
+	_, err = client.XAck(context.Background(), "events", "workers", []string{"1-0"})
+	assert.NoError(t, err)
+	_, err = client.XTrim(context.Background(), "events", models.XTrim{MinID: "3"})
+	assert.NoError(t, err)
+	assert.NoError(t, client.Close())
+
+	restored := NewNative()
+	assert.NoError(t, restored.OpenAOF(path, FsyncNo))
+	defer restored.Close()
+
+	value, err := restored.GetString(context.Background(), "user:1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ivan", value)
+	assert.Equal(t, client.data["user:1"].expireAt, restored.data["user:1"].expireAt)
+
+	_, err = restored.GetString(context.Background(), "user:2")
+	assert.Equal(t, redis.Nil, err)
+
+	hash, err := restored.GetHash(context.Background(), "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "Ivan", "age": "20"}, hash)
+
+	list, err := restored.GetList(context.Background(), "list")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"b", int64(1), 2.5}, list)
+
+	for _, key := range []string{"roles", "others"} {
+		members, err := restored.SMembers(context.Background(), key)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"admin", "user"}, members)
+	}
+
+	scores, err := restored.ZRange(context.Background(), "scores", models.ZRangeQuery{Start: "0", Stop: "-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ZMember{{Member: "petr", Score: 2}}, scores)
+
+	entries, err := restored.XRange(context.Background(), "events", models.XRangeQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.StreamEntry{{ID: "3-0", Fields: map[string]interface{}{"name": "ivan", "age": int64(25)}}}, entries)
+
+	pending, err := restored.XPending(context.Background(), "events", "workers")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.XPending{Count: 2, Lower: "2-0", Higher: "3-0", Consumers: map[string]int64{"ivan": 2}}, pending)
+
+	s := restored.data["events"].value.(*stream)
+	assert.Equal(t, client.data["events"].value.(*stream).groups["workers"].pending, s.groups["workers"].pending)
+	assert.Equal(t, streamID{ms: 3}, s.groups["workers"].lastID)
+}
+
+func TestAOFTruncated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aof")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "appendonly.aof")
+	complete := "*3\r\n$3\r\nSET\r\n$4\r\nuser\r\n$4\r\nIvan\r\n"
+	assert.NoError(t, ioutil.WriteFile(path, []byte(complete+"*3\r\n$3\r\nSET\r\n$4\r\nus"), 0644))
+
+	client := NewNative()
+	assert.NoError(t, client.OpenAOF(path, FsyncEverySec))
+
+	value, err := client.GetString(context.Background(), "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ivan", value)
+
+	_, err = client.SetString(context.Background(), "user", "Petr", models.Expiration{})
+	assert.NoError(t, err)
+	assert.NoError(t, client.Close())
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, complete+"*3\r\n$3\r\nSET\r\n$4\r\nuser\r\n$4\r\nPetr\r\n", string(data))
+}
+
+func TestAOFBlankLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aof")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "appendonly.aof")
+	set := "*3\r\n$3\r\nSET\r\n$4\r\nuser\r\n$4\r\nIvan\r\n"
+	assert.NoError(t, ioutil.WriteFile(path, []byte("\r\n"+set+"*0\r\n\n"), 0644))
+
+	client := NewNative()
+	assert.NoError(t, client.OpenAOF(path, FsyncNo))
+
+	value, err := client.GetString(context.Background(), "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ivan", value)
+	assert.NoError(t, client.Close())
+}
+
+func TestAOFBadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aof")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "appendonly.aof")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("*2\r\n$7\r\nUNKNOWN\r\n$4\r\nuser\r\n"), 0644))
+
+	client := NewNative()
+	assert.Error(t, client.OpenAOF(path, FsyncNo))
+	assert.Error(t, client.OpenAOF(path, "sometimes"))
+}
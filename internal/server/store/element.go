@@ -0,0 +1,275 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+)
+
+// encodeListElement - serializes value into models.ListElement, so that its type can be restored. Floats are
+// written with the shortest text restoring the same value, because LPOS, LREM and LINSERT compare the encodings.
+func encodeListElement(value interface{}) (string, error) {
+	element := models.ListElement{}
+	switch v := value.(type) {
+	case nil:
+		element.Dtype = "null"
+	case bool:
+		element.Dtype = "bool"
+		element.Data = strconv.FormatBool(v)
+	case float64:
+		element.Dtype = "float64"
+		element.Data = strconv.FormatFloat(v, 'g', -1, 64)
+	case int64:
+		element.Dtype = "int64"
+		element.Data = strconv.FormatInt(v, 10)
+	case int:
+		element.Dtype = "int"
+		element.Data = strconv.Itoa(v)
+	case map[string]interface{}:
+		serializedData, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+
+		element.Dtype = "map"
+		element.Data = string(serializedData)
+	case string:
+		element.Dtype = "string"
+		element.Data = v
+	default:
+		serializedData, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+
+		element.Dtype = "json"
+		element.Data = string(serializedData)
+	}
+
+	serialized, err := json.Marshal(element)
+	if err != nil {
+		return "", err
+	}
+
+	return string(serialized), nil
+}
+
+// decodeListElement - restores value serialized by encodeListElement
+func decodeListElement(raw string) (interface{}, error) {
+	var lElement models.ListElement
+	if err := json.Unmarshal([]byte(raw), &lElement); err != nil {
+		return nil, err
+	}
+
+	switch lElement.Dtype {
+	case "float64":
+		floatVal, err := strconv.ParseFloat(lElement.Data, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		return floatVal, nil
+	case "int64", "int":
+		intVal, err := strconv.ParseInt(lElement.Data, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		return intVal, nil
+	case "string":
+		return lElement.Data, nil
+	case "null":
+		return nil, nil
+	case "bool":
+		return strconv.ParseBool(lElement.Data)
+	case "map", "json":
+		var deserializedValue interface{}
+		if err := json.Unmarshal([]byte(lElement.Data), &deserializedValue); err != nil {
+			return nil, err
+		}
+
+		return deserializedValue, nil
+	}
+
+	return nil, nil
+}
+
+// decodeListElements - restores every element of the list
+func decodeListElements(raw []string) ([]interface{}, error) {
+	resultSlice := make([]interface{}, len(raw))
+	for i, el := range raw {
+		value, err := decodeListElement(el)
+		if err != nil {
+			return nil, err
+		}
+
+		resultSlice[i] = value
+	}
+
+	return resultSlice, nil
+}
+
+// encodeListElements - serializes every element of the list
+func encodeListElements(values []interface{}) ([]string, error) {
+	encoded := make([]string, len(values))
+	for i, val := range values {
+		serialized, err := encodeListElement(val)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded[i] = serialized
+	}
+
+	return encoded, nil
+}
+
+// encodeHashValue - serializes value of the hash field into models.ListElement, so that only values written
+// by the server are restored with their types. Numbers keep the text they are given, e.g. json.Number.
+func encodeHashValue(value interface{}) (string, error) {
+	element := models.ListElement{}
+	switch v := value.(type) {
+	case nil:
+		element.Dtype = "null"
+	case string:
+		element.Dtype = "string"
+		element.Data = v
+	case bool:
+		element.Dtype = "bool"
+		element.Data = strconv.FormatBool(v)
+	case json.Number:
+		element.Dtype = "float64"
+		if _, err := v.Int64(); err == nil {
+			element.Dtype = "int64"
+		}
+		element.Data = v.String()
+	case int, int64:
+		element.Dtype = "int64"
+		element.Data = fmt.Sprint(v)
+	case float64:
+		serialized, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+
+		element.Dtype = "float64"
+		element.Data = string(serialized)
+	default:
+		serialized, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+
+		element.Dtype = "json"
+		element.Data = string(serialized)
+	}
+
+	serialized, err := json.Marshal(element)
+	if err != nil {
+		return "", err
+	}
+
+	return string(serialized), nil
+}
+
+// HashValues - values of the hash keep their types unless raw strings are requested, numbers of the raw
+// hash are written as they are given, so that HINCRBY and HINCRBYFLOAT work with them
+func HashValues(values map[string]interface{}, raw bool) (map[string]interface{}, error) {
+	encoded := make(map[string]interface{}, len(values))
+	for field, val := range values {
+		if raw {
+			if number, ok := val.(json.Number); ok {
+				val = number.String()
+			}
+			encoded[field] = val
+			continue
+		}
+
+		serialized, err := encodeHashValue(val)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded[field] = serialized
+	}
+
+	return encoded, nil
+}
+
+// DecodeHashValue - restores value serialized by HashValues, integers are int64 and other numbers are
+// float64, see typedNumbers. Any other value, e.g. written in raw mode or by redis-cli, is returned as is.
+func DecodeHashValue(raw string) interface{} {
+	var element models.ListElement
+	if !strings.HasPrefix(raw, "{") || json.Unmarshal([]byte(raw), &element) != nil {
+		return raw
+	}
+	// only the exact encoding is decoded, e.g. not the JSON with other fields written in raw mode
+	if serialized, err := json.Marshal(element); err != nil || string(serialized) != raw {
+		return raw
+	}
+
+	switch element.Dtype {
+	case "null":
+		return nil
+	case "string":
+		return element.Data
+	case "bool":
+		if value, err := strconv.ParseBool(element.Data); err == nil {
+			return value
+		}
+	case "int64", "float64":
+		if _, err := json.Number(element.Data).Float64(); err == nil && json.Valid([]byte(element.Data)) {
+			return typedNumbers(json.Number(element.Data))
+		}
+	case "json":
+		decoder := json.NewDecoder(strings.NewReader(element.Data))
+		decoder.UseNumber()
+
+		var value interface{}
+		if err := decoder.Decode(&value); err == nil {
+			return typedNumbers(value)
+		}
+	}
+
+	return raw
+}
+
+// DecodeHashValues - restores every value of the hash
+func DecodeHashValues(hash map[string]string) map[string]interface{} {
+	decoded := make(map[string]interface{}, len(hash))
+	for field, val := range hash {
+		decoded[field] = DecodeHashValue(val)
+	}
+
+	return decoded
+}
+
+// typedNumbers - replaces json.Number in the decoded value by int64 or float64. The number which can't be
+// written back the same way, e.g. 1.0 or an integer out of int64 range, stays json.Number, so that it is not
+// rounded.
+func typedNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if integer, err := v.Int64(); err == nil {
+			return integer
+		}
+		if float, err := v.Float64(); err == nil {
+			if serialized, err := json.Marshal(float); err == nil && string(serialized) == v.String() {
+				return float
+			}
+		}
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = typedNumbers(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = typedNumbers(val)
+		}
+	}
+
+	return value
+}
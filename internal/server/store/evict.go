@@ -0,0 +1,247 @@
+package store
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policies of eviction, the same as maxmemory-policy of redis
+const (
+	PolicyNoEviction    = "noeviction"
+	PolicyAllKeysLRU    = "allkeys-lru"
+	PolicyAllKeysLFU    = "allkeys-lfu"
+	PolicyAllKeysRandom = "allkeys-random"
+	PolicyVolatileLRU   = "volatile-lru"
+	PolicyVolatileTTL   = "volatile-ttl"
+)
+
+const (
+	// entryOverhead - estimated number of bytes used by the entry and the key in maps besides their content
+	entryOverhead = 64
+	// fieldOverhead - estimated number of bytes used by the field of the hash besides its content
+	fieldOverhead = 32
+	// elementOverhead - estimated number of bytes used by the element of the list besides its content
+	elementOverhead = 16
+
+	// defaultMaxmemorySamples - number of keys checked to find the best key to evict, like maxmemory-samples
+	defaultMaxmemorySamples = 5
+
+	// lfuInitCounter - new keys are not evicted before they have a chance to be accessed
+	lfuInitCounter = 5
+	// lfuLogFactor - the higher is the factor, the more accesses are needed to increment the counter
+	lfuLogFactor = 10
+	// lfuDecayTime - counter is decremented every minute when the key is not accessed
+	lfuDecayTime = 1
+)
+
+// SetMaxMemory - limits memory used by keys, 0 means no limit. When the limit is exceeded keys are evicted
+// by the policy before writes, samples is the number of keys checked to find the key to evict.
+func (n *Native) SetMaxMemory(maxmemory int64, policy string, samples int) error {
+	switch policy {
+	case PolicyNoEviction, PolicyAllKeysLRU, PolicyAllKeysLFU, PolicyAllKeysRandom, PolicyVolatileLRU, PolicyVolatileTTL:
+	default:
+		return fmt.Errorf("Unknown maxmemory policy %s", policy)
+	}
+
+	if maxmemory < 0 {
+		return fmt.Errorf("maxmemory can't be negative")
+	}
+	if samples < 1 {
+		return fmt.Errorf("Number of samples has to be positive")
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.maxmemory = maxmemory
+	n.maxmemoryPolicy = policy
+	n.maxmemorySamples = samples
+
+	return nil
+}
+
+// freeMemory - evicts keys until used memory is below the limit, it is called before every write
+func (n *Native) freeMemory() error {
+	if n.maxmemory == 0 {
+		return nil
+	}
+
+	for n.used > n.maxmemory {
+		key, ok := n.evictionCandidate()
+		if !ok {
+			return ErrOOM
+		}
+
+		n.remove(key)
+		n.evictedKeys++
+		n.notify(notifyEvicted, "evicted", key)
+		if err := n.propagate("DEL", key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// evictionCandidate - returns the best key to evict among sampled ones, false if nothing can be evicted
+func (n *Native) evictionCandidate() (string, bool) {
+	if n.maxmemoryPolicy == PolicyNoEviction {
+		return "", false
+	}
+
+	volatile := n.maxmemoryPolicy == PolicyVolatileLRU || n.maxmemoryPolicy == PolicyVolatileTTL
+	if (volatile && len(n.expires) == 0) || len(n.data) == 0 {
+		return "", false
+	}
+
+	if n.maxmemoryPolicy == PolicyAllKeysRandom {
+		return n.randomKey(), true
+	}
+
+	now := nowMs()
+	var best string
+	var bestScore int64
+	sampled := 0
+	// order of map iteration is random, so the first keys are a sample
+	sample := func(key string, e *entry) bool {
+		// the higher is the score, the better is the key for eviction
+		var score int64
+		switch n.maxmemoryPolicy {
+		case PolicyAllKeysLRU, PolicyVolatileLRU:
+			score = now - e.access
+		case PolicyAllKeysLFU:
+			score = 255 - int64(lfuDecay(e, now))
+		case PolicyVolatileTTL:
+			score = -e.expireAt
+		}
+
+		if sampled == 0 || score > bestScore {
+			best, bestScore = key, score
+		}
+
+		sampled++
+		return sampled < n.maxmemorySamples
+	}
+
+	if volatile {
+		for key := range n.expires {
+			if !sample(key, n.data[key]) {
+				break
+			}
+		}
+	} else {
+		for key, e := range n.data {
+			if !sample(key, e) {
+				break
+			}
+		}
+	}
+
+	return best, true
+}
+
+// randomKey - map iteration starts from random position, random number of keys is skipped in addition
+func (n *Native) randomKey() string {
+	skip := rand.Intn(n.maxmemorySamples)
+	var res string
+	for key := range n.data {
+		res = key
+		if skip == 0 {
+			break
+		}
+		skip--
+	}
+
+	return res
+}
+
+// newEntry - creates entry with the initial size, access time and the next version
+func (n *Native) newEntry(key string, value interface{}) *entry {
+	now := nowMs()
+	e := &entry{
+		value:      value,
+		access:     now,
+		lfuCounter: lfuInitCounter,
+		lfuTime:    now / int64(time.Minute/time.Millisecond),
+	}
+	n.resize(e, entryOverhead+int64(len(key)))
+	n.version++
+	e.version = n.version
+
+	return e
+}
+
+// resize - changes size of the entry and used memory
+func (n *Native) resize(e *entry, delta int64) {
+	e.size += delta
+	n.used += delta
+}
+
+// touch - counts access to the entry by LRU and LFU
+func (n *Native) touch(e *entry) {
+	now := nowMs()
+	e.access = now
+	e.lfuCounter = lfuIncrement(lfuDecay(e, now))
+	e.lfuTime = now / int64(time.Minute/time.Millisecond)
+}
+
+// lfuDecay - counter of the entry decremented by number of minutes passed since the last access
+func lfuDecay(e *entry, now int64) uint8 {
+	periods := (now/int64(time.Minute/time.Millisecond) - e.lfuTime) / lfuDecayTime
+	if periods >= int64(e.lfuCounter) {
+		return 0
+	}
+
+	return e.lfuCounter - uint8(periods)
+}
+
+// lfuIncrement - logarithmic increment, the greater is the counter, the less likely it is incremented
+func lfuIncrement(counter uint8) uint8 {
+	if counter == 255 {
+		return counter
+	}
+
+	base := float64(counter) - lfuInitCounter
+	if base < 0 {
+		base = 0
+	}
+
+	if rand.Float64() < 1/(base*lfuLogFactor+1) {
+		return counter + 1
+	}
+
+	return counter
+}
+
+// ParseMemory - converts memory size like 100mb or 1gb into bytes, the same units as in redis.conf
+func ParseMemory(value string) (int64, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	units := []struct {
+		suffix string
+		size   int64
+	}{
+		{"kb", 1024}, {"mb", 1024 * 1024}, {"gb", 1024 * 1024 * 1024},
+		{"k", 1000}, {"m", 1000 * 1000}, {"g", 1000 * 1000 * 1000},
+		{"b", 1},
+	}
+
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSuffix(value, unit.suffix)
+			multiplier = unit.size
+			break
+		}
+	}
+
+	res, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || res < 0 {
+		return 0, fmt.Errorf("Invalid memory size %s", value)
+	}
+
+	return res * multiplier, nil
+}
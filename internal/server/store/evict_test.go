@@ -0,0 +1,166 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryAccounting(t *testing.T) {
+	client := NewNative()
+
+	_, err := client.SetString(context.Background(), "user:1", "Ivan", models.Expiration{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(entryOverhead+len("user:1")+len("Ivan")), client.used)
+
+	assert.NoError(t, client.SetHash(context.Background(), "hash", map[string]interface{}{"name": "Ivan"}, models.Expiration{}))
+	_, err = client.HSet(context.Background(), "hash", map[string]interface{}{"name": "Petr", "age": 20})
+	assert.NoError(t, err)
+	assert.NoError(t, client.SetList(context.Background(), "list", []interface{}{"a", "b"}, models.Expiration{}))
+	_, err = client.LSet(context.Background(), "list", 0, "long value")
+	assert.NoError(t, err)
+
+	var total int64
+	for _, e := range client.data {
+		total += e.size
+	}
+	assert.Equal(t, total, client.used)
+
+	for _, key := range []string{"user:1", "hash", "list"} {
+		_, err := client.Delete(context.Background(), key)
+		assert.NoError(t, err)
+	}
+	assert.Zero(t, client.used)
+}
+
+func TestNoEviction(t *testing.T) {
+	client := NewNative()
+	assert.NoError(t, client.SetMaxMemory(100, PolicyNoEviction, 5))
+
+	_, err := client.SetString(context.Background(), "user:1", "Ivan Lapshin Ivan Lapshin Ivan Lapshin", models.Expiration{})
+	assert.NoError(t, err)
+
+	_, err = client.SetString(context.Background(), "user:2", "Ivan", models.Expiration{})
+	assert.Equal(t, ErrOOM, err)
+	assert.True(t, IsOOM(err))
+	assert.Equal(t, ErrOOM, client.SetList(context.Background(), "list", []interface{}{"a"}, models.Expiration{}))
+
+	// reads and deletes are allowed
+	_, err = client.GetString(context.Background(), "user:1")
+	assert.NoError(t, err)
+	_, err = client.Delete(context.Background(), "user:1")
+	assert.NoError(t, err)
+
+	_, err = client.SetString(context.Background(), "user:2", "Ivan", models.Expiration{})
+	assert.NoError(t, err)
+}
+
+func TestEvictionPolicies(t *testing.T) {
+	type testCase struct {
+		policy string
+		// key which has to be evicted first
+		victim string
+	}
+
+	tCases := []testCase{
+		{policy: PolicyAllKeysLRU, victim: "old"},
+		{policy: PolicyAllKeysLFU, victim: "rare"},
+		{policy: PolicyVolatileLRU, victim: "volatile"},
+		{policy: PolicyVolatileTTL, victim: "soon"},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			client := NewNative()
+			for _, key := range []string{"old", "rare", "volatile", "soon", "hot"} {
+				_, err := client.SetString(context.Background(), key, "value", models.Expiration{})
+				assert.NoError(t, err)
+			}
+
+			now := nowMs()
+			for _, e := range client.data {
+				e.access = now
+				e.lfuCounter = 100
+			}
+			client.data["old"].access = now - 10000
+			client.data["rare"].lfuCounter = 1
+			assert.True(t, client.pexpireAt("volatile", now+100000))
+			assert.True(t, client.pexpireAt("soon", now+1000))
+			client.data["soon"].access = now
+			// the least recently used among keys with ttl, but more recent than old
+			client.data["volatile"].access = now - 5000
+
+			assert.NoError(t, client.SetMaxMemory(client.used, tc.policy, 10))
+
+			_, err := client.SetString(context.Background(), "new", "value", models.Expiration{})
+			assert.NoError(t, err)
+			_, err = client.SetString(context.Background(), "newer", "value", models.Expiration{})
+			assert.NoError(t, err)
+
+			assert.NotContains(t, client.data, tc.victim)
+			assert.Contains(t, client.data, "newer")
+			assert.Equal(t, int64(1), client.evictedKeys)
+		})
+	}
+}
+
+func TestAllKeysRandom(t *testing.T) {
+	client := NewNative()
+	for i := 0; i < 10; i++ {
+		_, err := client.SetString(context.Background(), fmt.Sprintf("user:%d", i), "value", models.Expiration{})
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, client.SetMaxMemory(client.used/2, PolicyAllKeysRandom, 5))
+	_, err := client.SetString(context.Background(), "user:10", "value", models.Expiration{})
+	assert.NoError(t, err)
+	assert.True(t, client.used <= client.maxmemory+client.data["user:10"].size)
+
+	info, err := client.Info(context.Background(), "memory")
+	assert.NoError(t, err)
+	assert.Equal(t, PolicyAllKeysRandom, info["maxmemory_policy"])
+}
+
+func TestVolatileWithoutTTL(t *testing.T) {
+	client := NewNative()
+	_, err := client.SetString(context.Background(), "user:1", "value", models.Expiration{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, client.SetMaxMemory(1, PolicyVolatileLRU, 5))
+	_, err = client.SetString(context.Background(), "user:2", "value", models.Expiration{})
+	assert.Equal(t, ErrOOM, err)
+
+	assert.Error(t, client.SetMaxMemory(1, "sometimes", 5))
+}
+
+func TestParseMemory(t *testing.T) {
+	type testCase struct {
+		value   string
+		bytes   int64
+		isError bool
+	}
+
+	tCases := []testCase{
+		{value: "100", bytes: 100},
+		{value: "1kb", bytes: 1024},
+		{value: "100MB", bytes: 100 * 1024 * 1024},
+		{value: "1g", bytes: 1000 * 1000 * 1000},
+		{value: "mb", isError: true},
+		{value: "-1", isError: true},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.value, func(t *testing.T) {
+			bytes, err := ParseMemory(tc.value)
+			if tc.isError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.bytes, bytes)
+			}
+		})
+	}
+}
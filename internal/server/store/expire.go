@@ -0,0 +1,169 @@
+package store
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// activeExpireKeysPerLoop - number of keys with time to live checked by one loop of the cycle
+	activeExpireKeysPerLoop = 20
+	// activeExpireAcceptableStale - percent of expired keys in the sample, when the cycle may stop
+	activeExpireAcceptableStale = 10
+	// activeExpireTimePerc - percent of the period between cycles which may be spent on expiration
+	activeExpireTimePerc = 25
+	// MaxExpireEffort - the largest effort of active expiration, like active-expire-effort of redis
+	MaxExpireEffort = 10
+)
+
+// expireStats - metrics of expiration, named after fields of INFO stats
+type expireStats struct {
+	// number of removed keys, expired on access and by the cycle
+	expiredKeys   int64
+	expiredLazy   int64
+	expiredActive int64
+	// estimated percent of expired keys among keys with time to live
+	stalePerc float64
+	// number of cycles stopped because they run out of time
+	timeCapReached int64
+	cycles         int64
+	// total time spent by the cycle
+	cycleTime time.Duration
+}
+
+// StartActiveExpire - starts removing expired keys in background, hz times a second like redis does.
+// Effort 1-10 allows the cycle to check more keys and to spend more time.
+func (n *Native) StartActiveExpire(hz, effort int) error {
+	if hz < 1 || hz > 500 {
+		return fmt.Errorf("hz has to be from 1 to 500, got %d", hz)
+	}
+	if effort < 1 || effort > MaxExpireEffort {
+		return fmt.Errorf("Effort has to be from 1 to %d, got %d", MaxExpireEffort, effort)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.stopExpire != nil {
+		return fmt.Errorf("Active expiration is already started")
+	}
+
+	n.stopExpire = make(chan struct{})
+	n.expireDone = make(chan struct{})
+	go n.activeExpireLoop(time.Second/time.Duration(hz), effort-1, n.stopExpire, n.expireDone)
+
+	return nil
+}
+
+// StopActiveExpire - stops background removing of expired keys
+func (n *Native) StopActiveExpire() {
+	n.mu.Lock()
+	stop, done := n.stopExpire, n.expireDone
+	n.stopExpire, n.expireDone = nil, nil
+	n.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+
+	close(stop)
+	<-done
+}
+
+func (n *Native) activeExpireLoop(period time.Duration, effort int, stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			n.activeExpireCycle(period, effort)
+		}
+	}
+}
+
+// activeExpireCycle - samples keys with time to live and removes expired ones. Sampling is repeated
+// while many of sampled keys are expired, but no longer than the time budget. Lock is released between
+// samples, so requests are not stalled by large keyspaces.
+func (n *Native) activeExpireCycle(period time.Duration, effort int) {
+	keysPerLoop := activeExpireKeysPerLoop + activeExpireKeysPerLoop/4*effort
+	acceptableStale := activeExpireAcceptableStale - effort
+	budget := period * time.Duration(activeExpireTimePerc+2*effort) / 100
+
+	start := time.Now()
+	var sampled, expired int
+	for {
+		loopSampled, loopExpired := n.expireSample(keysPerLoop)
+		sampled += loopSampled
+		expired += loopExpired
+
+		if loopSampled == 0 || loopExpired*100/loopSampled <= acceptableStale {
+			break
+		}
+
+		if time.Since(start) > budget {
+			n.mu.Lock()
+			n.expireStats.timeCapReached++
+			n.mu.Unlock()
+			break
+		}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.expireStats.cycles++
+	n.expireStats.cycleTime += time.Since(start)
+	if sampled > 0 {
+		// running average, the same as expired_stale_perc of redis
+		current := float64(expired) * 100 / float64(sampled)
+		n.expireStats.stalePerc = current*0.05 + n.expireStats.stalePerc*0.95
+	}
+}
+
+// expireSample - checks up to count keys with time to live, returns number of checked and removed keys.
+// Order of map iteration is random, so keys are sampled without additional structures.
+func (n *Native) expireSample(count int) (int, int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := nowMs()
+	var sampled, expired int
+	for key := range n.expires {
+		if sampled == count {
+			break
+		}
+		sampled++
+
+		if e, ok := n.data[key]; ok && e.expireAt != 0 && e.expireAt > now {
+			continue
+		}
+
+		n.expireKey(key, true)
+		expired++
+	}
+
+	return sampled, expired
+}
+
+// expireKey - removes expired key, expiration is written as DEL, so that replay of append only file
+// does not depend on the time of loading
+func (n *Native) expireKey(key string, active bool) {
+	n.remove(key)
+	n.notify(notifyExpired, "expired", key)
+	n.expireStats.expiredKeys++
+	if active {
+		n.expireStats.expiredActive++
+	} else {
+		n.expireStats.expiredLazy++
+	}
+
+	if err := n.propagate("DEL", key); err != nil {
+		log.Println(err)
+	}
+}
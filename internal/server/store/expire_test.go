@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActiveExpireCycle(t *testing.T) {
+	client := NewNative()
+
+	past := nowMs() - 1
+	for i := 0; i < 100; i++ {
+		client.setString(fmt.Sprintf("expired:%d", i), "value", past)
+	}
+	for i := 0; i < 10; i++ {
+		_, err := client.SetString(context.Background(), fmt.Sprintf("alive:%d", i), "value", models.Expiration{TTL: models.TTL(10 * time.Minute)})
+		assert.NoError(t, err)
+	}
+	_, err := client.SetString(context.Background(), "persistent", "value", models.Expiration{})
+	assert.NoError(t, err)
+
+	// all expired keys are removed because sampling is repeated while most of sampled keys are expired
+	client.activeExpireCycle(time.Second, 0)
+
+	assert.Len(t, client.data, 11)
+	assert.Len(t, client.expires, 10)
+
+	info, err := client.Info(context.Background(), "stats")
+	assert.NoError(t, err)
+	assert.Equal(t, "100", info["expired_keys"])
+	assert.Equal(t, "100", info["expired_active_keys"])
+	assert.Equal(t, "1", info["expire_cycles"])
+
+	info, err = client.Info(context.Background(), "keyspace")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"db0": "keys=11,expires=10,avg_ttl=0"}, info)
+}
+
+func TestActiveExpireTimeCap(t *testing.T) {
+	client := NewNative()
+
+	past := nowMs() - 1
+	for i := 0; i < 1000; i++ {
+		client.setString(fmt.Sprintf("expired:%d", i), "value", past)
+	}
+
+	// there is no time for the second sample
+	client.activeExpireCycle(0, 0)
+
+	assert.Len(t, client.data, 1000-activeExpireKeysPerLoop)
+	assert.Equal(t, int64(1), client.expireStats.timeCapReached)
+}
+
+func TestActiveExpireBackground(t *testing.T) {
+	client := NewNative()
+	assert.Error(t, client.StartActiveExpire(0, 1))
+	assert.Error(t, client.StartActiveExpire(10, MaxExpireEffort+1))
+
+	client.setString("session", "value", nowMs()+50)
+	_, err := client.SetString(context.Background(), "user:1", "Ivan", models.Expiration{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, client.StartActiveExpire(100, 1))
+	assert.Error(t, client.StartActiveExpire(100, 1))
+
+	assert.Eventually(t, func() bool {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+
+		_, ok := client.data["session"]
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, client.Close())
+	assert.Contains(t, client.data, "user:1")
+}
+
+func TestLazyExpire(t *testing.T) {
+	client := NewNative()
+	client.setString("session", "value", nowMs()-1)
+
+	keys, err := client.GetKeys(context.Background(), "*")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+	assert.Empty(t, client.expires)
+
+	info, err := client.Info(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", info["expired_lazy_keys"])
+	assert.Equal(t, RedisVersion, info["redis_version"])
+}
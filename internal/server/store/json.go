@@ -0,0 +1,477 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonLegacyRoot - root of the document in the legacy path syntax, it is used when the path is omitted
+const jsonLegacyRoot = "."
+
+var (
+	errJSONRoot   = errors.New("ERR new objects must be created at the root")
+	errJSONNoKey  = errors.New("ERR could not perform this operation on a key that doesn't exist")
+	errJSONNumber = errors.New("ERR increment is not a JSON number")
+)
+
+// jsonDoc - document of JSON.* commands. Objects are map[string]interface{}, arrays are *jsonArray, so that
+// they can be modified in place, numbers are json.Number, so that they are not rounded.
+type jsonDoc struct {
+	root interface{}
+	// length of the serialized document, used to estimate memory
+	size int64
+}
+
+// jsonArray - array of the document
+type jsonArray struct {
+	elements []interface{}
+}
+
+// clone - deep copy of the document
+func (d *jsonDoc) clone() *jsonDoc {
+	return &jsonDoc{root: cloneJSON(d.root), size: d.size}
+}
+
+// jsonSegment kinds
+const (
+	// jsonMember - .name or ['name']
+	jsonMember = iota
+	// jsonIndex - [n], negative index is counted from the end of the array
+	jsonIndex
+	// jsonWildcard - .* or [*], all members of the object or elements of the array
+	jsonWildcard
+)
+
+// jsonSegment - step of the path from the value to its children
+type jsonSegment struct {
+	kind  int
+	key   string
+	index int
+	// ..name, the step is made from the value and from all its descendants
+	recursive bool
+}
+
+// jsonPath - subset of JSONPath supported by JSON.* commands: $, .name, ['name'], [n], [*], .* and ..name.
+// Legacy paths of RedisJSON v1 start with . or with the name of the member, they match only one value and
+// commands reply with it instead of array of all matches.
+type jsonPath struct {
+	raw      string
+	segments []jsonSegment
+	legacy   bool
+}
+
+// jsonMatch - value matched by the path and its place in the document, so that it can be replaced or removed
+type jsonMatch struct {
+	value interface{}
+	// map[string]interface{} or *jsonArray holding the value, nil for the root
+	parent interface{}
+	key    string
+	index  int
+}
+
+// parseJSONPath - empty path is the legacy root
+func parseJSONPath(path string) (*jsonPath, error) {
+	p := &jsonPath{raw: path}
+
+	rest := path
+	switch {
+	case strings.HasPrefix(path, "$"):
+		rest = path[1:]
+	case path == "" || path == jsonLegacyRoot:
+		p.raw = jsonLegacyRoot
+		p.legacy = true
+		return p, nil
+	default:
+		p.legacy = true
+		if path[0] != '.' && path[0] != '[' {
+			rest = "." + path
+		}
+	}
+
+	for rest != "" {
+		seg := jsonSegment{}
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			seg.recursive = true
+			rest = rest[2:]
+		case rest[0] == '.':
+			rest = rest[1:]
+			if rest == "" || rest[0] == '[' {
+				return nil, invalidJSONPath(path)
+			}
+		case rest[0] != '[':
+			return nil, invalidJSONPath(path)
+		}
+
+		ok := false
+		if rest != "" && rest[0] == '[' {
+			rest, ok = parseJSONBracket(rest, &seg)
+		} else {
+			rest, ok = parseJSONName(rest, &seg)
+		}
+		if !ok {
+			return nil, invalidJSONPath(path)
+		}
+
+		p.segments = append(p.segments, seg)
+	}
+
+	return p, nil
+}
+
+// parseJSONName - name of the member after . or .., returns the rest of the path
+func parseJSONName(rest string, seg *jsonSegment) (string, bool) {
+	end := strings.IndexAny(rest, ".[")
+	if end < 0 {
+		end = len(rest)
+	}
+	if end == 0 {
+		return "", false
+	}
+
+	if rest[:end] == "*" {
+		seg.kind = jsonWildcard
+	} else {
+		seg.kind = jsonMember
+		seg.key = rest[:end]
+	}
+
+	return rest[end:], true
+}
+
+// parseJSONBracket - [n], [*], ['name'] or ["name"], returns the rest of the path
+func parseJSONBracket(rest string, seg *jsonSegment) (string, bool) {
+	if len(rest) > 1 && (rest[1] == '\'' || rest[1] == '"') {
+		end := strings.IndexByte(rest[2:], rest[1])
+		if end < 0 || !strings.HasPrefix(rest[2+end+1:], "]") {
+			return "", false
+		}
+
+		seg.kind = jsonMember
+		seg.key = rest[2 : 2+end]
+		return rest[2+end+2:], true
+	}
+
+	end := strings.IndexByte(rest, ']')
+	if end < 0 {
+		return "", false
+	}
+
+	inside := strings.TrimSpace(rest[1:end])
+	if inside == "*" {
+		seg.kind = jsonWildcard
+		return rest[end+1:], true
+	}
+
+	index, err := strconv.Atoi(inside)
+	if err != nil {
+		return "", false
+	}
+
+	seg.kind = jsonIndex
+	seg.index = index
+	return rest[end+1:], true
+}
+
+func invalidJSONPath(path string) error {
+	return fmt.Errorf("ERR invalid or unsupported path '%s'", path)
+}
+
+// isRoot - the path matches only the root of the document
+func (p *jsonPath) isRoot() bool {
+	return len(p.segments) == 0
+}
+
+// find - values matched by the path in the document order, keys of objects are visited in sorted order. The
+// legacy path matches only the first value.
+func (p *jsonPath) find(root interface{}) []jsonMatch {
+	matches := []jsonMatch{{value: root}}
+	for _, seg := range p.segments {
+		var next []jsonMatch
+		for _, m := range matches {
+			from := []jsonMatch{m}
+			if seg.recursive {
+				from = jsonDescendants(m, nil)
+			}
+
+			for _, f := range from {
+				next = append(next, seg.children(f)...)
+			}
+		}
+		matches = next
+	}
+
+	if p.legacy && len(matches) > 1 {
+		return matches[:1]
+	}
+
+	return matches
+}
+
+// parent - the path without its last segment, it is used to add the missing member
+func (p *jsonPath) parent() (*jsonPath, string, bool) {
+	if len(p.segments) == 0 {
+		return nil, "", false
+	}
+
+	last := p.segments[len(p.segments)-1]
+	if last.kind != jsonMember || last.recursive {
+		return nil, "", false
+	}
+
+	return &jsonPath{raw: p.raw, segments: p.segments[:len(p.segments)-1], legacy: p.legacy}, last.key, true
+}
+
+// children - values matched by the segment in the value of m
+func (seg jsonSegment) children(m jsonMatch) []jsonMatch {
+	switch v := m.value.(type) {
+	case map[string]interface{}:
+		switch seg.kind {
+		case jsonMember:
+			if val, ok := v[seg.key]; ok {
+				return []jsonMatch{{value: val, parent: v, key: seg.key}}
+			}
+		case jsonWildcard:
+			res := make([]jsonMatch, 0, len(v))
+			for _, key := range jsonKeys(v) {
+				res = append(res, jsonMatch{value: v[key], parent: v, key: key})
+			}
+			return res
+		}
+	case *jsonArray:
+		switch seg.kind {
+		case jsonIndex:
+			index := seg.index
+			if index < 0 {
+				index += len(v.elements)
+			}
+			if index >= 0 && index < len(v.elements) {
+				return []jsonMatch{{value: v.elements[index], parent: v, index: index}}
+			}
+		case jsonWildcard:
+			res := make([]jsonMatch, len(v.elements))
+			for i, val := range v.elements {
+				res[i] = jsonMatch{value: val, parent: v, index: i}
+			}
+			return res
+		}
+	}
+
+	return nil
+}
+
+// jsonDescendants - the value and all values nested in it
+func jsonDescendants(m jsonMatch, res []jsonMatch) []jsonMatch {
+	res = append(res, m)
+	for _, child := range (jsonSegment{kind: jsonWildcard}).children(m) {
+		res = jsonDescendants(child, res)
+	}
+
+	return res
+}
+
+// parseJSON - decodes the only JSON value, numbers are kept as json.Number
+func parseJSON(raw string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("ERR invalid JSON value: %v", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("ERR invalid JSON value: trailing data")
+	}
+
+	return toJSONArrays(value), nil
+}
+
+// toJSONArrays - replaces decoded slices by *jsonArray
+func toJSONArrays(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = toJSONArrays(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = toJSONArrays(val)
+		}
+		return &jsonArray{elements: v}
+	}
+
+	return value
+}
+
+// cloneJSON - deep copy of the value, so that the same value can be stored in several places
+func cloneJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			res[key] = cloneJSON(val)
+		}
+		return res
+	case *jsonArray:
+		res := &jsonArray{elements: make([]interface{}, len(v.elements))}
+		for i, val := range v.elements {
+			res.elements[i] = cloneJSON(val)
+		}
+		return res
+	}
+
+	return value
+}
+
+// marshalJSON - serializes the value without spaces, unlike json.Marshal characters <, > and & are not escaped
+func marshalJSON(value interface{}) string {
+	var b bytes.Buffer
+	writeJSON(&b, value)
+	return b.String()
+}
+
+func writeJSON(b *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		b.WriteString(v.String())
+	case string:
+		writeJSONString(b, v)
+	case map[string]interface{}:
+		b.WriteByte('{')
+		for i, key := range jsonKeys(v) {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeJSONString(b, key)
+			b.WriteByte(':')
+			writeJSON(b, v[key])
+		}
+		b.WriteByte('}')
+	case *jsonArray:
+		writeJSONArray(b, v.elements)
+	case []interface{}:
+		writeJSONArray(b, v)
+	}
+}
+
+func writeJSONArray(b *bytes.Buffer, elements []interface{}) {
+	b.WriteByte('[')
+	for i, val := range elements {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		writeJSON(b, val)
+	}
+	b.WriteByte(']')
+}
+
+func writeJSONString(b *bytes.Buffer, value string) {
+	encoder := json.NewEncoder(b)
+	encoder.SetEscapeHTML(false)
+	// encoding of string never fails, the trailing newline is removed
+	encoder.Encode(value)
+	b.Truncate(b.Len() - 1)
+}
+
+// jsonKeys - keys of the object in sorted order
+func jsonKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// jsonTypeName - type of the value in messages of errors, the same as JSON.TYPE of RedisJSON replies with
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if isJSONInteger(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case *jsonArray:
+		return "array"
+	}
+
+	return "unknown"
+}
+
+func wrongJSONType(expected string, value interface{}) error {
+	return fmt.Errorf("ERR wrong type of path value - expected %s but found %s", expected, jsonTypeName(value))
+}
+
+func isJSONInteger(number json.Number) bool {
+	return !strings.ContainsAny(number.String(), ".eE")
+}
+
+// addJSONNumbers - the sum of integers is integer unless it overflows, otherwise the sum is float, which is
+// written with the fraction, e.g. 2.0, like RedisJSON does
+func addJSONNumbers(a, b json.Number) (json.Number, error) {
+	if isJSONInteger(a) && isJSONInteger(b) {
+		x, errX := a.Int64()
+		y, errY := b.Int64()
+		if errX == nil && errY == nil {
+			if sum, err := addInt(x, y); err == nil {
+				return json.Number(strconv.FormatInt(sum, 10)), nil
+			}
+		}
+	}
+
+	x, err := a.Float64()
+	if err != nil {
+		return "", errIncrNaN
+	}
+	y, err := b.Float64()
+	if err != nil {
+		return "", errIncrNaN
+	}
+
+	sum := x + y
+	if math.IsNaN(sum) || math.IsInf(sum, 0) {
+		return "", errIncrNaN
+	}
+
+	res := strconv.FormatFloat(sum, 'g', -1, 64)
+	if !strings.ContainsAny(res, ".eE") {
+		res += ".0"
+	}
+
+	return json.Number(res), nil
+}
+
+// parseJSONNumber - increment of JSON.NUMINCRBY
+func parseJSONNumber(value string) (json.Number, error) {
+	parsed, err := parseJSON(value)
+	if err != nil {
+		return "", errJSONNumber
+	}
+
+	number, ok := parsed.(json.Number)
+	if !ok {
+		return "", errJSONNumber
+	}
+
+	return number, nil
+}
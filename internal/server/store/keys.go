@@ -0,0 +1,269 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+var errExpireCmd = errors.New("ERR invalid expire time in expire")
+
+// Type - name of the data type of the value, "none" if there is no such key
+func (n *Native) Type(ctx context.Context, key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	e := n.peek(key)
+	if e == nil {
+		return "none", nil
+	}
+
+	return e.typeName(), nil
+}
+
+// TTL - remaining time to live in seconds, -1 if the key does not expire, -2 if there is no such key
+func (n *Native) TTL(ctx context.Context, key string) (int64, error) {
+	ttl, err := n.PTTL(ctx, key)
+	if err != nil || ttl < 0 {
+		return ttl, err
+	}
+
+	return (ttl + 500) / 1000, nil
+}
+
+// PTTL - remaining time to live in milliseconds, -1 if the key does not expire, -2 if there is no such key
+func (n *Native) PTTL(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	e := n.peek(key)
+	switch {
+	case e == nil:
+		return -2, nil
+	case e.expireAt == 0:
+		return -1, nil
+	}
+
+	ttl := e.expireAt - nowMs()
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	return ttl, nil
+}
+
+// Expire - sets or replaces time to live of the key, false if there is no such key
+func (n *Native) Expire(ctx context.Context, key string, opts models.ExpireOptions) (bool, error) {
+	if err := checkExpire(key, opts); err != nil {
+		return false, err
+	}
+
+	defer n.lock(ctx)()
+
+	if n.peek(key) == nil {
+		return false, nil
+	}
+
+	return true, n.expire(key, expireOptionsAt(opts))
+}
+
+// Persist - removes time to live of the key, false if there is no such key or it does not expire
+func (n *Native) Persist(ctx context.Context, key string) (bool, error) {
+	if key == "" {
+		return false, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	if !n.persist(key) {
+		return false, nil
+	}
+
+	n.notify(notifyGeneric, "persist", key)
+	return true, n.propagate("PERSIST", key)
+}
+
+// Exists - number of existing keys, the same key given several times is counted several times
+func (n *Native) Exists(ctx context.Context, keys []string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, fmt.Errorf("Empty keys")
+	}
+
+	defer n.lock(ctx)()
+
+	var res int64
+	for _, key := range keys {
+		if n.peek(key) != nil {
+			res++
+		}
+	}
+
+	return res, nil
+}
+
+// Type ...
+func (r *Redis) Type(ctx context.Context, key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("Empty key")
+	}
+
+	return r.client.Type(ctx, key).Result()
+}
+
+// TTL ...
+func (r *Redis) TTL(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	ttl, err := r.client.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	return durationReply(ttl, time.Second), nil
+}
+
+// PTTL ...
+func (r *Redis) PTTL(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	ttl, err := r.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	return durationReply(ttl, time.Millisecond), nil
+}
+
+// Expire - version of the missing key is not created, see versionFunc
+func (r *Redis) Expire(ctx context.Context, key string, opts models.ExpireOptions) (bool, error) {
+	if err := checkExpire(key, opts); err != nil {
+		return false, err
+	}
+
+	var cmd *redis.BoolCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		if opts.TTL > 0 {
+			cmd = c.PExpire(ctx, key, opts.TTL.Duration())
+		} else {
+			at := expireOptionsAt(opts)
+			cmd = c.PExpireAt(ctx, key, time.Unix(0, at*int64(time.Millisecond)))
+		}
+		return cmd.Err()
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// Persist ...
+func (r *Redis) Persist(ctx context.Context, key string) (bool, error) {
+	if key == "" {
+		return false, fmt.Errorf("Empty key")
+	}
+
+	var cmd *redis.BoolCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.Persist(ctx, key)
+		return cmd.Err()
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// Exists ...
+func (r *Redis) Exists(ctx context.Context, keys []string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, fmt.Errorf("Empty keys")
+	}
+
+	return r.client.Exists(ctx, keys...).Result()
+}
+
+// checkExpire - exactly one kind of expiration has to be given
+func checkExpire(key string, opts models.ExpireOptions) error {
+	if key == "" {
+		return fmt.Errorf("Empty key")
+	}
+	if invalidExpiration(opts.Expiration) || opts.ExAt < 0 || opts.PxAt < 0 {
+		return errExpireCmd
+	}
+	if expirations(opts.TTL > 0, opts.ExpireAt != nil, opts.ExAt > 0, opts.PxAt > 0) != 1 {
+		return errSyntax
+	}
+
+	return nil
+}
+
+// expireOptionsAt - unix time in milliseconds when the key expires
+func expireOptionsAt(opts models.ExpireOptions) int64 {
+	switch {
+	case opts.TTL > 0 || opts.ExpireAt != nil:
+		return expirationAt(opts.Expiration)
+	case opts.ExAt > 0:
+		return opts.ExAt * 1000
+	}
+
+	return opts.PxAt
+}
+
+// checkExpiration - expiration of SetHash, SetString and SetList, only one of TTL and ExpireAt may be set
+func checkExpiration(exp models.Expiration) error {
+	if invalidExpiration(exp) {
+		return errExpireSet
+	}
+	if exp.TTL > 0 && exp.ExpireAt != nil {
+		return errSyntax
+	}
+
+	return nil
+}
+
+// invalidExpiration - negative TTL or ExpireAt not after the epoch, like non positive EXAT of redis
+func invalidExpiration(exp models.Expiration) bool {
+	return exp.TTL < 0 || (exp.ExpireAt != nil && unixMs(*exp.ExpireAt) <= 0)
+}
+
+// expirationAt - unix time in milliseconds when the key expires, 0 if the expiration is not set
+func expirationAt(exp models.Expiration) int64 {
+	switch {
+	case exp.TTL > 0:
+		return expireAt(exp.TTL.Duration())
+	case exp.ExpireAt != nil:
+		return unixMs(*exp.ExpireAt)
+	}
+
+	return 0
+}
+
+// unixMs - unlike UnixNano it does not overflow for dates after 2262
+func unixMs(t time.Time) int64 {
+	return t.Unix()*1000 + int64(t.Nanosecond())/int64(time.Millisecond)
+}
+
+// durationReply - TTL or PTTL in the unit, negative replies of redis are kept as they are
+func durationReply(ttl, unit time.Duration) int64 {
+	if ttl < 0 {
+		return int64(ttl)
+	}
+
+	return int64(ttl / unit)
+}
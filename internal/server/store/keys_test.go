@@ -0,0 +1,236 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNativeKeys(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	_, err := client.SetString(ctx, "name", "ivan", models.Expiration{})
+	assert.NoError(t, err)
+	assert.NoError(t, client.SetHash(ctx, "user", map[string]interface{}{"role": "admin"}, models.Expiration{TTL: models.TTL(time.Minute)}))
+
+	type testCase struct {
+		name    string
+		key     string
+		keyType string
+		ttl     int64
+		pttl    int64
+	}
+
+	tCases := []testCase{
+		{name: "Without ttl", key: "name", keyType: "string", ttl: -1, pttl: -1},
+		{name: "With ttl", key: "user", keyType: "hash", ttl: 60, pttl: 60000},
+		{name: "Missing key", key: "missing", keyType: "none", ttl: -2, pttl: -2},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keyType, err := client.Type(ctx, tc.key)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.keyType, keyType)
+
+			ttl, err := client.TTL(ctx, tc.key)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.ttl, ttl)
+
+			pttl, err := client.PTTL(ctx, tc.key)
+			assert.NoError(t, err)
+			assert.InDelta(t, tc.pttl, pttl, 1000)
+		})
+	}
+
+	exists, err := client.Exists(ctx, []string{"name", "user", "missing", "name"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), exists)
+
+	set, err := client.Expire(ctx, "name", models.ExpireOptions{PxAt: nowMs() + 10000})
+	assert.NoError(t, err)
+	assert.True(t, set)
+	ttl, err := client.TTL(ctx, "name")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), ttl)
+
+	persisted, err := client.Persist(ctx, "name")
+	assert.NoError(t, err)
+	assert.True(t, persisted)
+	persisted, err = client.Persist(ctx, "name")
+	assert.NoError(t, err)
+	assert.False(t, persisted)
+
+	set, err = client.Expire(ctx, "missing", models.ExpireOptions{Expiration: models.Expiration{TTL: models.TTL(time.Minute)}})
+	assert.NoError(t, err)
+	assert.False(t, set)
+
+	// time in the past removes the key
+	set, err = client.Expire(ctx, "user", models.ExpireOptions{ExAt: 1})
+	assert.NoError(t, err)
+	assert.True(t, set)
+	keyType, err := client.Type(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "none", keyType)
+
+	_, err = client.Expire(ctx, "name", models.ExpireOptions{})
+	assert.Equal(t, errSyntax, err)
+	_, err = client.Expire(ctx, "name", models.ExpireOptions{Expiration: models.Expiration{TTL: models.TTL(time.Minute)}, PxAt: 1})
+	assert.Equal(t, errSyntax, err)
+	_, err = client.Expire(ctx, "name", models.ExpireOptions{ExAt: -1})
+	assert.Equal(t, errExpireCmd, err)
+}
+
+func TestNativeExpireAOF(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aof")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "appendonly.aof")
+	ctx := context.Background()
+
+	client := NewNative()
+	assert.NoError(t, client.OpenAOF(path, FsyncAlways))
+
+	at := nowMs() + int64(time.Hour/time.Millisecond)
+	for _, key := range []string{"expiring", "persistent", "removed"} {
+		_, err = client.SetString(ctx, key, "value", models.Expiration{})
+		assert.NoError(t, err)
+	}
+	_, err = client.Expire(ctx, "expiring", models.ExpireOptions{PxAt: at})
+	assert.NoError(t, err)
+	_, err = client.Expire(ctx, "persistent", models.ExpireOptions{Expiration: models.Expiration{TTL: models.TTL(time.Minute)}})
+	assert.NoError(t, err)
+	_, err = client.Persist(ctx, "persistent")
+	assert.NoError(t, err)
+	_, err = client.Expire(ctx, "removed", models.ExpireOptions{PxAt: 1})
+	assert.NoError(t, err)
+	assert.NoError(t, client.Close())
+
+	restored := NewNative()
+	assert.NoError(t, restored.OpenAOF(path, FsyncNo))
+	defer restored.Close()
+
+	pttl, err := restored.PTTL(ctx, "expiring")
+	assert.NoError(t, err)
+	assert.InDelta(t, at-nowMs(), pttl, 1000)
+
+	ttl, err := restored.TTL(ctx, "persistent")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), ttl)
+
+	exists, err := restored.Exists(ctx, []string{"removed"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), exists)
+}
+
+func TestKeyOperations(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+	ctx := context.Background()
+
+	mock.ExpectTTL("user").SetVal(-2)
+	ttl, err := client.TTL(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-2), ttl)
+
+	mock.ExpectPTTL("user").SetVal(1500 * time.Millisecond)
+	ttl, err = client.PTTL(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1500), ttl)
+
+	// version of the missing key is removed by the script
+	mock.ExpectPExpire("missing", time.Minute).SetVal(false)
+	expectVersion(mock, "missing").SetVal(int64(1))
+	set, err := client.Expire(ctx, "missing", models.ExpireOptions{Expiration: models.Expiration{TTL: models.TTL(time.Minute)}})
+	assert.NoError(t, err)
+	assert.False(t, set)
+
+	mock.ExpectPExpireAt("user", time.Unix(1700000000, 0)).SetVal(true)
+	expectVersion(mock, "user").SetVal(int64(2))
+	set, err = client.Expire(ctx, "user", models.ExpireOptions{ExAt: 1700000000})
+	assert.NoError(t, err)
+	assert.True(t, set)
+
+	_, err = client.Expire(ctx, "user", models.ExpireOptions{})
+	assert.Equal(t, errSyntax, err)
+
+	_, err = client.Exists(ctx, nil)
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWriteExpiration(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	past := time.Unix(1, 0)
+	future := time.Now().Add(time.Hour)
+
+	type testCase struct {
+		name   string
+		key    string
+		exp    models.Expiration
+		err    error
+		exists int64
+		pttl   int64
+	}
+
+	tCases := []testCase{
+		{name: "Without expiration", key: "plain", exists: 1, pttl: -1},
+		{name: "TTL", key: "session", exp: models.Expiration{TTL: models.TTL(1500 * time.Millisecond)}, exists: 1, pttl: 1500},
+		{name: "Expire at", key: "token", exp: models.Expiration{ExpireAt: &future}, exists: 1, pttl: 3600000},
+		{name: "Expire at in the past", key: "expired", exp: models.Expiration{ExpireAt: &past}, exists: 0, pttl: -2},
+		{name: "Negative TTL", key: "negative", exp: models.Expiration{TTL: -1}, err: errExpireSet, pttl: -2},
+		{name: "TTL with expire at", key: "both", exp: models.Expiration{TTL: models.TTL(time.Second), ExpireAt: &future}, err: errSyntax, pttl: -2},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := client.SetList(ctx, tc.key, []interface{}{"a"}, tc.exp)
+			assert.Equal(t, tc.err, err)
+
+			exists, err := client.Exists(ctx, []string{tc.key})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.exists, exists)
+
+			pttl, err := client.PTTL(ctx, tc.key)
+			assert.NoError(t, err)
+			assert.InDelta(t, tc.pttl, pttl, 1000)
+		})
+	}
+
+	db, mock := newClientMock()
+	redisClient := Redis{
+		client: db,
+	}
+
+	// ttl of the string is set by SET itself, the version gets ttl of the key from the script
+	mock.ExpectSet("name", "ivan", 1500*time.Millisecond).SetVal("OK")
+	expectVersion(mock, "name").SetVal(int64(1))
+	_, err := redisClient.SetString(ctx, "name", "ivan", models.Expiration{TTL: models.TTL(1500 * time.Millisecond)})
+	assert.NoError(t, err)
+
+	mock.ExpectRPush("list", []string{`{"Dtype":"string","Data":"a"}`}).SetVal(1)
+	mock.ExpectPExpireAt("list", future).SetVal(true)
+	expectVersion(mock, "list").SetVal(int64(2))
+	err = redisClient.SetList(ctx, "list", []interface{}{"a"}, models.Expiration{ExpireAt: &future})
+	assert.NoError(t, err)
+
+	_, err = redisClient.SetString(ctx, "name", "ivan", models.Expiration{TTL: -1})
+	assert.Equal(t, errExpireSet, err)
+	err = redisClient.SetHash(ctx, "user", map[string]interface{}{"name": "ivan"}, models.Expiration{TTL: models.TTL(time.Second), ExpireAt: &future})
+	assert.Equal(t, errSyntax, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
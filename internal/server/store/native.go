@@ -0,0 +1,785 @@
+package store
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	// ErrWrongType - returned when the key holds a value of another data type
+	ErrWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+	errNoSuchKey       = errors.New("ERR no such key")
+	errIndexOutOfRange = errors.New("ERR index out of range")
+)
+
+// Native - in-memory implementation of RedisImpl, works without external redis
+type Native struct {
+	mu   sync.Mutex
+	data map[string]*entry
+	// keys ordered by scanHash, so that SCAN resumes from the cursor without walking all keys
+	keys *skiplist
+	// keys with time to live, they are sampled by active expiration
+	expires     map[string]struct{}
+	expireStats expireStats
+	// stops active expiration
+	stopExpire chan struct{}
+	expireDone chan struct{}
+	// every write is appended to the file when persistence is enabled
+	aof *aof
+	// path of RDB file written by Save and BGSave
+	dbFilename string
+	// entries being saved, they are copied before modification while saving is in progress
+	snapshot   map[string]*entry
+	saveStatus models.SaveStatus
+	saving     sync.WaitGroup
+	// estimated number of bytes used by all keys
+	used int64
+	// keys are evicted according to the policy when used memory exceeds maxmemory, 0 - no limit
+	maxmemory        int64
+	maxmemoryPolicy  string
+	maxmemorySamples int
+	evictedKeys      int64
+	// subscribers of channels and patterns
+	pubsub *broker
+	// classes of keyspace events published by writes, see notify.go
+	notifyFlags int
+	// transaction holding the lock, nil if commands are executed one by one
+	tx *nativeTx
+	// the last version given to the created or modified key
+	version int64
+	// clients of BLPOP, BRPOP and BLMOVE queued by keys in order of blocking, see native_blocking.go
+	blocked map[string][]*blockedClient
+	// keys with blocked clients pushed to while the lock is held
+	ready []string
+}
+
+// entry - value stored by the key
+type entry struct {
+	// string, map[string]string (hash), []string (list of encoded models.ListElement),
+	// map[string]struct{} (set), *zset (sorted set), *stream or *jsonDoc (document of JSON.* commands)
+	value interface{}
+	// unix time in milliseconds, 0 - key does not expire
+	expireAt int64
+	// estimated number of bytes used by the key and the value
+	size int64
+	// unix time in milliseconds of the last access, used by LRU eviction
+	access int64
+	// logarithmic counter of accesses and time of its last decrement in minutes, used by LFU eviction
+	lfuCounter uint8
+	lfuTime    int64
+	// incremented by every write of the key, see version.go
+	version int64
+}
+
+// clone - copies the entry with its value
+func (e *entry) clone() *entry {
+	res := &entry{}
+	*res = *e
+
+	switch value := e.value.(type) {
+	case map[string]string:
+		hash := make(map[string]string, len(value))
+		for field, val := range value {
+			hash[field] = val
+		}
+		res.value = hash
+	case []string:
+		res.value = append([]string{}, value...)
+	case map[string]struct{}:
+		set := make(map[string]struct{}, len(value))
+		for member := range value {
+			set[member] = struct{}{}
+		}
+		res.value = set
+	case *zset:
+		res.value = value.clone()
+	case *stream:
+		res.value = value.clone()
+	case *jsonDoc:
+		res.value = value.clone()
+	}
+
+	return res
+}
+
+// typeName - name of the data type of the value, the same as returned by TYPE command
+func (e *entry) typeName() string {
+	switch e.value.(type) {
+	case string:
+		return "string"
+	case map[string]string:
+		return "hash"
+	case []string:
+		return "list"
+	case map[string]struct{}:
+		return "set"
+	case *zset:
+		return "zset"
+	case *stream:
+		return "stream"
+	case *jsonDoc:
+		// the same name of the type as of RedisJSON module
+		return "ReJSON-RL"
+	}
+
+	return "none"
+}
+
+// NewNative - helper to init native storage engine
+func NewNative() *Native {
+	return &Native{
+		data:             make(map[string]*entry),
+		keys:             newSkiplist(),
+		expires:          make(map[string]struct{}),
+		dbFilename:       "dump.rdb",
+		maxmemoryPolicy:  PolicyNoEviction,
+		maxmemorySamples: defaultMaxmemorySamples,
+		pubsub:           newBroker(),
+		saveStatus: models.SaveStatus{
+			Success: true,
+		},
+	}
+}
+
+// SetHash ...
+func (n *Native) SetHash(ctx context.Context, key string, value map[string]interface{}, exp models.Expiration) error {
+	if key == "" || len(value) == 0 {
+		return fmt.Errorf("Empty key or field")
+	}
+	if err := checkExpiration(exp); err != nil {
+		return err
+	}
+
+	fields, err := formatHash(value)
+	if err != nil {
+		return err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return err
+	}
+
+	if _, err := n.hset(key, fields); err != nil {
+		return err
+	}
+
+	n.notify(notifyHash, "hset", key)
+	if err := n.propagate(hashCommand(key, fields)...); err != nil {
+		return err
+	}
+
+	if at := expirationAt(exp); at != 0 {
+		return n.expire(key, at)
+	}
+
+	return nil
+}
+
+// SetString ...
+func (n *Native) SetString(ctx context.Context, key, value string, exp models.Expiration) (string, error) {
+	if key == "" || value == "" {
+		return "", fmt.Errorf("Empty key or field")
+	}
+	if err := checkExpiration(exp); err != nil {
+		return "", err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return "", err
+	}
+
+	at := expirationAt(exp)
+	if at == 0 {
+		n.setString(key, value, 0)
+		n.notify(notifyString, "set", key)
+		return "OK", n.propagate("SET", key, value)
+	}
+
+	n.setString(key, value, at)
+	n.notify(notifyString, "set", key)
+	n.notify(notifyGeneric, "expire", key)
+	return "OK", n.propagate("SET", key, value, "PXAT", strconv.FormatInt(at, 10))
+}
+
+// SetList ...
+func (n *Native) SetList(ctx context.Context, key string, value []interface{}, exp models.Expiration) error {
+	if key == "" || len(value) == 0 {
+		return fmt.Errorf("Empty key or field")
+	}
+	if err := checkExpiration(exp); err != nil {
+		return err
+	}
+
+	strSlice := make([]string, len(value))
+	for i, val := range value {
+		serialized, err := encodeListElement(val)
+		if err != nil {
+			return err
+		}
+		strSlice[i] = serialized
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return err
+	}
+
+	if _, err := n.rpush(key, strSlice); err != nil {
+		return err
+	}
+
+	n.notify(notifyList, "rpush", key)
+	if err := n.propagate(append([]string{"RPUSH", key}, strSlice...)...); err != nil {
+		return err
+	}
+
+	if at := expirationAt(exp); at != 0 {
+		return n.expire(key, at)
+	}
+
+	return nil
+}
+
+// GetHash ...
+func (n *Native) GetHash(ctx context.Context, key string) (map[string]string, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	hash, err := n.hash(key)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]string, len(hash))
+	for field, val := range hash {
+		res[field] = val
+	}
+
+	return res, nil
+}
+
+// GetString ...
+func (n *Native) GetString(ctx context.Context, key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	e := n.lookup(key)
+	if e == nil {
+		return "", redis.Nil
+	}
+
+	str, ok := e.value.(string)
+	if !ok {
+		return "", ErrWrongType
+	}
+
+	return str, nil
+}
+
+// GetList ...
+func (n *Native) GetList(ctx context.Context, key string) ([]interface{}, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+
+	return n.LRange(ctx, key, 0, -1)
+}
+
+// GetKeys - all keys matching the pattern, see GetKeysLimit
+func (n *Native) GetKeys(ctx context.Context, pattern string) ([]string, error) {
+	return n.GetKeysLimit(ctx, pattern, 0)
+}
+
+// Delete ...
+func (n *Native) Delete(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	if !n.del(key) {
+		return 0, nil
+	}
+
+	n.notify(notifyGeneric, "del", key)
+	return 1, n.propagate("DEL", key)
+}
+
+// HGet ...
+func (n *Native) HGet(ctx context.Context, key string, field string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	hash, err := n.hash(key)
+	if err != nil {
+		return "", err
+	}
+
+	val, ok := hash[field]
+	if !ok {
+		return "", redis.Nil
+	}
+
+	return val, nil
+}
+
+// HSet ...
+func (n *Native) HSet(ctx context.Context, key string, values map[string]interface{}) (int64, error) {
+	if key == "" || len(values) == 0 {
+		return 0, fmt.Errorf("Empty key of value")
+	}
+
+	fields, err := formatHash(values)
+	if err != nil {
+		return 0, err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return 0, err
+	}
+
+	added, err := n.hset(key, fields)
+	if err != nil {
+		return 0, err
+	}
+
+	n.notify(notifyHash, "hset", key)
+	return added, n.propagate(hashCommand(key, fields)...)
+}
+
+// LRange ...
+func (n *Native) LRange(ctx context.Context, key string, start, stop int64) ([]interface{}, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	list, err := n.list(key)
+	if err != nil {
+		return nil, err
+	}
+
+	start, stop, ok := normalizeRange(int64(len(list)), start, stop)
+	if !ok {
+		return []interface{}{}, nil
+	}
+
+	return decodeListElements(list[start : stop+1])
+}
+
+// LSet ...
+func (n *Native) LSet(ctx context.Context, key string, index int64, value interface{}) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("Empty key")
+	}
+
+	valueToInsert, err := encodeListElement(value)
+	if err != nil {
+		return "", err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return "", err
+	}
+
+	if err := n.lset(key, index, valueToInsert); err != nil {
+		return "", err
+	}
+
+	n.notify(notifyList, "lset", key)
+	return "OK", n.propagate("LSET", key, strconv.FormatInt(index, 10), valueToInsert)
+}
+
+// setString - stores string, previous value and time to live of the key are discarded
+func (n *Native) setString(key, value string, expireAt int64) {
+	if old, ok := n.data[key]; ok {
+		n.used -= old.size
+	}
+
+	e := n.newEntry(key, value)
+	e.expireAt = expireAt
+	n.resize(e, int64(len(value)))
+	n.putEntry(key, e)
+
+	if expireAt != 0 {
+		n.expires[key] = struct{}{}
+	} else {
+		delete(n.expires, key)
+	}
+}
+
+// hset - sets fields of the hash, returns number of added fields
+func (n *Native) hset(key string, fields map[string]string) (int64, error) {
+	hash, err := n.hashForWrite(key)
+	if err != nil {
+		return 0, err
+	}
+
+	e := n.data[key]
+	var added int64
+	for field, val := range fields {
+		if old, ok := hash[field]; ok {
+			n.resize(e, int64(len(val)-len(old)))
+		} else {
+			n.resize(e, fieldOverhead+int64(len(field)+len(val)))
+			added++
+		}
+		hash[field] = val
+	}
+
+	return added, nil
+}
+
+// rpush - appends encoded elements to the list, returns length of the list
+func (n *Native) rpush(key string, values []string) (int64, error) {
+	e := n.lookupWrite(key)
+	if e == nil {
+		e = n.newEntry(key, []string{})
+		n.putEntry(key, e)
+	}
+
+	list, ok := e.value.([]string)
+	if !ok {
+		return 0, ErrWrongType
+	}
+	e.value = append(list, values...)
+	n.signalReady(key)
+
+	for _, el := range values {
+		n.resize(e, elementOverhead+int64(len(el)))
+	}
+
+	return int64(len(list) + len(values)), nil
+}
+
+// lset - replaces encoded element of the list
+func (n *Native) lset(key string, index int64, value string) error {
+	e := n.lookupWrite(key)
+	if e == nil {
+		return errNoSuchKey
+	}
+
+	list, ok := e.value.([]string)
+	if !ok {
+		return ErrWrongType
+	}
+
+	if index < 0 {
+		index += int64(len(list))
+	}
+	if index < 0 || index >= int64(len(list)) {
+		return errIndexOutOfRange
+	}
+
+	n.resize(e, int64(len(value)-len(list[index])))
+	list[index] = value
+	return nil
+}
+
+// del - removes the key, returns false if there was no such key
+func (n *Native) del(key string) bool {
+	if n.lookup(key) == nil {
+		return false
+	}
+
+	n.remove(key)
+	return true
+}
+
+// putEntry - stores the entry by the key, the new key is added to the index of SCAN
+func (n *Native) putEntry(key string, e *entry) {
+	if _, ok := n.data[key]; !ok {
+		n.keys.insert(float64(scanHash(key)), key)
+	}
+	n.data[key] = e
+}
+
+// remove - removes the key together with its time to live
+func (n *Native) remove(key string) {
+	if e, ok := n.data[key]; ok {
+		n.used -= e.size
+		n.keys.delete(float64(scanHash(key)), key)
+	}
+
+	delete(n.data, key)
+	delete(n.expires, key)
+}
+
+// pexpireAt - sets unix time in milliseconds when the key expires, returns false if there is no such key
+func (n *Native) pexpireAt(key string, at int64) bool {
+	e := n.lookupWrite(key)
+	if e == nil {
+		return false
+	}
+
+	if at <= nowMs() {
+		n.remove(key)
+		return true
+	}
+
+	e.expireAt = at
+	n.expires[key] = struct{}{}
+	return true
+}
+
+// propagate - appends command to the append only file, if persistence is enabled
+func (n *Native) propagate(args ...string) error {
+	if n.aof == nil {
+		return nil
+	}
+
+	if n.tx != nil && !n.tx.multi {
+		n.tx.multi = true
+		if err := n.aof.write([]string{"MULTI"}); err != nil {
+			return err
+		}
+	}
+
+	return n.aof.write(args)
+}
+
+// lookup - returns entry of the key or nil if there is no such key. Expired keys are removed on access.
+func (n *Native) lookup(key string) *entry {
+	e := n.peek(key)
+	if e != nil {
+		n.touch(e)
+	}
+
+	return e
+}
+
+// peek - the same as lookup, but the access is not counted by LRU and LFU
+func (n *Native) peek(key string) *entry {
+	e, ok := n.data[key]
+	if !ok {
+		return nil
+	}
+
+	if e.expireAt != 0 && e.expireAt <= nowMs() {
+		n.expireKey(key, false)
+		return nil
+	}
+
+	return e
+}
+
+// lookupWrite - returns entry of the key which may be modified in place. If the entry is being saved,
+// it is replaced by a copy, so that the snapshot keeps the value it had when saving started.
+func (n *Native) lookupWrite(key string) *entry {
+	e := n.lookup(key)
+	if e == nil || n.snapshot == nil || n.snapshot[key] != e {
+		return e
+	}
+
+	e = e.clone()
+	n.putEntry(key, e)
+	return e
+}
+
+// hash - returns hash stored by the key, missing key is treated as an empty hash
+func (n *Native) hash(key string) (map[string]string, error) {
+	e := n.lookup(key)
+	if e == nil {
+		return map[string]string{}, nil
+	}
+
+	hash, ok := e.value.(map[string]string)
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	return hash, nil
+}
+
+// hashForWrite - returns hash stored by the key, creates it if there is no such key
+func (n *Native) hashForWrite(key string) (map[string]string, error) {
+	e := n.lookupWrite(key)
+	if e == nil {
+		e = n.newEntry(key, map[string]string{})
+		n.putEntry(key, e)
+	}
+
+	hash, ok := e.value.(map[string]string)
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	return hash, nil
+}
+
+// list - returns list stored by the key, missing key is treated as an empty list
+func (n *Native) list(key string) ([]string, error) {
+	e := n.lookup(key)
+	if e == nil {
+		return []string{}, nil
+	}
+
+	list, ok := e.value.([]string)
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	return list, nil
+}
+
+// expire - sets unix time in milliseconds when the key expires, the same way as PEXPIREAT: time in the past
+// removes the key
+func (n *Native) expire(key string, at int64) error {
+	if at <= nowMs() {
+		if !n.del(key) {
+			return nil
+		}
+		n.notify(notifyGeneric, "del", key)
+		return n.propagate("DEL", key)
+	}
+
+	if !n.pexpireAt(key, at) {
+		return nil
+	}
+
+	n.notify(notifyGeneric, "expire", key)
+	return n.propagate("PEXPIREAT", key, strconv.FormatInt(at, 10))
+}
+
+// hashCommand - HSET command with fields sorted by name
+func hashCommand(key string, fields map[string]string) []string {
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	args := make([]string, 0, len(fields)*2+2)
+	args = append(args, "HSET", key)
+	for _, field := range names {
+		args = append(args, field, fields[field])
+	}
+
+	return args
+}
+
+// normalizeRange - converts redis-style start/stop indexes, which may be negative, into slice bounds
+func normalizeRange(length, start, stop int64) (int64, int64, bool) {
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+
+	if start > stop || start >= length {
+		return 0, 0, false
+	}
+
+	return start, stop, true
+}
+
+// formatHash - converts values of the hash to strings the same way go-redis does
+func formatHash(values map[string]interface{}) (map[string]string, error) {
+	res := make(map[string]string, len(values))
+	for field, val := range values {
+		str, err := formatArg(val)
+		if err != nil {
+			return nil, err
+		}
+		res[field] = str
+	}
+
+	return res, nil
+}
+
+// formatArg - converts argument to string the same way go-redis writes it to redis
+func formatArg(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 64), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return "", fmt.Errorf("Can't marshal %T", value)
+	}
+}
+
+// expireAt - converts time to live into unix time in milliseconds
+func expireAt(ttl time.Duration) int64 {
+	return nowMs() + int64(ttl/time.Millisecond)
+}
+
+func nowMs() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,235 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+var errTimeoutNegative = errors.New("ERR timeout is negative")
+
+// blockedClient - client of BLPOP, BRPOP or BLMOVE waiting until any of its lists becomes non-empty
+type blockedClient struct {
+	keys []string
+	// pops the element of the ready key, lock is held by the caller
+	serve func(key string) (interface{}, error)
+	// buffered, receives the only reply of the served client
+	reply chan blockedReply
+}
+
+// blockedReply - the element popped for the blocked client and the key of its list
+type blockedReply struct {
+	key   string
+	value interface{}
+	err   error
+}
+
+// BLPop - LPop of one element of the first non-empty list, otherwise waits until an element is pushed to any
+// of keys. 0 timeout waits until the context is done. redis.Nil on timeout.
+func (n *Native) BLPop(ctx context.Context, keys []string, timeout time.Duration) (*models.BPopResult, error) {
+	return n.bpop(ctx, keys, timeout, true)
+}
+
+// BRPop - the same as BLPop, but the element is popped from the tail
+func (n *Native) BRPop(ctx context.Context, keys []string, timeout time.Duration) (*models.BPopResult, error) {
+	return n.bpop(ctx, keys, timeout, false)
+}
+
+// BLMove - LMove waiting for the element of source the same way as BLPop
+func (n *Native) BLMove(ctx context.Context, source, destination, from, to string, timeout time.Duration) (interface{}, error) {
+	if source == "" || destination == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+	if err := checkListEnds(from, to); err != nil {
+		return nil, err
+	}
+
+	_, value, err := n.block(ctx, []string{source}, timeout, func(key string) (interface{}, error) {
+		element, err := n.lmove(source, destination, from == ListLeft, to == ListLeft)
+		if err != nil {
+			return nil, err
+		}
+
+		n.notify(notifyList, from[:1]+"pop", source)
+		n.notifyDeleted(source)
+		n.notify(notifyList, to[:1]+"push", destination)
+		if err := n.propagate("LMOVE", source, destination, strings.ToUpper(from), strings.ToUpper(to)); err != nil {
+			return nil, err
+		}
+
+		return decodeListElement(element)
+	})
+
+	return value, err
+}
+
+// bpop - BLPOP or BRPOP
+func (n *Native) bpop(ctx context.Context, keys []string, timeout time.Duration, left bool) (*models.BPopResult, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("Empty key")
+	}
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("Empty key")
+		}
+	}
+
+	command := "RPOP"
+	if left {
+		command = "LPOP"
+	}
+
+	key, value, err := n.block(ctx, keys, timeout, func(key string) (interface{}, error) {
+		popped, err := n.lpop(key, 1, left)
+		if err != nil {
+			return nil, err
+		}
+
+		n.notify(notifyList, strings.ToLower(command), key)
+		n.notifyDeleted(key)
+		if err := n.propagate(command, key, "1"); err != nil {
+			return nil, err
+		}
+
+		return decodeListElement(popped[0])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BPopResult{Key: key, Value: value}, nil
+}
+
+// block - serves the first non-empty list of keys at once. Otherwise the client is queued on every key until
+// serveBlocked hands it an element, the timeout elapses or the context is done. Commands of the transaction
+// are never blocked, like in redis.
+func (n *Native) block(ctx context.Context, keys []string, timeout time.Duration, serve func(key string) (interface{}, error)) (string, interface{}, error) {
+	if timeout < 0 {
+		return "", nil, errTimeoutNegative
+	}
+
+	unlock := n.lock(ctx)
+	for _, key := range keys {
+		list, err := n.list(key)
+		if err != nil {
+			unlock()
+			return "", nil, err
+		}
+
+		if len(list) > 0 {
+			value, err := serve(key)
+			unlock()
+			return key, value, err
+		}
+	}
+
+	if n.tx != nil {
+		unlock()
+		return "", nil, redis.Nil
+	}
+
+	client := &blockedClient{keys: keys, serve: serve, reply: make(chan blockedReply, 1)}
+	if n.blocked == nil {
+		n.blocked = make(map[string][]*blockedClient)
+	}
+	for _, key := range keys {
+		n.blocked[key] = append(n.blocked[key], client)
+	}
+	unlock()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	var err error
+	select {
+	case reply := <-client.reply:
+		return reply.key, reply.value, reply.err
+	case <-expired:
+		err = redis.Nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	defer n.lock(ctx)()
+
+	// the client may be served while the lock is acquired, the element is already popped then
+	select {
+	case reply := <-client.reply:
+		return reply.key, reply.value, reply.err
+	default:
+	}
+
+	n.unblock(client)
+	return "", nil, err
+}
+
+// unblock - removes the client from queues of all its keys, lock has to be held by the caller
+func (n *Native) unblock(client *blockedClient) {
+	for _, key := range client.keys {
+		queue := n.blocked[key]
+		for i, c := range queue {
+			if c == client {
+				queue = append(queue[:i:i], queue[i+1:]...)
+				break
+			}
+		}
+
+		if len(queue) == 0 {
+			delete(n.blocked, key)
+		} else {
+			n.blocked[key] = queue
+		}
+	}
+}
+
+// signalReady - the element is pushed to the list, clients blocked on the key are served when the lock is
+// released, like by signalKeyAsReady of redis. Lock has to be held by the caller.
+func (n *Native) signalReady(key string) {
+	if len(n.blocked[key]) == 0 {
+		return
+	}
+
+	for _, ready := range n.ready {
+		if ready == key {
+			return
+		}
+	}
+	n.ready = append(n.ready, key)
+}
+
+// serveBlocked - elements of ready lists are handed to the clients in order of blocking, so that the client
+// blocked first is served first. Lists modified by serving, e.g. destination of BLMOVE, become ready as well.
+// Lock has to be held by the caller.
+func (n *Native) serveBlocked() {
+	for len(n.ready) > 0 {
+		key := n.ready[0]
+		n.ready = n.ready[1:]
+
+		for len(n.blocked[key]) > 0 {
+			if list, err := n.list(key); err != nil || len(list) == 0 {
+				break
+			}
+
+			client := n.blocked[key][0]
+			n.unblock(client)
+
+			value, err := client.serve(key)
+			client.reply <- blockedReply{key: key, value: value, err: err}
+		}
+	}
+}
+
+// unlock - serves clients blocked on the lists which became ready while the lock was held and releases it
+func (n *Native) unlock() {
+	n.serveBlocked()
+	n.mu.Unlock()
+}
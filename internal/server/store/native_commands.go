@@ -0,0 +1,491 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+)
+
+// nativeCommand - command applied directly to data of the native engine, it is used to replay append only file
+type nativeCommand struct {
+	handler func(n *Native, args []string) (interface{}, error)
+	// number of arguments including the name of command, negative value means "at least"
+	arity int
+}
+
+var nativeCommands map[string]nativeCommand
+
+func init() {
+	nativeCommands = map[string]nativeCommand{
+		"set":            {handler: nativeSet, arity: -3},
+		"append":         {handler: nativeAppend, arity: 3},
+		"setrange":       {handler: nativeSetRange, arity: 4},
+		"hset":           {handler: nativeHSet, arity: -4},
+		"hdel":           {handler: nativeHDel, arity: -3},
+		"rpush":          {handler: nativeRPush, arity: -3},
+		"lset":           {handler: nativeLSet, arity: 4},
+		"lpush":          {handler: nativeLPush, arity: -3},
+		"lpop":           {handler: nativeLPop, arity: 3},
+		"rpop":           {handler: nativeRPop, arity: 3},
+		"linsert":        {handler: nativeLInsert, arity: 5},
+		"lrem":           {handler: nativeLRem, arity: 4},
+		"ltrim":          {handler: nativeLTrim, arity: 4},
+		"lmove":          {handler: nativeLMove, arity: 5},
+		"sadd":           {handler: nativeSAdd, arity: -3},
+		"srem":           {handler: nativeSRem, arity: -3},
+		"zadd":           {handler: nativeZAdd, arity: -4},
+		"zrem":           {handler: nativeZRem, arity: -3},
+		"xadd":           {handler: nativeXAdd, arity: -5},
+		"xtrim":          {handler: nativeXTrim, arity: 4},
+		"xsetid":         {handler: nativeXSetID, arity: 3},
+		"xgroup":         {handler: nativeXGroup, arity: -5},
+		"xack":           {handler: nativeXAck, arity: -4},
+		"xclaim":         {handler: nativeXClaim, arity: -6},
+		"json.set":       {handler: nativeJSONSet, arity: 4},
+		"json.del":       {handler: nativeJSONDel, arity: 3},
+		"json.arrappend": {handler: nativeJSONArrAppend, arity: -4},
+		"json.numincrby": {handler: nativeJSONNumIncrBy, arity: 4},
+		"del":            {handler: nativeDel, arity: -2},
+		"pexpireat":      {handler: nativePExpireAt, arity: 3},
+		"persist":        {handler: nativePersist, arity: 2},
+	}
+}
+
+// apply - executes command without writing it to append only file, lock has to be held by the caller
+func (n *Native) apply(args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("Empty command")
+	}
+
+	name := strings.ToLower(args[0])
+	cmd, ok := nativeCommands[name]
+	if !ok {
+		return nil, fmt.Errorf("ERR unknown command '%s'", args[0])
+	}
+
+	if (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity) {
+		return nil, fmt.Errorf("ERR wrong number of arguments for '%s' command", name)
+	}
+
+	return cmd.handler(n, args[1:])
+}
+
+// nativeSet - SET key value [PXAT unix-time-milliseconds | KEEPTTL]
+func nativeSet(n *Native, args []string) (interface{}, error) {
+	var at int64
+	switch {
+	case len(args) == 4 && strings.ToLower(args[2]) == "pxat":
+		var err error
+		if at, err = strconv.ParseInt(args[3], 10, 64); err != nil {
+			return nil, err
+		}
+	case len(args) == 3 && strings.ToLower(args[2]) == "keepttl":
+		if e := n.peek(args[0]); e != nil {
+			at = e.expireAt
+		}
+	case len(args) != 2:
+		return nil, fmt.Errorf("ERR syntax error")
+	}
+
+	n.setString(args[0], args[1], at)
+	return "OK", nil
+}
+
+// nativeAppend - APPEND key value
+func nativeAppend(n *Native, args []string) (interface{}, error) {
+	return n.appendString(args[0], args[1])
+}
+
+// nativeSetRange - SETRANGE key offset value
+func nativeSetRange(n *Native, args []string) (interface{}, error) {
+	offset, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSetRange(offset, args[2]); err != nil {
+		return nil, err
+	}
+
+	length, _, err := n.setRange(args[0], offset, args[2])
+	return length, err
+}
+
+// nativeHSet - HSET key field value [field value ...]
+func nativeHSet(n *Native, args []string) (interface{}, error) {
+	if len(args)%2 != 1 {
+		return nil, fmt.Errorf("ERR wrong number of arguments for 'hset' command")
+	}
+
+	fields := make(map[string]string, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		fields[args[i]] = args[i+1]
+	}
+
+	return n.hset(args[0], fields)
+}
+
+// nativeHDel - HDEL key field [field ...]
+func nativeHDel(n *Native, args []string) (interface{}, error) {
+	return n.hdel(args[0], args[1:])
+}
+
+// nativeRPush - RPUSH key element [element ...], elements are already encoded
+func nativeRPush(n *Native, args []string) (interface{}, error) {
+	return n.rpush(args[0], args[1:])
+}
+
+// nativeLSet - LSET key index element
+func nativeLSet(n *Native, args []string) (interface{}, error) {
+	index, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := n.lset(args[0], index, args[2]); err != nil {
+		return nil, err
+	}
+
+	return "OK", nil
+}
+
+// nativeLPush - LPUSH key element [element ...], elements are already encoded
+func nativeLPush(n *Native, args []string) (interface{}, error) {
+	return n.lpush(args[0], args[1:])
+}
+
+// nativeLPop - LPOP key count
+func nativeLPop(n *Native, args []string) (interface{}, error) {
+	return nativePop(n, args, true)
+}
+
+// nativeRPop - RPOP key count
+func nativeRPop(n *Native, args []string) (interface{}, error) {
+	return nativePop(n, args, false)
+}
+
+func nativePop(n *Native, args []string, left bool) (interface{}, error) {
+	count, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return n.lpop(args[0], count, left)
+}
+
+// nativeLInsert - LINSERT key BEFORE|AFTER pivot element
+func nativeLInsert(n *Native, args []string) (interface{}, error) {
+	switch strings.ToLower(args[1]) {
+	case "before":
+		return n.linsert(args[0], true, args[2], args[3])
+	case "after":
+		return n.linsert(args[0], false, args[2], args[3])
+	}
+
+	return nil, fmt.Errorf("ERR syntax error")
+}
+
+// nativeLRem - LREM key count element
+func nativeLRem(n *Native, args []string) (interface{}, error) {
+	count, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return n.lrem(args[0], count, args[2])
+}
+
+// nativeLTrim - LTRIM key start stop
+func nativeLTrim(n *Native, args []string) (interface{}, error) {
+	start, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	stop, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return n.ltrim(args[0], start, stop)
+}
+
+// nativeLMove - LMOVE source destination LEFT|RIGHT LEFT|RIGHT
+func nativeLMove(n *Native, args []string) (interface{}, error) {
+	from, to := strings.ToLower(args[2]), strings.ToLower(args[3])
+	if err := checkListEnds(from, to); err != nil {
+		return nil, err
+	}
+
+	return n.lmove(args[0], args[1], from == ListLeft, to == ListLeft)
+}
+
+// nativeSAdd - SADD key member [member ...]
+func nativeSAdd(n *Native, args []string) (interface{}, error) {
+	return n.sadd(args[0], args[1:])
+}
+
+// nativeSRem - SREM key member [member ...]
+func nativeSRem(n *Native, args []string) (interface{}, error) {
+	return n.srem(args[0], args[1:])
+}
+
+// nativeZAdd - ZADD key score member [score member ...]
+func nativeZAdd(n *Native, args []string) (interface{}, error) {
+	if len(args)%2 != 1 {
+		return nil, fmt.Errorf("ERR syntax error")
+	}
+
+	members := make([]models.ZMember, 0, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		score, err := strconv.ParseFloat(args[i], 64)
+		if err != nil {
+			return nil, errNotFloat
+		}
+		members = append(members, models.ZMember{Member: args[i+1], Score: score})
+	}
+
+	res, err := n.zadd(args[0], members, models.ZAddOptions{}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.added, nil
+}
+
+// nativeZRem - ZREM key member [member ...]
+func nativeZRem(n *Native, args []string) (interface{}, error) {
+	return n.zrem(args[0], args[1:])
+}
+
+// nativeXAdd - XADD key id field value [field value ...], the id is already generated and values are encoded
+func nativeXAdd(n *Native, args []string) (interface{}, error) {
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("ERR wrong number of arguments for 'xadd' command")
+	}
+
+	s, err := n.stream(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		s = newStream()
+	}
+
+	id, err := nextStreamID(s, args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	e, s, _ := n.streamForWrite(args[0], true)
+	n.xadd(e, s, id, args[2:])
+	return id.String(), nil
+}
+
+// nativeXTrim - XTRIM key MAXLEN|MINID threshold
+func nativeXTrim(n *Native, args []string) (interface{}, error) {
+	var trim models.XTrim
+	switch strings.ToLower(args[1]) {
+	case "maxlen":
+		maxLen, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return nil, errNotInteger
+		}
+		trim.MaxLen = &maxLen
+	case "minid":
+		trim.MinID = args[2]
+	default:
+		return nil, fmt.Errorf("ERR syntax error")
+	}
+
+	if err := checkXTrim(args[0], trim, true); err != nil {
+		return nil, err
+	}
+
+	e, s, err := n.streamForWrite(args[0], false)
+	if err != nil || s == nil {
+		return int64(0), err
+	}
+
+	return n.xtrim(e, s, trim), nil
+}
+
+// nativeXSetID - XSETID key last-id, the id can't be less than id of the last entry
+func nativeXSetID(n *Native, args []string) (interface{}, error) {
+	id, err := parseStreamID(args[1], 0)
+	if err != nil {
+		return nil, err
+	}
+
+	_, s, err := n.streamForWrite(args[0], false)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, errNoSuchKey
+	}
+
+	if len(s.entries) != 0 && id.less(s.entries[len(s.entries)-1].id) {
+		return nil, errXSetIDSmall
+	}
+
+	s.lastID = id
+	return "OK", nil
+}
+
+// nativeXGroup - XGROUP CREATE key group id [MKSTREAM] or XGROUP SETID key group id
+func nativeXGroup(n *Native, args []string) (interface{}, error) {
+	key, group, id := args[1], args[2], args[3]
+	if err := checkXGroupCreate(key, group, id); err != nil {
+		return nil, err
+	}
+
+	switch subcommand := strings.ToLower(args[0]); {
+	case subcommand == "create" && len(args) <= 5:
+		mkstream := len(args) == 5 && strings.ToLower(args[4]) == "mkstream"
+		if len(args) == 5 && !mkstream {
+			return nil, fmt.Errorf("ERR syntax error")
+		}
+
+		if _, err := n.xgroupCreate(key, group, id, mkstream); err != nil {
+			return nil, err
+		}
+		return "OK", nil
+	case subcommand == "setid" && len(args) == 4:
+		_, s, err := n.streamForWrite(key, false)
+		if err != nil {
+			return nil, err
+		}
+		if s == nil || s.groups[group] == nil {
+			return nil, errNoGroup(key, group, "XGROUP")
+		}
+
+		s.groups[group].lastID = s.lastID
+		if id != "$" {
+			s.groups[group].lastID, _ = parseStreamID(id, 0)
+		}
+		return "OK", nil
+	}
+
+	return nil, fmt.Errorf("ERR syntax error")
+}
+
+// nativeXAck - XACK key group id [id ...]
+func nativeXAck(n *Native, args []string) (interface{}, error) {
+	if err := checkXAck(args[0], args[1], args[2:]); err != nil {
+		return nil, err
+	}
+
+	acked, err := n.xack(args[0], args[1], args[2:])
+	return int64(len(acked)), err
+}
+
+// nativeXClaim - XCLAIM key group consumer min-idle-time id TIME ms RETRYCOUNT count FORCE JUSTID, the only form
+// written by the native engine. The pending entry is set as it is, if the entry exists in the stream.
+func nativeXClaim(n *Native, args []string) (interface{}, error) {
+	if len(args) != 11 || strings.ToLower(args[5]) != "time" || strings.ToLower(args[7]) != "retrycount" {
+		return nil, fmt.Errorf("ERR syntax error")
+	}
+
+	key, group, consumer := args[0], args[1], args[2]
+	id, err := parseStreamID(args[4], 0)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := &streamPending{consumer: consumer}
+	if pending.deliveryTime, err = strconv.ParseInt(args[6], 10, 64); err != nil {
+		return nil, errNotInteger
+	}
+	if pending.deliveryCount, err = strconv.ParseInt(args[8], 10, 64); err != nil {
+		return nil, errNotInteger
+	}
+
+	if _, err := n.streamGroup(key, group, "XCLAIM"); err != nil {
+		return nil, err
+	}
+
+	e, s, _ := n.streamForWrite(key, false)
+	if _, ok := s.get(id); !ok {
+		return []string{}, nil
+	}
+
+	g := s.groups[group]
+	if _, ok := g.pending[id]; !ok {
+		n.resize(e, elementOverhead)
+	}
+	g.pending[id] = pending
+	n.touchConsumer(e, g, consumer, pending.deliveryTime)
+
+	return []string{id.String()}, nil
+}
+
+// nativeJSONSet - JSON.SET key path value
+func nativeJSONSet(n *Native, args []string) (interface{}, error) {
+	p, value, err := checkJSONSet(args[0], args[1], args[2], models.JSONSetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return n.jsonSet(args[0], p, value, models.JSONSetOptions{})
+}
+
+// nativeJSONDel - JSON.DEL key path
+func nativeJSONDel(n *Native, args []string) (interface{}, error) {
+	p, err := parseJSONPath(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return n.jsonDel(args[0], p)
+}
+
+// nativeJSONArrAppend - JSON.ARRAPPEND key path value [value ...]
+func nativeJSONArrAppend(n *Native, args []string) (interface{}, error) {
+	p, values, err := checkJSONArrAppend(args[0], args[1], args[2:])
+	if err != nil {
+		return nil, err
+	}
+
+	res, _, err := n.jsonArrAppend(args[0], p, values)
+	return res, err
+}
+
+// nativeJSONNumIncrBy - JSON.NUMINCRBY key path number
+func nativeJSONNumIncrBy(n *Native, args []string) (interface{}, error) {
+	p, number, err := checkJSONNumIncrBy(args[0], args[1], args[2])
+	if err != nil {
+		return nil, err
+	}
+
+	res, _, err := n.jsonNumIncrBy(args[0], p, number)
+	return res, err
+}
+
+// nativeDel - DEL key [key ...]
+func nativeDel(n *Native, args []string) (interface{}, error) {
+	var deleted int64
+	for _, key := range args {
+		if n.del(key) {
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// nativePExpireAt - PEXPIREAT key unix-time-milliseconds
+func nativePExpireAt(n *Native, args []string) (interface{}, error) {
+	at, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return n.pexpireAt(args[0], at), nil
+}
+
+// nativePersist - PERSIST key
+func nativePersist(n *Native, args []string) (interface{}, error) {
+	return n.persist(args[0]), nil
+}
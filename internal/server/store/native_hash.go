@@ -0,0 +1,323 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+var (
+	errHashNotInteger = errors.New("ERR hash value is not an integer")
+	errHashNotFloat   = errors.New("ERR hash value is not a float")
+)
+
+// HDel - removes fields of the hash, the key is removed together with the last field. Returns number of
+// removed fields.
+func (n *Native) HDel(ctx context.Context, key string, fields []string) (int64, error) {
+	if key == "" || len(fields) == 0 {
+		return 0, fmt.Errorf("Empty key or field")
+	}
+
+	defer n.lock(ctx)()
+
+	removed, err := n.hdel(key, fields)
+	if err != nil || removed == 0 {
+		return 0, err
+	}
+
+	n.notify(notifyHash, "hdel", key)
+	n.notifyDeleted(key)
+	return removed, n.propagate(append([]string{"HDEL", key}, fields...)...)
+}
+
+// HExists - checks if the hash has the field
+func (n *Native) HExists(ctx context.Context, key, field string) (bool, error) {
+	if key == "" {
+		return false, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	hash, err := n.hash(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := hash[field]
+	return ok, nil
+}
+
+// HLen - number of fields of the hash, 0 if there is no such key
+func (n *Native) HLen(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	hash, err := n.hash(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(hash)), nil
+}
+
+// HKeys - fields of the hash in lexicographical order
+func (n *Native) HKeys(ctx context.Context, key string) ([]string, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	hash, err := n.hash(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return hashFields(hash), nil
+}
+
+// HVals - values of the hash in the same order as fields returned by HKeys
+func (n *Native) HVals(ctx context.Context, key string) ([]string, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	hash, err := n.hash(key)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := hashFields(hash)
+	values := make([]string, len(fields))
+	for i, field := range fields {
+		values[i] = hash[field]
+	}
+
+	return values, nil
+}
+
+// HMGet - values of the fields, nil for the missing field
+func (n *Native) HMGet(ctx context.Context, key string, fields []string) ([]interface{}, error) {
+	if key == "" || len(fields) == 0 {
+		return nil, fmt.Errorf("Empty key or field")
+	}
+
+	defer n.lock(ctx)()
+
+	hash, err := n.hash(key)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		if val, ok := hash[field]; ok {
+			values[i] = val
+		}
+	}
+
+	return values, nil
+}
+
+// HSetNX - sets the field only if the hash has no such field, returns false if the field is not set
+func (n *Native) HSetNX(ctx context.Context, key, field string, value interface{}) (bool, error) {
+	if key == "" || field == "" {
+		return false, fmt.Errorf("Empty key or field")
+	}
+
+	val, err := formatArg(value)
+	if err != nil {
+		return false, err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return false, err
+	}
+
+	hash, err := n.hash(key)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := hash[field]; ok {
+		return false, nil
+	}
+
+	if _, err := n.hset(key, map[string]string{field: val}); err != nil {
+		return false, err
+	}
+
+	n.notify(notifyHash, "hset", key)
+	return true, n.propagate("HSET", key, field, val)
+}
+
+// HIncrBy - increments integer stored by the field, missing field is treated as 0. The value typed by HashValues
+// keeps its type, missing field is typed too unless raw is set.
+func (n *Native) HIncrBy(ctx context.Context, key, field string, increment int64, raw bool) (int64, error) {
+	if key == "" || field == "" {
+		return 0, fmt.Errorf("Empty key or field")
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return 0, err
+	}
+
+	value, typed, err := n.hashCounter(key, field, raw)
+	if err != nil {
+		return 0, err
+	}
+
+	current, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, errHashNotInteger
+	}
+
+	current, err = addInt(current, increment)
+	if err != nil {
+		return 0, err
+	}
+
+	value = strconv.FormatInt(current, 10)
+	if typed {
+		if value, err = encodeHashValue(current); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := n.setHashCounter(key, field, value, "hincrby"); err != nil {
+		return 0, err
+	}
+
+	return current, nil
+}
+
+// HIncrByFloat - increments float stored by the field, missing field is treated as 0. Types are kept like by HIncrBy.
+func (n *Native) HIncrByFloat(ctx context.Context, key, field string, increment float64, raw bool) (float64, error) {
+	if key == "" || field == "" {
+		return 0, fmt.Errorf("Empty key or field")
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return 0, err
+	}
+
+	value, typed, err := n.hashCounter(key, field, raw)
+	if err != nil {
+		return 0, err
+	}
+
+	current, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(current) || math.IsInf(current, 0) {
+		return 0, errHashNotFloat
+	}
+
+	current += increment
+	if math.IsNaN(current) || math.IsInf(current, 0) {
+		return 0, errIncrNaN
+	}
+
+	value = formatFloat(current)
+	if typed {
+		if value, err = encodeHashValue(current); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := n.setHashCounter(key, field, value, "hincrbyfloat"); err != nil {
+		return 0, err
+	}
+
+	return current, nil
+}
+
+// hdel - see HDel
+func (n *Native) hdel(key string, fields []string) (int64, error) {
+	e := n.lookupWrite(key)
+	if e == nil {
+		return 0, nil
+	}
+
+	hash, ok := e.value.(map[string]string)
+	if !ok {
+		return 0, ErrWrongType
+	}
+
+	var removed int64
+	for _, field := range fields {
+		val, ok := hash[field]
+		if !ok {
+			continue
+		}
+
+		n.resize(e, -fieldOverhead-int64(len(field)+len(val)))
+		delete(hash, field)
+		removed++
+	}
+
+	if len(hash) == 0 {
+		n.remove(key)
+	}
+
+	return removed, nil
+}
+
+// hashCounter - number stored by the field and whether it is typed by HashValues, "0" if there is no such
+// field, which is typed unless raw is set. Typed values other than numbers are returned encoded, so that they
+// are not numbers.
+func (n *Native) hashCounter(key, field string, raw bool) (string, bool, error) {
+	hash, err := n.hash(key)
+	if err != nil {
+		return "", false, err
+	}
+
+	value, ok := hash[field]
+	if !ok {
+		return "0", !raw, nil
+	}
+
+	switch v := DecodeHashValue(value).(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), true, nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true, nil
+	case json.Number:
+		return v.String(), true, nil
+	}
+
+	return value, false, nil
+}
+
+// setHashCounter - the result is written to append only file as HSET, like redis propagates HINCRBYFLOAT
+func (n *Native) setHashCounter(key, field, value, event string) error {
+	if _, err := n.hset(key, map[string]string{field: value}); err != nil {
+		return err
+	}
+
+	n.notify(notifyHash, event, key)
+	return n.propagate("HSET", key, field, value)
+}
+
+// hashFields - fields of the hash are sorted, so that HKEYS and HVALS are repeatable
+func hashFields(hash map[string]string) []string {
+	fields := make([]string, 0, len(hash))
+	for field := range hash {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
@@ -0,0 +1,262 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNativeHashCommands(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	_, err := client.HSet(ctx, "user", map[string]interface{}{"name": "ivan", "age": 21, "role": "admin"})
+	assert.NoError(t, err)
+
+	length, err := client.HLen(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), length)
+
+	keys, err := client.HKeys(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"age", "name", "role"}, keys)
+
+	values, err := client.HVals(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"21", "ivan", "admin"}, values)
+
+	exists, err := client.HExists(ctx, "user", "age")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	fields, err := client.HMGet(ctx, "user", []string{"name", "missing"})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"ivan", nil}, fields)
+
+	set, err := client.HSetNX(ctx, "user", "name", "petr")
+	assert.NoError(t, err)
+	assert.False(t, set)
+
+	set, err = client.HSetNX(ctx, "user", "city", "moscow")
+	assert.NoError(t, err)
+	assert.True(t, set)
+
+	removed, err := client.HDel(ctx, "user", []string{"name", "city", "missing"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+
+	// the key is removed together with the last field
+	removed, err = client.HDel(ctx, "user", []string{"age", "role"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+	assert.Nil(t, client.peek("user"))
+
+	keys, err = client.HKeys(ctx, "user")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	_, err = client.SAdd(ctx, "roles", []string{"admin"})
+	assert.NoError(t, err)
+	_, err = client.HDel(ctx, "roles", []string{"admin"})
+	assert.Equal(t, ErrWrongType, err)
+}
+
+func TestNativeHIncr(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	_, err := client.HSet(ctx, "counters", map[string]interface{}{"name": "ivan", "max": math.MaxInt64})
+	assert.NoError(t, err)
+
+	type testCase struct {
+		name      string
+		field     string
+		increment int64
+		result    int64
+		err       error
+	}
+
+	tCases := []testCase{
+		{name: "Missing field", field: "visits", increment: 5, result: 5},
+		{name: "Existing field", field: "visits", increment: -7, result: -2},
+		{name: "Not an integer", field: "name", increment: 1, err: errHashNotInteger},
+		{name: "Overflow", field: "max", increment: 1, err: errOverflow},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := client.HIncrBy(ctx, "counters", tc.field, tc.increment, true)
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.result, result)
+		})
+	}
+
+	result, err := client.HIncrByFloat(ctx, "counters", "visits", 0.5, true)
+	assert.NoError(t, err)
+	assert.Equal(t, -1.5, result)
+
+	_, err = client.HIncrByFloat(ctx, "counters", "name", 0.5, true)
+	assert.Equal(t, errHashNotFloat, err)
+	assert.True(t, IsNotNumber(err))
+
+	value, err := client.HGet(ctx, "counters", "visits")
+	assert.NoError(t, err)
+	assert.Equal(t, "-1.5", value)
+}
+
+func TestNativeHashAOF(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aof")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "appendonly.aof")
+	ctx := context.Background()
+
+	client := NewNative()
+	assert.NoError(t, client.OpenAOF(path, FsyncAlways))
+
+	_, err = client.HSet(ctx, "user", map[string]interface{}{"name": "ivan", "age": 21})
+	assert.NoError(t, err)
+	_, err = client.HDel(ctx, "user", []string{"age"})
+	assert.NoError(t, err)
+	_, err = client.HSetNX(ctx, "user", "role", "admin")
+	assert.NoError(t, err)
+	_, err = client.HIncrBy(ctx, "user", "visits", 3, true)
+	assert.NoError(t, err)
+	_, err = client.HIncrByFloat(ctx, "user", "balance", 10.25, true)
+	assert.NoError(t, err)
+	assert.NoError(t, client.Close())
+
+	restored := NewNative()
+	assert.NoError(t, restored.OpenAOF(path, FsyncNo))
+	defer restored.Close()
+
+	hash, err := restored.GetHash(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "ivan", "role": "admin", "visits": "3", "balance": "10.25"}, hash)
+}
+
+func TestHashValueCodec(t *testing.T) {
+	type testCase struct {
+		name    string
+		value   interface{}
+		encoded string
+		decoded interface{}
+	}
+
+	tCases := []testCase{
+		{name: "string", value: "ivan", encoded: `{"Dtype":"string","Data":"ivan"}`, decoded: "ivan"},
+		{name: "numeric string", value: "21", encoded: `{"Dtype":"string","Data":"21"}`, decoded: "21"},
+		{name: "integer", value: 21, encoded: `{"Dtype":"int64","Data":"21"}`, decoded: int64(21)},
+		{name: "big integer", value: json.Number("9007199254740993"), encoded: `{"Dtype":"int64","Data":"9007199254740993"}`, decoded: int64(9007199254740993)},
+		{name: "out of int64", value: json.Number("18446744073709551616"), encoded: `{"Dtype":"float64","Data":"18446744073709551616"}`, decoded: json.Number("18446744073709551616")},
+		{name: "float", value: 2.5, encoded: `{"Dtype":"float64","Data":"2.5"}`, decoded: 2.5},
+		{name: "float with zero fraction", value: json.Number("1.0"), encoded: `{"Dtype":"float64","Data":"1.0"}`, decoded: json.Number("1.0")},
+		{name: "bool", value: true, encoded: `{"Dtype":"bool","Data":"true"}`, decoded: true},
+		{name: "null", value: nil, encoded: `{"Dtype":"null","Data":""}`, decoded: nil},
+		{
+			name:    "object",
+			value:   map[string]interface{}{"city": "moscow", "tags": []interface{}{1, "a"}},
+			encoded: `{"Dtype":"json","Data":"{\"city\":\"moscow\",\"tags\":[1,\"a\"]}"}`,
+			decoded: map[string]interface{}{"city": "moscow", "tags": []interface{}{int64(1), "a"}},
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			values, err := HashValues(map[string]interface{}{"field": tc.value}, false)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.encoded, values["field"])
+			assert.Equal(t, tc.decoded, DecodeHashValue(tc.encoded))
+		})
+	}
+
+	// values written in raw mode or by redis-cli are returned as they are, even if they look like JSON
+	for _, raw := range []string{"ivan", "", "21", "true", "null", `"ivan"`, `{"age":21}`, `{"Dtype":"int64","Data":"x"}`, `{"Dtype":"bool","Data":"true","Other":1}`} {
+		assert.Equal(t, raw, DecodeHashValue(raw))
+	}
+
+	values, err := HashValues(map[string]interface{}{"age": json.Number("21"), "name": "ivan"}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"age": "21", "name": "ivan"}, values)
+
+	client := NewNative()
+	ctx := context.Background()
+
+	values, err = HashValues(map[string]interface{}{"admin": false}, false)
+	assert.NoError(t, err)
+	values["age"] = "21"
+	_, err = client.HSet(ctx, "user", values)
+	assert.NoError(t, err)
+
+	// raw numbers stay raw, like in redis
+	age, err := client.HIncrBy(ctx, "user", "age", 1, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(22), age)
+
+	_, err = client.HIncrBy(ctx, "user", "admin", 1, false)
+	assert.True(t, IsNotNumber(err))
+
+	hash, err := client.GetHash(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"age": "22", "admin": false}, DecodeHashValues(hash))
+}
+
+func TestHashTypedCounters(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	values, err := HashValues(map[string]interface{}{"age": 21, "score": 2.5, "name": "ivan"}, false)
+	assert.NoError(t, err)
+	_, err = client.HSet(ctx, "user", values)
+	assert.NoError(t, err)
+
+	type testCase struct {
+		name      string
+		field     string
+		increment float64
+		integer   bool
+		raw       bool
+		result    float64
+		err       error
+	}
+
+	tCases := []testCase{
+		{name: "Typed integer", field: "age", increment: 1, integer: true, result: 22},
+		{name: "Typed float by integer", field: "score", increment: 1, integer: true, err: errHashNotInteger},
+		{name: "Typed float", field: "score", increment: 0.5, result: 3},
+		{name: "Typed string", field: "name", increment: 1, integer: true, err: errHashNotInteger},
+		{name: "Missing typed integer", field: "visits", increment: 3, integer: true, result: 3},
+		{name: "Missing typed float", field: "balance", increment: 0.25, result: 0.25},
+		{name: "Missing raw integer", field: "raw", increment: 5, integer: true, raw: true, result: 5},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var result float64
+			if tc.integer {
+				var n int64
+				n, err = client.HIncrBy(ctx, "user", tc.field, int64(tc.increment), tc.raw)
+				result = float64(n)
+			} else {
+				result, err = client.HIncrByFloat(ctx, "user", tc.field, tc.increment, tc.raw)
+			}
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.result, result)
+		})
+	}
+
+	hash, err := client.GetHash(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Dtype":"int64","Data":"22"}`, hash["age"])
+	assert.Equal(t, `{"Dtype":"float64","Data":"3"}`, hash["score"])
+	assert.Equal(t, map[string]interface{}{
+		"age": int64(22), "score": int64(3), "name": "ivan", "visits": int64(3), "balance": 0.25, "raw": "5",
+	}, DecodeHashValues(hash))
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// infoSections - sections of INFO returned when no section is requested
+var infoSections = []string{"server", "memory", "persistence", "stats", "keyspace"}
+
+// Info - statistics of the engine in the format of INFO command: fields of the requested section,
+// all sections are returned for empty section, "all" or "default"
+func (n *Native) Info(ctx context.Context, section string) (map[string]string, error) {
+	sections := []string{strings.ToLower(section)}
+	if section == "" || sections[0] == "all" || sections[0] == "default" || sections[0] == "everything" {
+		sections = infoSections
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	res := make(map[string]string)
+	for _, name := range sections {
+		switch name {
+		case "server":
+			res["redis_version"] = RedisVersion
+			res["redis_mode"] = "standalone"
+			res["process_id"] = strconv.Itoa(os.Getpid())
+		case "memory":
+			res["used_memory"] = strconv.FormatInt(n.used, 10)
+			res["maxmemory"] = strconv.FormatInt(n.maxmemory, 10)
+			res["maxmemory_policy"] = n.maxmemoryPolicy
+		case "persistence":
+			n.persistenceInfo(res)
+		case "stats":
+			n.statsInfo(res)
+		case "keyspace":
+			if len(n.data) > 0 {
+				res["db0"] = fmt.Sprintf("keys=%d,expires=%d,avg_ttl=0", len(n.data), len(n.expires))
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (n *Native) persistenceInfo(res map[string]string) {
+	res["rdb_bgsave_in_progress"] = formatBool(n.saveStatus.InProgress)
+	res["rdb_last_save_time"] = strconv.FormatInt(n.saveStatus.LastSave, 10)
+	res["rdb_last_bgsave_time_sec"] = "-1"
+	if n.saveStatus.LastSave != 0 {
+		res["rdb_last_bgsave_time_sec"] = strconv.FormatInt(n.saveStatus.Duration/int64(time.Second/time.Millisecond), 10)
+	}
+
+	res["rdb_last_bgsave_status"] = "ok"
+	if !n.saveStatus.Success {
+		res["rdb_last_bgsave_status"] = "err"
+	}
+
+	res["aof_enabled"] = formatBool(n.aof != nil)
+}
+
+func (n *Native) statsInfo(res map[string]string) {
+	res["evicted_keys"] = strconv.FormatInt(n.evictedKeys, 10)
+	res["expired_keys"] = strconv.FormatInt(n.expireStats.expiredKeys, 10)
+	res["expired_lazy_keys"] = strconv.FormatInt(n.expireStats.expiredLazy, 10)
+	res["expired_active_keys"] = strconv.FormatInt(n.expireStats.expiredActive, 10)
+	res["expired_stale_perc"] = strconv.FormatFloat(n.expireStats.stalePerc, 'f', 2, 64)
+	res["expired_time_cap_reached_count"] = strconv.FormatInt(n.expireStats.timeCapReached, 10)
+	res["expire_cycles"] = strconv.FormatInt(n.expireStats.cycles, 10)
+	res["expire_cycle_cpu_milliseconds"] = strconv.FormatInt(int64(n.expireStats.cycleTime/time.Millisecond), 10)
+}
+
+func formatBool(value bool) string {
+	if value {
+		return "1"
+	}
+
+	return "0"
+}
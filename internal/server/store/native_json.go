@@ -0,0 +1,564 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// JSONSet - stores the value by the path. The new key is created only by the root path, the missing member is
+// added to objects matched by the rest of the path. NX - only if the path matches nothing, XX - only if it
+// matches. The result is OK or redis.Nil if nothing is set.
+func (n *Native) JSONSet(ctx context.Context, key, path, value string, opts models.JSONSetOptions) (string, error) {
+	p, parsed, err := checkJSONSet(key, path, value, opts)
+	if err != nil {
+		return "", err
+	}
+	serialized := marshalJSON(parsed)
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return "", err
+	}
+
+	set, err := n.jsonSet(key, p, parsed, opts)
+	if err != nil {
+		return "", err
+	}
+	if !set {
+		return "", redis.Nil
+	}
+
+	n.notify(notifyGeneric, "json.set", key)
+	// the same values are matched when the command is replayed, so that NX and XX are not needed
+	return "OK", n.propagate("JSON.SET", key, p.raw, serialized)
+}
+
+// JSONGet - values matched by the paths serialized as JSON, the root without paths. The legacy path gives
+// the matched value, JSONPath gives array of all matched values. Several paths give object with the result
+// of every path. redis.Nil if there is no such key.
+func (n *Native) JSONGet(ctx context.Context, key string, paths []string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("Empty key")
+	}
+	if len(paths) == 0 {
+		paths = []string{jsonLegacyRoot}
+	}
+
+	parsed := make([]*jsonPath, len(paths))
+	for i, path := range paths {
+		p, err := parseJSONPath(path)
+		if err != nil {
+			return "", err
+		}
+		parsed[i] = p
+	}
+
+	defer n.lock(ctx)()
+
+	doc, err := n.json(key)
+	if err != nil {
+		return "", err
+	}
+	if doc == nil {
+		return "", redis.Nil
+	}
+
+	if len(parsed) == 1 {
+		value, err := jsonPathValue(doc.root, parsed[0])
+		if err != nil {
+			return "", err
+		}
+		return marshalJSON(value), nil
+	}
+
+	res := make(map[string]interface{}, len(parsed))
+	for _, p := range parsed {
+		value, err := jsonPathValue(doc.root, p)
+		if err != nil {
+			return "", err
+		}
+		res[p.raw] = value
+	}
+
+	return marshalJSON(res), nil
+}
+
+// JSONDel - removes values matched by the path, the root removes the key. Returns number of removed values.
+func (n *Native) JSONDel(ctx context.Context, key, path string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	p, err := parseJSONPath(path)
+	if err != nil {
+		return 0, err
+	}
+
+	defer n.lock(ctx)()
+
+	removed, err := n.jsonDel(key, p)
+	if err != nil || removed == 0 {
+		return 0, err
+	}
+
+	n.notify(notifyGeneric, "json.del", key)
+	n.notifyDeleted(key)
+	return removed, n.propagate("JSON.DEL", key, p.raw)
+}
+
+// JSONArrAppend - appends values to arrays matched by the path. The legacy path gives the new length of the
+// array, JSONPath gives new lengths of all matched arrays and nil for other values.
+func (n *Native) JSONArrAppend(ctx context.Context, key, path string, values []string) (interface{}, error) {
+	p, parsed, err := checkJSONArrAppend(key, path, values)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := make([]string, len(parsed))
+	for i, val := range parsed {
+		serialized[i] = marshalJSON(val)
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return nil, err
+	}
+
+	res, appended, err := n.jsonArrAppend(key, p, parsed)
+	if err != nil || !appended {
+		return res, err
+	}
+
+	n.notify(notifyGeneric, "json.arrappend", key)
+	return res, n.propagate(append([]string{"JSON.ARRAPPEND", key, p.raw}, serialized...)...)
+}
+
+// JSONNumIncrBy - increments numbers matched by the path, integers stay integers. The legacy path gives the
+// new number, JSONPath gives array of new numbers and null for other values, both serialized as JSON.
+func (n *Native) JSONNumIncrBy(ctx context.Context, key, path, increment string) (string, error) {
+	p, number, err := checkJSONNumIncrBy(key, path, increment)
+	if err != nil {
+		return "", err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return "", err
+	}
+
+	res, incremented, err := n.jsonNumIncrBy(key, p, number)
+	if err != nil {
+		return "", err
+	}
+
+	if incremented {
+		n.notify(notifyGeneric, "json.numincrby", key)
+		if err := n.propagate("JSON.NUMINCRBY", key, p.raw, number.String()); err != nil {
+			return "", err
+		}
+	}
+
+	return marshalJSON(res), nil
+}
+
+// JSONObjKeys - keys of the object matched by the path in sorted order. JSONPath gives keys of every matched
+// object and nil for other values. redis.Nil if there is no such key.
+func (n *Native) JSONObjKeys(ctx context.Context, key, path string) (interface{}, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+
+	p, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer n.lock(ctx)()
+
+	doc, err := n.json(key)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, redis.Nil
+	}
+
+	matches := p.find(doc.root)
+	if p.legacy {
+		if len(matches) == 0 {
+			return nil, jsonPathNotFound(p)
+		}
+
+		obj, ok := matches[0].value.(map[string]interface{})
+		if !ok {
+			return nil, wrongJSONType("object", matches[0].value)
+		}
+		return jsonObjKeys(obj), nil
+	}
+
+	res := make([]interface{}, len(matches))
+	for i, m := range matches {
+		if obj, ok := m.value.(map[string]interface{}); ok {
+			res[i] = jsonObjKeys(obj)
+		}
+	}
+
+	return res, nil
+}
+
+// jsonSet - see JSONSet, returns false if nothing is set
+func (n *Native) jsonSet(key string, p *jsonPath, value interface{}, opts models.JSONSetOptions) (bool, error) {
+	e, doc, err := n.jsonForWrite(key)
+	if err != nil {
+		return false, err
+	}
+
+	if doc == nil {
+		if !p.isRoot() {
+			return false, errJSONRoot
+		}
+		if opts.XX {
+			return false, nil
+		}
+
+		doc = &jsonDoc{root: value}
+		e = n.newEntry(key, doc)
+		n.putEntry(key, e)
+		n.resizeJSON(e, doc)
+		return true, nil
+	}
+
+	matches := p.find(doc.root)
+	if len(matches) > 0 {
+		if opts.NX {
+			return false, nil
+		}
+
+		for i, m := range matches {
+			if i > 0 {
+				value = cloneJSON(value)
+			}
+			doc.replace(m, value)
+		}
+
+		n.resizeJSON(e, doc)
+		return true, nil
+	}
+
+	parent, member, ok := p.parent()
+	if opts.XX || !ok {
+		return false, nil
+	}
+
+	added := 0
+	for _, m := range parent.find(doc.root) {
+		if obj, ok := m.value.(map[string]interface{}); ok {
+			if added > 0 {
+				value = cloneJSON(value)
+			}
+			obj[member] = value
+			added++
+		}
+	}
+	if added == 0 {
+		return false, nil
+	}
+
+	n.resizeJSON(e, doc)
+	return true, nil
+}
+
+// jsonDel - see JSONDel
+func (n *Native) jsonDel(key string, p *jsonPath) (int64, error) {
+	e, doc, err := n.jsonForWrite(key)
+	if err != nil || doc == nil {
+		return 0, err
+	}
+
+	if p.isRoot() {
+		n.remove(key)
+		return 1, nil
+	}
+
+	matches := p.find(doc.root)
+	if len(matches) == 0 {
+		return 0, nil
+	}
+
+	// elements are removed from the end of the array, so that indexes of other matches stay the same
+	arrays := make(map[*jsonArray][]int)
+	for _, m := range matches {
+		switch parent := m.parent.(type) {
+		case map[string]interface{}:
+			delete(parent, m.key)
+		case *jsonArray:
+			arrays[parent] = append(arrays[parent], m.index)
+		}
+	}
+	for arr, indexes := range arrays {
+		sort.Sort(sort.Reverse(sort.IntSlice(indexes)))
+		for _, i := range indexes {
+			arr.elements = append(arr.elements[:i], arr.elements[i+1:]...)
+		}
+	}
+
+	n.resizeJSON(e, doc)
+	return int64(len(matches)), nil
+}
+
+// jsonArrAppend - see JSONArrAppend, returns false if nothing is appended
+func (n *Native) jsonArrAppend(key string, p *jsonPath, values []interface{}) (interface{}, bool, error) {
+	e, doc, err := n.jsonForWrite(key)
+	if err != nil {
+		return nil, false, err
+	}
+	if doc == nil {
+		return nil, false, errJSONNoKey
+	}
+
+	matches := p.find(doc.root)
+	if p.legacy {
+		if len(matches) == 0 {
+			return nil, false, jsonPathNotFound(p)
+		}
+
+		arr, ok := matches[0].value.(*jsonArray)
+		if !ok {
+			return nil, false, wrongJSONType("array", matches[0].value)
+		}
+
+		arr.append(values)
+		n.resizeJSON(e, doc)
+		return int64(len(arr.elements)), true, nil
+	}
+
+	res := make([]interface{}, len(matches))
+	appended := false
+	for i, m := range matches {
+		if arr, ok := m.value.(*jsonArray); ok {
+			arr.append(values)
+			res[i] = int64(len(arr.elements))
+			appended = true
+		}
+	}
+	if appended {
+		n.resizeJSON(e, doc)
+	}
+
+	return res, appended, nil
+}
+
+// jsonNumIncrBy - see JSONNumIncrBy, returns false if nothing is incremented
+func (n *Native) jsonNumIncrBy(key string, p *jsonPath, increment json.Number) (interface{}, bool, error) {
+	e, doc, err := n.jsonForWrite(key)
+	if err != nil {
+		return nil, false, err
+	}
+	if doc == nil {
+		return nil, false, errJSONNoKey
+	}
+
+	matches := p.find(doc.root)
+	if p.legacy {
+		if len(matches) == 0 {
+			return nil, false, jsonPathNotFound(p)
+		}
+
+		number, ok := matches[0].value.(json.Number)
+		if !ok {
+			return nil, false, wrongJSONType("a number", matches[0].value)
+		}
+
+		sum, err := addJSONNumbers(number, increment)
+		if err != nil {
+			return nil, false, err
+		}
+
+		doc.replace(matches[0], sum)
+		n.resizeJSON(e, doc)
+		return sum, true, nil
+	}
+
+	// nothing is changed if any of sums is not a number
+	res := make([]interface{}, len(matches))
+	for i, m := range matches {
+		if number, ok := m.value.(json.Number); ok {
+			sum, err := addJSONNumbers(number, increment)
+			if err != nil {
+				return nil, false, err
+			}
+			res[i] = sum
+		}
+	}
+
+	incremented := false
+	for i, m := range matches {
+		if res[i] != nil {
+			doc.replace(m, res[i])
+			incremented = true
+		}
+	}
+	if incremented {
+		n.resizeJSON(e, doc)
+	}
+
+	return res, incremented, nil
+}
+
+// json - document stored by the key, nil if there is no such key
+func (n *Native) json(key string) (*jsonDoc, error) {
+	e := n.lookup(key)
+	if e == nil {
+		return nil, nil
+	}
+
+	doc, ok := e.value.(*jsonDoc)
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	return doc, nil
+}
+
+// jsonForWrite - entry and document of the key which may be modified in place, nil if there is no such key
+func (n *Native) jsonForWrite(key string) (*entry, *jsonDoc, error) {
+	e := n.lookupWrite(key)
+	if e == nil {
+		return nil, nil, nil
+	}
+
+	doc, ok := e.value.(*jsonDoc)
+	if !ok {
+		return nil, nil, ErrWrongType
+	}
+
+	return e, doc, nil
+}
+
+// resizeJSON - memory used by the document is estimated by its serialized length after every write
+func (n *Native) resizeJSON(e *entry, doc *jsonDoc) {
+	size := int64(len(marshalJSON(doc.root)))
+	n.resize(e, size-doc.size)
+	doc.size = size
+}
+
+// replace - stores the value in place of the matched one
+func (d *jsonDoc) replace(m jsonMatch, value interface{}) {
+	switch parent := m.parent.(type) {
+	case map[string]interface{}:
+		parent[m.key] = value
+	case *jsonArray:
+		parent.elements[m.index] = value
+	default:
+		d.root = value
+	}
+}
+
+// append - copies of values are appended, so that the same values may be appended to several arrays
+func (a *jsonArray) append(values []interface{}) {
+	for _, val := range values {
+		a.elements = append(a.elements, cloneJSON(val))
+	}
+}
+
+// jsonPathValue - the value matched by the legacy path or array of values matched by JSONPath
+func jsonPathValue(root interface{}, p *jsonPath) (interface{}, error) {
+	matches := p.find(root)
+	if p.legacy {
+		if len(matches) == 0 {
+			return nil, jsonPathNotFound(p)
+		}
+		return matches[0].value, nil
+	}
+
+	res := make([]interface{}, len(matches))
+	for i, m := range matches {
+		res[i] = m.value
+	}
+
+	return res, nil
+}
+
+func jsonObjKeys(obj map[string]interface{}) []interface{} {
+	keys := jsonKeys(obj)
+	res := make([]interface{}, len(keys))
+	for i, key := range keys {
+		res[i] = key
+	}
+
+	return res
+}
+
+func jsonPathNotFound(p *jsonPath) error {
+	return fmt.Errorf("ERR Path '%s' does not exist", p.raw)
+}
+
+// checkJSONSet - arguments of JSON.SET are checked the same way by both engines
+func checkJSONSet(key, path, value string, opts models.JSONSetOptions) (*jsonPath, interface{}, error) {
+	if key == "" {
+		return nil, nil, fmt.Errorf("Empty key")
+	}
+	if opts.NX && opts.XX {
+		return nil, nil, errSyntax
+	}
+
+	p, err := parseJSONPath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parsed, err := parseJSON(value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p, parsed, nil
+}
+
+// checkJSONArrAppend - at least one value is appended
+func checkJSONArrAppend(key, path string, values []string) (*jsonPath, []interface{}, error) {
+	if key == "" || len(values) == 0 {
+		return nil, nil, fmt.Errorf("Empty key or value")
+	}
+
+	p, err := parseJSONPath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parsed := make([]interface{}, len(values))
+	for i, val := range values {
+		if parsed[i], err = parseJSON(val); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return p, parsed, nil
+}
+
+// checkJSONNumIncrBy - the increment is JSON number
+func checkJSONNumIncrBy(key, path, increment string) (*jsonPath, json.Number, error) {
+	if key == "" {
+		return nil, "", fmt.Errorf("Empty key")
+	}
+
+	p, err := parseJSONPath(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	number, err := parseJSONNumber(increment)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return p, number, nil
+}
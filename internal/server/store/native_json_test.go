@@ -0,0 +1,363 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+const testJSONDocument = `{"name":"ivan","age":21,"score":1.5,"tags":["admin","dev"],"address":{"city":"moscow","zip":"101000"},` +
+	`"friends":[{"name":"petr","age":30},{"name":"anna"}]}`
+
+func TestParseJSONPath(t *testing.T) {
+	type testCase struct {
+		name     string
+		path     string
+		legacy   bool
+		segments []jsonSegment
+		err      bool
+	}
+
+	tCases := []testCase{
+		{name: "Empty path", path: "", legacy: true},
+		{name: "Legacy root", path: ".", legacy: true},
+		{name: "Root", path: "$"},
+		{name: "Member", path: "$.name", segments: []jsonSegment{{kind: jsonMember, key: "name"}}},
+		{name: "Legacy member", path: ".address.city", legacy: true, segments: []jsonSegment{{kind: jsonMember, key: "address"}, {kind: jsonMember, key: "city"}}},
+		{name: "Legacy member without dot", path: "name", legacy: true, segments: []jsonSegment{{kind: jsonMember, key: "name"}}},
+		{name: "Quoted member", path: `$['first name']["x.y"]`, segments: []jsonSegment{{kind: jsonMember, key: "first name"}, {kind: jsonMember, key: "x.y"}}},
+		{name: "Index", path: "$.tags[-1]", segments: []jsonSegment{{kind: jsonMember, key: "tags"}, {kind: jsonIndex, index: -1}}},
+		{name: "Wildcards", path: "$.friends[*].*", segments: []jsonSegment{{kind: jsonMember, key: "friends"}, {kind: jsonWildcard}, {kind: jsonWildcard}}},
+		{name: "Recursive descent", path: "$..name", segments: []jsonSegment{{kind: jsonMember, key: "name", recursive: true}}},
+		{name: "Dot before bracket", path: "$.[0]", err: true},
+		{name: "Trailing dot", path: "$.name.", err: true},
+		{name: "Unclosed bracket", path: "$.tags[0", err: true},
+		{name: "Not an index", path: "$.tags[a]", err: true},
+		{name: "Filter", path: "$.tags[?(@.a)]", err: true},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := parseJSONPath(tc.path)
+			if tc.err {
+				assert.True(t, IsInvalidArgument(err))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.legacy, p.legacy)
+			assert.Equal(t, tc.segments, p.segments)
+		})
+	}
+}
+
+func TestNativeJSON(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	res, err := client.JSONSet(ctx, "user", "$", testJSONDocument, models.JSONSetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", res)
+
+	type testCase struct {
+		name   string
+		exec   func() (interface{}, error)
+		result interface{}
+		err    error
+	}
+
+	get := func(paths ...string) func() (interface{}, error) {
+		return func() (interface{}, error) {
+			return client.JSONGet(ctx, "user", paths)
+		}
+	}
+
+	tCases := []testCase{
+		{name: "Get legacy member", exec: get(".address.city"), result: `"moscow"`},
+		{name: "Get JSONPath member", exec: get("$.address.city"), result: `["moscow"]`},
+		{name: "Get missing legacy path", exec: get(".missing"), err: errors.New("ERR Path '.missing' does not exist")},
+		{name: "Get missing JSONPath", exec: get("$.missing"), result: `[]`},
+		{name: "Get index", exec: get("$.tags[-1]"), result: `["dev"]`},
+		{name: "Get wildcard", exec: get("$.friends[*].name"), result: `["petr","anna"]`},
+		{name: "Get recursive descent", exec: get("$..age"), result: `[21,30]`},
+		{name: "Get several paths", exec: get("$.age", ".name"), result: `{"$.age":[21],".name":"ivan"}`},
+		{
+			name: "Set NX existing member",
+			exec: func() (interface{}, error) {
+				return client.JSONSet(ctx, "user", "$.age", "22", models.JSONSetOptions{NX: true})
+			},
+			err: redis.Nil,
+		},
+		{
+			name: "Set XX missing member",
+			exec: func() (interface{}, error) {
+				return client.JSONSet(ctx, "user", "$.phone", "null", models.JSONSetOptions{XX: true})
+			},
+			err: redis.Nil,
+		},
+		{
+			name: "Set nested member",
+			exec: func() (interface{}, error) {
+				return client.JSONSet(ctx, "user", "$.address.house", `{"number":7}`, models.JSONSetOptions{NX: true})
+			},
+			result: "OK",
+		},
+		{name: "Get added member", exec: get("$.address"), result: `[{"city":"moscow","house":{"number":7},"zip":"101000"}]`},
+		{
+			name: "Set member of missing parent",
+			exec: func() (interface{}, error) {
+				return client.JSONSet(ctx, "user", "$.job.title", `"dev"`, models.JSONSetOptions{})
+			},
+			err: redis.Nil,
+		},
+		{
+			name: "Set every match",
+			exec: func() (interface{}, error) {
+				return client.JSONSet(ctx, "user", "$.friends[*].age", `18`, models.JSONSetOptions{})
+			},
+			result: "OK",
+		},
+		{name: "Get every match", exec: get("$.friends[*].age"), result: `[18]`},
+		{
+			name: "Set not at the root of missing key",
+			exec: func() (interface{}, error) {
+				return client.JSONSet(ctx, "missing", "$.name", `"ivan"`, models.JSONSetOptions{})
+			},
+			err: errJSONRoot,
+		},
+		{
+			name: "Set invalid JSON",
+			exec: func() (interface{}, error) {
+				return client.JSONSet(ctx, "user", "$.name", `ivan`, models.JSONSetOptions{})
+			},
+			err: errors.New("ERR invalid JSON value: invalid character 'i' looking for beginning of value"),
+		},
+		{
+			name: "Numincrby integer",
+			exec: func() (interface{}, error) {
+				return client.JSONNumIncrBy(ctx, "user", ".age", "2")
+			},
+			result: "23",
+		},
+		{
+			name: "Numincrby float",
+			exec: func() (interface{}, error) {
+				return client.JSONNumIncrBy(ctx, "user", "$.score", "0.5")
+			},
+			result: "[2.0]",
+		},
+		{
+			name: "Numincrby recursive descent",
+			exec: func() (interface{}, error) {
+				return client.JSONNumIncrBy(ctx, "user", "$..age", "1")
+			},
+			result: "[24,19]",
+		},
+		{
+			name: "Numincrby legacy not a number",
+			exec: func() (interface{}, error) {
+				return client.JSONNumIncrBy(ctx, "user", ".name", "1")
+			},
+			err: errors.New("ERR wrong type of path value - expected a number but found string"),
+		},
+		{
+			name: "Numincrby increment not a number",
+			exec: func() (interface{}, error) {
+				return client.JSONNumIncrBy(ctx, "user", ".age", `"1"`)
+			},
+			err: errJSONNumber,
+		},
+		{
+			name: "Arrappend legacy",
+			exec: func() (interface{}, error) {
+				return client.JSONArrAppend(ctx, "user", ".tags", []string{`"ops"`, `{"level":1}`})
+			},
+			result: int64(4),
+		},
+		{
+			name: "Arrappend JSONPath",
+			exec: func() (interface{}, error) {
+				return client.JSONArrAppend(ctx, "user", "$.*", []string{`1`})
+			},
+			result: []interface{}{nil, nil, int64(3), nil, nil, int64(5)},
+		},
+		{
+			name: "Arrappend not an array",
+			exec: func() (interface{}, error) {
+				return client.JSONArrAppend(ctx, "user", ".address", []string{`1`})
+			},
+			err: errors.New("ERR wrong type of path value - expected array but found object"),
+		},
+		{name: "Get appended elements", exec: get(".tags"), result: `["admin","dev","ops",{"level":1},1]`},
+		{
+			name: "Objkeys legacy",
+			exec: func() (interface{}, error) {
+				return client.JSONObjKeys(ctx, "user", ".address")
+			},
+			result: []interface{}{"city", "house", "zip"},
+		},
+		{
+			name: "Objkeys JSONPath",
+			exec: func() (interface{}, error) {
+				return client.JSONObjKeys(ctx, "user", "$.friends[*]")
+			},
+			result: []interface{}{[]interface{}{"age", "name"}, []interface{}{"name"}, nil},
+		},
+		{
+			name: "Objkeys missing key",
+			exec: func() (interface{}, error) {
+				return client.JSONObjKeys(ctx, "missing", "")
+			},
+			err: redis.Nil,
+		},
+		{
+			name: "Del array elements",
+			exec: func() (interface{}, error) {
+				return client.JSONDel(ctx, "user", "$.tags[*]")
+			},
+			result: int64(5),
+		},
+		{
+			name: "Del members",
+			exec: func() (interface{}, error) {
+				return client.JSONDel(ctx, "user", "$..name")
+			},
+			result: int64(3),
+		},
+		{name: "Get after del", exec: get("$"), result: `[{"address":{"city":"moscow","house":{"number":7},"zip":"101000"},"age":24,"friends":[{"age":19},{},1],"score":2.0,"tags":[]}]`},
+		{
+			name: "Del root",
+			exec: func() (interface{}, error) {
+				return client.JSONDel(ctx, "user", "")
+			},
+			result: int64(1),
+		},
+		{name: "Get missing key", exec: get(), err: redis.Nil},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := tc.exec()
+			if tc.err != nil {
+				assert.Equal(t, tc.err, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.result, res)
+		})
+	}
+
+	_, err = client.SetString(ctx, "name", "ivan", models.Expiration{})
+	assert.NoError(t, err)
+	_, err = client.JSONGet(ctx, "name", nil)
+	assert.Equal(t, ErrWrongType, err)
+	_, err = client.JSONSet(ctx, "name", "$", `1`, models.JSONSetOptions{})
+	assert.Equal(t, ErrWrongType, err)
+}
+
+func TestNativeJSONMemory(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	_, err := client.JSONSet(ctx, "doc", "$", `{"a":[1,2,3]}`, models.JSONSetOptions{})
+	assert.NoError(t, err)
+	used := client.used
+
+	_, err = client.JSONArrAppend(ctx, "doc", "$.a", []string{`"four"`})
+	assert.NoError(t, err)
+	assert.Equal(t, used+int64(len(`,"four"`)), client.used)
+
+	_, err = client.JSONDel(ctx, "doc", "$")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), client.used)
+}
+
+func TestNativeJSONAOF(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aof")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "appendonly.aof")
+	ctx := context.Background()
+
+	client := NewNative()
+	assert.NoError(t, client.OpenAOF(path, FsyncAlways))
+
+	_, err = client.JSONSet(ctx, "user", "$", testJSONDocument, models.JSONSetOptions{})
+	assert.NoError(t, err)
+	_, err = client.JSONSet(ctx, "user", "$.address.house", `7`, models.JSONSetOptions{NX: true})
+	assert.NoError(t, err)
+	_, err = client.JSONNumIncrBy(ctx, "user", "$..age", "1")
+	assert.NoError(t, err)
+	_, err = client.JSONArrAppend(ctx, "user", ".tags", []string{`"ops"`})
+	assert.NoError(t, err)
+	_, err = client.JSONDel(ctx, "user", "$.friends[0]")
+	assert.NoError(t, err)
+	expected, err := client.JSONGet(ctx, "user", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, client.Close())
+
+	restored := NewNative()
+	assert.NoError(t, restored.OpenAOF(path, FsyncNo))
+	defer restored.Close()
+
+	doc, err := restored.JSONGet(ctx, "user", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, doc)
+	assert.Equal(t, `{"address":{"city":"moscow","house":7,"zip":"101000"},"age":22,"friends":[{"name":"anna"}],"name":"ivan",`+
+		`"score":1.5,"tags":["admin","dev","ops"]}`, doc)
+}
+
+func TestNativeJSONRDB(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	_, err := client.JSONSet(ctx, "doc", "$", `{"a":1}`, models.JSONSetOptions{})
+	assert.NoError(t, err)
+	_, err = client.SetString(ctx, "name", "ivan", models.Expiration{})
+	assert.NoError(t, err)
+
+	var b bytes.Buffer
+	_, err = writeRDB(&b, client.data)
+	assert.NoError(t, err)
+
+	restored := NewNative()
+	loaded, err := restored.LoadRDB(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, loaded)
+}
+
+func TestAddJSONNumbers(t *testing.T) {
+	type testCase struct {
+		name   string
+		a, b   string
+		result string
+		err    error
+	}
+
+	tCases := []testCase{
+		{name: "Integers", a: "21", b: "-1", result: "20"},
+		{name: "Integer and float", a: "1", b: "0.5", result: "1.5"},
+		{name: "Float with zero fraction", a: "1.5", b: "0.5", result: "2.0"},
+		{name: "Integer overflow", a: "9223372036854775807", b: "1", result: "9.223372036854776e+18"},
+		{name: "Infinity", a: "1e308", b: "1e308", err: errIncrNaN},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := addJSONNumbers(json.Number(tc.a), json.Number(tc.b))
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.result, res.String())
+		})
+	}
+}
@@ -0,0 +1,551 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// Ends of the list used by LMOVE
+const (
+	ListLeft  = "left"
+	ListRight = "right"
+)
+
+var (
+	errCountNegative  = errors.New("ERR COUNT can't be negative")
+	errMaxLenNegative = errors.New("ERR MAXLEN can't be negative")
+	errRankZero       = errors.New("ERR RANK can't be zero: use 1 to start from the first match, 2 from the second ... or use negative to start from the end of the list")
+)
+
+// LPush - prepends elements one by one, so that the last element becomes the head. Returns length of the list.
+func (n *Native) LPush(ctx context.Context, key string, values []interface{}) (int64, error) {
+	return n.push(ctx, key, values, true)
+}
+
+// RPush - appends elements to the list, returns length of the list
+func (n *Native) RPush(ctx context.Context, key string, values []interface{}) (int64, error) {
+	return n.push(ctx, key, values, false)
+}
+
+// LPop - removes and returns count elements from the head, redis.Nil if there is no such key
+func (n *Native) LPop(ctx context.Context, key string, count int64) ([]interface{}, error) {
+	return n.pop(ctx, key, count, true)
+}
+
+// RPop - removes and returns count elements from the tail, redis.Nil if there is no such key
+func (n *Native) RPop(ctx context.Context, key string, count int64) ([]interface{}, error) {
+	return n.pop(ctx, key, count, false)
+}
+
+// LLen - length of the list, 0 if there is no such key
+func (n *Native) LLen(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	list, err := n.list(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(list)), nil
+}
+
+// LIndex - element by index, negative index is counted from the tail. redis.Nil if the index is out of range.
+func (n *Native) LIndex(ctx context.Context, key string, index int64) (interface{}, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	list, err := n.list(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if index < 0 {
+		index += int64(len(list))
+	}
+	if index < 0 || index >= int64(len(list)) {
+		return nil, redis.Nil
+	}
+
+	return decodeListElement(list[index])
+}
+
+// LInsert - inserts the value before or after the first element equal to pivot. Returns length of the list,
+// -1 if there is no pivot and 0 if there is no such key.
+func (n *Native) LInsert(ctx context.Context, key string, before bool, pivot, value interface{}) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	encodedPivot, err := encodeListElement(pivot)
+	if err != nil {
+		return 0, err
+	}
+
+	encoded, err := encodeListElement(value)
+	if err != nil {
+		return 0, err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return 0, err
+	}
+
+	length, err := n.linsert(key, before, encodedPivot, encoded)
+	if err != nil || length <= 0 {
+		return length, err
+	}
+
+	where := "AFTER"
+	if before {
+		where = "BEFORE"
+	}
+
+	n.notify(notifyList, "linsert", key)
+	return length, n.propagate("LINSERT", key, where, encodedPivot, encoded)
+}
+
+// LRem - removes count elements equal to the value from the head, negative count removes them from the tail,
+// 0 removes all of them. Returns number of removed elements.
+func (n *Native) LRem(ctx context.Context, key string, count int64, value interface{}) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	encoded, err := encodeListElement(value)
+	if err != nil {
+		return 0, err
+	}
+
+	defer n.lock(ctx)()
+
+	removed, err := n.lrem(key, count, encoded)
+	if err != nil || removed == 0 {
+		return 0, err
+	}
+
+	n.notify(notifyList, "lrem", key)
+	n.notifyDeleted(key)
+	return removed, n.propagate("LREM", key, strconv.FormatInt(count, 10), encoded)
+}
+
+// LTrim - keeps only elements between start and stop, the key is removed if nothing is left
+func (n *Native) LTrim(ctx context.Context, key string, start, stop int64) error {
+	if key == "" {
+		return fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	trimmed, err := n.ltrim(key, start, stop)
+	if err != nil || !trimmed {
+		return err
+	}
+
+	n.notify(notifyList, "ltrim", key)
+	n.notifyDeleted(key)
+	return n.propagate("LTRIM", key, strconv.FormatInt(start, 10), strconv.FormatInt(stop, 10))
+}
+
+// LPos - indexes of elements equal to the value, see models.LPosArgs
+func (n *Native) LPos(ctx context.Context, key string, value interface{}, args models.LPosArgs) ([]int64, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+	if err := checkLPos(args); err != nil {
+		return nil, err
+	}
+
+	encoded, err := encodeListElement(value)
+	if err != nil {
+		return nil, err
+	}
+
+	defer n.lock(ctx)()
+
+	list, err := n.list(key)
+	if err != nil {
+		return nil, err
+	}
+
+	rank, limit := lposRank(args), lposLimit(args)
+	step, i := int64(1), int64(0)
+	if rank < 0 {
+		step, i, rank = -1, int64(len(list))-1, -rank
+	}
+
+	res := []int64{}
+	for compared := int64(0); i >= 0 && i < int64(len(list)); i += step {
+		if args.MaxLen > 0 && compared == args.MaxLen {
+			break
+		}
+		compared++
+
+		if list[i] != encoded {
+			continue
+		}
+		if rank > 1 {
+			rank--
+			continue
+		}
+
+		res = append(res, i)
+		if limit > 0 && int64(len(res)) == limit {
+			break
+		}
+	}
+
+	return res, nil
+}
+
+// LMove - pops the element from one end of the source and pushes it to one end of the destination,
+// redis.Nil if there is no source
+func (n *Native) LMove(ctx context.Context, source, destination, from, to string) (interface{}, error) {
+	if source == "" || destination == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+	if err := checkListEnds(from, to); err != nil {
+		return nil, err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return nil, err
+	}
+
+	element, err := n.lmove(source, destination, from == ListLeft, to == ListLeft)
+	if err != nil {
+		return nil, err
+	}
+
+	n.notify(notifyList, from[:1]+"pop", source)
+	n.notifyDeleted(source)
+	n.notify(notifyList, to[:1]+"push", destination)
+	if err := n.propagate("LMOVE", source, destination, strings.ToUpper(from), strings.ToUpper(to)); err != nil {
+		return nil, err
+	}
+
+	return decodeListElement(element)
+}
+
+// push - LPUSH or RPUSH
+func (n *Native) push(ctx context.Context, key string, values []interface{}, left bool) (int64, error) {
+	if key == "" || len(values) == 0 {
+		return 0, fmt.Errorf("Empty key or field")
+	}
+
+	encoded, err := encodeListElements(values)
+	if err != nil {
+		return 0, err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return 0, err
+	}
+
+	command := "RPUSH"
+	var length int64
+	if left {
+		command = "LPUSH"
+		length, err = n.lpush(key, encoded)
+	} else {
+		length, err = n.rpush(key, encoded)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	n.notify(notifyList, strings.ToLower(command), key)
+	return length, n.propagate(append([]string{command, key}, encoded...)...)
+}
+
+// pop - LPOP or RPOP with count
+func (n *Native) pop(ctx context.Context, key string, count int64, left bool) ([]interface{}, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+	if count <= 0 {
+		return nil, errNegative
+	}
+
+	defer n.lock(ctx)()
+
+	popped, err := n.lpop(key, count, left)
+	if err != nil {
+		return nil, err
+	}
+
+	command := "RPOP"
+	if left {
+		command = "LPOP"
+	}
+
+	n.notify(notifyList, strings.ToLower(command), key)
+	n.notifyDeleted(key)
+	if err := n.propagate(command, key, strconv.FormatInt(count, 10)); err != nil {
+		return nil, err
+	}
+
+	return decodeListElements(popped)
+}
+
+// lpush - prepends encoded elements to the list, returns length of the list
+func (n *Native) lpush(key string, values []string) (int64, error) {
+	e := n.lookupWrite(key)
+	if e == nil {
+		e = n.newEntry(key, []string{})
+		n.putEntry(key, e)
+	}
+
+	list, ok := e.value.([]string)
+	if !ok {
+		return 0, ErrWrongType
+	}
+
+	res := make([]string, 0, len(list)+len(values))
+	for i := len(values) - 1; i >= 0; i-- {
+		res = append(res, values[i])
+		n.resize(e, elementOverhead+int64(len(values[i])))
+	}
+	e.value = append(res, list...)
+	n.signalReady(key)
+
+	return int64(len(res) + len(list)), nil
+}
+
+// lpop - removes count encoded elements from the head or the tail, the key is removed together with the last
+// element. redis.Nil if there is no such key.
+func (n *Native) lpop(key string, count int64, left bool) ([]string, error) {
+	e := n.lookupWrite(key)
+	if e == nil {
+		return nil, redis.Nil
+	}
+
+	list, ok := e.value.([]string)
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	if count > int64(len(list)) {
+		count = int64(len(list))
+	}
+
+	popped := make([]string, count)
+	if left {
+		copy(popped, list[:count])
+		e.value = append([]string{}, list[count:]...)
+	} else {
+		for i := range popped {
+			popped[i] = list[len(list)-1-i]
+		}
+		e.value = list[:int64(len(list))-count]
+	}
+
+	for _, el := range popped {
+		n.resize(e, -elementOverhead-int64(len(el)))
+	}
+
+	if len(e.value.([]string)) == 0 {
+		n.remove(key)
+	}
+
+	return popped, nil
+}
+
+// linsert - see LInsert, elements are encoded
+func (n *Native) linsert(key string, before bool, pivot, value string) (int64, error) {
+	e := n.lookupWrite(key)
+	if e == nil {
+		return 0, nil
+	}
+
+	list, ok := e.value.([]string)
+	if !ok {
+		return 0, ErrWrongType
+	}
+
+	for i, el := range list {
+		if el != pivot {
+			continue
+		}
+
+		if !before {
+			i++
+		}
+
+		res := make([]string, 0, len(list)+1)
+		res = append(res, list[:i]...)
+		res = append(res, value)
+		e.value = append(res, list[i:]...)
+		n.resize(e, elementOverhead+int64(len(value)))
+
+		return int64(len(list) + 1), nil
+	}
+
+	return -1, nil
+}
+
+// lrem - see LRem, the key is removed together with the last element
+func (n *Native) lrem(key string, count int64, value string) (int64, error) {
+	e := n.lookupWrite(key)
+	if e == nil {
+		return 0, nil
+	}
+
+	list, ok := e.value.([]string)
+	if !ok {
+		return 0, ErrWrongType
+	}
+
+	limit := count
+	if limit < 0 {
+		limit = -limit
+	}
+
+	remove := make(map[int]struct{})
+	for i := range list {
+		j := i
+		if count < 0 {
+			j = len(list) - 1 - i
+		}
+
+		if list[j] == value {
+			remove[j] = struct{}{}
+			if limit > 0 && int64(len(remove)) == limit {
+				break
+			}
+		}
+	}
+
+	if len(remove) == 0 {
+		return 0, nil
+	}
+
+	res := make([]string, 0, len(list)-len(remove))
+	for i, el := range list {
+		if _, ok := remove[i]; ok {
+			n.resize(e, -elementOverhead-int64(len(el)))
+			continue
+		}
+		res = append(res, el)
+	}
+	e.value = res
+
+	if len(res) == 0 {
+		n.remove(key)
+	}
+
+	return int64(len(remove)), nil
+}
+
+// ltrim - see LTrim, returns false if there is no such key
+func (n *Native) ltrim(key string, start, stop int64) (bool, error) {
+	e := n.lookupWrite(key)
+	if e == nil {
+		return false, nil
+	}
+
+	list, ok := e.value.([]string)
+	if !ok {
+		return false, ErrWrongType
+	}
+
+	start, stop, ok = normalizeRange(int64(len(list)), start, stop)
+	if !ok {
+		n.remove(key)
+		return true, nil
+	}
+
+	for i, el := range list {
+		if int64(i) < start || int64(i) > stop {
+			n.resize(e, -elementOverhead-int64(len(el)))
+		}
+	}
+	e.value = append([]string{}, list[start:stop+1]...)
+
+	return true, nil
+}
+
+// lmove - see LMove, returns the encoded element
+func (n *Native) lmove(source, destination string, fromLeft, toLeft bool) (string, error) {
+	if _, err := n.list(destination); err != nil {
+		return "", err
+	}
+
+	popped, err := n.lpop(source, 1, fromLeft)
+	if err != nil {
+		return "", err
+	}
+
+	if toLeft {
+		_, err = n.lpush(destination, popped)
+	} else {
+		_, err = n.rpush(destination, popped)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return popped[0], nil
+}
+
+// checkLPos - RANK may not be zero unless it is omitted, COUNT and MAXLEN may not be negative
+func checkLPos(args models.LPosArgs) error {
+	if args.Rank != nil && *args.Rank == 0 {
+		return errRankZero
+	}
+	if args.Count != nil && *args.Count < 0 {
+		return errCountNegative
+	}
+	if args.MaxLen < 0 {
+		return errMaxLenNegative
+	}
+
+	return nil
+}
+
+// lposRank - omitted rank means the first match
+func lposRank(args models.LPosArgs) int64 {
+	if args.Rank == nil {
+		return 1
+	}
+
+	return *args.Rank
+}
+
+// lposLimit - without count only the first match is returned, 0 count means all matches
+func lposLimit(args models.LPosArgs) int64 {
+	if args.Count == nil {
+		return 1
+	}
+
+	return *args.Count
+}
+
+// checkListEnds - ends of LMOVE are left or right
+func checkListEnds(ends ...string) error {
+	for _, end := range ends {
+		if end != ListLeft && end != ListRight {
+			return errSyntax
+		}
+	}
+
+	return nil
+}
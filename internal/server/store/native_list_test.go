@@ -0,0 +1,411 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNativeListPushPop(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	length, err := client.RPush(ctx, "list", []interface{}{"b", int64(3)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), length)
+
+	length, err = client.LPush(ctx, "list", []interface{}{2.5, map[string]interface{}{"name": "ivan"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), length)
+
+	list, err := client.GetList(ctx, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "ivan"}, 2.5, "b", int64(3)}, list)
+
+	popped, err := client.RPop(ctx, "list", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(3)}, popped)
+
+	popped, err = client.LPop(ctx, "list", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "ivan"}, 2.5, "b"}, popped)
+
+	// the key is removed together with the last element
+	_, err = client.LPop(ctx, "list", 1)
+	assert.Equal(t, redis.Nil, err)
+	assert.Nil(t, client.peek("list"))
+
+	_, err = client.LPop(ctx, "list", 0)
+	assert.Equal(t, errNegative, err)
+
+	_, err = client.SAdd(ctx, "roles", []string{"admin"})
+	assert.NoError(t, err)
+	_, err = client.LPush(ctx, "roles", []interface{}{"a"})
+	assert.Equal(t, ErrWrongType, err)
+}
+
+func TestListElementCodec(t *testing.T) {
+	type testCase struct {
+		name    string
+		value   interface{}
+		encoded string
+		decoded interface{}
+	}
+
+	tCases := []testCase{
+		{name: "string", value: "ivan", encoded: `{"Dtype":"string","Data":"ivan"}`, decoded: "ivan"},
+		{name: "int", value: 21, encoded: `{"Dtype":"int","Data":"21"}`, decoded: int64(21)},
+		{name: "int64", value: int64(-9007199254740993), encoded: `{"Dtype":"int64","Data":"-9007199254740993"}`, decoded: int64(-9007199254740993)},
+		{name: "float", value: 0.1234567, encoded: `{"Dtype":"float64","Data":"0.1234567"}`, decoded: 0.1234567},
+		{name: "big float", value: 1e21, encoded: `{"Dtype":"float64","Data":"1e+21"}`, decoded: 1e21},
+		{name: "bool", value: true, encoded: `{"Dtype":"bool","Data":"true"}`, decoded: true},
+		{name: "null", value: nil, encoded: `{"Dtype":"null","Data":""}`, decoded: nil},
+		{name: "map", value: map[string]interface{}{"name": "ivan"}, encoded: `{"Dtype":"map","Data":"{\"name\":\"ivan\"}"}`, decoded: map[string]interface{}{"name": "ivan"}},
+		{name: "array", value: []interface{}{1.5, "a"}, encoded: `{"Dtype":"json","Data":"[1.5,\"a\"]"}`, decoded: []interface{}{1.5, "a"}},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded, err := encodeListElement(tc.value)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.encoded, encoded)
+
+			decoded, err := decodeListElement(encoded)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.decoded, decoded)
+		})
+	}
+
+	// elements are matched by their encodings, so that close floats and strings looking like other types differ
+	client := NewNative()
+	ctx := context.Background()
+	_, err := client.RPush(ctx, "list", []interface{}{0.1234567, 0.12346, "true", true, "<nil>", nil})
+	assert.NoError(t, err)
+
+	for i, value := range []interface{}{0.1234567, 0.12346, "true", true, "<nil>", nil} {
+		positions, err := client.LPos(ctx, "list", value, models.LPosArgs{})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{int64(i)}, positions)
+	}
+}
+
+func TestNativeListEdit(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	_, err := client.RPush(ctx, "list", []interface{}{"a", int64(1), "b", int64(1), "c", int64(1)})
+	assert.NoError(t, err)
+
+	length, err := client.LLen(ctx, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), length)
+
+	el, err := client.LIndex(ctx, "list", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), el)
+
+	_, err = client.LIndex(ctx, "list", 6)
+	assert.Equal(t, redis.Nil, err)
+
+	// the float is not equal to the integer with the same value
+	length, err = client.LInsert(ctx, "list", true, 1.0, "x")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), length)
+
+	length, err = client.LInsert(ctx, "list", false, "a", "x")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), length)
+
+	length, err = client.LInsert(ctx, "missing", false, "a", "x")
+	assert.NoError(t, err)
+	assert.Zero(t, length)
+
+	type testCase struct {
+		name   string
+		value  interface{}
+		args   models.LPosArgs
+		result []int64
+		err    error
+	}
+
+	zero, one, two, minusOne := int64(0), int64(1), int64(2), int64(-1)
+	tCases := []testCase{
+		{name: "First match", value: int64(1), result: []int64{2}},
+		{name: "Rank", value: int64(1), args: models.LPosArgs{Rank: &two}, result: []int64{4}},
+		{name: "Negative rank", value: int64(1), args: models.LPosArgs{Rank: &minusOne, Count: &two}, result: []int64{6, 4}},
+		{name: "Zero rank", value: int64(1), args: models.LPosArgs{Rank: &zero}, err: errRankZero},
+		{name: "All matches", value: int64(1), args: models.LPosArgs{Count: &zero}, result: []int64{2, 4, 6}},
+		{name: "All matches from rank", value: int64(1), args: models.LPosArgs{Rank: &two, Count: &zero}, result: []int64{4, 6}},
+		{name: "Count", value: int64(1), args: models.LPosArgs{Rank: &one, Count: &two}, result: []int64{2, 4}},
+		{name: "Maxlen", value: "c", args: models.LPosArgs{MaxLen: 3}, result: []int64{}},
+		{name: "No match", value: "z", result: []int64{}},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := client.LPos(ctx, "list", tc.value, tc.args)
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.result, result)
+		})
+	}
+
+	removed, err := client.LRem(ctx, "list", -2, int64(1))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+
+	list, err := client.GetList(ctx, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "x", int64(1), "b", "c"}, list)
+
+	assert.NoError(t, client.LTrim(ctx, "list", 1, -2))
+	list, err = client.GetList(ctx, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"x", int64(1), "b"}, list)
+
+	// empty range removes the key
+	assert.NoError(t, client.LTrim(ctx, "list", 5, 10))
+	assert.Nil(t, client.peek("list"))
+}
+
+func TestNativeLMove(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	_, err := client.RPush(ctx, "src", []interface{}{"a", int64(2)})
+	assert.NoError(t, err)
+	_, err = client.SAdd(ctx, "roles", []string{"admin"})
+	assert.NoError(t, err)
+
+	el, err := client.LMove(ctx, "src", "dst", ListRight, ListLeft)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), el)
+
+	// rotation of the list
+	el, err = client.LMove(ctx, "src", "src", ListLeft, ListRight)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", el)
+
+	_, err = client.LMove(ctx, "src", "roles", ListLeft, ListRight)
+	assert.Equal(t, ErrWrongType, err)
+
+	_, err = client.LMove(ctx, "missing", "dst", ListLeft, ListRight)
+	assert.Equal(t, redis.Nil, err)
+
+	_, err = client.LMove(ctx, "src", "dst", "up", ListRight)
+	assert.Equal(t, errSyntax, err)
+
+	el, err = client.LMove(ctx, "src", "dst", ListLeft, ListRight)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", el)
+	assert.Nil(t, client.peek("src"))
+
+	list, err := client.GetList(ctx, "dst")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(2), "a"}, list)
+}
+
+func TestNativeListAOF(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aof")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "appendonly.aof")
+	ctx := context.Background()
+
+	client := NewNative()
+	assert.NoError(t, client.OpenAOF(path, FsyncAlways))
+
+	_, err = client.RPush(ctx, "list", []interface{}{"a", "b", "c", "d", "e"})
+	assert.NoError(t, err)
+	_, err = client.LPush(ctx, "list", []interface{}{int64(1)})
+	assert.NoError(t, err)
+	_, err = client.LPop(ctx, "list", 2)
+	assert.NoError(t, err)
+	_, err = client.RPop(ctx, "list", 1)
+	assert.NoError(t, err)
+	_, err = client.LInsert(ctx, "list", true, "c", 2.5)
+	assert.NoError(t, err)
+	_, err = client.LRem(ctx, "list", 0, "d")
+	assert.NoError(t, err)
+	_, err = client.LMove(ctx, "list", "other", ListLeft, ListLeft)
+	assert.NoError(t, err)
+	assert.NoError(t, client.LTrim(ctx, "list", 0, 0))
+	assert.NoError(t, client.Close())
+
+	restored := NewNative()
+	assert.NoError(t, restored.OpenAOF(path, FsyncNo))
+	defer restored.Close()
+
+	list, err := restored.GetList(ctx, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{2.5}, list)
+
+	list, err = restored.GetList(ctx, "other")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"b"}, list)
+}
+
+func TestNativeBlockingPop(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	_, err := client.RPush(ctx, "second", []interface{}{"a", "b"})
+	assert.NoError(t, err)
+
+	// the first non-empty list is served at once
+	res, err := client.BRPop(ctx, []string{"first", "second"}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.BPopResult{Key: "second", Value: "b"}, res)
+
+	_, err = client.BLPop(ctx, []string{"first"}, 10*time.Millisecond)
+	assert.Equal(t, redis.Nil, err)
+
+	_, err = client.BLPop(ctx, []string{"first"}, -time.Second)
+	assert.Equal(t, errTimeoutNegative, err)
+
+	_, err = client.SAdd(ctx, "roles", []string{"admin"})
+	assert.NoError(t, err)
+	_, err = client.BLPop(ctx, []string{"first", "roles"}, 0)
+	assert.Equal(t, ErrWrongType, err)
+
+	// the waiting client is served by the push to any of its keys
+	done := make(chan *models.BPopResult)
+	go func() {
+		res, err := client.BLPop(ctx, []string{"first", "third"}, 0)
+		assert.NoError(t, err)
+		done <- res
+	}()
+
+	waitBlocked(t, client, "third", 1)
+	_, err = client.RPush(ctx, "third", []interface{}{int64(1), int64(2)})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.BPopResult{Key: "third", Value: int64(1)}, <-done)
+	assert.Empty(t, client.blocked)
+
+	list, err := client.GetList(ctx, "third")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(2)}, list)
+
+	// cancellation of the context unblocks the client
+	cancelCtx, cancel := context.WithCancel(ctx)
+	errs := make(chan error)
+	go func() {
+		_, err := client.BLPop(cancelCtx, []string{"first"}, 0)
+		errs <- err
+	}()
+
+	waitBlocked(t, client, "first", 1)
+	cancel()
+	assert.Equal(t, context.Canceled, <-errs)
+	assert.Empty(t, client.blocked)
+}
+
+func TestNativeBlockingFairness(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	type testCase struct {
+		name   string
+		values []interface{}
+		// elements received by the clients in order of blocking
+		served []interface{}
+	}
+
+	replies := make([]chan interface{}, 3)
+	for i := range replies {
+		replies[i] = make(chan interface{}, 1)
+		go func(reply chan interface{}) {
+			res, err := client.BLPop(ctx, []string{"list"}, time.Second)
+			assert.NoError(t, err)
+			reply <- res.Value
+		}(replies[i])
+
+		// clients are blocked one after another
+		waitBlocked(t, client, "list", i+1)
+	}
+
+	// elements are handed to the clients in order of blocking, one element per client
+	tCases := []testCase{
+		{name: "First client", values: []interface{}{"a"}, served: []interface{}{"a"}},
+		{name: "Second and third clients", values: []interface{}{"b", "c", "d"}, served: []interface{}{"b", "c"}},
+	}
+
+	next := 0
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := client.RPush(ctx, "list", tc.values)
+			assert.NoError(t, err)
+
+			for _, value := range tc.served {
+				assert.Equal(t, value, <-replies[next])
+				next++
+			}
+		})
+	}
+
+	list, err := client.GetList(ctx, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"d"}, list)
+}
+
+func TestNativeBLMove(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aof")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "appendonly.aof")
+	ctx := context.Background()
+
+	client := NewNative()
+	assert.NoError(t, client.OpenAOF(path, FsyncAlways))
+
+	_, err = client.BLMove(ctx, "src", "dst", "up", ListLeft, 0)
+	assert.Equal(t, errSyntax, err)
+
+	moved := make(chan interface{})
+	go func() {
+		el, err := client.BLMove(ctx, "src", "dst", ListRight, ListLeft, 0)
+		assert.NoError(t, err)
+		moved <- el
+	}()
+
+	// the destination of BLMOVE becomes ready for the client blocked on it
+	popped := make(chan *models.BPopResult)
+	go func() {
+		waitBlocked(t, client, "src", 1)
+		res, err := client.BRPop(ctx, []string{"dst"}, 0)
+		assert.NoError(t, err)
+		popped <- res
+	}()
+
+	waitBlocked(t, client, "dst", 1)
+	_, err = client.LPush(ctx, "src", []interface{}{"a"})
+	assert.NoError(t, err)
+	assert.Equal(t, "a", <-moved)
+	assert.Equal(t, &models.BPopResult{Key: "dst", Value: "a"}, <-popped)
+	assert.NoError(t, client.Close())
+
+	restored := NewNative()
+	assert.NoError(t, restored.OpenAOF(path, FsyncNo))
+	defer restored.Close()
+
+	assert.Nil(t, restored.peek("src"))
+	assert.Nil(t, restored.peek("dst"))
+}
+
+// waitBlocked - waits until count clients are blocked on the key
+func waitBlocked(t *testing.T, client *Native, key string, count int) {
+	assert.Eventually(t, func() bool {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+
+		return len(client.blocked[key]) == count
+	}, time.Second, time.Millisecond)
+}
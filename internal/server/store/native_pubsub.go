@@ -0,0 +1,30 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// SetPubSubBuffer - number of messages kept for the subscriber, the subscriber is dropped when it is exceeded
+func (n *Native) SetPubSubBuffer(buffer int) error {
+	return n.pubsub.setBuffer(buffer)
+}
+
+// Publish - sends the message to subscribers of the channel and matching patterns, returns number of receivers
+func (n *Native) Publish(ctx context.Context, channel, message string) (int64, error) {
+	if channel == "" {
+		return 0, fmt.Errorf("Empty channel")
+	}
+
+	return n.pubsub.publish(channel, message), nil
+}
+
+// Subscribe - subscription to channels and patterns, it has to be closed by the client
+func (n *Native) Subscribe(ctx context.Context, channels, patterns []string) (Subscription, error) {
+	sub, err := n.pubsub.subscribe(channels, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
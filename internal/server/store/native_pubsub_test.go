@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNativePubSub(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	news, err := client.Subscribe(ctx, []string{"news", "sport"}, nil)
+	assert.NoError(t, err)
+	all, err := client.Subscribe(ctx, nil, []string{"n*"})
+	assert.NoError(t, err)
+
+	type testCase struct {
+		name      string
+		channel   string
+		receivers int64
+	}
+
+	tCases := []testCase{
+		{name: "Channel and pattern", channel: "news", receivers: 2},
+		{name: "Only channel", channel: "sport", receivers: 1},
+		{name: "Only pattern", channel: "notes", receivers: 1},
+		{name: "Nobody", channel: "weather", receivers: 0},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			receivers, err := client.Publish(ctx, tc.channel, "hello")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.receivers, receivers)
+		})
+	}
+
+	assert.Equal(t, &models.Message{Channel: "news", Payload: "hello"}, <-news.Messages())
+	assert.Equal(t, &models.Message{Channel: "sport", Payload: "hello"}, <-news.Messages())
+	assert.Equal(t, &models.Message{Channel: "news", Pattern: "n*", Payload: "hello"}, <-all.Messages())
+	assert.Equal(t, &models.Message{Channel: "notes", Pattern: "n*", Payload: "hello"}, <-all.Messages())
+
+	assert.NoError(t, news.Close())
+	_, ok := <-news.Messages()
+	assert.False(t, ok)
+	assert.NoError(t, news.Err())
+	assert.NotContains(t, client.pubsub.channels, "news")
+
+	receivers, err := client.Publish(ctx, "news", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), receivers)
+	assert.NoError(t, all.Close())
+	assert.Empty(t, client.pubsub.patterns)
+
+	_, err = client.Subscribe(ctx, nil, nil)
+	assert.Error(t, err)
+	_, err = client.Publish(ctx, "", "hello")
+	assert.Error(t, err)
+}
+
+func TestNativeSlowSubscriber(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	assert.Error(t, client.SetPubSubBuffer(0))
+	assert.NoError(t, client.SetPubSubBuffer(2))
+
+	slow, err := client.Subscribe(ctx, []string{"news"}, nil)
+	assert.NoError(t, err)
+	fast, err := client.Subscribe(ctx, []string{"news"}, nil)
+	assert.NoError(t, err)
+	defer fast.Close()
+
+	for i := 0; i < 2; i++ {
+		receivers, err := client.Publish(ctx, "news", "hello")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), receivers)
+		<-fast.Messages()
+	}
+
+	// buffer of the slow subscriber is full, so that it is dropped
+	receivers, err := client.Publish(ctx, "news", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), receivers)
+
+	// buffered messages are still delivered before the channel is closed
+	for i := 0; i < 2; i++ {
+		_, ok := <-slow.Messages()
+		assert.True(t, ok)
+	}
+	_, ok := <-slow.Messages()
+	assert.False(t, ok)
+	assert.Equal(t, ErrSlowSubscriber, slow.Err())
+	assert.NoError(t, slow.Close())
+}
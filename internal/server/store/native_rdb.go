@@ -0,0 +1,320 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/Vysogota99/redis-implementation/internal/server/rdb"
+)
+
+// RedisVersion - version of redis which behavior is implemented, it is written to RDB files
+const RedisVersion = "6.0.9"
+
+// SetDBFilename - sets path of RDB file written by Save and BGSave
+func (n *Native) SetDBFilename(path string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.dbFilename = path
+}
+
+// Save - writes snapshot to RDB file and flushes append only file to disk. Writers are not blocked while
+// the file is written.
+func (n *Native) Save(ctx context.Context) error {
+	snapshot, path, err := n.startSave()
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	size, err := writeSnapshot(path, snapshot)
+	n.finishSave(start, size, err)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.aof == nil {
+		return nil
+	}
+
+	return n.aof.sync()
+}
+
+// BGSave - starts writing of snapshot to RDB file in background
+func (n *Native) BGSave(ctx context.Context) error {
+	snapshot, path, err := n.startSave()
+	if err != nil {
+		return err
+	}
+
+	n.saving.Add(1)
+	go func() {
+		defer n.saving.Done()
+
+		start := time.Now()
+		size, err := writeSnapshot(path, snapshot)
+		if err != nil {
+			log.Printf("Background saving failed: %s", err)
+		}
+		n.finishSave(start, size, err)
+	}()
+
+	return nil
+}
+
+// SaveStatus - status of the last saving
+func (n *Native) SaveStatus(ctx context.Context) (*models.SaveStatus, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	status := n.saveStatus
+	return &status, nil
+}
+
+// startSave - freezes current entries, they are copied by writers until saving is finished
+func (n *Native) startSave() (map[string]*entry, string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.snapshot != nil {
+		return nil, "", ErrSaveInProgress
+	}
+
+	now := nowMs()
+	snapshot := make(map[string]*entry, len(n.data))
+	for key, e := range n.data {
+		if e.expireAt != 0 && e.expireAt <= now {
+			continue
+		}
+		snapshot[key] = e
+	}
+
+	n.snapshot = snapshot
+	n.saveStatus.InProgress = true
+
+	return snapshot, n.dbFilename, nil
+}
+
+// finishSave - releases frozen entries and updates status of saving
+func (n *Native) finishSave(start time.Time, size int64, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.snapshot = nil
+	n.saveStatus.InProgress = false
+	n.saveStatus.Duration = int64(time.Since(start) / time.Millisecond)
+
+	if err != nil {
+		n.saveStatus.Success = false
+		n.saveStatus.Error = err.Error()
+		return
+	}
+
+	n.saveStatus.Success = true
+	n.saveStatus.Error = ""
+	n.saveStatus.LastSave = time.Now().Unix()
+	n.saveStatus.Size = size
+}
+
+// writeSnapshot - writes entries to temporary file and renames it, so that the previous file is
+// replaced only by complete one. Returns size of the file.
+func writeSnapshot(path string, snapshot map[string]*entry) (int64, error) {
+	tmp := filepath.Join(filepath.Dir(path), fmt.Sprintf("temp-%d.rdb", os.Getpid()))
+	file, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp)
+
+	size, err := writeRDB(file, snapshot)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// writeRDB - writes entries in RDB format, returns number of written bytes
+func writeRDB(w io.Writer, snapshot map[string]*entry) (int64, error) {
+	writer := rdb.NewWriter(w)
+	if err := writer.WriteHeader(map[string]string{
+		"redis-ver":  RedisVersion,
+		"redis-bits": "64",
+		"ctime":      strconv.FormatInt(time.Now().Unix(), 10),
+	}); err != nil {
+		return 0, err
+	}
+
+	for key, e := range snapshot {
+		if _, ok := e.value.(*jsonDoc); ok {
+			// RDB of modules is not supported, documents are persisted only by append only file
+			log.Printf("Key %s of type %s is not saved to RDB", key, e.typeName())
+			continue
+		}
+
+		// sets are written as lists of members, sorted sets as members with scores, streams with their groups
+		value := e.value
+		switch v := value.(type) {
+		case map[string]struct{}:
+			value = sortedMembers(v)
+		case *zset:
+			value = v.scores()
+		case *stream:
+			value = v.rdbStream()
+		}
+
+		if err := writer.WriteEntry(&rdb.Entry{
+			Key:      key,
+			Type:     e.typeName(),
+			Value:    value,
+			ExpireAt: e.expireAt,
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := writer.WriteEnd(); err != nil {
+		return 0, err
+	}
+
+	return writer.Size(), nil
+}
+
+// LoadRDB - imports keys of the first database from RDB file, returns number of loaded keys.
+// Expired keys are skipped, loaded keys replace existing ones.
+func (n *Native) LoadRDB(r io.Reader) (int, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var loaded int
+	err := rdb.Parse(r, func(entry *rdb.Entry) error {
+		// native engine has only one database
+		if entry.DB != 0 {
+			log.Printf("Key %s of database %d is skipped", entry.Key, entry.DB)
+			return nil
+		}
+
+		if entry.ExpireAt != 0 && entry.ExpireAt <= nowMs() {
+			return nil
+		}
+
+		if err := n.loadEntry(entry); err != nil {
+			return err
+		}
+
+		loaded++
+		return nil
+	})
+
+	return loaded, err
+}
+
+// loadEntry - stores the key from RDB file and writes it to append only file
+func (n *Native) loadEntry(entry *rdb.Entry) error {
+	if n.del(entry.Key) {
+		if err := n.propagate("DEL", entry.Key); err != nil {
+			return err
+		}
+	}
+
+	switch entry.Type {
+	case rdb.TypeString:
+		value := entry.Value.(string)
+		n.setString(entry.Key, value, 0)
+		if err := n.propagate("SET", entry.Key, value); err != nil {
+			return err
+		}
+	case rdb.TypeHash:
+		fields := entry.Value.(map[string]string)
+		if _, err := n.hset(entry.Key, fields); err != nil {
+			return err
+		}
+		if err := n.propagate(hashCommand(entry.Key, fields)...); err != nil {
+			return err
+		}
+	case rdb.TypeList:
+		list, err := importList(entry.Value.([]string))
+		if err != nil {
+			return err
+		}
+		if _, err := n.rpush(entry.Key, list); err != nil {
+			return err
+		}
+		if err := n.propagate(append([]string{"RPUSH", entry.Key}, list...)...); err != nil {
+			return err
+		}
+	case rdb.TypeSet:
+		members := entry.Value.([]string)
+		if _, err := n.sadd(entry.Key, members); err != nil {
+			return err
+		}
+		if err := n.propagate(append([]string{"SADD", entry.Key}, members...)...); err != nil {
+			return err
+		}
+	case rdb.TypeZSet:
+		members := zmembersOf(entry.Value.(map[string]float64))
+		if _, err := n.zadd(entry.Key, members, models.ZAddOptions{}, false); err != nil {
+			return err
+		}
+		if err := n.propagate(zaddCommand(entry.Key, members)...); err != nil {
+			return err
+		}
+	case rdb.TypeStream:
+		// stream is restored by commands, so that it is written to append only file the same way
+		for _, args := range streamCommands(entry.Key, entry.Value.(*rdb.Stream)) {
+			if _, err := n.apply(args); err != nil {
+				return err
+			}
+			if err := n.propagate(args...); err != nil {
+				return err
+			}
+		}
+	}
+
+	if entry.ExpireAt == 0 {
+		return nil
+	}
+
+	n.pexpireAt(entry.Key, entry.ExpireAt)
+	return n.propagate("PEXPIREAT", entry.Key, strconv.FormatInt(entry.ExpireAt, 10))
+}
+
+// importList - elements written by the router are already encoded as models.ListElement,
+// elements written by other clients are imported as strings
+func importList(list []string) ([]string, error) {
+	res := make([]string, len(list))
+	for i, el := range list {
+		if _, err := decodeListElement(el); err == nil {
+			res[i] = el
+			continue
+		}
+
+		encoded, err := encodeListElement(el)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = encoded
+	}
+
+	return res, nil
+}
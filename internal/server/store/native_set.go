@@ -0,0 +1,320 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Operations of sets combined by combineSets
+const (
+	setInter = "SINTER"
+	setUnion = "SUNION"
+	setDiff  = "SDIFF"
+)
+
+// SAdd - adds members to the set, returns number of added members
+func (n *Native) SAdd(ctx context.Context, key string, members []string) (int64, error) {
+	if key == "" || len(members) == 0 {
+		return 0, fmt.Errorf("Empty key or members")
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return 0, err
+	}
+
+	added, err := n.sadd(key, members)
+	if err != nil {
+		return 0, err
+	}
+
+	if added > 0 {
+		n.notify(notifySet, "sadd", key)
+	}
+	return added, n.propagate(append([]string{"SADD", key}, members...)...)
+}
+
+// SRem - removes members from the set, returns number of removed members
+func (n *Native) SRem(ctx context.Context, key string, members []string) (int64, error) {
+	if key == "" || len(members) == 0 {
+		return 0, fmt.Errorf("Empty key or members")
+	}
+
+	defer n.lock(ctx)()
+
+	removed, err := n.srem(key, members)
+	if err != nil || removed == 0 {
+		return 0, err
+	}
+
+	n.notify(notifySet, "srem", key)
+	n.notifyDeleted(key)
+	return removed, n.propagate(append([]string{"SREM", key}, members...)...)
+}
+
+// SMembers - members of the set sorted in lexicographical order
+func (n *Native) SMembers(ctx context.Context, key string) ([]string, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	set, err := n.members(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return sortedMembers(set), nil
+}
+
+// SIsMember ...
+func (n *Native) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	if key == "" {
+		return false, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	set, err := n.members(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := set[member]
+	return ok, nil
+}
+
+// SCard - number of members of the set
+func (n *Native) SCard(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	set, err := n.members(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(set)), nil
+}
+
+// SInter - members which belong to all sets
+func (n *Native) SInter(ctx context.Context, keys []string) ([]string, error) {
+	return n.setOperation(ctx, setInter, keys)
+}
+
+// SUnion - members which belong to any of sets
+func (n *Native) SUnion(ctx context.Context, keys []string) ([]string, error) {
+	return n.setOperation(ctx, setUnion, keys)
+}
+
+// SDiff - members of the first set which do not belong to other sets
+func (n *Native) SDiff(ctx context.Context, keys []string) ([]string, error) {
+	return n.setOperation(ctx, setDiff, keys)
+}
+
+// SInterStore - stores intersection of sets in destination, returns its cardinality
+func (n *Native) SInterStore(ctx context.Context, destination string, keys []string) (int64, error) {
+	return n.setOperationStore(ctx, setInter, destination, keys)
+}
+
+// SUnionStore - stores union of sets in destination, returns its cardinality
+func (n *Native) SUnionStore(ctx context.Context, destination string, keys []string) (int64, error) {
+	return n.setOperationStore(ctx, setUnion, destination, keys)
+}
+
+// SDiffStore - stores difference of sets in destination, returns its cardinality
+func (n *Native) SDiffStore(ctx context.Context, destination string, keys []string) (int64, error) {
+	return n.setOperationStore(ctx, setDiff, destination, keys)
+}
+
+func (n *Native) setOperation(ctx context.Context, op string, keys []string) ([]string, error) {
+	if err := checkKeys(keys); err != nil {
+		return nil, err
+	}
+
+	defer n.lock(ctx)()
+
+	res, err := n.combineSets(op, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return sortedMembers(res), nil
+}
+
+// setOperationStore - result of the operation replaces destination together with its time to live,
+// empty result removes destination
+func (n *Native) setOperationStore(ctx context.Context, op, destination string, keys []string) (int64, error) {
+	if err := checkKeys(append([]string{destination}, keys...)); err != nil {
+		return 0, err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return 0, err
+	}
+
+	res, err := n.combineSets(op, keys)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := n.del(destination)
+	if deleted {
+		if err := n.propagate("DEL", destination); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(res) == 0 {
+		if deleted {
+			n.notify(notifyGeneric, "del", destination)
+		}
+		return 0, nil
+	}
+
+	members := sortedMembers(res)
+	if _, err := n.sadd(destination, members); err != nil {
+		return 0, err
+	}
+
+	n.notify(notifySet, strings.ToLower(op)+"store", destination)
+	return int64(len(members)), n.propagate(append([]string{"SADD", destination}, members...)...)
+}
+
+// combineSets - applies the operation to sets stored by keys, missing keys are treated as empty sets
+func (n *Native) combineSets(op string, keys []string) (map[string]struct{}, error) {
+	sets := make([]map[string]struct{}, len(keys))
+	for i, key := range keys {
+		set, err := n.members(key)
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+
+	res := make(map[string]struct{})
+	switch op {
+	case setInter:
+		for member := range sets[0] {
+			inAll := true
+			for _, set := range sets[1:] {
+				if _, ok := set[member]; !ok {
+					inAll = false
+					break
+				}
+			}
+
+			if inAll {
+				res[member] = struct{}{}
+			}
+		}
+	case setUnion:
+		for _, set := range sets {
+			for member := range set {
+				res[member] = struct{}{}
+			}
+		}
+	case setDiff:
+		for member := range sets[0] {
+			res[member] = struct{}{}
+		}
+		for _, set := range sets[1:] {
+			for member := range set {
+				delete(res, member)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// sadd - adds members to the set, creates it if there is no such key. Returns number of added members.
+func (n *Native) sadd(key string, members []string) (int64, error) {
+	e := n.lookupWrite(key)
+	if e == nil {
+		e = n.newEntry(key, map[string]struct{}{})
+		n.putEntry(key, e)
+	}
+
+	set, ok := e.value.(map[string]struct{})
+	if !ok {
+		return 0, ErrWrongType
+	}
+
+	var added int64
+	for _, member := range members {
+		if _, ok := set[member]; ok {
+			continue
+		}
+
+		set[member] = struct{}{}
+		n.resize(e, elementOverhead+int64(len(member)))
+		added++
+	}
+
+	return added, nil
+}
+
+// srem - removes members from the set, the key is removed together with the last member
+func (n *Native) srem(key string, members []string) (int64, error) {
+	e := n.lookupWrite(key)
+	if e == nil {
+		return 0, nil
+	}
+
+	set, ok := e.value.(map[string]struct{})
+	if !ok {
+		return 0, ErrWrongType
+	}
+
+	var removed int64
+	for _, member := range members {
+		if _, ok := set[member]; !ok {
+			continue
+		}
+
+		delete(set, member)
+		n.resize(e, -elementOverhead-int64(len(member)))
+		removed++
+	}
+
+	if len(set) == 0 {
+		n.remove(key)
+	}
+
+	return removed, nil
+}
+
+// members - returns set stored by the key, missing key is treated as an empty set
+func (n *Native) members(key string) (map[string]struct{}, error) {
+	e := n.lookup(key)
+	if e == nil {
+		return map[string]struct{}{}, nil
+	}
+
+	set, ok := e.value.(map[string]struct{})
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	return set, nil
+}
+
+func sortedMembers(set map[string]struct{}) []string {
+	res := make([]string, 0, len(set))
+	for member := range set {
+		res = append(res, member)
+	}
+	sort.Strings(res)
+
+	return res
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNativeSet(t *testing.T) {
+	client := NewNative()
+
+	added, err := client.SAdd(context.Background(), "roles", []string{"user", "admin", "user"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), added)
+
+	members, err := client.SMembers(context.Background(), "roles")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"admin", "user"}, members)
+
+	ok, err := client.SIsMember(context.Background(), "roles", "admin")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	removed, err := client.SRem(context.Background(), "roles", []string{"admin", "guest"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+
+	card, err := client.SCard(context.Background(), "roles")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), card)
+
+	// the key is removed together with the last member
+	_, err = client.SRem(context.Background(), "roles", []string{"user"})
+	assert.NoError(t, err)
+	assert.NotContains(t, client.data, "roles")
+	assert.Zero(t, client.used)
+
+	members, err = client.SMembers(context.Background(), "roles")
+	assert.NoError(t, err)
+	assert.Empty(t, members)
+
+	_, err = client.SetString(context.Background(), "user:1", "Ivan", models.Expiration{})
+	assert.NoError(t, err)
+	_, err = client.SAdd(context.Background(), "user:1", []string{"admin"})
+	assert.Equal(t, ErrWrongType, err)
+	assert.True(t, IsWrongType(err))
+}
+
+func TestNativeSetOperations(t *testing.T) {
+	type testCase struct {
+		name      string
+		operation func(ctx context.Context, keys []string) ([]string, error)
+		store     func(ctx context.Context, destination string, keys []string) (int64, error)
+		keys      []string
+		members   []string
+	}
+
+	client := NewNative()
+	for key, members := range map[string][]string{
+		"a": {"1", "2", "3"},
+		"b": {"2", "3", "4"},
+		"c": {"3", "5"},
+	} {
+		_, err := client.SAdd(context.Background(), key, members)
+		assert.NoError(t, err)
+	}
+
+	tCases := []testCase{
+		{
+			name:      "Inter",
+			operation: client.SInter,
+			store:     client.SInterStore,
+			keys:      []string{"a", "b", "c"},
+			members:   []string{"3"},
+		},
+		{
+			name:      "Union",
+			operation: client.SUnion,
+			store:     client.SUnionStore,
+			keys:      []string{"a", "c"},
+			members:   []string{"1", "2", "3", "5"},
+		},
+		{
+			name:      "Diff",
+			operation: client.SDiff,
+			store:     client.SDiffStore,
+			keys:      []string{"a", "b"},
+			members:   []string{"1"},
+		},
+		{
+			name:      "Missing key",
+			operation: client.SInter,
+			store:     client.SInterStore,
+			keys:      []string{"a", "missing"},
+			members:   []string{},
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			members, err := tc.operation(context.Background(), tc.keys)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.members, members)
+
+			card, err := tc.store(context.Background(), "dest", tc.keys)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(tc.members)), card)
+
+			members, err = client.SMembers(context.Background(), "dest")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.members, members)
+		})
+	}
+
+	// destination may be one of the sources
+	card, err := client.SUnionStore(context.Background(), "a", []string{"a", "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), card)
+
+	_, err = client.SInter(context.Background(), []string{"a", ""})
+	assert.Error(t, err)
+}
@@ -0,0 +1,678 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+)
+
+// XAdd - adds the entry to the stream, returns its id. The id is generated from the current time unless it is given.
+func (n *Native) XAdd(ctx context.Context, key, id string, fields map[string]interface{}, trim models.XTrim) (string, error) {
+	if err := checkXAdd(key, id, fields, trim); err != nil {
+		return "", err
+	}
+
+	values, err := streamFields(fields)
+	if err != nil {
+		return "", err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return "", err
+	}
+
+	s, err := n.stream(key)
+	if err != nil {
+		return "", err
+	}
+	if s == nil {
+		s = newStream()
+	}
+
+	next, err := nextStreamID(s, id)
+	if err != nil {
+		return "", err
+	}
+
+	e, s, _ := n.streamForWrite(key, true)
+	n.xadd(e, s, next, values)
+	n.notify(notifyStream, "xadd", key)
+	if err := n.propagate(append([]string{"XADD", key, next.String()}, values...)...); err != nil {
+		return "", err
+	}
+
+	if _, err := n.xtrimPropagate(key, e, s, trim); err != nil {
+		return "", err
+	}
+
+	return next.String(), nil
+}
+
+// XRange - entries between start and end ordered by id
+func (n *Native) XRange(ctx context.Context, key string, query models.XRangeQuery) ([]models.StreamEntry, error) {
+	query = xrangeDefaults(query, false)
+	return n.xrange(ctx, key, query.Start, query.End, query.Count, false)
+}
+
+// XRevRange - entries between start and end in reverse order, start is the highest bound
+func (n *Native) XRevRange(ctx context.Context, key string, query models.XRangeQuery) ([]models.StreamEntry, error) {
+	query = xrangeDefaults(query, true)
+	return n.xrange(ctx, key, query.End, query.Start, query.Count, true)
+}
+
+// XLen - number of entries of the stream
+func (n *Native) XLen(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	s, err := n.stream(key)
+	if err != nil || s == nil {
+		return 0, err
+	}
+
+	return int64(len(s.entries)), nil
+}
+
+// XTrim - removes the oldest entries, returns number of removed entries
+func (n *Native) XTrim(ctx context.Context, key string, trim models.XTrim) (int64, error) {
+	if err := checkXTrim(key, trim, true); err != nil {
+		return 0, err
+	}
+
+	defer n.lock(ctx)()
+
+	e, s, err := n.streamForWrite(key, false)
+	if err != nil || s == nil {
+		return 0, err
+	}
+
+	return n.xtrimPropagate(key, e, s, trim)
+}
+
+// XRead - entries of streams with ids greater than the given ones, streams without such entries are skipped
+func (n *Native) XRead(ctx context.Context, query models.XReadQuery) ([]models.XStream, error) {
+	if err := checkXRead(query.Keys, query.IDs, false); err != nil {
+		return nil, err
+	}
+
+	defer n.lock(ctx)()
+
+	res := []models.XStream{}
+	for i, key := range query.Keys {
+		s, err := n.stream(key)
+		if err != nil {
+			return nil, err
+		}
+		if s == nil {
+			continue
+		}
+
+		after := s.lastID
+		if query.IDs[i] != "$" {
+			after, _ = parseStreamID(query.IDs[i], 0)
+		}
+
+		start, ok := after.incr()
+		if !ok {
+			continue
+		}
+
+		entries := s.rangeEntries(start, maxStreamID, query.Count, false)
+		if len(entries) != 0 {
+			res = append(res, models.XStream{Stream: key, Entries: streamEntryModels(entries)})
+		}
+	}
+
+	return res, nil
+}
+
+// XGroupCreate - creates consumer group which starts after id, "$" is the last id of the stream
+func (n *Native) XGroupCreate(ctx context.Context, key, group, id string, mkstream bool) error {
+	if id == "" {
+		id = "$"
+	}
+	if err := checkXGroupCreate(key, group, id); err != nil {
+		return err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return err
+	}
+
+	created := n.peek(key) == nil
+	lastID, err := n.xgroupCreate(key, group, id, mkstream)
+	if err != nil {
+		return err
+	}
+
+	n.notify(notifyStream, "xgroup-create", key)
+	args := []string{"XGROUP", "CREATE", key, group, lastID.String()}
+	if created {
+		args = append(args, "MKSTREAM")
+	}
+
+	return n.propagate(args...)
+}
+
+// XReadGroup - with ">" entries never delivered to the group are read and added to pending entries list of
+// the consumer, other ids read entries pending for the consumer
+func (n *Native) XReadGroup(ctx context.Context, args models.XReadGroupRequest) ([]models.XStream, error) {
+	ids, err := checkXReadGroup(args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return nil, err
+	}
+
+	// groups are checked before anything is read, so that the command fails as a whole
+	for _, key := range args.Keys {
+		if _, err := n.streamGroup(key, args.Group, "XREADGROUP"); err != nil {
+			return nil, err
+		}
+	}
+
+	res := []models.XStream{}
+	now := nowMs()
+	for i, key := range args.Keys {
+		e, s, _ := n.streamForWrite(key, false)
+		group := s.groups[args.Group]
+		n.touchConsumer(e, group, args.Consumer, now)
+
+		if ids[i] != ">" {
+			after, _ := parseStreamID(ids[i], 0)
+			res = append(res, models.XStream{
+				Stream:  key,
+				Entries: consumerPending(s, group, args.Consumer, after, args.Count),
+			})
+			continue
+		}
+
+		start, _ := group.lastID.incr()
+		entries := s.rangeEntries(start, maxStreamID, args.Count, false)
+		if len(entries) == 0 {
+			continue
+		}
+		group.lastID = entries[len(entries)-1].id
+
+		// delivered entries are written as claimed, so that replay does not depend on the time
+		for i := 0; i < len(entries) && !args.NoAck; i++ {
+			pending := n.deliver(e, group, args.Consumer, entries[i].id, now)
+			if err := n.propagate(xclaimCommand(key, args.Group, args.Consumer, entries[i].id, pending)...); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := n.propagate("XGROUP", "SETID", key, args.Group, group.lastID.String()); err != nil {
+			return nil, err
+		}
+
+		res = append(res, models.XStream{Stream: key, Entries: streamEntryModels(entries)})
+	}
+
+	return res, nil
+}
+
+// XAck - removes entries from pending entries list of the group, returns number of acknowledged entries
+func (n *Native) XAck(ctx context.Context, key, group string, ids []string) (int64, error) {
+	if err := checkXAck(key, group, ids); err != nil {
+		return 0, err
+	}
+
+	defer n.lock(ctx)()
+
+	acked, err := n.xack(key, group, ids)
+	if err != nil || len(acked) == 0 {
+		return 0, err
+	}
+
+	return int64(len(acked)), n.propagate(append([]string{"XACK", key, group}, acked...)...)
+}
+
+// XPending - summary of pending entries of the group
+func (n *Native) XPending(ctx context.Context, key, group string) (*models.XPending, error) {
+	if key == "" || group == "" {
+		return nil, fmt.Errorf("Empty key or group")
+	}
+
+	defer n.lock(ctx)()
+
+	g, err := n.streamGroup(key, group, "XPENDING")
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.XPending{
+		Count:     int64(len(g.pending)),
+		Consumers: map[string]int64{},
+	}
+
+	ids := g.pendingIDs()
+	if len(ids) != 0 {
+		res.Lower = ids[0].String()
+		res.Higher = ids[len(ids)-1].String()
+	}
+	for _, pending := range g.pending {
+		res.Consumers[pending.consumer]++
+	}
+
+	return res, nil
+}
+
+// XPendingExt - pending entries of the group between start and end, optionally of the consumer
+func (n *Native) XPendingExt(ctx context.Context, key string, query models.XPendingQuery) ([]models.XPendingEntry, error) {
+	query = xpendingDefaults(query)
+	if err := checkXPendingExt(key, query); err != nil {
+		return nil, err
+	}
+
+	defer n.lock(ctx)()
+
+	g, err := n.streamGroup(key, query.Group, "XPENDING")
+	if err != nil {
+		return nil, err
+	}
+
+	start, okStart, _ := parseStreamBound(query.Start, false)
+	end, okEnd, _ := parseStreamBound(query.End, true)
+
+	res := []models.XPendingEntry{}
+	if !okStart || !okEnd {
+		return res, nil
+	}
+
+	now := nowMs()
+	for _, id := range g.pendingIDs() {
+		if int64(len(res)) >= query.Count {
+			break
+		}
+		if id.less(start) || end.less(id) {
+			continue
+		}
+
+		pending := g.pending[id]
+		if query.Consumer != "" && pending.consumer != query.Consumer {
+			continue
+		}
+
+		res = append(res, models.XPendingEntry{
+			ID:         id.String(),
+			Consumer:   pending.consumer,
+			Idle:       idleTime(now, pending.deliveryTime),
+			RetryCount: pending.deliveryCount,
+		})
+	}
+
+	return res, nil
+}
+
+// XClaim - transfers pending entries idle for at least min idle milliseconds to the consumer, returns claimed
+// entries. Entries removed from the stream are removed from pending entries list.
+func (n *Native) XClaim(ctx context.Context, key string, claim models.XClaim) ([]models.StreamEntry, error) {
+	if err := checkXClaim(key, claim); err != nil {
+		return nil, err
+	}
+
+	ids := make([]streamID, len(claim.IDs))
+	for i, id := range claim.IDs {
+		ids[i], _ = parseStreamID(id, 0)
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return nil, err
+	}
+
+	return n.xclaim(key, claim.Group, claim.Consumer, claim.MinIdle, ids)
+}
+
+// XAutoClaim - the same as XClaim, but count pending entries starting from start are checked. The id to continue
+// with is returned, it is "0-0" when all pending entries are checked.
+func (n *Native) XAutoClaim(ctx context.Context, key string, claim models.XAutoClaim) (*models.XAutoClaimResult, error) {
+	claim = xautoclaimDefaults(claim)
+	if err := checkXAutoClaim(key, claim); err != nil {
+		return nil, err
+	}
+
+	start, _ := parseStreamID(claim.Start, 0)
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return nil, err
+	}
+
+	g, err := n.streamGroup(key, claim.Group, "XAUTOCLAIM")
+	if err != nil {
+		return nil, err
+	}
+
+	next := streamID{}
+	ids := []streamID{}
+	for _, id := range g.pendingIDs() {
+		if id.less(start) {
+			continue
+		}
+		if int64(len(ids)) >= claim.Count {
+			next = id
+			break
+		}
+		ids = append(ids, id)
+	}
+
+	entries, err := n.xclaim(key, claim.Group, claim.Consumer, claim.MinIdle, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.XAutoClaimResult{Next: next.String(), Entries: entries}, nil
+}
+
+// xrange - XRANGE and XREVRANGE, start is always the lowest bound
+func (n *Native) xrange(ctx context.Context, key, start, end string, count int64, rev bool) ([]models.StreamEntry, error) {
+	if err := checkXRange(key, start, end); err != nil {
+		return nil, err
+	}
+
+	defer n.lock(ctx)()
+
+	s, err := n.stream(key)
+	if err != nil {
+		return nil, err
+	}
+
+	from, okStart, _ := parseStreamBound(start, false)
+	to, okEnd, _ := parseStreamBound(end, true)
+	if s == nil || !okStart || !okEnd {
+		return []models.StreamEntry{}, nil
+	}
+
+	return streamEntryModels(s.rangeEntries(from, to, count, rev)), nil
+}
+
+// xadd - appends the entry, the id has to be greater than the last id of the stream
+func (n *Native) xadd(e *entry, s *stream, id streamID, fields []string) {
+	entry := streamEntry{id: id, fields: fields}
+	s.entries = append(s.entries, entry)
+	s.lastID = id
+	n.resize(e, entry.size())
+}
+
+// xtrim - removes the oldest entries so that the stream has at most maxLen entries or all the entries
+// have ids greater or equal than minID, returns number of removed entries
+func (n *Native) xtrim(e *entry, s *stream, trim models.XTrim) int64 {
+	count := 0
+	switch {
+	case trim.MaxLen != nil:
+		if int64(len(s.entries)) > *trim.MaxLen {
+			count = len(s.entries) - int(*trim.MaxLen)
+		}
+	case trim.MinID != "":
+		minID, _ := parseStreamID(trim.MinID, 0)
+		count = s.search(minID)
+	}
+
+	for _, entry := range s.trim(count) {
+		n.resize(e, -entry.size())
+	}
+
+	return int64(count)
+}
+
+// xtrimPropagate - trims the stream and writes the command with the exact bound, if anything is removed
+func (n *Native) xtrimPropagate(key string, e *entry, s *stream, trim models.XTrim) (int64, error) {
+	trimmed := n.xtrim(e, s, trim)
+	if trimmed == 0 {
+		return 0, nil
+	}
+
+	n.notify(notifyStream, "xtrim", key)
+	if trim.MaxLen != nil {
+		return trimmed, n.propagate("XTRIM", key, "MAXLEN", strconv.FormatInt(*trim.MaxLen, 10))
+	}
+
+	minID, _ := parseStreamID(trim.MinID, 0)
+	return trimmed, n.propagate("XTRIM", key, "MINID", minID.String())
+}
+
+// xgroupCreate - creates the group, returns its last id
+func (n *Native) xgroupCreate(key, group, id string, mkstream bool) (streamID, error) {
+	e, s, err := n.streamForWrite(key, mkstream)
+	if err != nil {
+		return streamID{}, err
+	}
+	if s == nil {
+		return streamID{}, errXGroupNoKey
+	}
+
+	if _, ok := s.groups[group]; ok {
+		return streamID{}, errBusyGroup
+	}
+
+	lastID := s.lastID
+	if id != "$" {
+		lastID, _ = parseStreamID(id, 0)
+	}
+
+	s.groups[group] = newStreamGroup(lastID)
+	n.resize(e, fieldOverhead+int64(len(group)))
+
+	return lastID, nil
+}
+
+// xack - removes entries from pending entries list of the group, returns acknowledged ids
+func (n *Native) xack(key, group string, ids []string) ([]string, error) {
+	e, s, err := n.streamForWrite(key, false)
+	if err != nil || s == nil || s.groups[group] == nil {
+		return nil, err
+	}
+
+	g := s.groups[group]
+	acked := []string{}
+	for _, raw := range ids {
+		id, err := parseStreamID(raw, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := g.pending[id]; !ok {
+			continue
+		}
+
+		delete(g.pending, id)
+		n.resize(e, -elementOverhead)
+		acked = append(acked, id.String())
+	}
+
+	return acked, nil
+}
+
+// xclaim - claims pending entries idle for at least minIdle milliseconds, unknown ids are skipped
+func (n *Native) xclaim(key, group, consumer string, minIdle int64, ids []streamID) ([]models.StreamEntry, error) {
+	if _, err := n.streamGroup(key, group, "XCLAIM"); err != nil {
+		return nil, err
+	}
+
+	e, s, _ := n.streamForWrite(key, false)
+	g := s.groups[group]
+	now := nowMs()
+	n.touchConsumer(e, g, consumer, now)
+
+	res := []models.StreamEntry{}
+	for _, id := range ids {
+		pending, ok := g.pending[id]
+		if !ok || idleTime(now, pending.deliveryTime) < minIdle {
+			continue
+		}
+
+		entry, ok := s.get(id)
+		if !ok {
+			// the entry is trimmed, so that it can't be delivered anymore
+			delete(g.pending, id)
+			n.resize(e, -elementOverhead)
+			if err := n.propagate("XACK", key, group, id.String()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		pending = n.deliver(e, g, consumer, id, now)
+		if err := n.propagate(xclaimCommand(key, group, consumer, id, pending)...); err != nil {
+			return nil, err
+		}
+
+		res = append(res, streamEntryModel(id.String(), entry.fields))
+	}
+
+	return res, nil
+}
+
+// deliver - adds the entry to pending entries list of the consumer or increments number of its deliveries
+func (n *Native) deliver(e *entry, g *streamGroup, consumer string, id streamID, now int64) *streamPending {
+	pending, ok := g.pending[id]
+	if !ok {
+		pending = &streamPending{}
+		g.pending[id] = pending
+		n.resize(e, elementOverhead)
+	}
+
+	pending.consumer = consumer
+	pending.deliveryTime = now
+	pending.deliveryCount++
+	n.touchConsumer(e, g, consumer, now)
+
+	return pending
+}
+
+// touchConsumer - creates the consumer if there is no such one and updates its seen time
+func (n *Native) touchConsumer(e *entry, g *streamGroup, consumer string, now int64) {
+	if _, ok := g.consumers[consumer]; !ok {
+		n.resize(e, fieldOverhead+int64(len(consumer)))
+	}
+
+	g.consumers[consumer] = now
+}
+
+// stream - returns stream stored by the key, nil if there is no such key
+func (n *Native) stream(key string) (*stream, error) {
+	e := n.lookup(key)
+	if e == nil {
+		return nil, nil
+	}
+
+	s, ok := e.value.(*stream)
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	return s, nil
+}
+
+// streamForWrite - returns stream stored by the key which may be modified in place, with create empty stream
+// is created if there is no such key. Nil stream is returned if the key is not created.
+func (n *Native) streamForWrite(key string, create bool) (*entry, *stream, error) {
+	e := n.lookupWrite(key)
+	if e == nil {
+		if !create {
+			return nil, nil, nil
+		}
+		e = n.newEntry(key, newStream())
+		n.putEntry(key, e)
+	}
+
+	s, ok := e.value.(*stream)
+	if !ok {
+		return nil, nil, ErrWrongType
+	}
+
+	return e, s, nil
+}
+
+// streamGroup - returns consumer group, NOGROUP error is returned if there is no such key or group
+func (n *Native) streamGroup(key, group, command string) (*streamGroup, error) {
+	s, err := n.stream(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if s == nil || s.groups[group] == nil {
+		return nil, errNoGroup(key, group, command)
+	}
+
+	return s.groups[group], nil
+}
+
+// nextStreamID - id of the new entry, it is generated for empty id or "*"
+func nextStreamID(s *stream, id string) (streamID, error) {
+	if id != "" && id != "*" {
+		next, _ := parseStreamID(id, 0)
+		if next == (streamID{}) {
+			return next, errXAddIDZero
+		}
+		if !s.lastID.less(next) {
+			return next, errXAddIDSmall
+		}
+		return next, nil
+	}
+
+	now := uint64(nowMs())
+	if s.lastID.ms < now {
+		return streamID{ms: now}, nil
+	}
+
+	next, ok := s.lastID.incr()
+	if !ok {
+		return next, errStreamExhausted
+	}
+
+	return next, nil
+}
+
+// consumerPending - entries pending for the consumer with ids greater than after, removed entries have nil fields
+func consumerPending(s *stream, g *streamGroup, consumer string, after streamID, count int64) []models.StreamEntry {
+	res := []models.StreamEntry{}
+	for _, id := range g.pendingIDs() {
+		if count > 0 && int64(len(res)) >= count {
+			break
+		}
+		if !after.less(id) || g.pending[id].consumer != consumer {
+			continue
+		}
+
+		entry, _ := s.get(id)
+		res = append(res, streamEntryModel(id.String(), entry.fields))
+	}
+
+	return res
+}
+
+func streamEntryModels(entries []streamEntry) []models.StreamEntry {
+	res := make([]models.StreamEntry, len(entries))
+	for i, entry := range entries {
+		res[i] = streamEntryModel(entry.id.String(), entry.fields)
+	}
+
+	return res
+}
+
+// idleTime - milliseconds since the delivery, clock may go backwards
+func idleTime(now, deliveryTime int64) int64 {
+	if now < deliveryTime {
+		return 0
+	}
+
+	return now - deliveryTime
+}
@@ -0,0 +1,293 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestStream - stream with entries 1-1, 1-2, 2-0 and 3-0, field name has typed values
+func newTestStream(t *testing.T) *Native {
+	client := NewNative()
+	for i, id := range []string{"1-1", "1-2", "2", "3-0"} {
+		_, err := client.XAdd(context.Background(), "events", id, map[string]interface{}{"n": int64(i)}, models.XTrim{})
+		assert.NoError(t, err)
+	}
+
+	return client
+}
+
+func TestParseStreamBound(t *testing.T) {
+	type testCase struct {
+		name  string
+		bound string
+		end   bool
+		id    streamID
+		ok    bool
+		err   error
+	}
+
+	tCases := []testCase{
+		{name: "Min", bound: "-", id: streamID{}, ok: true},
+		{name: "Max", bound: "+", id: maxStreamID, ok: true},
+		{name: "Start without seq", bound: "5", id: streamID{ms: 5}, ok: true},
+		{name: "End without seq", bound: "5", end: true, id: streamID{ms: 5, seq: maxStreamID.seq}, ok: true},
+		{name: "Exclusive start", bound: "(5-1", id: streamID{ms: 5, seq: 2}, ok: true},
+		{name: "Exclusive end", bound: "(5-0", end: true, id: streamID{ms: 4, seq: maxStreamID.seq}, ok: true},
+		{name: "Exclusive 0-0", bound: "(0-0", end: true, ok: false},
+		{name: "Invalid", bound: "5-a", err: errStreamID},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			id, ok, err := parseStreamBound(tc.bound, tc.end)
+			assert.Equal(t, tc.err, err)
+			if err == nil {
+				assert.Equal(t, tc.ok, ok)
+			}
+			if tc.ok {
+				assert.Equal(t, tc.id, id)
+			}
+		})
+	}
+}
+
+func TestNativeXAdd(t *testing.T) {
+	client := newTestStream(t)
+
+	_, err := client.XAdd(context.Background(), "events", "3-0", map[string]interface{}{"n": 1}, models.XTrim{})
+	assert.Equal(t, errXAddIDSmall, err)
+	_, err = client.XAdd(context.Background(), "new", "0-0", map[string]interface{}{"n": 1}, models.XTrim{})
+	assert.Equal(t, errXAddIDZero, err)
+	assert.NotContains(t, client.data, "new")
+
+	id, err := client.XAdd(context.Background(), "events", "", map[string]interface{}{"name": "ivan", "score": 1.5}, models.XTrim{})
+	assert.NoError(t, err)
+
+	entries, err := client.XRevRange(context.Background(), "events", models.XRangeQuery{Count: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.StreamEntry{{ID: id, Fields: map[string]interface{}{"name": "ivan", "score": 1.5}}}, entries)
+
+	// generated ids grow even if the last id is in the future
+	_, err = client.XAdd(context.Background(), "future", "99999999999999-5", map[string]interface{}{"n": 1}, models.XTrim{})
+	assert.NoError(t, err)
+	id, err = client.XAdd(context.Background(), "future", "*", map[string]interface{}{"n": 2}, models.XTrim{})
+	assert.NoError(t, err)
+	assert.Equal(t, "99999999999999-6", id)
+
+	maxLen := int64(2)
+	_, err = client.XAdd(context.Background(), "events", "", map[string]interface{}{"n": 5}, models.XTrim{MaxLen: &maxLen})
+	assert.NoError(t, err)
+	length, err := client.XLen(context.Background(), "events")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), length)
+
+	_, err = client.XAdd(context.Background(), "events", "", map[string]interface{}{"n": 5}, models.XTrim{MaxLen: &maxLen, MinID: "1"})
+	assert.Equal(t, errXTrimMaxLenMinID, err)
+	_, err = client.XAdd(context.Background(), "events", "a-1", map[string]interface{}{"n": 5}, models.XTrim{})
+	assert.Equal(t, errStreamID, err)
+
+	_, err = client.SetString(context.Background(), "user:1", "Ivan", models.Expiration{})
+	assert.NoError(t, err)
+	_, err = client.XAdd(context.Background(), "user:1", "", map[string]interface{}{"n": 1}, models.XTrim{})
+	assert.Equal(t, ErrWrongType, err)
+	_, err = client.XLen(context.Background(), "user:1")
+	assert.Equal(t, ErrWrongType, err)
+}
+
+func TestNativeXRange(t *testing.T) {
+	client := newTestStream(t)
+
+	type testCase struct {
+		name  string
+		query models.XRangeQuery
+		rev   bool
+		ids   []string
+	}
+
+	tCases := []testCase{
+		{name: "All", ids: []string{"1-1", "1-2", "2-0", "3-0"}},
+		{name: "Milliseconds", query: models.XRangeQuery{Start: "1", End: "2"}, ids: []string{"1-1", "1-2", "2-0"}},
+		{name: "Exclusive", query: models.XRangeQuery{Start: "(1-1", End: "(3-0"}, ids: []string{"1-2", "2-0"}},
+		{name: "Count", query: models.XRangeQuery{Start: "1-2", Count: 2}, ids: []string{"1-2", "2-0"}},
+		{name: "Rev", query: models.XRangeQuery{Start: "2", Count: 2}, rev: true, ids: []string{"2-0", "1-2"}},
+		{name: "Empty", query: models.XRangeQuery{Start: "3", End: "1"}, ids: []string{}},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var entries []models.StreamEntry
+			var err error
+			if tc.rev {
+				entries, err = client.XRevRange(context.Background(), "events", tc.query)
+			} else {
+				entries, err = client.XRange(context.Background(), "events", tc.query)
+			}
+			assert.NoError(t, err)
+
+			ids := []string{}
+			for _, entry := range entries {
+				ids = append(ids, entry.ID)
+			}
+			assert.Equal(t, tc.ids, ids)
+		})
+	}
+
+	entries, err := client.XRange(context.Background(), "events", models.XRangeQuery{Start: "2", End: "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.StreamEntry{{ID: "2-0", Fields: map[string]interface{}{"n": int64(2)}}}, entries)
+
+	entries, err = client.XRange(context.Background(), "missing", models.XRangeQuery{})
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestNativeXTrimAndRead(t *testing.T) {
+	client := newTestStream(t)
+	used := client.used
+
+	trimmed, err := client.XTrim(context.Background(), "events", models.XTrim{MinID: "1-2"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), trimmed)
+	assert.Less(t, client.used, used)
+
+	_, err = client.XTrim(context.Background(), "events", models.XTrim{})
+	assert.Equal(t, errXTrimStrategy, err)
+
+	_, err = client.XAdd(context.Background(), "other", "5-0", map[string]interface{}{"name": "ivan"}, models.XTrim{})
+	assert.NoError(t, err)
+
+	streams, err := client.XRead(context.Background(), models.XReadQuery{
+		Keys:  []string{"events", "other", "missing"},
+		IDs:   []string{"1-2", "0", "0"},
+		Count: 1,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.XStream{
+		{Stream: "events", Entries: []models.StreamEntry{{ID: "2-0", Fields: map[string]interface{}{"n": int64(2)}}}},
+		{Stream: "other", Entries: []models.StreamEntry{{ID: "5-0", Fields: map[string]interface{}{"name": "ivan"}}}},
+	}, streams)
+
+	streams, err = client.XRead(context.Background(), models.XReadQuery{Keys: []string{"events"}, IDs: []string{"$"}})
+	assert.NoError(t, err)
+	assert.Empty(t, streams)
+
+	_, err = client.XRead(context.Background(), models.XReadQuery{Keys: []string{"events"}, IDs: []string{">"}})
+	assert.Equal(t, errXReadGroupID, err)
+	_, err = client.XRead(context.Background(), models.XReadQuery{Keys: []string{"events", "other"}, IDs: []string{"0"}})
+	assert.Equal(t, errXReadUnbalanced, err)
+
+	maxLen := int64(0)
+	trimmed, err = client.XTrim(context.Background(), "events", models.XTrim{MaxLen: &maxLen})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), trimmed)
+
+	// empty stream keeps its last id
+	_, err = client.XAdd(context.Background(), "events", "3-0", map[string]interface{}{"n": 1}, models.XTrim{})
+	assert.Equal(t, errXAddIDSmall, err)
+}
+
+func TestNativeConsumerGroups(t *testing.T) {
+	client := newTestStream(t)
+	ctx := context.Background()
+
+	assert.Equal(t, errXGroupNoKey, client.XGroupCreate(ctx, "missing", "workers", "$", false))
+	assert.NoError(t, client.XGroupCreate(ctx, "created", "workers", "", true))
+	assert.NoError(t, client.XGroupCreate(ctx, "events", "workers", "0", false))
+	assert.Equal(t, errBusyGroup, client.XGroupCreate(ctx, "events", "workers", "0", false))
+	assert.True(t, IsBusyGroup(errBusyGroup))
+
+	_, err := client.XReadGroup(ctx, models.XReadGroupRequest{Group: "workers", Consumer: "ivan", Keys: []string{"events", "missing"}})
+	assert.True(t, IsNoGroup(err))
+
+	streams, err := client.XReadGroup(ctx, models.XReadGroupRequest{Group: "workers", Consumer: "ivan", Keys: []string{"events"}, Count: 2})
+	assert.NoError(t, err)
+	assert.Len(t, streams, 1)
+	assert.Len(t, streams[0].Entries, 2)
+	assert.Equal(t, "1-1", streams[0].Entries[0].ID)
+
+	streams, err = client.XReadGroup(ctx, models.XReadGroupRequest{Group: "workers", Consumer: "petr", Keys: []string{"events"}})
+	assert.NoError(t, err)
+	assert.Len(t, streams[0].Entries, 2)
+	assert.Equal(t, "2-0", streams[0].Entries[0].ID)
+
+	// nothing new is left for the group
+	streams, err = client.XReadGroup(ctx, models.XReadGroupRequest{Group: "workers", Consumer: "petr", Keys: []string{"events"}})
+	assert.NoError(t, err)
+	assert.Empty(t, streams)
+
+	// history of the consumer
+	streams, err = client.XReadGroup(ctx, models.XReadGroupRequest{Group: "workers", Consumer: "ivan", Keys: []string{"events"}, IDs: []string{"1-1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.XStream{{
+		Stream:  "events",
+		Entries: []models.StreamEntry{{ID: "1-2", Fields: map[string]interface{}{"n": int64(1)}}},
+	}}, streams)
+
+	pending, err := client.XPending(ctx, "events", "workers")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.XPending{Count: 4, Lower: "1-1", Higher: "3-0", Consumers: map[string]int64{"ivan": 2, "petr": 2}}, pending)
+
+	acked, err := client.XAck(ctx, "events", "workers", []string{"1-1", "1-1", "5-0"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), acked)
+
+	entries, err := client.XPendingExt(ctx, "events", models.XPendingQuery{Group: "workers", Consumer: "petr"})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "2-0", entries[0].ID)
+	assert.Equal(t, int64(1), entries[0].RetryCount)
+
+	// entries are not idle long enough
+	claimed, err := client.XClaim(ctx, "events", models.XClaim{Group: "workers", Consumer: "oleg", MinIdle: 60000, IDs: []string{"2-0"}})
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	claimed, err = client.XClaim(ctx, "events", models.XClaim{Group: "workers", Consumer: "oleg", IDs: []string{"2-0", "4-0"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.StreamEntry{{ID: "2-0", Fields: map[string]interface{}{"n": int64(2)}}}, claimed)
+
+	entries, err = client.XPendingExt(ctx, "events", models.XPendingQuery{Group: "workers", Start: "(1-2", Count: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "oleg", entries[0].Consumer)
+	assert.Equal(t, int64(2), entries[0].RetryCount)
+
+	// trimmed entries are removed from pending entries list when they are claimed
+	maxLen := int64(1)
+	_, err = client.XTrim(ctx, "events", models.XTrim{MaxLen: &maxLen})
+	assert.NoError(t, err)
+
+	result, err := client.XAutoClaim(ctx, "events", models.XAutoClaim{Group: "workers", Consumer: "anna", Count: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "3-0", result.Next)
+	assert.Empty(t, result.Entries)
+
+	result, err = client.XAutoClaim(ctx, "events", models.XAutoClaim{Group: "workers", Consumer: "anna", Start: result.Next})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.XAutoClaimResult{
+		Next:    "0-0",
+		Entries: []models.StreamEntry{{ID: "3-0", Fields: map[string]interface{}{"n": int64(3)}}},
+	}, result)
+
+	pending, err = client.XPending(ctx, "events", "workers")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.XPending{Count: 1, Lower: "3-0", Higher: "3-0", Consumers: map[string]int64{"anna": 1}}, pending)
+
+	// with noack entries are not pending
+	_, err = client.XAdd(ctx, "events", "4-0", map[string]interface{}{"n": 4}, models.XTrim{})
+	assert.NoError(t, err)
+	streams, err = client.XReadGroup(ctx, models.XReadGroupRequest{Group: "workers", Consumer: "anna", Keys: []string{"events"}, NoAck: true})
+	assert.NoError(t, err)
+	assert.Len(t, streams, 1)
+
+	pending, err = client.XPending(ctx, "events", "workers")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), pending.Count)
+
+	_, err = client.XPending(ctx, "events", "missing")
+	assert.True(t, IsNoGroup(err))
+	_, err = client.XClaim(ctx, "events", models.XClaim{Group: "workers", Consumer: "anna"})
+	assert.Equal(t, errStreamIDsRequired, err)
+}
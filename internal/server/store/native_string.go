@@ -0,0 +1,414 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// IncrBy - increments integer stored by the key, missing key is treated as 0. Time to live of the key is kept.
+func (n *Native) IncrBy(ctx context.Context, key string, increment int64) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return 0, err
+	}
+
+	value, at, err := n.counter(key)
+	if err != nil {
+		return 0, err
+	}
+
+	current, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, errNotInteger
+	}
+
+	current, err = addInt(current, increment)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := n.setCounter(key, strconv.FormatInt(current, 10), at, "incrby"); err != nil {
+		return 0, err
+	}
+
+	return current, nil
+}
+
+// IncrByFloat - increments float stored by the key, missing key is treated as 0. Time to live of the key is kept.
+func (n *Native) IncrByFloat(ctx context.Context, key string, increment float64) (float64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return 0, err
+	}
+
+	value, at, err := n.counter(key)
+	if err != nil {
+		return 0, err
+	}
+
+	current, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(current) || math.IsInf(current, 0) {
+		return 0, errNotFloat
+	}
+
+	current += increment
+	if math.IsNaN(current) || math.IsInf(current, 0) {
+		return 0, errIncrNaN
+	}
+
+	if err := n.setCounter(key, formatFloat(current), at, "incrbyfloat"); err != nil {
+		return 0, err
+	}
+
+	return current, nil
+}
+
+// counter - string stored by the key and its expiration time, "0" if there is no such key
+func (n *Native) counter(key string) (string, int64, error) {
+	e := n.lookup(key)
+	if e == nil {
+		return "0", 0, nil
+	}
+
+	value, ok := e.value.(string)
+	if !ok {
+		return "", 0, ErrWrongType
+	}
+
+	return value, e.expireAt, nil
+}
+
+// setCounter - the result is written to append only file as SET with KEEPTTL, like redis propagates
+// INCRBYFLOAT, so that replay does not depend on arithmetic
+func (n *Native) setCounter(key, value string, at int64, event string) error {
+	n.setString(key, value, at)
+	n.notify(notifyString, event, key)
+
+	return n.propagate("SET", key, value, "KEEPTTL")
+}
+
+// addInt - sum of the counter and the increment, errOverflow if it is out of range of int64
+func addInt(current, increment int64) (int64, error) {
+	if (increment < 0 && current < 0 && increment < math.MinInt64-current) ||
+		(increment > 0 && current > 0 && increment > math.MaxInt64-current) {
+		return 0, errOverflow
+	}
+
+	return current + increment, nil
+}
+
+// formatFloat - the shortest representation without exponent, like redis formats result of INCRBYFLOAT
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// SetArgs - SET with options. Without GET the result is OK or redis.Nil if the value is not set because of NX
+// or XX, with GET it is the previous value or redis.Nil if there was no value.
+func (n *Native) SetArgs(ctx context.Context, key, value string, opts models.SetOptions) (string, error) {
+	if err := checkSet(key, opts); err != nil {
+		return "", err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return "", err
+	}
+
+	e := n.lookup(key)
+	old, isString := "", false
+	if e != nil {
+		if old, isString = e.value.(string); !isString && opts.Get {
+			return "", ErrWrongType
+		}
+	}
+
+	if (opts.NX && e != nil) || (opts.XX && e == nil) {
+		return setReply(old, isString, opts.Get, false)
+	}
+
+	var at int64
+	switch {
+	case opts.KeepTTL && e != nil:
+		at = e.expireAt
+	case opts.TTL > 0 || opts.ExpireAt != nil:
+		at = expirationAt(opts.Expiration)
+	case opts.ExAt > 0:
+		at = opts.ExAt * 1000
+	case opts.PxAt > 0:
+		at = opts.PxAt
+	}
+
+	n.setString(key, value, at)
+	n.notify(notifyString, "set", key)
+	if at == 0 {
+		if err := n.propagate("SET", key, value); err != nil {
+			return "", err
+		}
+	} else {
+		n.notify(notifyGeneric, "expire", key)
+		if err := n.propagate("SET", key, value, "PXAT", strconv.FormatInt(at, 10)); err != nil {
+			return "", err
+		}
+	}
+
+	return setReply(old, isString, opts.Get, true)
+}
+
+// GetSet - sets the value and returns the previous one, time to live of the key is discarded
+func (n *Native) GetSet(ctx context.Context, key, value string) (string, error) {
+	return n.SetArgs(ctx, key, value, models.SetOptions{Get: true})
+}
+
+// GetDel - returns the value and removes the key
+func (n *Native) GetDel(ctx context.Context, key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	value, err := n.str(key)
+	if err != nil {
+		return "", err
+	}
+
+	n.remove(key)
+	n.notify(notifyGeneric, "del", key)
+	return value, n.propagate("DEL", key)
+}
+
+// GetEx - returns the value and changes its time to live, time in the past removes the key
+func (n *Native) GetEx(ctx context.Context, key string, opts models.GetExOptions) (string, error) {
+	if err := checkGetEx(key, opts); err != nil {
+		return "", err
+	}
+
+	defer n.lock(ctx)()
+
+	value, err := n.str(key)
+	if err != nil {
+		return "", err
+	}
+
+	var at int64
+	switch {
+	case opts.Persist:
+		if n.persist(key) {
+			n.notify(notifyGeneric, "persist", key)
+			return value, n.propagate("PERSIST", key)
+		}
+		return value, nil
+	case opts.TTL > 0 || opts.ExpireAt != nil:
+		at = expirationAt(opts.Expiration)
+	case opts.ExAt > 0:
+		at = opts.ExAt * 1000
+	case opts.PxAt > 0:
+		at = opts.PxAt
+	default:
+		return value, nil
+	}
+
+	if at <= nowMs() {
+		n.remove(key)
+		n.notify(notifyGeneric, "del", key)
+		return value, n.propagate("DEL", key)
+	}
+
+	n.pexpireAt(key, at)
+	n.notify(notifyGeneric, "expire", key)
+	return value, n.propagate("PEXPIREAT", key, strconv.FormatInt(at, 10))
+}
+
+// Append - appends the value to the string, missing key is created. Returns length of the string.
+func (n *Native) Append(ctx context.Context, key, value string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return 0, err
+	}
+
+	length, err := n.appendString(key, value)
+	if err != nil {
+		return 0, err
+	}
+
+	n.notify(notifyString, "append", key)
+	return length, n.propagate("APPEND", key, value)
+}
+
+// StrLen - length of the string, 0 if there is no such key
+func (n *Native) StrLen(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	value, err := n.str(key)
+	if err == redis.Nil {
+		return 0, nil
+	}
+
+	return int64(len(value)), err
+}
+
+// GetRange - substring between start and end inclusive, negative offsets are counted from the end of the string
+func (n *Native) GetRange(ctx context.Context, key string, start, end int64) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	value, err := n.str(key)
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	start, end, ok := normalizeRange(int64(len(value)), start, end)
+	if !ok {
+		return "", nil
+	}
+
+	return value[start : end+1], nil
+}
+
+// SetRange - overwrites part of the string starting at the offset, the string is padded with zero bytes if it
+// is shorter. Returns length of the string.
+func (n *Native) SetRange(ctx context.Context, key string, offset int64, value string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+	if err := checkSetRange(offset, value); err != nil {
+		return 0, err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return 0, err
+	}
+
+	length, modified, err := n.setRange(key, offset, value)
+	if err != nil || !modified {
+		return length, err
+	}
+
+	n.notify(notifyString, "setrange", key)
+	return length, n.propagate("SETRANGE", key, strconv.FormatInt(offset, 10), value)
+}
+
+// str - string stored by the key, redis.Nil if there is no such key
+func (n *Native) str(key string) (string, error) {
+	e := n.lookup(key)
+	if e == nil {
+		return "", redis.Nil
+	}
+
+	value, ok := e.value.(string)
+	if !ok {
+		return "", ErrWrongType
+	}
+
+	return value, nil
+}
+
+// appendString - appends the value keeping time to live of the key, returns length of the string
+func (n *Native) appendString(key, value string) (int64, error) {
+	e := n.lookupWrite(key)
+	if e == nil {
+		n.setString(key, value, 0)
+		return int64(len(value)), nil
+	}
+
+	old, ok := e.value.(string)
+	if !ok {
+		return 0, ErrWrongType
+	}
+	if int64(len(old)+len(value)) > maxStringSize {
+		return 0, errStringSize
+	}
+
+	e.value = old + value
+	n.resize(e, int64(len(value)))
+	return int64(len(old) + len(value)), nil
+}
+
+// setRange - false is returned if nothing is written: the value is empty or there is no such key
+func (n *Native) setRange(key string, offset int64, value string) (int64, bool, error) {
+	e := n.lookupWrite(key)
+	if e == nil {
+		if value == "" {
+			return 0, false, nil
+		}
+
+		n.setString(key, strings.Repeat("\x00", int(offset))+value, 0)
+		return offset + int64(len(value)), true, nil
+	}
+
+	old, ok := e.value.(string)
+	if !ok {
+		return 0, false, ErrWrongType
+	}
+	if value == "" {
+		return int64(len(old)), false, nil
+	}
+
+	buf := []byte(old)
+	if end := offset + int64(len(value)); end > int64(len(buf)) {
+		buf = append(buf, make([]byte, end-int64(len(buf)))...)
+	}
+	copy(buf[offset:], value)
+
+	e.value = string(buf)
+	n.resize(e, int64(len(buf)-len(old)))
+	return int64(len(buf)), true, nil
+}
+
+// persist - removes time to live of the key, false if there is no such key or it has no time to live
+func (n *Native) persist(key string) bool {
+	e := n.lookupWrite(key)
+	if e == nil || e.expireAt == 0 {
+		return false
+	}
+
+	e.expireAt = 0
+	delete(n.expires, key)
+	return true
+}
+
+// setReply - reply of SET: the previous value with GET, otherwise OK or redis.Nil if the value is not set
+func setReply(old string, isString, get, set bool) (string, error) {
+	switch {
+	case get && isString:
+		return old, nil
+	case get || !set:
+		return "", redis.Nil
+	}
+
+	return "OK", nil
+}
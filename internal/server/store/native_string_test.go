@@ -0,0 +1,332 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNativeIncrBy(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	_, err := client.SetString(ctx, "name", "ivan", models.Expiration{})
+	assert.NoError(t, err)
+	_, err = client.SetString(ctx, "min", "-9223372036854775808", models.Expiration{})
+	assert.NoError(t, err)
+	_, err = client.SAdd(ctx, "roles", []string{"admin"})
+	assert.NoError(t, err)
+
+	type testCase struct {
+		name      string
+		key       string
+		increment int64
+		result    int64
+		err       error
+	}
+
+	tCases := []testCase{
+		{name: "Missing key", key: "counter", increment: 5, result: 5},
+		{name: "Negative", key: "counter", increment: -7, result: -2},
+		{name: "Not an integer", key: "name", increment: 1, err: errNotInteger},
+		{name: "Overflow", key: "min", increment: -1, err: errOverflow},
+		{name: "Wrong type", key: "roles", increment: 1, err: ErrWrongType},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := client.IncrBy(ctx, tc.key, tc.increment)
+			if tc.err != nil {
+				assert.Equal(t, tc.err, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.result, result)
+		})
+	}
+
+	assert.True(t, IsNotNumber(errNotInteger))
+	assert.True(t, IsOverflow(errOverflow))
+}
+
+func TestNativeIncrByFloat(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	_, err := client.SetString(ctx, "counter", "10", models.Expiration{})
+	assert.NoError(t, err)
+
+	result, err := client.IncrByFloat(ctx, "counter", 0.5)
+	assert.NoError(t, err)
+	assert.Equal(t, 10.5, result)
+
+	value, err := client.GetString(ctx, "counter")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.5", value)
+
+	_, err = client.IncrByFloat(ctx, "counter", math.MaxFloat64)
+	assert.NoError(t, err)
+	_, err = client.IncrByFloat(ctx, "counter", math.MaxFloat64)
+	assert.Equal(t, errIncrNaN, err)
+
+	// the float is not an integer
+	_, err = client.SetString(ctx, "counter", "1.5", models.Expiration{})
+	assert.NoError(t, err)
+	_, err = client.IncrBy(ctx, "counter", 1)
+	assert.Equal(t, errNotInteger, err)
+}
+
+func TestNativeIncrByKeepTTL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aof")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "appendonly.aof")
+	ctx := context.Background()
+
+	client := NewNative()
+	assert.NoError(t, client.OpenAOF(path, FsyncAlways))
+
+	_, err = client.SetString(ctx, "counter", "1", models.Expiration{TTL: models.TTL(10 * time.Minute)})
+	assert.NoError(t, err)
+	_, err = client.IncrBy(ctx, "counter", 2)
+	assert.NoError(t, err)
+	_, err = client.IncrByFloat(ctx, "counter", 1.5)
+	assert.NoError(t, err)
+	assert.NoError(t, client.Close())
+
+	restored := NewNative()
+	assert.NoError(t, restored.OpenAOF(path, FsyncNo))
+	defer restored.Close()
+
+	value, err := restored.GetString(ctx, "counter")
+	assert.NoError(t, err)
+	assert.Equal(t, "4.5", value)
+	assert.NotZero(t, restored.data["counter"].expireAt)
+	assert.Equal(t, client.data["counter"].expireAt, restored.data["counter"].expireAt)
+}
+
+func TestNativeSetArgs(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	_, err := client.SAdd(ctx, "roles", []string{"admin"})
+	assert.NoError(t, err)
+
+	type testCase struct {
+		name   string
+		key    string
+		value  string
+		opts   models.SetOptions
+		result string
+		err    error
+		stored string
+	}
+
+	tCases := []testCase{
+		{name: "Empty value", key: "flag", value: "", result: "OK", stored: ""},
+		{name: "NX existing key", key: "flag", value: "1", opts: models.SetOptions{NX: true}, err: redis.Nil, stored: ""},
+		{name: "XX existing key", key: "flag", value: "1", opts: models.SetOptions{XX: true}, result: "OK", stored: "1"},
+		{name: "XX missing key", key: "lock", value: "1", opts: models.SetOptions{XX: true}, err: redis.Nil},
+		{name: "NX missing key", key: "lock", value: "1", opts: models.SetOptions{NX: true}, result: "OK", stored: "1"},
+		{name: "GET previous value", key: "lock", value: "2", opts: models.SetOptions{Get: true}, result: "1", stored: "2"},
+		{name: "GET missing key", key: "name", value: "ivan", opts: models.SetOptions{Get: true}, err: redis.Nil, stored: "ivan"},
+		{name: "GET wrong type", key: "roles", value: "1", opts: models.SetOptions{Get: true}, err: ErrWrongType},
+		{name: "NX and XX", key: "lock", value: "1", opts: models.SetOptions{NX: true, XX: true}, err: errSyntax},
+		{name: "Two expirations", key: "lock", value: "1", opts: models.SetOptions{Expiration: models.Expiration{TTL: models.TTL(time.Minute)}, KeepTTL: true}, err: errSyntax},
+		{name: "Negative expiration", key: "lock", value: "1", opts: models.SetOptions{PxAt: -1}, err: errExpireSet},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := client.SetArgs(ctx, tc.key, tc.value, tc.opts)
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.result, result)
+
+			if tc.err == nil || tc.err == redis.Nil {
+				stored, _ := client.GetString(ctx, tc.key)
+				assert.Equal(t, tc.stored, stored)
+			}
+		})
+	}
+}
+
+func TestNativeSetArgsTTL(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	at := time.Now().Add(time.Hour).Unix()
+	_, err := client.SetArgs(ctx, "lock", "1", models.SetOptions{ExAt: at})
+	assert.NoError(t, err)
+	assert.Equal(t, at*1000, client.data["lock"].expireAt)
+
+	_, err = client.SetArgs(ctx, "lock", "2", models.SetOptions{KeepTTL: true})
+	assert.NoError(t, err)
+	assert.Equal(t, at*1000, client.data["lock"].expireAt)
+
+	// time to live is discarded without options
+	_, err = client.SetArgs(ctx, "lock", "3", models.SetOptions{})
+	assert.NoError(t, err)
+	assert.Zero(t, client.data["lock"].expireAt)
+
+	// time in the past expires the key
+	_, err = client.SetArgs(ctx, "lock", "4", models.SetOptions{PxAt: 1})
+	assert.NoError(t, err)
+	_, err = client.GetString(ctx, "lock")
+	assert.Equal(t, redis.Nil, err)
+}
+
+func TestNativeStringRange(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	length, err := client.Append(ctx, "name", "Hello")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), length)
+
+	length, err = client.Append(ctx, "name", " World")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), length)
+
+	length, err = client.StrLen(ctx, "name")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), length)
+
+	length, err = client.StrLen(ctx, "missing")
+	assert.NoError(t, err)
+	assert.Zero(t, length)
+
+	type testCase struct {
+		name   string
+		start  int64
+		end    int64
+		result string
+	}
+
+	tCases := []testCase{
+		{name: "Prefix", start: 0, end: 4, result: "Hello"},
+		{name: "Negative", start: -5, end: -1, result: "World"},
+		{name: "End out of range", start: 6, end: 100, result: "World"},
+		{name: "Whole string", start: 0, end: -1, result: "Hello World"},
+		{name: "Start after end", start: 5, end: 3, result: ""},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := client.GetRange(ctx, "name", tc.start, tc.end)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.result, result)
+		})
+	}
+
+	length, err = client.SetRange(ctx, "name", 6, "Redis")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), length)
+
+	value, err := client.GetString(ctx, "name")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello Redis", value)
+
+	// the missing key is padded with zero bytes
+	length, err = client.SetRange(ctx, "padded", 2, "ab")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), length)
+
+	value, err = client.GetString(ctx, "padded")
+	assert.NoError(t, err)
+	assert.Equal(t, "\x00\x00ab", value)
+
+	// nothing is created by the empty value
+	length, err = client.SetRange(ctx, "empty", 5, "")
+	assert.NoError(t, err)
+	assert.Zero(t, length)
+	assert.Nil(t, client.peek("empty"))
+
+	_, err = client.SetRange(ctx, "name", -1, "a")
+	assert.Equal(t, errOffset, err)
+
+	_, err = client.SAdd(ctx, "roles", []string{"admin"})
+	assert.NoError(t, err)
+	_, err = client.Append(ctx, "roles", "a")
+	assert.Equal(t, ErrWrongType, err)
+}
+
+func TestNativeGetDelGetEx(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	_, err := client.SetString(ctx, "name", "ivan", models.Expiration{})
+	assert.NoError(t, err)
+
+	old, err := client.GetSet(ctx, "name", "petr")
+	assert.NoError(t, err)
+	assert.Equal(t, "ivan", old)
+
+	_, err = client.GetSet(ctx, "missing", "petr")
+	assert.Equal(t, redis.Nil, err)
+
+	value, err := client.GetEx(ctx, "name", models.GetExOptions{Expiration: models.Expiration{TTL: models.TTL(10 * time.Minute)}})
+	assert.NoError(t, err)
+	assert.Equal(t, "petr", value)
+	assert.NotZero(t, client.data["name"].expireAt)
+
+	value, err = client.GetEx(ctx, "name", models.GetExOptions{Persist: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "petr", value)
+	assert.Zero(t, client.data["name"].expireAt)
+
+	_, err = client.GetEx(ctx, "name", models.GetExOptions{Expiration: models.Expiration{TTL: models.TTL(10 * time.Minute)}, Persist: true})
+	assert.Equal(t, errSyntax, err)
+
+	value, err = client.GetDel(ctx, "name")
+	assert.NoError(t, err)
+	assert.Equal(t, "petr", value)
+
+	_, err = client.GetDel(ctx, "name")
+	assert.Equal(t, redis.Nil, err)
+}
+
+func TestNativeStringAOF(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aof")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "appendonly.aof")
+	ctx := context.Background()
+
+	client := NewNative()
+	assert.NoError(t, client.OpenAOF(path, FsyncAlways))
+
+	_, err = client.SetArgs(ctx, "name", "Hello", models.SetOptions{Expiration: models.Expiration{TTL: models.TTL(10 * time.Minute)}})
+	assert.NoError(t, err)
+	_, err = client.Append(ctx, "name", " World")
+	assert.NoError(t, err)
+	_, err = client.SetRange(ctx, "name", 6, "Redis")
+	assert.NoError(t, err)
+	_, err = client.GetEx(ctx, "name", models.GetExOptions{Persist: true})
+	assert.NoError(t, err)
+	_, err = client.SetString(ctx, "tmp", "1", models.Expiration{})
+	assert.NoError(t, err)
+	_, err = client.GetDel(ctx, "tmp")
+	assert.NoError(t, err)
+	assert.NoError(t, client.Close())
+
+	restored := NewNative()
+	assert.NoError(t, restored.OpenAOF(path, FsyncNo))
+	defer restored.Close()
+
+	value, err := restored.GetString(ctx, "name")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello Redis", value)
+	assert.Zero(t, restored.data["name"].expireAt)
+	assert.Nil(t, restored.peek("tmp"))
+}
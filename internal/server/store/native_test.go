@@ -0,0 +1,387 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNativeSetString(t *testing.T) {
+	client := NewNative()
+
+	res, err := client.SetString(context.Background(), "user:1", "Ivan", models.Expiration{})
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", res)
+
+	value, err := client.GetString(context.Background(), "user:1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ivan", value)
+
+	_, err = client.GetString(context.Background(), "user:2")
+	assert.Equal(t, redis.Nil, err)
+
+	_, err = client.SetString(context.Background(), "user:1", "", models.Expiration{})
+	assert.Error(t, err)
+}
+
+func TestNativeSetHash(t *testing.T) {
+	client := NewNative()
+
+	type testCase struct {
+		name    string
+		key     string
+		values  map[string]interface{}
+		isError bool
+	}
+
+	tCases := []testCase{
+		{
+			name: "Success",
+			key:  "user:1",
+			values: map[string]interface{}{
+				"name": "Ivan",
+				"age":  21,
+			},
+			isError: false,
+		},
+		{
+			name:    "No fields",
+			key:     "user:2",
+			isError: true,
+		},
+		{
+			name: "Nested map",
+			key:  "user:3",
+			values: map[string]interface{}{
+				"name": map[string]interface{}{"first": "Ivan"},
+			},
+			isError: true,
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := client.SetHash(context.Background(), tc.key, tc.values, models.Expiration{})
+			if tc.isError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	hash, err := client.GetHash(context.Background(), "user:1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "Ivan", "age": "21"}, hash)
+
+	added, err := client.HSet(context.Background(), "user:1", map[string]interface{}{"age": 22, "role": "admin"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), added)
+
+	field, err := client.HGet(context.Background(), "user:1", "age")
+	assert.NoError(t, err)
+	assert.Equal(t, "22", field)
+
+	_, err = client.HGet(context.Background(), "user:1", "lastname")
+	assert.Equal(t, redis.Nil, err)
+}
+
+func TestNativeList(t *testing.T) {
+	client := NewNative()
+
+	values := []interface{}{
+		"Ivan",
+		195.5,
+		21,
+		map[string]interface{}{
+			"name": "Ivan",
+		},
+	}
+
+	err := client.SetList(context.Background(), "list:1", values, models.Expiration{})
+	assert.NoError(t, err)
+
+	list, err := client.GetList(context.Background(), "list:1")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"Ivan", 195.5, int64(21), map[string]interface{}{"name": "Ivan"}}, list)
+
+	list, err = client.LRange(context.Background(), "list:1", -3, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{195.5}, list)
+
+	res, err := client.LSet(context.Background(), "list:1", -1, "last")
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", res)
+
+	list, err = client.LRange(context.Background(), "list:1", 3, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"last"}, list)
+
+	_, err = client.LSet(context.Background(), "list:1", 10, "value")
+	assert.Error(t, err)
+
+	_, err = client.LSet(context.Background(), "list:2", 0, "value")
+	assert.Error(t, err)
+}
+
+func TestNativeWrongType(t *testing.T) {
+	client := NewNative()
+
+	_, err := client.SetString(context.Background(), "key", "value", models.Expiration{})
+	assert.NoError(t, err)
+
+	_, err = client.GetHash(context.Background(), "key")
+	assert.Equal(t, ErrWrongType, err)
+
+	err = client.SetList(context.Background(), "key", []interface{}{"value"}, models.Expiration{})
+	assert.Equal(t, ErrWrongType, err)
+}
+
+func TestNativeKeysAndDelete(t *testing.T) {
+	client := NewNative()
+
+	for _, key := range []string{"user:1", "user:2", "list:1"} {
+		_, err := client.SetString(context.Background(), key, "value", models.Expiration{})
+		assert.NoError(t, err)
+	}
+
+	keys, err := client.GetKeys(context.Background(), "user:*")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:1", "user:2"}, keys)
+
+	deleted, err := client.Delete(context.Background(), "user:1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	deleted, err = client.Delete(context.Background(), "user:1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+
+	keys, err = client.GetKeys(context.Background(), "*")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:2", "list:1"}, keys)
+}
+
+func TestNativeExpiration(t *testing.T) {
+	client := NewNative()
+
+	_, err := client.SetString(context.Background(), "session", "value", models.Expiration{TTL: models.TTL(10 * time.Minute)})
+	assert.NoError(t, err)
+
+	client.data["session"].expireAt = nowMs() - int64(time.Second/time.Millisecond)
+
+	_, err = client.GetString(context.Background(), "session")
+	assert.Equal(t, redis.Nil, err)
+	assert.Empty(t, client.data)
+}
+
+func TestMatchPattern(t *testing.T) {
+	type testCase struct {
+		pattern string
+		str     string
+		match   bool
+	}
+
+	tCases := []testCase{
+		{pattern: "*", str: "user:1", match: true},
+		{pattern: "user:*", str: "user:1", match: true},
+		{pattern: "user:?", str: "user:10", match: false},
+		{pattern: "h[ae]llo", str: "hello", match: true},
+		{pattern: "h[^e]llo", str: "hello", match: false},
+		{pattern: "h[a-b]llo", str: "hbllo", match: true},
+		{pattern: "path/*", str: "path/to/key", match: true},
+		{pattern: `h\*llo`, str: "h*llo", match: true},
+		{pattern: `h\*llo`, str: "hello", match: false},
+	}
+
+	for _, tc := range tCases {
+		assert.Equal(t, tc.match, matchPattern(tc.pattern, tc.str), tc.pattern)
+	}
+}
+
+func TestNativeLoadRDB(t *testing.T) {
+	dump, err := os.Open("../../../build/redis/data/dump.rdb")
+	assert.NoError(t, err)
+	defer dump.Close()
+
+	client := NewNative()
+	_, err = client.SetString(context.Background(), "list:1", "value", models.Expiration{})
+	assert.NoError(t, err)
+
+	// user:4 and the json key are expired long ago
+	loaded, err := client.LoadRDB(dump)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, loaded)
+
+	value, err := client.GetString(context.Background(), "user:ivan")
+	assert.NoError(t, err)
+	assert.Equal(t, "lapshin", value)
+
+	hash, err := client.GetHash(context.Background(), "user:2")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "Ivan", "lastname": "Lapshin"}, hash)
+
+	list, err := client.GetList(context.Background(), "list:1")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"ivan", 1.0, 3.2}, list)
+
+	_, err = client.GetHash(context.Background(), "user:4")
+	assert.NoError(t, err)
+	assert.NotContains(t, client.data, "user:4")
+}
+
+func TestImportList(t *testing.T) {
+	list, err := importList([]string{`{"Dtype":"int64","Data":"1"}`, "raw"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`{"Dtype":"int64","Data":"1"}`, `{"Dtype":"string","Data":"raw"}`}, list)
+}
+
+func TestNativeSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rdb")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "dump.rdb")
+
+	client := NewNative()
+	client.SetDBFilename(path)
+	_, err = client.SetString(context.Background(), "user:1", "Ivan", models.Expiration{TTL: models.TTL(10 * time.Minute)})
+	assert.NoError(t, err)
+	assert.NoError(t, client.SetHash(context.Background(), "hash", map[string]interface{}{"name": "Ivan"}, models.Expiration{}))
+	assert.NoError(t, client.SetList(context.Background(), "list", []interface{}{"a", int64(1)}, models.Expiration{}))
+	_, err = client.SAdd(context.Background(), "roles", []string{"user", "admin"})
+	assert.NoError(t, err)
+	_, err = client.ZAdd(context.Background(), "scores", []models.ZMember{{Member: "ivan", Score: 1.5}, {Member: "petr", Score: -2}}, models.ZAddOptions{})
+	assert.NoError(t, err)
+	_, err = client.XAdd(context.Background(), "events", "1-0", map[string]interface{}{"name": "ivan"}, models.XTrim{})
+	assert.NoError(t, err)
+	assert.NoError(t, client.XGroupCreate(context.Background(), "events", "workers", "0", false))
+	_, err = client.XReadGroup(context.Background(), models.XReadGroupRequest{Group: "workers", Consumer: "ivan", Keys: []string{"events"}})
+	assert.NoError(t, err)
+	assert.NoError(t, client.XGroupCreate(context.Background(), "empty", "workers", "$", true))
+
+	assert.NoError(t, client.Save(context.Background()))
+
+	status, err := client.SaveStatus(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, status.Success)
+	assert.False(t, status.InProgress)
+	assert.NotZero(t, status.LastSave)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Size(), status.Size)
+
+	assert.NoError(t, client.BGSave(context.Background()))
+	assert.NoError(t, client.Close())
+
+	dump, err := os.Open(path)
+	assert.NoError(t, err)
+	defer dump.Close()
+
+	restored := NewNative()
+	loaded, err := restored.LoadRDB(dump)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, loaded)
+	assert.Equal(t, client.data["user:1"].expireAt, restored.data["user:1"].expireAt)
+
+	list, err := restored.GetList(context.Background(), "list")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", int64(1)}, list)
+
+	members, err := restored.SMembers(context.Background(), "roles")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"admin", "user"}, members)
+
+	scores, err := restored.ZRange(context.Background(), "scores", models.ZRangeQuery{Start: "0", Stop: "-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ZMember{{Member: "petr", Score: -2}, {Member: "ivan", Score: 1.5}}, scores)
+
+	for _, key := range []string{"events", "empty"} {
+		assert.Equal(t, client.data[key].value, restored.data[key].value)
+	}
+
+	client.SetDBFilename(filepath.Join(dir, "missing", "dump.rdb"))
+	assert.Error(t, client.Save(context.Background()))
+
+	status, err = client.SaveStatus(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, status.Success)
+	assert.NotEmpty(t, status.Error)
+}
+
+func TestNativeSnapshotCopyOnWrite(t *testing.T) {
+	client := NewNative()
+	assert.NoError(t, client.SetHash(context.Background(), "hash", map[string]interface{}{"name": "Ivan"}, models.Expiration{}))
+	assert.NoError(t, client.SetList(context.Background(), "list", []interface{}{"a"}, models.Expiration{}))
+	_, err := client.SetString(context.Background(), "user:1", "Ivan", models.Expiration{})
+	assert.NoError(t, err)
+	_, err = client.ZAdd(context.Background(), "scores", []models.ZMember{{Member: "ivan", Score: 1}}, models.ZAddOptions{})
+	assert.NoError(t, err)
+	_, err = client.XAdd(context.Background(), "events", "1-0", map[string]interface{}{"name": "ivan"}, models.XTrim{})
+	assert.NoError(t, err)
+	assert.NoError(t, client.XGroupCreate(context.Background(), "events", "workers", "0", false))
+
+	snapshot, _, err := client.startSave()
+	assert.NoError(t, err)
+	assert.Equal(t, ErrSaveInProgress, client.BGSave(context.Background()))
+
+	_, err = client.HSet(context.Background(), "hash", map[string]interface{}{"name": "Petr"})
+	assert.NoError(t, err)
+	_, err = client.LSet(context.Background(), "list", 0, "b")
+	assert.NoError(t, err)
+	assert.NoError(t, client.SetList(context.Background(), "list", []interface{}{"c"}, models.Expiration{TTL: models.TTL(10 * time.Minute)}))
+	_, err = client.Delete(context.Background(), "user:1")
+	assert.NoError(t, err)
+	_, err = client.ZAdd(context.Background(), "scores", []models.ZMember{{Member: "ivan", Score: 5}, {Member: "petr", Score: 2}}, models.ZAddOptions{})
+	assert.NoError(t, err)
+	_, err = client.XAdd(context.Background(), "events", "2-0", map[string]interface{}{"name": "petr"}, models.XTrim{})
+	assert.NoError(t, err)
+	_, err = client.XReadGroup(context.Background(), models.XReadGroupRequest{Group: "workers", Consumer: "ivan", Keys: []string{"events"}})
+	assert.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	_, err = writeRDB(buf, snapshot)
+	assert.NoError(t, err)
+	client.finishSave(time.Now(), int64(buf.Len()), nil)
+
+	restored := NewNative()
+	_, err = restored.LoadRDB(buf)
+	assert.NoError(t, err)
+
+	hash, err := restored.GetHash(context.Background(), "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "Ivan"}, hash)
+
+	list, err := restored.GetList(context.Background(), "list")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a"}, list)
+	assert.Zero(t, restored.data["list"].expireAt)
+
+	scores, err := restored.ZRange(context.Background(), "scores", models.ZRangeQuery{Start: "0", Stop: "-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ZMember{{Member: "ivan", Score: 1}}, scores)
+
+	length, err := restored.XLen(context.Background(), "events")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), length)
+	pending, err := restored.XPending(context.Background(), "events", "workers")
+	assert.NoError(t, err)
+	assert.Zero(t, pending.Count)
+
+	_, err = restored.GetString(context.Background(), "user:1")
+	assert.NoError(t, err)
+
+	list, err = client.GetList(context.Background(), "list")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"b", "c"}, list)
+	assert.Nil(t, client.snapshot)
+}
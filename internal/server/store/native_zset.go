@@ -0,0 +1,543 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// zaddResult - result of update of sorted set
+type zaddResult struct {
+	added int64
+	// members which are added or which scores are changed, with the new scores
+	changed []models.ZMember
+	// score of the last member after the update, nil if the member is skipped because of options
+	score *float64
+}
+
+// ZAdd - adds members to the sorted set or updates their scores, returns number of added members
+// or number of changed members with CH option
+func (n *Native) ZAdd(ctx context.Context, key string, members []models.ZMember, opts models.ZAddOptions) (int64, error) {
+	if err := checkZAdd(key, members, opts, false); err != nil {
+		return 0, err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return 0, err
+	}
+
+	res, err := n.zadd(key, members, opts, false)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(res.changed) > 0 {
+		n.notify(notifyZSet, "zadd", key)
+	}
+	if err := n.propagateZAdd(key, res.changed); err != nil {
+		return 0, err
+	}
+
+	if opts.CH {
+		return int64(len(res.changed)), nil
+	}
+	return res.added, nil
+}
+
+// ZAddIncr - increments score of the member like ZADD with INCR, returns the new score.
+// redis.Nil is returned when the member is not updated because of the options.
+func (n *Native) ZAddIncr(ctx context.Context, key string, member models.ZMember, opts models.ZAddOptions) (float64, error) {
+	members := []models.ZMember{member}
+	if err := checkZAdd(key, members, opts, true); err != nil {
+		return 0, err
+	}
+
+	defer n.lock(ctx)()
+
+	if err := n.freeMemory(); err != nil {
+		return 0, err
+	}
+
+	res, err := n.zadd(key, members, opts, true)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(res.changed) > 0 {
+		n.notify(notifyZSet, "zincr", key)
+	}
+	if err := n.propagateZAdd(key, res.changed); err != nil {
+		return 0, err
+	}
+
+	if res.score == nil {
+		return 0, redis.Nil
+	}
+	return *res.score, nil
+}
+
+// ZRem - removes members from the sorted set, returns number of removed members
+func (n *Native) ZRem(ctx context.Context, key string, members []string) (int64, error) {
+	if key == "" || len(members) == 0 {
+		return 0, fmt.Errorf("Empty key or members")
+	}
+
+	defer n.lock(ctx)()
+
+	removed, err := n.zrem(key, members)
+	if err != nil || removed == 0 {
+		return 0, err
+	}
+
+	n.notify(notifyZSet, "zrem", key)
+	n.notifyDeleted(key)
+	return removed, n.propagate(append([]string{"ZREM", key}, members...)...)
+}
+
+// ZScore - score of the member, redis.Nil is returned if there is no such member
+func (n *Native) ZScore(ctx context.Context, key, member string) (float64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	z, err := n.zset(key)
+	if err != nil {
+		return 0, err
+	}
+
+	score, ok := z.dict[member]
+	if !ok {
+		return 0, redis.Nil
+	}
+
+	return score, nil
+}
+
+// ZRank - 0-based position of the member ordered by score, redis.Nil is returned if there is no such member
+func (n *Native) ZRank(ctx context.Context, key, member string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	z, err := n.zset(key)
+	if err != nil {
+		return 0, err
+	}
+
+	score, ok := z.dict[member]
+	if !ok {
+		return 0, redis.Nil
+	}
+
+	return z.zsl.rank(score, member) - 1, nil
+}
+
+// ZRange - members with scores between start and stop by index, score or lex
+func (n *Native) ZRange(ctx context.Context, key string, query models.ZRangeQuery) ([]models.ZMember, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+
+	by, err := checkZRange(query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer n.lock(ctx)()
+
+	z, err := n.zset(key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch by {
+	case ZRangeByScore:
+		min, max := query.Start, query.Stop
+		if query.Rev {
+			min, max = max, min
+		}
+
+		r, _ := parseScoreRange(min, max)
+		return zrangeNodes(z.zsl, r, query), nil
+	case ZRangeByLex:
+		min, max := query.Start, query.Stop
+		if query.Rev {
+			min, max = max, min
+		}
+
+		r, _ := parseLexRange(min, max)
+		return zrangeNodes(z.zsl, r, query), nil
+	}
+
+	start, _ := strconv.ParseInt(query.Start, 10, 64)
+	stop, _ := strconv.ParseInt(query.Stop, 10, 64)
+	start, stop, ok := normalizeRange(z.zsl.length, start, stop)
+	if !ok {
+		return []models.ZMember{}, nil
+	}
+
+	res := make([]models.ZMember, 0, stop-start+1)
+	if query.Rev {
+		for x := z.zsl.byRank(z.zsl.length - start); x != nil && int64(len(res)) <= stop-start; x = x.backward {
+			res = append(res, models.ZMember{Member: x.member, Score: x.score})
+		}
+		return res, nil
+	}
+
+	for x := z.zsl.byRank(start + 1); x != nil && int64(len(res)) <= stop-start; x = x.level[0].forward {
+		res = append(res, models.ZMember{Member: x.member, Score: x.score})
+	}
+	return res, nil
+}
+
+// ZPopMin - removes and returns count members with the lowest scores
+func (n *Native) ZPopMin(ctx context.Context, key string, count int64) ([]models.ZMember, error) {
+	return n.zpop(ctx, key, count, false)
+}
+
+// ZPopMax - removes and returns count members with the highest scores
+func (n *Native) ZPopMax(ctx context.Context, key string, count int64) ([]models.ZMember, error) {
+	return n.zpop(ctx, key, count, true)
+}
+
+// ZUnion - members of all sorted sets, scores are aggregated
+func (n *Native) ZUnion(ctx context.Context, store models.ZStore) ([]models.ZMember, error) {
+	return n.zcombine(ctx, store, false)
+}
+
+// ZInter - members which belong to all sorted sets, scores are aggregated
+func (n *Native) ZInter(ctx context.Context, store models.ZStore) ([]models.ZMember, error) {
+	return n.zcombine(ctx, store, true)
+}
+
+// zpop - members are removed from the head or, for max, from the tail of skiplist
+func (n *Native) zpop(ctx context.Context, key string, count int64, max bool) ([]models.ZMember, error) {
+	if err := checkZPop(key, count); err != nil {
+		return nil, err
+	}
+
+	defer n.lock(ctx)()
+
+	z, err := n.zset(key)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []models.ZMember{}
+	x := z.zsl.header.level[0].forward
+	if max {
+		x = z.zsl.tail
+	}
+	for x != nil && int64(len(res)) < count {
+		res = append(res, models.ZMember{Member: x.member, Score: x.score})
+		if max {
+			x = x.backward
+		} else {
+			x = x.level[0].forward
+		}
+	}
+
+	if len(res) == 0 {
+		return res, nil
+	}
+
+	members := make([]string, len(res))
+	for i, member := range res {
+		members[i] = member.Member
+	}
+
+	if _, err := n.zrem(key, members); err != nil {
+		return nil, err
+	}
+
+	if max {
+		n.notify(notifyZSet, "zpopmax", key)
+	} else {
+		n.notify(notifyZSet, "zpopmin", key)
+	}
+	n.notifyDeleted(key)
+	return res, n.propagate(append([]string{"ZREM", key}, members...)...)
+}
+
+// zcombine - plain sets are combined as sorted sets with score 1, missing keys are treated as empty sets
+func (n *Native) zcombine(ctx context.Context, store models.ZStore, inter bool) ([]models.ZMember, error) {
+	aggregate, err := checkZStore(store)
+	if err != nil {
+		return nil, err
+	}
+
+	defer n.lock(ctx)()
+
+	sets := make([]map[string]float64, len(store.Keys))
+	for i, key := range store.Keys {
+		if sets[i], err = n.zsetScores(key); err != nil {
+			return nil, err
+		}
+	}
+
+	weight := func(i int) float64 {
+		if len(store.Weights) == 0 {
+			return 1
+		}
+		return store.Weights[i]
+	}
+
+	res := make(map[string]float64)
+	for i, set := range sets {
+		for member, score := range set {
+			score = zscore(score * weight(i))
+
+			current, ok := res[member]
+			if !ok {
+				if inter && i > 0 {
+					continue
+				}
+				res[member] = score
+				continue
+			}
+
+			res[member] = zaggregate(aggregate, current, score)
+		}
+
+		if inter && i > 0 {
+			for member := range res {
+				if _, ok := set[member]; !ok {
+					delete(res, member)
+				}
+			}
+		}
+	}
+
+	return zmembersOf(res), nil
+}
+
+// zadd - adds members according to options the same way as ZADD
+func (n *Native) zadd(key string, members []models.ZMember, opts models.ZAddOptions, incr bool) (*zaddResult, error) {
+	res := &zaddResult{}
+
+	e := n.lookupWrite(key)
+	if e == nil {
+		if opts.XX {
+			return res, nil
+		}
+		e = n.newEntry(key, newZSet())
+		n.putEntry(key, e)
+	}
+
+	z, ok := e.value.(*zset)
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	for _, member := range members {
+		res.score = nil
+		score := member.Score
+
+		current, exists := z.dict[member.Member]
+		if !exists {
+			if opts.XX {
+				continue
+			}
+
+			z.add(member.Member, score)
+			n.resize(e, fieldOverhead+int64(len(member.Member)))
+			res.added++
+			res.changed = append(res.changed, member)
+			res.score = &score
+			continue
+		}
+
+		if opts.NX {
+			continue
+		}
+
+		if incr {
+			score += current
+			if math.IsNaN(score) {
+				return nil, errNaN
+			}
+		}
+
+		if (opts.GT && score <= current) || (opts.LT && score >= current) {
+			continue
+		}
+
+		res.score = &score
+		if score != current {
+			z.add(member.Member, score)
+			res.changed = append(res.changed, models.ZMember{Member: member.Member, Score: score})
+		}
+	}
+
+	return res, nil
+}
+
+// zrem - removes members from the sorted set, the key is removed together with the last member
+func (n *Native) zrem(key string, members []string) (int64, error) {
+	e := n.lookupWrite(key)
+	if e == nil {
+		return 0, nil
+	}
+
+	z, ok := e.value.(*zset)
+	if !ok {
+		return 0, ErrWrongType
+	}
+
+	var removed int64
+	for _, member := range members {
+		if !z.remove(member) {
+			continue
+		}
+
+		n.resize(e, -fieldOverhead-int64(len(member)))
+		removed++
+	}
+
+	if len(z.dict) == 0 {
+		n.remove(key)
+	}
+
+	return removed, nil
+}
+
+// zset - returns sorted set stored by the key, missing key is treated as an empty set
+func (n *Native) zset(key string) (*zset, error) {
+	e := n.lookup(key)
+	if e == nil {
+		return newZSet(), nil
+	}
+
+	z, ok := e.value.(*zset)
+	if !ok {
+		return nil, ErrWrongType
+	}
+
+	return z, nil
+}
+
+// zsetScores - scores of members of sorted set or of plain set, members of plain set have score 1
+func (n *Native) zsetScores(key string) (map[string]float64, error) {
+	e := n.lookup(key)
+	if e == nil {
+		return map[string]float64{}, nil
+	}
+
+	switch value := e.value.(type) {
+	case *zset:
+		return value.dict, nil
+	case map[string]struct{}:
+		res := make(map[string]float64, len(value))
+		for member := range value {
+			res[member] = 1
+		}
+		return res, nil
+	}
+
+	return nil, ErrWrongType
+}
+
+// propagateZAdd - only changed members are written with their new scores, so that options are not needed
+// to replay the command
+func (n *Native) propagateZAdd(key string, members []models.ZMember) error {
+	if len(members) == 0 {
+		return nil
+	}
+
+	return n.propagate(zaddCommand(key, members)...)
+}
+
+// zaddCommand - ZADD key score member [score member ...]
+func zaddCommand(key string, members []models.ZMember) []string {
+	args := make([]string, 0, len(members)*2+2)
+	args = append(args, "ZADD", key)
+	for _, member := range members {
+		args = append(args, strconv.FormatFloat(member.Score, 'g', -1, 64), member.Member)
+	}
+
+	return args
+}
+
+// zmembersOf - members with scores ordered the same way as in skiplist
+func zmembersOf(scores map[string]float64) []models.ZMember {
+	res := make([]models.ZMember, 0, len(scores))
+	for member, score := range scores {
+		res = append(res, models.ZMember{Member: member, Score: score})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score < res[j].Score
+		}
+		return res[i].Member < res[j].Member
+	})
+
+	return res
+}
+
+// zrangeNodes - members in the range of scores or lex with offset and limit of query
+func zrangeNodes(zsl *skiplist, r zrange, query models.ZRangeQuery) []models.ZMember {
+	res := []models.ZMember{}
+	if query.Offset < 0 {
+		return res
+	}
+
+	var x *skiplistNode
+	if query.Rev {
+		x = zsl.last(r.after)
+	} else {
+		x = zsl.first(r.before)
+	}
+
+	offset := query.Offset
+	for x != nil && !r.before(x) && !r.after(x) {
+		if query.Count > 0 && int64(len(res)) >= query.Count {
+			break
+		}
+
+		if offset > 0 {
+			offset--
+		} else {
+			res = append(res, models.ZMember{Member: x.member, Score: x.score})
+		}
+
+		if query.Rev {
+			x = x.backward
+		} else {
+			x = x.level[0].forward
+		}
+	}
+
+	return res
+}
+
+// zscore - NaN, e.g. result of 0 * inf, is treated as 0 like in redis
+func zscore(score float64) float64 {
+	if math.IsNaN(score) {
+		return 0
+	}
+
+	return score
+}
+
+func zaggregate(aggregate string, a, b float64) float64 {
+	switch aggregate {
+	case "min":
+		return math.Min(a, b)
+	case "max":
+		return math.Max(a, b)
+	}
+
+	return zscore(a + b)
+}
@@ -0,0 +1,385 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkiplist(t *testing.T) {
+	sl := newSkiplist()
+	for i := 0; i < 1000; i++ {
+		sl.insert(float64(i%100), fmt.Sprintf("member:%04d", i))
+	}
+	assert.Equal(t, int64(1000), sl.length)
+
+	// members with equal scores are ordered by member
+	var prev *skiplistNode
+	for x := sl.header.level[0].forward; x != nil; x = x.level[0].forward {
+		if prev != nil {
+			assert.True(t, prev.less(x.score, x.member))
+		}
+		assert.Equal(t, prev, x.backward)
+		prev = x
+	}
+	assert.Equal(t, prev, sl.tail)
+
+	for rank := int64(1); rank <= sl.length; rank += 37 {
+		x := sl.byRank(rank)
+		assert.NotNil(t, x)
+		assert.Equal(t, rank, sl.rank(x.score, x.member))
+	}
+	assert.Nil(t, sl.byRank(0))
+	assert.Nil(t, sl.byRank(1001))
+	assert.Zero(t, sl.rank(5, "missing"))
+
+	for i := 0; i < 1000; i += 2 {
+		assert.True(t, sl.delete(float64(i%100), fmt.Sprintf("member:%04d", i)))
+	}
+	assert.False(t, sl.delete(0, "member:0000"))
+	assert.Equal(t, int64(500), sl.length)
+	assert.Equal(t, "member:0001", sl.byRank(1).member)
+	assert.Equal(t, "member:0999", sl.tail.member)
+	assert.Equal(t, int64(500), sl.rank(99, "member:0999"))
+}
+
+func TestNativeZAdd(t *testing.T) {
+	type testCase struct {
+		name    string
+		members []models.ZMember
+		opts    models.ZAddOptions
+		result  int64
+		scores  map[string]float64
+		err     error
+	}
+
+	tCases := []testCase{
+		{
+			name:    "Add",
+			members: []models.ZMember{{Member: "petr", Score: 3}, {Member: "oleg", Score: 4}},
+			result:  1,
+			scores:  map[string]float64{"ivan": 1, "petr": 3, "oleg": 4},
+		},
+		{
+			name:    "CH",
+			members: []models.ZMember{{Member: "petr", Score: 3}, {Member: "oleg", Score: 4}, {Member: "ivan", Score: 1}},
+			opts:    models.ZAddOptions{CH: true},
+			result:  2,
+			scores:  map[string]float64{"ivan": 1, "petr": 3, "oleg": 4},
+		},
+		{
+			name:    "NX",
+			members: []models.ZMember{{Member: "petr", Score: 3}, {Member: "oleg", Score: 4}},
+			opts:    models.ZAddOptions{NX: true},
+			result:  1,
+			scores:  map[string]float64{"ivan": 1, "petr": 2, "oleg": 4},
+		},
+		{
+			name:    "XX",
+			members: []models.ZMember{{Member: "petr", Score: 3}, {Member: "oleg", Score: 4}},
+			opts:    models.ZAddOptions{XX: true, CH: true},
+			result:  1,
+			scores:  map[string]float64{"ivan": 1, "petr": 3},
+		},
+		{
+			name:    "GT",
+			members: []models.ZMember{{Member: "petr", Score: 1}, {Member: "ivan", Score: 5}, {Member: "oleg", Score: 4}},
+			opts:    models.ZAddOptions{GT: true, CH: true},
+			result:  2,
+			scores:  map[string]float64{"ivan": 5, "petr": 2, "oleg": 4},
+		},
+		{
+			name:    "LT",
+			members: []models.ZMember{{Member: "petr", Score: 1}, {Member: "ivan", Score: 5}},
+			opts:    models.ZAddOptions{LT: true},
+			result:  0,
+			scores:  map[string]float64{"ivan": 1, "petr": 1},
+		},
+		{
+			name:    "NX and XX",
+			members: []models.ZMember{{Member: "petr", Score: 1}},
+			opts:    models.ZAddOptions{NX: true, XX: true},
+			err:     errZAddNXXX,
+		},
+		{
+			name:    "GT and NX",
+			members: []models.ZMember{{Member: "petr", Score: 1}},
+			opts:    models.ZAddOptions{NX: true, GT: true},
+			err:     errZAddGTLTNX,
+		},
+		{
+			name:    "NaN",
+			members: []models.ZMember{{Member: "petr", Score: math.NaN()}},
+			err:     errNotFloat,
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewNative()
+			_, err := client.ZAdd(context.Background(), "scores", []models.ZMember{{Member: "ivan", Score: 1}, {Member: "petr", Score: 2}}, models.ZAddOptions{})
+			assert.NoError(t, err)
+
+			result, err := client.ZAdd(context.Background(), "scores", tc.members, tc.opts)
+			assert.Equal(t, tc.err, err)
+			if tc.err != nil {
+				return
+			}
+
+			assert.Equal(t, tc.result, result)
+			assert.Equal(t, tc.scores, client.data["scores"].value.(*zset).scores())
+		})
+	}
+
+	// XX does not create the key
+	client := NewNative()
+	_, err := client.ZAdd(context.Background(), "scores", []models.ZMember{{Member: "ivan", Score: 1}}, models.ZAddOptions{XX: true})
+	assert.NoError(t, err)
+	assert.NotContains(t, client.data, "scores")
+}
+
+func TestNativeZAddIncr(t *testing.T) {
+	client := NewNative()
+
+	score, err := client.ZAddIncr(context.Background(), "scores", models.ZMember{Member: "ivan", Score: 1.5}, models.ZAddOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, score)
+
+	score, err = client.ZAddIncr(context.Background(), "scores", models.ZMember{Member: "ivan", Score: 2}, models.ZAddOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3.5, score)
+
+	_, err = client.ZAddIncr(context.Background(), "scores", models.ZMember{Member: "ivan", Score: -1}, models.ZAddOptions{GT: true})
+	assert.Equal(t, redis.Nil, err)
+
+	_, err = client.ZAddIncr(context.Background(), "scores", models.ZMember{Member: "ivan", Score: 1}, models.ZAddOptions{NX: true})
+	assert.Equal(t, redis.Nil, err)
+
+	_, err = client.ZAddIncr(context.Background(), "scores", models.ZMember{Member: "ivan", Score: math.Inf(1)}, models.ZAddOptions{})
+	assert.NoError(t, err)
+	_, err = client.ZAddIncr(context.Background(), "scores", models.ZMember{Member: "ivan", Score: math.Inf(-1)}, models.ZAddOptions{})
+	assert.Equal(t, errNaN, err)
+
+	score, err = client.ZScore(context.Background(), "scores", "ivan")
+	assert.NoError(t, err)
+	assert.True(t, math.IsInf(score, 1))
+}
+
+func TestNativeZSet(t *testing.T) {
+	client := NewNative()
+
+	_, err := client.ZAdd(context.Background(), "scores", []models.ZMember{
+		{Member: "ivan", Score: 1},
+		{Member: "petr", Score: 2},
+		{Member: "oleg", Score: 2},
+		{Member: "anna", Score: 3},
+	}, models.ZAddOptions{})
+	assert.NoError(t, err)
+
+	rank, err := client.ZRank(context.Background(), "scores", "petr")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), rank)
+
+	_, err = client.ZRank(context.Background(), "scores", "olga")
+	assert.Equal(t, redis.Nil, err)
+	_, err = client.ZScore(context.Background(), "missing", "olga")
+	assert.Equal(t, redis.Nil, err)
+
+	removed, err := client.ZRem(context.Background(), "scores", []string{"oleg", "olga"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+
+	members, err := client.ZPopMin(context.Background(), "scores", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ZMember{{Member: "ivan", Score: 1}}, members)
+
+	members, err = client.ZPopMax(context.Background(), "scores", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ZMember{{Member: "anna", Score: 3}, {Member: "petr", Score: 2}}, members)
+
+	// the key is removed together with the last member
+	assert.NotContains(t, client.data, "scores")
+	assert.Zero(t, client.used)
+
+	_, err = client.SetString(context.Background(), "user:1", "Ivan", models.Expiration{})
+	assert.NoError(t, err)
+	_, err = client.ZAdd(context.Background(), "user:1", []models.ZMember{{Member: "ivan"}}, models.ZAddOptions{})
+	assert.Equal(t, ErrWrongType, err)
+	_, err = client.ZRange(context.Background(), "user:1", models.ZRangeQuery{Start: "0", Stop: "-1"})
+	assert.Equal(t, ErrWrongType, err)
+}
+
+func TestNativeZRange(t *testing.T) {
+	type testCase struct {
+		name    string
+		query   models.ZRangeQuery
+		members []string
+		err     error
+	}
+
+	client := NewNative()
+	_, err := client.ZAdd(context.Background(), "scores", []models.ZMember{
+		{Member: "a", Score: 1},
+		{Member: "b", Score: 2},
+		{Member: "c", Score: 2},
+		{Member: "d", Score: 3},
+		{Member: "e", Score: 4},
+	}, models.ZAddOptions{})
+	assert.NoError(t, err)
+
+	tCases := []testCase{
+		{
+			name:    "Index",
+			query:   models.ZRangeQuery{Start: "1", Stop: "-2"},
+			members: []string{"b", "c", "d"},
+		},
+		{
+			name:    "Index rev",
+			query:   models.ZRangeQuery{Start: "0", Stop: "1", Rev: true},
+			members: []string{"e", "d"},
+		},
+		{
+			name:    "Index out of range",
+			query:   models.ZRangeQuery{Start: "5", Stop: "10"},
+			members: []string{},
+		},
+		{
+			name:    "Score",
+			query:   models.ZRangeQuery{Start: "(1", Stop: "3", By: ZRangeByScore},
+			members: []string{"b", "c", "d"},
+		},
+		{
+			name:    "Score with limit",
+			query:   models.ZRangeQuery{Start: "-inf", Stop: "+inf", By: ZRangeByScore, Offset: 1, Count: 2},
+			members: []string{"b", "c"},
+		},
+		{
+			name:    "Score rev",
+			query:   models.ZRangeQuery{Start: "3", Stop: "2", By: ZRangeByScore, Rev: true},
+			members: []string{"d", "c", "b"},
+		},
+		{
+			name:    "Score rev with limit",
+			query:   models.ZRangeQuery{Start: "+inf", Stop: "(1", By: ZRangeByScore, Rev: true, Offset: 3},
+			members: []string{"b"},
+		},
+		{
+			name:    "Lex",
+			query:   models.ZRangeQuery{Start: "-", Stop: "(c", By: ZRangeByLex},
+			members: []string{"a", "b"},
+		},
+		{
+			name:    "Lex rev",
+			query:   models.ZRangeQuery{Start: "+", Stop: "[d", By: ZRangeByLex, Rev: true},
+			members: []string{"e", "d"},
+		},
+		{
+			name:  "Bad score",
+			query: models.ZRangeQuery{Start: "a", Stop: "1", By: ZRangeByScore},
+			err:   errMinMaxFloat,
+		},
+		{
+			name:  "Bad lex",
+			query: models.ZRangeQuery{Start: "a", Stop: "+", By: ZRangeByLex},
+			err:   errMinMaxLex,
+		},
+		{
+			name:  "Limit of index",
+			query: models.ZRangeQuery{Start: "0", Stop: "1", Count: 1},
+			err:   errZRangeLimit,
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := client.ZRange(context.Background(), "scores", tc.query)
+			assert.Equal(t, tc.err, err)
+			if tc.err != nil {
+				return
+			}
+
+			members := make([]string, len(result))
+			for i, member := range result {
+				members[i] = member.Member
+			}
+			assert.Equal(t, tc.members, members)
+		})
+	}
+}
+
+func TestNativeZCombine(t *testing.T) {
+	type testCase struct {
+		name      string
+		operation func(ctx context.Context, store models.ZStore) ([]models.ZMember, error)
+		store     models.ZStore
+		members   []models.ZMember
+		err       error
+	}
+
+	client := NewNative()
+	_, err := client.ZAdd(context.Background(), "a", []models.ZMember{{Member: "ivan", Score: 1}, {Member: "petr", Score: 2}}, models.ZAddOptions{})
+	assert.NoError(t, err)
+	_, err = client.ZAdd(context.Background(), "b", []models.ZMember{{Member: "petr", Score: 3}, {Member: "oleg", Score: 4}}, models.ZAddOptions{})
+	assert.NoError(t, err)
+	_, err = client.SAdd(context.Background(), "c", []string{"petr", "anna"})
+	assert.NoError(t, err)
+
+	tCases := []testCase{
+		{
+			name:      "Union",
+			operation: client.ZUnion,
+			store:     models.ZStore{Keys: []string{"a", "b"}},
+			members:   []models.ZMember{{Member: "ivan", Score: 1}, {Member: "oleg", Score: 4}, {Member: "petr", Score: 5}},
+		},
+		{
+			name:      "Union with set and weights",
+			operation: client.ZUnion,
+			store:     models.ZStore{Keys: []string{"a", "c", "missing"}, Weights: []float64{2, 10, 1}, Aggregate: "MAX"},
+			members:   []models.ZMember{{Member: "ivan", Score: 2}, {Member: "anna", Score: 10}, {Member: "petr", Score: 10}},
+		},
+		{
+			name:      "Inter",
+			operation: client.ZInter,
+			store:     models.ZStore{Keys: []string{"a", "b", "c"}, Aggregate: "min"},
+			members:   []models.ZMember{{Member: "petr", Score: 1}},
+		},
+		{
+			name:      "Inter with missing key",
+			operation: client.ZInter,
+			store:     models.ZStore{Keys: []string{"a", "missing"}},
+			members:   []models.ZMember{},
+		},
+		{
+			name:      "Weights",
+			operation: client.ZUnion,
+			store:     models.ZStore{Keys: []string{"a", "b"}, Weights: []float64{1}},
+			err:       errWeights,
+		},
+		{
+			name:      "Aggregate",
+			operation: client.ZUnion,
+			store:     models.ZStore{Keys: []string{"a", "b"}, Aggregate: "avg"},
+			err:       errAggregate,
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := tc.operation(context.Background(), tc.store)
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.members, result)
+		})
+	}
+
+	// 0 * inf is treated as 0
+	_, err = client.ZAdd(context.Background(), "inf", []models.ZMember{{Member: "ivan", Score: math.Inf(1)}}, models.ZAddOptions{})
+	assert.NoError(t, err)
+	result, err := client.ZUnion(context.Background(), models.ZStore{Keys: []string{"inf"}, Weights: []float64{0}})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ZMember{{Member: "ivan", Score: 0}}, result)
+}
@@ -0,0 +1,159 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Classes of keyspace events, the same as letters of notify-keyspace-events of redis
+const (
+	// notifyKeyspace - K, events are published to __keyspace@0__:<key> with the event as message
+	notifyKeyspace = 1 << iota
+	// notifyKeyevent - E, events are published to __keyevent@0__:<event> with the key as message
+	notifyKeyevent
+	// notifyGeneric - g, commands which are not specific to a type: del, expire
+	notifyGeneric
+	// notifyString - $
+	notifyString
+	// notifyList - l
+	notifyList
+	// notifySet - s
+	notifySet
+	// notifyHash - h
+	notifyHash
+	// notifyZSet - z
+	notifyZSet
+	// notifyExpired - x, generated every time the expired key is removed
+	notifyExpired
+	// notifyEvicted - e, generated every time the key is evicted by maxmemory
+	notifyEvicted
+	// notifyStream - t
+	notifyStream
+
+	// notifyAll - A, alias for "g$lshzxet"
+	notifyAll = notifyGeneric | notifyString | notifyList | notifySet | notifyHash | notifyZSet |
+		notifyExpired | notifyEvicted | notifyStream
+)
+
+// Prefixes of channels of keyspace events, native engine has only one database
+const (
+	keyspaceChannel = "__keyspace@0__:"
+	keyeventChannel = "__keyevent@0__:"
+)
+
+// errNotifyFlags - returned for unknown classes of keyspace events
+var errNotifyFlags = errors.New("ERR Invalid argument 'notify-keyspace-events'")
+
+// notifyClasses - letters of event classes in the order used by CONFIG GET
+var notifyClasses = []struct {
+	letter byte
+	class  int
+}{
+	{'g', notifyGeneric},
+	{'$', notifyString},
+	{'l', notifyList},
+	{'s', notifySet},
+	{'h', notifyHash},
+	{'z', notifyZSet},
+	{'x', notifyExpired},
+	{'e', notifyEvicted},
+	{'t', notifyStream},
+	{'K', notifyKeyspace},
+	{'E', notifyKeyevent},
+}
+
+// parseNotifyFlags - converts value of notify-keyspace-events into classes, empty value disables notifications
+func parseNotifyFlags(value string) (int, error) {
+	flags := 0
+	for i := 0; i < len(value); i++ {
+		if value[i] == 'A' {
+			flags |= notifyAll
+			continue
+		}
+
+		known := false
+		for _, c := range notifyClasses {
+			if c.letter == value[i] {
+				flags |= c.class
+				known = true
+				break
+			}
+		}
+		if !known {
+			return 0, errNotifyFlags
+		}
+	}
+
+	return flags, nil
+}
+
+// formatNotifyFlags - value of notify-keyspace-events returned by CONFIG GET, e.g. "AKE"
+func formatNotifyFlags(flags int) string {
+	var b strings.Builder
+	for _, c := range notifyClasses {
+		if c.class&notifyAll != 0 && flags&notifyAll == notifyAll {
+			if c.class == notifyGeneric {
+				b.WriteByte('A')
+			}
+			continue
+		}
+
+		if flags&c.class != 0 {
+			b.WriteByte(c.letter)
+		}
+	}
+
+	return b.String()
+}
+
+// SetNotifyKeyspaceEvents - classes of keyspace events published by writes, e.g. "KEA", empty value disables them
+func (n *Native) SetNotifyKeyspaceEvents(ctx context.Context, value string) error {
+	flags, err := parseNotifyFlags(value)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.notifyFlags = flags
+	return nil
+}
+
+// NotifyKeyspaceEvents - classes of keyspace events in the same format as CONFIG GET notify-keyspace-events
+func (n *Native) NotifyKeyspaceEvents(ctx context.Context) (string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return formatNotifyFlags(n.notifyFlags), nil
+}
+
+// notifyDeleted - generic del event for the key removed because it became empty
+func (n *Native) notifyDeleted(key string) {
+	if _, ok := n.data[key]; !ok {
+		n.notify(notifyGeneric, "del", key)
+	}
+}
+
+// notify - every write reports the modified key here, so that its version is incremented like by
+// signalModifiedKey of redis. Then the event of the class is published if it is enabled, lock has to be
+// held by the caller.
+func (n *Native) notify(class int, event, key string) {
+	if e, ok := n.data[key]; ok {
+		n.version++
+		e.version = n.version
+	}
+
+	flags := n.notifyFlags
+	if flags&class == 0 {
+		return
+	}
+
+	if flags&notifyKeyspace != 0 {
+		n.pubsub.publish(keyspaceChannel+key, event)
+	}
+	if flags&notifyKeyevent != 0 {
+		n.pubsub.publish(keyeventChannel+event, key)
+	}
+}
@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNotifyFlags(t *testing.T) {
+	type testCase struct {
+		name      string
+		value     string
+		formatted string
+		wantErr   bool
+	}
+
+	tCases := []testCase{
+		{name: "Disabled", value: "", formatted: ""},
+		{name: "All classes", value: "KEA", formatted: "AKE"},
+		{name: "All classes by letters", value: "Eg$lshzxetK", formatted: "AKE"},
+		{name: "Some classes", value: "hKlE", formatted: "lhKE"},
+		{name: "Class without channel", value: "g", formatted: "g"},
+		{name: "Unknown class", value: "KEq", wantErr: true},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			flags, err := parseNotifyFlags(tc.value)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.formatted, formatNotifyFlags(flags))
+		})
+	}
+}
+
+func TestNativeNotify(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	events, err := client.Subscribe(ctx, nil, []string{"__keyevent@0__:*"})
+	assert.NoError(t, err)
+	defer events.Close()
+	keyspace, err := client.Subscribe(ctx, []string{"__keyspace@0__:user"}, nil)
+	assert.NoError(t, err)
+	defer keyspace.Close()
+
+	// nothing is published until notifications are enabled
+	_, err = client.SetString(ctx, "user", "ivan", models.Expiration{})
+	assert.NoError(t, err)
+
+	assert.Error(t, client.SetNotifyKeyspaceEvents(ctx, "KEq"))
+	assert.NoError(t, client.SetNotifyKeyspaceEvents(ctx, "KEA"))
+	value, err := client.NotifyKeyspaceEvents(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "AKE", value)
+
+	type testCase struct {
+		name   string
+		write  func() error
+		events []string
+	}
+
+	tCases := []testCase{
+		{
+			name: "String with ttl",
+			write: func() error {
+				_, err := client.SetString(ctx, "user", "petr", models.Expiration{TTL: models.TTL(time.Minute)})
+				return err
+			},
+			events: []string{"set", "expire"},
+		},
+		{
+			name: "Hash",
+			write: func() error {
+				_, err := client.HSet(ctx, "user:1", map[string]interface{}{"name": "ivan"})
+				return err
+			},
+			events: []string{"hset"},
+		},
+		{
+			name: "List",
+			write: func() error {
+				return client.SetList(ctx, "list", []interface{}{1, "2"}, models.Expiration{})
+			},
+			events: []string{"rpush"},
+		},
+		{
+			name: "Set which becomes empty",
+			write: func() error {
+				if _, err := client.SAdd(ctx, "tags", []string{"go"}); err != nil {
+					return err
+				}
+				_, err := client.SRem(ctx, "tags", []string{"go"})
+				return err
+			},
+			events: []string{"sadd", "srem", "del"},
+		},
+		{
+			name: "Sorted set",
+			write: func() error {
+				_, err := client.ZAdd(ctx, "scores", []models.ZMember{{Member: "ivan", Score: 1}}, models.ZAddOptions{})
+				return err
+			},
+			events: []string{"zadd"},
+		},
+		{
+			name: "Stream",
+			write: func() error {
+				_, err := client.XAdd(ctx, "events", "", map[string]interface{}{"name": "ivan"}, models.XTrim{})
+				return err
+			},
+			events: []string{"xadd"},
+		},
+		{
+			name: "Missing key is not deleted",
+			write: func() error {
+				_, err := client.Delete(ctx, "missing")
+				return err
+			},
+		},
+		{
+			name: "Delete",
+			write: func() error {
+				_, err := client.Delete(ctx, "user")
+				return err
+			},
+			events: []string{"del"},
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.NoError(t, tc.write())
+
+			for _, event := range tc.events {
+				message := <-events.Messages()
+				assert.Equal(t, "__keyevent@0__:"+event, message.Channel)
+			}
+			assert.Empty(t, events.Messages())
+		})
+	}
+
+	// only events of the key are published to its keyspace channel
+	for _, event := range []string{"set", "expire", "del"} {
+		assert.Equal(t, &models.Message{Channel: "__keyspace@0__:user", Payload: event}, <-keyspace.Messages())
+	}
+	assert.Empty(t, keyspace.Messages())
+}
+
+func TestNativeNotifyExpired(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	assert.NoError(t, client.SetNotifyKeyspaceEvents(ctx, "Ex"))
+	events, err := client.Subscribe(ctx, []string{"__keyevent@0__:expired", "__keyevent@0__:set"}, nil)
+	assert.NoError(t, err)
+	defer events.Close()
+
+	_, err = client.SetString(ctx, "user", "ivan", models.Expiration{TTL: models.TTL(time.Minute)})
+	assert.NoError(t, err)
+
+	client.mu.Lock()
+	client.data["user"].expireAt = nowMs() - int64(time.Second/time.Millisecond)
+	client.mu.Unlock()
+
+	_, err = client.GetString(ctx, "user")
+	assert.Error(t, err)
+
+	// only expired class is enabled, so that set is not published
+	assert.Equal(t, &models.Message{Channel: "__keyevent@0__:expired", Payload: "user"}, <-events.Messages())
+	assert.Empty(t, events.Messages())
+}
@@ -0,0 +1,218 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+)
+
+// DefaultPubSubBuffer - number of messages kept for the subscriber which does not read them
+const DefaultPubSubBuffer = 1000
+
+// ErrSlowSubscriber - the subscriber is dropped because its buffer is full, like the client which
+// exceeds client-output-buffer-limit of redis
+var ErrSlowSubscriber = errors.New("ERR output buffer limit of the subscriber is reached")
+
+// Subscription - messages of channels or patterns the client is subscribed to
+type Subscription interface {
+	// Messages - the channel is closed when the subscription is closed or dropped
+	Messages() <-chan *models.Message
+	// Err - the reason why the subscription is dropped, nil if it is closed by the client
+	Err() error
+	Close() error
+}
+
+// subscription - messages are delivered without blocking the publisher, the subscriber is dropped when
+// its buffer is full
+type subscription struct {
+	messages chan *models.Message
+	// channels and patterns of the broker subscription
+	channels []string
+	patterns []string
+	once     sync.Once
+	mu       sync.Mutex
+	err      error
+	// removes the subscription from the broker or closes the connection to redis
+	onClose func()
+}
+
+func newSubscription(buffer int) *subscription {
+	return &subscription{
+		messages: make(chan *models.Message, buffer),
+	}
+}
+
+func (s *subscription) Messages() <-chan *models.Message {
+	return s.messages
+}
+
+func (s *subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+func (s *subscription) Close() error {
+	if s.onClose != nil {
+		s.onClose()
+	}
+
+	return nil
+}
+
+// deliver - false is returned if the buffer is full
+func (s *subscription) deliver(message *models.Message) bool {
+	select {
+	case s.messages <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+// finish - closes the channel of messages, it must be called by the sender
+func (s *subscription) finish(err error) {
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+
+		close(s.messages)
+	})
+}
+
+// broker - in-process pub/sub of native engine, it has its own lock, so that publishers are not
+// blocked by commands
+type broker struct {
+	mu       sync.Mutex
+	buffer   int
+	channels map[string]map[*subscription]struct{}
+	patterns map[string]map[*subscription]struct{}
+}
+
+func newBroker() *broker {
+	return &broker{
+		buffer:   DefaultPubSubBuffer,
+		channels: make(map[string]map[*subscription]struct{}),
+		patterns: make(map[string]map[*subscription]struct{}),
+	}
+}
+
+// setBuffer - applied to new subscriptions
+func (b *broker) setBuffer(buffer int) error {
+	if buffer < 1 {
+		return fmt.Errorf("Buffer of subscribers has to be positive")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buffer = buffer
+	return nil
+}
+
+// subscribe - subscription to channels and patterns
+func (b *broker) subscribe(channels, patterns []string) (*subscription, error) {
+	if err := checkSubscribe(channels, patterns); err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := newSubscription(b.buffer)
+	sub.channels, sub.patterns = channels, patterns
+	sub.onClose = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.remove(sub, nil)
+	}
+
+	for _, channel := range channels {
+		if b.channels[channel] == nil {
+			b.channels[channel] = make(map[*subscription]struct{})
+		}
+		b.channels[channel][sub] = struct{}{}
+	}
+	for _, pattern := range patterns {
+		if b.patterns[pattern] == nil {
+			b.patterns[pattern] = make(map[*subscription]struct{})
+		}
+		b.patterns[pattern][sub] = struct{}{}
+	}
+
+	return sub, nil
+}
+
+// publish - returns number of receivers, the subscriber of the channel and the matching pattern
+// receives the message twice, like in redis
+func (b *broker) publish(channel, payload string) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var receivers int64
+	var dropped []*subscription
+	for sub := range b.channels[channel] {
+		if !sub.deliver(&models.Message{Channel: channel, Payload: payload}) {
+			dropped = append(dropped, sub)
+			continue
+		}
+		receivers++
+	}
+
+	for pattern, subs := range b.patterns {
+		if !matchPattern(pattern, channel) {
+			continue
+		}
+
+		for sub := range subs {
+			if !sub.deliver(&models.Message{Channel: channel, Pattern: pattern, Payload: payload}) {
+				dropped = append(dropped, sub)
+				continue
+			}
+			receivers++
+		}
+	}
+
+	for _, sub := range dropped {
+		b.remove(sub, ErrSlowSubscriber)
+	}
+
+	return receivers
+}
+
+// remove - unsubscribes from all channels and patterns and closes the channel of messages
+func (b *broker) remove(sub *subscription, err error) {
+	for _, channel := range sub.channels {
+		delete(b.channels[channel], sub)
+		if len(b.channels[channel]) == 0 {
+			delete(b.channels, channel)
+		}
+	}
+	for _, pattern := range sub.patterns {
+		delete(b.patterns[pattern], sub)
+		if len(b.patterns[pattern]) == 0 {
+			delete(b.patterns, pattern)
+		}
+	}
+
+	sub.finish(err)
+}
+
+func checkSubscribe(channels, patterns []string) error {
+	if len(channels) == 0 && len(patterns) == 0 {
+		return fmt.Errorf("Empty channels")
+	}
+
+	for _, channel := range append(append([]string{}, channels...), patterns...) {
+		if channel == "" {
+			return fmt.Errorf("Empty channel")
+		}
+	}
+
+	return nil
+}
@@ -2,9 +2,10 @@ package store
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Vysogota99/redis-implementation/internal/server/models"
@@ -13,24 +14,158 @@ import (
 
 // RedisImpl - interface for redis implementation
 type RedisImpl interface {
-	SetHash(ctx context.Context, key string, value map[string]interface{}, ttl int) error
-	SetString(ctx context.Context, key, value string, ttl int) (string, error)
-	SetList(ctx context.Context, key string, value []interface{}, ttl int) error
+	SetHash(ctx context.Context, key string, value map[string]interface{}, exp models.Expiration) error
+	SetString(ctx context.Context, key, value string, exp models.Expiration) (string, error)
+	SetList(ctx context.Context, key string, value []interface{}, exp models.Expiration) error
 	GetHash(ctx context.Context, key string) (map[string]string, error)
 	GetString(ctx context.Context, key string) (string, error)
 	GetList(ctx context.Context, key string) ([]interface{}, error)
 	GetKeys(ctx context.Context, pattern string) ([]string, error)
+	Scan(ctx context.Context, cursor uint64, match string, count int64, keyType string) ([]string, uint64, error)
 	Delete(ctx context.Context, key string) (int64, error)
+	Type(ctx context.Context, key string) (string, error)
+	TTL(ctx context.Context, key string) (int64, error)
+	PTTL(ctx context.Context, key string) (int64, error)
+	Expire(ctx context.Context, key string, opts models.ExpireOptions) (bool, error)
+	Persist(ctx context.Context, key string) (bool, error)
+	Exists(ctx context.Context, keys []string) (int64, error)
 	HGet(ctx context.Context, key string, field string) (string, error)
 	HSet(ctx context.Context, key string, values map[string]interface{}) (int64, error)
+	HDel(ctx context.Context, key string, fields []string) (int64, error)
+	HExists(ctx context.Context, key, field string) (bool, error)
+	HLen(ctx context.Context, key string) (int64, error)
+	HKeys(ctx context.Context, key string) ([]string, error)
+	HVals(ctx context.Context, key string) ([]string, error)
+	HMGet(ctx context.Context, key string, fields []string) ([]interface{}, error)
+	HSetNX(ctx context.Context, key, field string, value interface{}) (bool, error)
+	HIncrBy(ctx context.Context, key, field string, increment int64, raw bool) (int64, error)
+	HIncrByFloat(ctx context.Context, key, field string, increment float64, raw bool) (float64, error)
+	HScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error)
 	LRange(ctx context.Context, key string, start, stop int64) ([]interface{}, error)
 	LSet(ctx context.Context, key string, index int64, value interface{}) (string, error)
+	LPush(ctx context.Context, key string, values []interface{}) (int64, error)
+	RPush(ctx context.Context, key string, values []interface{}) (int64, error)
+	LPop(ctx context.Context, key string, count int64) ([]interface{}, error)
+	RPop(ctx context.Context, key string, count int64) ([]interface{}, error)
+	LLen(ctx context.Context, key string) (int64, error)
+	LIndex(ctx context.Context, key string, index int64) (interface{}, error)
+	LInsert(ctx context.Context, key string, before bool, pivot, value interface{}) (int64, error)
+	LRem(ctx context.Context, key string, count int64, value interface{}) (int64, error)
+	LTrim(ctx context.Context, key string, start, stop int64) error
+	LPos(ctx context.Context, key string, value interface{}, args models.LPosArgs) ([]int64, error)
+	LMove(ctx context.Context, source, destination, from, to string) (interface{}, error)
+	BLPop(ctx context.Context, keys []string, timeout time.Duration) (*models.BPopResult, error)
+	BRPop(ctx context.Context, keys []string, timeout time.Duration) (*models.BPopResult, error)
+	BLMove(ctx context.Context, source, destination, from, to string, timeout time.Duration) (interface{}, error)
+	IncrBy(ctx context.Context, key string, increment int64) (int64, error)
+	IncrByFloat(ctx context.Context, key string, increment float64) (float64, error)
+	SetArgs(ctx context.Context, key, value string, opts models.SetOptions) (string, error)
+	GetSet(ctx context.Context, key, value string) (string, error)
+	GetDel(ctx context.Context, key string) (string, error)
+	GetEx(ctx context.Context, key string, opts models.GetExOptions) (string, error)
+	Append(ctx context.Context, key, value string) (int64, error)
+	StrLen(ctx context.Context, key string) (int64, error)
+	GetRange(ctx context.Context, key string, start, end int64) (string, error)
+	SetRange(ctx context.Context, key string, offset int64, value string) (int64, error)
+	SAdd(ctx context.Context, key string, members []string) (int64, error)
+	SRem(ctx context.Context, key string, members []string) (int64, error)
+	SMembers(ctx context.Context, key string) ([]string, error)
+	SIsMember(ctx context.Context, key, member string) (bool, error)
+	SCard(ctx context.Context, key string) (int64, error)
+	SInter(ctx context.Context, keys []string) ([]string, error)
+	SUnion(ctx context.Context, keys []string) ([]string, error)
+	SDiff(ctx context.Context, keys []string) ([]string, error)
+	SInterStore(ctx context.Context, destination string, keys []string) (int64, error)
+	SUnionStore(ctx context.Context, destination string, keys []string) (int64, error)
+	SDiffStore(ctx context.Context, destination string, keys []string) (int64, error)
+	SScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error)
+	ZAdd(ctx context.Context, key string, members []models.ZMember, opts models.ZAddOptions) (int64, error)
+	ZAddIncr(ctx context.Context, key string, member models.ZMember, opts models.ZAddOptions) (float64, error)
+	ZRem(ctx context.Context, key string, members []string) (int64, error)
+	ZScore(ctx context.Context, key, member string) (float64, error)
+	ZRank(ctx context.Context, key, member string) (int64, error)
+	ZRange(ctx context.Context, key string, query models.ZRangeQuery) ([]models.ZMember, error)
+	ZPopMin(ctx context.Context, key string, count int64) ([]models.ZMember, error)
+	ZPopMax(ctx context.Context, key string, count int64) ([]models.ZMember, error)
+	ZUnion(ctx context.Context, store models.ZStore) ([]models.ZMember, error)
+	ZInter(ctx context.Context, store models.ZStore) ([]models.ZMember, error)
+	ZScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error)
+	XAdd(ctx context.Context, key, id string, fields map[string]interface{}, trim models.XTrim) (string, error)
+	XRange(ctx context.Context, key string, query models.XRangeQuery) ([]models.StreamEntry, error)
+	XRevRange(ctx context.Context, key string, query models.XRangeQuery) ([]models.StreamEntry, error)
+	XLen(ctx context.Context, key string) (int64, error)
+	XTrim(ctx context.Context, key string, trim models.XTrim) (int64, error)
+	XRead(ctx context.Context, query models.XReadQuery) ([]models.XStream, error)
+	XGroupCreate(ctx context.Context, key, group, id string, mkstream bool) error
+	XReadGroup(ctx context.Context, args models.XReadGroupRequest) ([]models.XStream, error)
+	XAck(ctx context.Context, key, group string, ids []string) (int64, error)
+	XPending(ctx context.Context, key, group string) (*models.XPending, error)
+	XPendingExt(ctx context.Context, key string, query models.XPendingQuery) ([]models.XPendingEntry, error)
+	XClaim(ctx context.Context, key string, claim models.XClaim) ([]models.StreamEntry, error)
+	XAutoClaim(ctx context.Context, key string, claim models.XAutoClaim) (*models.XAutoClaimResult, error)
+	JSONSet(ctx context.Context, key, path, value string, opts models.JSONSetOptions) (string, error)
+	JSONGet(ctx context.Context, key string, paths []string) (string, error)
+	JSONDel(ctx context.Context, key, path string) (int64, error)
+	JSONArrAppend(ctx context.Context, key, path string, values []string) (interface{}, error)
+	JSONNumIncrBy(ctx context.Context, key, path, increment string) (string, error)
+	JSONObjKeys(ctx context.Context, key, path string) (interface{}, error)
+	Publish(ctx context.Context, channel, message string) (int64, error)
+	Subscribe(ctx context.Context, channels, patterns []string) (Subscription, error)
+	SetNotifyKeyspaceEvents(ctx context.Context, value string) error
+	Tx(ctx context.Context, watch []string, commands []models.TxCommand) ([]models.TxResult, error)
+	Version(ctx context.Context, key string) (int64, error)
+	SetHashCAS(ctx context.Context, key string, value map[string]interface{}, exp models.Expiration, version int64) (int64, error)
+	SetStringCAS(ctx context.Context, key, value string, exp models.Expiration, version int64) (int64, error)
+	SetListCAS(ctx context.Context, key string, value []interface{}, exp models.Expiration, version int64) (int64, error)
+	HSetCAS(ctx context.Context, key string, values map[string]interface{}, version int64) (int64, int64, error)
+	LSetCAS(ctx context.Context, key string, index int64, value interface{}, version int64) (int64, error)
+	NotifyKeyspaceEvents(ctx context.Context) (string, error)
 	Save(ctx context.Context) error
+	BGSave(ctx context.Context) error
+	SaveStatus(ctx context.Context) (*models.SaveStatus, error)
+	Info(ctx context.Context, section string) (map[string]string, error)
+}
+
+var (
+	// ErrSaveInProgress - returned when saving is requested while background saving is not finished
+	ErrSaveInProgress = errors.New("ERR Background save already in progress")
+	// ErrOOM - returned by writes when memory limit is reached and nothing can be evicted
+	ErrOOM = errors.New("OOM command not allowed when used memory > 'maxmemory'.")
+)
+
+// IsWrongType - checks if the operation is applied to the key holding another data type
+func IsWrongType(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "WRONGTYPE ")
+}
+
+// IsInvalidArgument - checks if the command is rejected because of its arguments, e.g. syntax error or
+// value which is not a number
+func IsInvalidArgument(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "ERR ")
+}
+
+// IsNoGroup - checks if the command is applied to missing stream or consumer group
+func IsNoGroup(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOGROUP ")
+}
+
+// IsBusyGroup - checks if the consumer group already exists
+func IsBusyGroup(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP ")
+}
+
+// IsOOM - checks if the write is rejected because of memory limit, redis returns its own error
+func IsOOM(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "OOM ")
 }
 
 // Redis ...
 type Redis struct {
 	client *redis.Client
+	// number of messages kept for the subscriber, DefaultPubSubBuffer if it is not set
+	pubsubBuffer int
+	// hooks of the client added to its dedicated connections, go-redis does not copy them
+	connHooks []redis.Hook
 }
 
 // New - helper to init redis
@@ -50,127 +185,58 @@ func New(addr string) (*Redis, error) {
 }
 
 // SetHash ...
-func (r *Redis) SetHash(ctx context.Context, key string, value map[string]interface{}, ttl int) error {
+func (r *Redis) SetHash(ctx context.Context, key string, value map[string]interface{}, exp models.Expiration) error {
 	if key == "" || value == nil {
 		return fmt.Errorf("Empty key or field")
 	}
-
-	txf := func(tx *redis.Tx) error {
-		_, err := r.client.HMSet(ctx, key, value).Result()
-		if err != nil {
-			return err
-		}
-
-		if ttl != 0 {
-			_, err = r.client.Expire(ctx, key, time.Duration(ttl)*time.Minute).Result()
-			if err != nil {
-				return err
-			}
-		}
-
-		return nil
-	}
-
-	err := r.client.Watch(ctx, txf)
-	if err != nil {
+	if err := checkExpiration(exp); err != nil {
 		return err
 	}
-	return nil
+
+	return r.write(ctx, key, exp, func(c redis.Pipeliner) error {
+		return c.HMSet(ctx, key, value).Err()
+	})
 }
 
 // SetString ...
-func (r *Redis) SetString(ctx context.Context, key, value string, ttl int) (string, error) {
+func (r *Redis) SetString(ctx context.Context, key, value string, exp models.Expiration) (string, error) {
 	if key == "" || value == "" {
 		return "", fmt.Errorf("Empty key or field")
 	}
+	if err := checkExpiration(exp); err != nil {
+		return "", err
+	}
 
-	result, err := r.client.Set(ctx, key, value, time.Duration(ttl)*time.Minute).Result()
+	// ttl is set by SET itself, expire_at by PEXPIREAT in the same transaction
+	var cmd *redis.StatusCmd
+	err := r.write(ctx, key, models.Expiration{ExpireAt: exp.ExpireAt}, func(c redis.Pipeliner) error {
+		cmd = c.Set(ctx, key, value, exp.TTL.Duration())
+		return cmd.Err()
+	})
 	if err != nil {
 		return "", err
 	}
 
-	return result, nil
+	return cmd.Val(), nil
 }
 
 // SetList ...
-func (r *Redis) SetList(ctx context.Context, key string, value []interface{}, ttl int) error {
+func (r *Redis) SetList(ctx context.Context, key string, value []interface{}, exp models.Expiration) error {
 	if key == "" || value == nil {
 		return fmt.Errorf("Empty key or field")
 	}
-
-	txf := func(tx *redis.Tx) error {
-		strSlice := make([]string, len(value))
-		for i, val := range value {
-			element := models.ListElement{}
-			switch val.(type) {
-			case float64:
-				element.Dtype = "float64"
-				element.Data = fmt.Sprintf("%.5f", val.(float64))
-				serialized, err := json.Marshal(element)
-				if err != nil {
-					return err
-				}
-				strSlice[i] = string(serialized)
-			case int64:
-				element.Dtype = "int64"
-				element.Data = fmt.Sprintf("%d", val.(int64))
-				serialized, err := json.Marshal(element)
-				if err != nil {
-					return err
-				}
-				strSlice[i] = string(serialized)
-			case int:
-				element.Dtype = "int"
-				element.Data = fmt.Sprintf("%d", val.(int))
-				serialized, err := json.Marshal(element)
-				if err != nil {
-					return err
-				}
-				strSlice[i] = string(serialized)
-			case map[string]interface{}:
-				serializedData, err := json.Marshal(val)
-				if err != nil {
-					return err
-				}
-
-				element.Dtype = "map"
-				element.Data = string(serializedData)
-				serialized, err := json.Marshal(element)
-				if err != nil {
-					return err
-				}
-				strSlice[i] = string(serialized)
-			default:
-				element.Dtype = "string"
-				element.Data = val.(string)
-				serialized, err := json.Marshal(element)
-				if err != nil {
-					return err
-				}
-				strSlice[i] = string(serialized)
-			}
-		}
-
-		_, err := r.client.RPush(ctx, key, strSlice).Result()
-		if err != nil {
-			return err
-		}
-
-		if ttl != 0 {
-			_, err = r.client.Expire(ctx, key, time.Duration(ttl)*time.Minute).Result()
-			if err != nil {
-				return err
-			}
-		}
-
-		return nil
+	if err := checkExpiration(exp); err != nil {
+		return err
 	}
 
-	err := r.client.Watch(ctx, txf)
+	strSlice, err := encodeListElements(value)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	return r.write(ctx, key, exp, func(c redis.Pipeliner) error {
+		return c.RPush(ctx, key, strSlice).Err()
+	})
 }
 
 // GetHash ...
@@ -211,48 +277,7 @@ func (r *Redis) GetList(ctx context.Context, key string) ([]interface{}, error)
 		return nil, err
 	}
 
-	resultSlice := make([]interface{}, len(res))
-	for i, el := range res {
-		var lElement models.ListElement
-		if err := json.Unmarshal([]byte(el), &lElement); err != nil {
-			return nil, err
-		}
-
-		switch lElement.Dtype {
-		case "float64":
-			floatVal, err := strconv.ParseFloat(lElement.Data, 64)
-			if err != nil {
-				return nil, err
-			}
-
-			resultSlice[i] = floatVal
-		case "int64":
-			intVal, err := strconv.ParseInt(lElement.Data, 10, 64)
-			if err != nil {
-				return nil, err
-			}
-
-			resultSlice[i] = intVal
-		case "int":
-			intVal, err := strconv.ParseInt(lElement.Data, 10, 64)
-			if err != nil {
-				return nil, err
-			}
-
-			resultSlice[i] = intVal
-		case "string":
-			resultSlice[i] = lElement.Data
-		case "map":
-			var deserializedValue interface{}
-			if err := json.Unmarshal([]byte(lElement.Data), &deserializedValue); err != nil {
-				return nil, err
-			}
-
-			resultSlice[i] = deserializedValue
-		}
-	}
-
-	return resultSlice, nil
+	return decodeListElements(res)
 }
 
 // GetKeys ...
@@ -261,11 +286,19 @@ func (r *Redis) GetKeys(ctx context.Context, pattern string) ([]string, error) {
 		return nil, fmt.Errorf("Empty pattern")
 	}
 
-	res, err := r.client.Keys(ctx, pattern).Result()
+	keys, err := r.client.Keys(ctx, pattern).Result()
 	if err != nil {
 		return nil, err
 	}
 
+	// versions of keys are not shown, see versionKey
+	res := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !strings.HasPrefix(key, versionPrefix) {
+			res = append(res, key)
+		}
+	}
+
 	return res, nil
 }
 
@@ -275,12 +308,17 @@ func (r *Redis) Delete(ctx context.Context, key string) (int64, error) {
 		return 0, fmt.Errorf("Empty key")
 	}
 
-	res, err := r.client.Del(ctx, key).Result()
+	// the version is removed together with the key, see versionFunc
+	var cmd *redis.IntCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.Del(ctx, key)
+		return cmd.Err()
+	})
 	if err != nil {
 		return 0, err
 	}
 
-	return res, nil
+	return cmd.Val(), nil
 }
 
 // HGet ...
@@ -302,11 +340,15 @@ func (r *Redis) HSet(ctx context.Context, key string, values map[string]interfac
 		return 0, fmt.Errorf("Empty key of value")
 	}
 
-	res, err := r.client.HSet(ctx, key, values).Result()
+	var cmd *redis.IntCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.HSet(ctx, key, values)
+		return cmd.Err()
+	})
 	if err != nil {
 		return 0, err
 	}
-	return res, nil
+	return cmd.Val(), nil
 }
 
 // LRange ...
@@ -320,48 +362,7 @@ func (r *Redis) LRange(ctx context.Context, key string, start, stop int64) ([]in
 		return nil, err
 	}
 
-	resultSlice := make([]interface{}, len(res))
-	for i, el := range res {
-		var lElement models.ListElement
-		if err := json.Unmarshal([]byte(el), &lElement); err != nil {
-			return nil, err
-		}
-
-		switch lElement.Dtype {
-		case "float64":
-			floatVal, err := strconv.ParseFloat(lElement.Data, 64)
-			if err != nil {
-				return nil, err
-			}
-
-			resultSlice[i] = floatVal
-		case "int64":
-			intVal, err := strconv.ParseInt(lElement.Data, 10, 64)
-			if err != nil {
-				return nil, err
-			}
-
-			resultSlice[i] = intVal
-		case "int":
-			intVal, err := strconv.ParseInt(lElement.Data, 10, 64)
-			if err != nil {
-				return nil, err
-			}
-
-			resultSlice[i] = intVal
-		case "string":
-			resultSlice[i] = lElement.Data
-		case "map":
-			var deserializedValue interface{}
-			if err := json.Unmarshal([]byte(lElement.Data), &deserializedValue); err != nil {
-				return nil, err
-			}
-
-			resultSlice[i] = deserializedValue
-		}
-	}
-
-	return resultSlice, nil
+	return decodeListElements(res)
 }
 
 // LSet ...
@@ -370,61 +371,20 @@ func (r *Redis) LSet(ctx context.Context, key string, index int64, value interfa
 		return "", fmt.Errorf("Empty key")
 	}
 
-	element := models.ListElement{}
-	var valueToInsert string
-	switch value.(type) {
-	case float64:
-		element.Dtype = "float64"
-		element.Data = fmt.Sprintf("%.2f", value.(float64))
-		serialized, err := json.Marshal(element)
-		if err != nil {
-			return "", err
-		}
-		valueToInsert = string(serialized)
-	case int64:
-		element.Dtype = "int64"
-		element.Data = fmt.Sprintf("%d", value.(int64))
-		serialized, err := json.Marshal(element)
-		if err != nil {
-			return "", err
-		}
-		valueToInsert = string(serialized)
-	case int:
-		element.Dtype = "int"
-		element.Data = fmt.Sprintf("%d", value.(int64))
-		serialized, err := json.Marshal(element)
-		if err != nil {
-			return "", err
-		}
-		valueToInsert = string(serialized)
-	case map[string]interface{}:
-		serializedData, err := json.Marshal(value)
-		if err != nil {
-			return "", err
-		}
-
-		element.Dtype = "map"
-		element.Data = string(serializedData)
-		serialized, err := json.Marshal(element)
-		if err != nil {
-			return "", err
-		}
-		valueToInsert = string(serialized)
-	default:
-		element.Dtype = "string"
-		element.Data = value.(string)
-		serialized, err := json.Marshal(element)
-		if err != nil {
-			return "", err
-		}
-		valueToInsert = string(serialized)
+	valueToInsert, err := encodeListElement(value)
+	if err != nil {
+		return "", err
 	}
 
-	res, err := r.client.LSet(ctx, key, index, valueToInsert).Result()
+	var cmd *redis.StatusCmd
+	err = r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.LSet(ctx, key, index, valueToInsert)
+		return cmd.Err()
+	})
 	if err != nil {
 		return "", nil
 	}
-	return res, nil
+	return cmd.Val(), nil
 }
 
 // Save redis dump
@@ -436,3 +396,77 @@ func (r *Redis) Save(ctx context.Context) error {
 
 	return nil
 }
+
+// BGSave - starts saving of redis dump in background
+func (r *Redis) BGSave(ctx context.Context) error {
+	_, err := r.client.BgSave(ctx).Result()
+	if err != nil && strings.HasPrefix(err.Error(), ErrSaveInProgress.Error()) {
+		return ErrSaveInProgress
+	}
+
+	return err
+}
+
+// SaveStatus - status of the last saving, redis reports it in persistence section of INFO
+func (r *Redis) SaveStatus(ctx context.Context) (*models.SaveStatus, error) {
+	info, err := r.client.Info(ctx, "persistence").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := parseInfo(info)
+	status := &models.SaveStatus{
+		InProgress: fields["rdb_bgsave_in_progress"] == "1",
+		Success:    fields["rdb_last_bgsave_status"] == "ok",
+	}
+
+	if status.LastSave, err = strconv.ParseInt(fields["rdb_last_save_time"], 10, 64); err != nil {
+		return nil, fmt.Errorf("Bad rdb_last_save_time in INFO: %w", err)
+	}
+
+	// -1 means that there was no background saving yet
+	duration, err := strconv.ParseInt(fields["rdb_last_bgsave_time_sec"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Bad rdb_last_bgsave_time_sec in INFO: %w", err)
+	}
+	if duration > 0 {
+		status.Duration = duration * int64(time.Second/time.Millisecond)
+	}
+
+	return status, nil
+}
+
+// Info - fields of the section of INFO, all sections are returned for empty section
+func (r *Redis) Info(ctx context.Context, section string) (map[string]string, error) {
+	var cmd *redis.StringCmd
+	if section == "" {
+		cmd = r.client.Info(ctx)
+	} else {
+		cmd = r.client.Info(ctx, section)
+	}
+
+	info, err := cmd.Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseInfo(info), nil
+}
+
+// parseInfo - converts reply of INFO into fields, comments with names of sections are skipped
+func parseInfo(info string) map[string]string {
+	res := make(map[string]string)
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 {
+			res[parts[0]] = parts[1]
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// HDel ...
+func (r *Redis) HDel(ctx context.Context, key string, fields []string) (int64, error) {
+	if key == "" || len(fields) == 0 {
+		return 0, fmt.Errorf("Empty key or field")
+	}
+
+	var cmd *redis.IntCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.HDel(ctx, key, fields...)
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// HExists ...
+func (r *Redis) HExists(ctx context.Context, key, field string) (bool, error) {
+	if key == "" {
+		return false, fmt.Errorf("Empty key")
+	}
+
+	return r.client.HExists(ctx, key, field).Result()
+}
+
+// HLen ...
+func (r *Redis) HLen(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	return r.client.HLen(ctx, key).Result()
+}
+
+// HKeys - fields are in the order of redis, it is not sorted unlike native engine
+func (r *Redis) HKeys(ctx context.Context, key string) ([]string, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+
+	return r.client.HKeys(ctx, key).Result()
+}
+
+// HVals ...
+func (r *Redis) HVals(ctx context.Context, key string) ([]string, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+
+	return r.client.HVals(ctx, key).Result()
+}
+
+// HMGet ...
+func (r *Redis) HMGet(ctx context.Context, key string, fields []string) ([]interface{}, error) {
+	if key == "" || len(fields) == 0 {
+		return nil, fmt.Errorf("Empty key or field")
+	}
+
+	return r.client.HMGet(ctx, key, fields...).Result()
+}
+
+// HSetNX ...
+func (r *Redis) HSetNX(ctx context.Context, key, field string, value interface{}) (bool, error) {
+	if key == "" || field == "" {
+		return false, fmt.Errorf("Empty key or field")
+	}
+
+	var cmd *redis.BoolCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.HSetNX(ctx, key, field, value)
+		return cmd.Err()
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// hincrScript - HINCRBY or HINCRBYFLOAT keeping the type given by HashValues: the number is taken out of the
+// typed value, incremented by redis, so that overflow is checked the same way, and typed again. The result is
+// returned as text, because Lua numbers lose precision of int64. ARGV are the command, the field, the increment
+// and "typed" if the missing field has to be typed.
+const hincrScript = `local value = redis.call('HGET', KEYS[1], ARGV[2])
+local typed = ARGV[4] == 'typed'
+if value then
+	local number = string.match(value, '^{"Dtype":"int64","Data":"(%-?%d+)"}$') or
+		string.match(value, '^{"Dtype":"float64","Data":"([^"\\]+)"}$')
+	typed = number ~= nil
+	if typed then
+		redis.call('HSET', KEYS[1], ARGV[2], number)
+	end
+elseif typed then
+	redis.call('HSET', KEYS[1], ARGV[2], '0')
+end
+local res = redis.pcall(ARGV[1], KEYS[1], ARGV[2], ARGV[3])
+if type(res) == 'table' and res.err then
+	if typed and value then
+		redis.call('HSET', KEYS[1], ARGV[2], value)
+	elseif typed then
+		redis.call('HDEL', KEYS[1], ARGV[2])
+	end
+	return res
+end
+local text = redis.call('HGET', KEYS[1], ARGV[2])
+if typed then
+	local dtype = ARGV[1] == 'HINCRBY' and 'int64' or 'float64'
+	redis.call('HSET', KEYS[1], ARGV[2], '{"Dtype":"' .. dtype .. '","Data":"' .. text .. '"}')
+end
+return text`
+
+// hincrTyped - the last ARGV of hincrScript
+func hincrTyped(raw bool) string {
+	if raw {
+		return ""
+	}
+	return "typed"
+}
+
+// HIncrBy - the typed value keeps its type, see hincrScript
+func (r *Redis) HIncrBy(ctx context.Context, key, field string, increment int64, raw bool) (int64, error) {
+	if key == "" || field == "" {
+		return 0, fmt.Errorf("Empty key or field")
+	}
+
+	var cmd *redis.Cmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.Eval(ctx, hincrScript, []string{key}, "HINCRBY", field, increment, hincrTyped(raw))
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	text, err := cmd.Text()
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(text, 10, 64)
+}
+
+// HIncrByFloat - the typed value keeps its type, see hincrScript
+func (r *Redis) HIncrByFloat(ctx context.Context, key, field string, increment float64, raw bool) (float64, error) {
+	if key == "" || field == "" {
+		return 0, fmt.Errorf("Empty key or field")
+	}
+
+	var cmd *redis.Cmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.Eval(ctx, hincrScript, []string{key}, "HINCRBYFLOAT", field, increment, hincrTyped(raw))
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	text, err := cmd.Text()
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(text, 64)
+}
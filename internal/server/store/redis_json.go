@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// JSON.* commands require RedisJSON module, e.g. redis-stack-server. Arguments are checked the same way as by
+// native engine, so that both engines accept the same paths and values.
+
+// JSONSet ...
+func (r *Redis) JSONSet(ctx context.Context, key, path, value string, opts models.JSONSetOptions) (string, error) {
+	p, parsed, err := checkJSONSet(key, path, value, opts)
+	if err != nil {
+		return "", err
+	}
+
+	args := []interface{}{"JSON.SET", key, p.raw, marshalJSON(parsed)}
+	switch {
+	case opts.NX:
+		args = append(args, "NX")
+	case opts.XX:
+		args = append(args, "XX")
+	}
+
+	var cmd *redis.Cmd
+	err = r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.Do(ctx, args...)
+		return cmd.Err()
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return cmd.Text()
+}
+
+// JSONGet ...
+func (r *Redis) JSONGet(ctx context.Context, key string, paths []string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("Empty key")
+	}
+
+	args := []interface{}{"JSON.GET", key}
+	for _, path := range paths {
+		if _, err := parseJSONPath(path); err != nil {
+			return "", err
+		}
+		args = append(args, path)
+	}
+
+	return r.client.Do(ctx, args...).Text()
+}
+
+// JSONDel ...
+func (r *Redis) JSONDel(ctx context.Context, key, path string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	p, err := parseJSONPath(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var cmd *redis.Cmd
+	err = r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.Do(ctx, "JSON.DEL", key, p.raw)
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Int64()
+}
+
+// JSONArrAppend ...
+func (r *Redis) JSONArrAppend(ctx context.Context, key, path string, values []string) (interface{}, error) {
+	p, parsed, err := checkJSONArrAppend(key, path, values)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{"JSON.ARRAPPEND", key, p.raw}
+	for _, val := range parsed {
+		args = append(args, marshalJSON(val))
+	}
+
+	var cmd *redis.Cmd
+	err = r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.Do(ctx, args...)
+		return cmd.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// JSONNumIncrBy ...
+func (r *Redis) JSONNumIncrBy(ctx context.Context, key, path, increment string) (string, error) {
+	p, number, err := checkJSONNumIncrBy(key, path, increment)
+	if err != nil {
+		return "", err
+	}
+
+	var cmd *redis.Cmd
+	err = r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.Do(ctx, "JSON.NUMINCRBY", key, p.raw, number.String())
+		return cmd.Err()
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return cmd.Text()
+}
+
+// JSONObjKeys ...
+func (r *Redis) JSONObjKeys(ctx context.Context, key, path string) (interface{}, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+
+	p, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.client.Do(ctx, "JSON.OBJKEYS", key, p.raw).Result()
+}
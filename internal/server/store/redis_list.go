@@ -0,0 +1,429 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// popScript - redis 6.0 has no count of LPOP and RPOP. ARGV are LPOP or RPOP and count, missing key is null.
+const popScript = versionFunc + `if redis.call('EXISTS', KEYS[1]) == 0 then return false end
+local res = {}
+for i = 1, tonumber(ARGV[2]) do
+	local value = redis.pcall(ARGV[1], KEYS[1])
+	if type(value) == 'table' and value.err then return value end
+	if not value then break end
+	res[i] = value
+end
+version(KEYS[1], KEYS[2])
+return res`
+
+// lmoveScript - redis 6.0 has no LMOVE. KEYS are source, destination, their versions and the counter, ARGV are
+// LPOP or RPOP and LPUSH or RPUSH.
+const lmoveScript = versionFunc + `local t = redis.call('TYPE', KEYS[2]).ok
+if t ~= 'none' and t ~= 'list' then
+	return redis.error_reply('WRONGTYPE Operation against a key holding the wrong kind of value')
+end
+local value = redis.pcall(ARGV[1], KEYS[1])
+if type(value) == 'table' and value.err then return value end
+if not value then return false end
+redis.call(ARGV[2], KEYS[2], value)
+version(KEYS[1], KEYS[3])
+version(KEYS[2], KEYS[4])
+return value`
+
+// LPush ...
+func (r *Redis) LPush(ctx context.Context, key string, values []interface{}) (int64, error) {
+	return r.push(ctx, key, values, true)
+}
+
+// RPush ...
+func (r *Redis) RPush(ctx context.Context, key string, values []interface{}) (int64, error) {
+	return r.push(ctx, key, values, false)
+}
+
+// LPop ...
+func (r *Redis) LPop(ctx context.Context, key string, count int64) ([]interface{}, error) {
+	return r.pop(ctx, key, count, "LPOP")
+}
+
+// RPop ...
+func (r *Redis) RPop(ctx context.Context, key string, count int64) ([]interface{}, error) {
+	return r.pop(ctx, key, count, "RPOP")
+}
+
+// LLen ...
+func (r *Redis) LLen(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	return r.client.LLen(ctx, key).Result()
+}
+
+// LIndex ...
+func (r *Redis) LIndex(ctx context.Context, key string, index int64) (interface{}, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+
+	res, err := r.client.LIndex(ctx, key, index).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeListElement(res)
+}
+
+// LInsert ...
+func (r *Redis) LInsert(ctx context.Context, key string, before bool, pivot, value interface{}) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	encodedPivot, err := encodeListElement(pivot)
+	if err != nil {
+		return 0, err
+	}
+
+	encoded, err := encodeListElement(value)
+	if err != nil {
+		return 0, err
+	}
+
+	op := "after"
+	if before {
+		op = "before"
+	}
+
+	var cmd *redis.IntCmd
+	err = r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.LInsert(ctx, key, op, encodedPivot, encoded)
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// LRem ...
+func (r *Redis) LRem(ctx context.Context, key string, count int64, value interface{}) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	encoded, err := encodeListElement(value)
+	if err != nil {
+		return 0, err
+	}
+
+	var cmd *redis.IntCmd
+	err = r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.LRem(ctx, key, count, encoded)
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// LTrim ...
+func (r *Redis) LTrim(ctx context.Context, key string, start, stop int64) error {
+	if key == "" {
+		return fmt.Errorf("Empty key")
+	}
+
+	return r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		return c.LTrim(ctx, key, start, stop).Err()
+	})
+}
+
+// LPos ...
+func (r *Redis) LPos(ctx context.Context, key string, value interface{}, args models.LPosArgs) ([]int64, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+	if err := checkLPos(args); err != nil {
+		return nil, err
+	}
+
+	encoded, err := encodeListElement(value)
+	if err != nil {
+		return nil, err
+	}
+
+	posArgs := redis.LPosArgs{Rank: lposRank(args), MaxLen: args.MaxLen}
+	if args.Count != nil {
+		return r.client.LPosCount(ctx, key, encoded, *args.Count, posArgs).Result()
+	}
+
+	index, err := r.client.LPos(ctx, key, encoded, posArgs).Result()
+	if err == redis.Nil {
+		return []int64{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return []int64{index}, nil
+}
+
+// LMove ...
+func (r *Redis) LMove(ctx context.Context, source, destination, from, to string) (interface{}, error) {
+	if source == "" || destination == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+	if err := checkListEnds(from, to); err != nil {
+		return nil, err
+	}
+
+	res, err := r.client.Eval(ctx, lmoveScript, versionKeys(source, destination), lmoveArgs(from, to)...).Text()
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeListElement(res)
+}
+
+// BLPop - the command is interrupted when the context is done, e.g. the client is disconnected, see blocking
+func (r *Redis) BLPop(ctx context.Context, keys []string, timeout time.Duration) (*models.BPopResult, error) {
+	return r.bpop(ctx, keys, timeout, true)
+}
+
+// BRPop ...
+func (r *Redis) BRPop(ctx context.Context, keys []string, timeout time.Duration) (*models.BPopResult, error) {
+	return r.bpop(ctx, keys, timeout, false)
+}
+
+// BLMove - redis 6.0 has only BRPOPLPUSH, which is used to move the element from right to left. Other ends
+// require BLMOVE of redis 6.2. The element moved for the client which is gone is moved back.
+func (r *Redis) BLMove(ctx context.Context, source, destination, from, to string, timeout time.Duration) (interface{}, error) {
+	if source == "" || destination == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+	if err := checkListEnds(from, to); err != nil {
+		return nil, err
+	}
+	if timeout < 0 {
+		return nil, errTimeoutNegative
+	}
+
+	var cmd *redis.StringCmd
+	err := r.blocking(ctx, func(c *redis.Conn) error {
+		if from == ListRight && to == ListLeft {
+			cmd = c.BRPopLPush(ctx, source, destination, timeout)
+		} else {
+			seconds := strconv.FormatFloat(timeout.Seconds(), 'f', -1, 64)
+			cmd = redis.NewStringCmd(ctx, "BLMOVE", source, destination, strings.ToUpper(from), strings.ToUpper(to), seconds)
+			_ = c.Process(ctx, cmd)
+		}
+		return cmd.Err()
+	})
+	// the client is gone, the element popped before CLIENT UNBLOCK is returned below
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if cmd == nil || cmd.Err() != nil {
+		return nil, err
+	}
+
+	for _, key := range []string{source, destination} {
+		if err == nil {
+			err = r.incrVersion(ctx, key)
+		}
+	}
+	if err != nil {
+		// lmoveScript increments versions of both keys
+		moveBack := r.client.Eval(context.Background(), lmoveScript, versionKeys(destination, source), lmoveArgs(to, from)...)
+		if moveBack.Err() != nil {
+			return nil, moveBack.Err()
+		}
+		return nil, err
+	}
+
+	return decodeListElement(cmd.Val())
+}
+
+// push - LPUSH or RPUSH
+func (r *Redis) push(ctx context.Context, key string, values []interface{}, left bool) (int64, error) {
+	if key == "" || len(values) == 0 {
+		return 0, fmt.Errorf("Empty key or field")
+	}
+
+	encoded, err := encodeListElements(values)
+	if err != nil {
+		return 0, err
+	}
+
+	var cmd *redis.IntCmd
+	err = r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		if left {
+			cmd = c.LPush(ctx, key, encoded)
+		} else {
+			cmd = c.RPush(ctx, key, encoded)
+		}
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// pop - LPOP or RPOP with count
+func (r *Redis) pop(ctx context.Context, key string, count int64, command string) ([]interface{}, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+	if count <= 0 {
+		return nil, errNegative
+	}
+
+	res, err := r.client.Eval(ctx, popScript, versionKeys(key), command, count).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	elements, ok := res.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Unexpected reply of %s: %v", command, res)
+	}
+
+	popped := make([]interface{}, len(elements))
+	for i, el := range elements {
+		raw, _ := el.(string)
+		if popped[i], err = decodeListElement(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	return popped, nil
+}
+
+// bpop - BLPOP or BRPOP, version of the key is incremented after the element is popped
+func (r *Redis) bpop(ctx context.Context, keys []string, timeout time.Duration, left bool) (*models.BPopResult, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("Empty key")
+	}
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("Empty key")
+		}
+	}
+	if timeout < 0 {
+		return nil, errTimeoutNegative
+	}
+
+	var cmd *redis.StringSliceCmd
+	err := r.blocking(ctx, func(c *redis.Conn) error {
+		if left {
+			cmd = c.BLPop(ctx, timeout, keys...)
+		} else {
+			cmd = c.BRPop(ctx, timeout, keys...)
+		}
+		return cmd.Err()
+	})
+	// the client is gone, the element popped before CLIENT UNBLOCK is returned below
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if cmd == nil || cmd.Err() != nil {
+		return nil, err
+	}
+
+	res := cmd.Val()
+	if len(res) != 2 {
+		return nil, fmt.Errorf("Unexpected reply of blocking pop: %v", res)
+	}
+	if err == nil {
+		err = r.incrVersion(ctx, res[0])
+	}
+	if err != nil {
+		if err := r.unpop(res[0], res[1], left); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+
+	value, err := decodeListElement(res[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BPopResult{Key: res[0], Value: value}, nil
+}
+
+// unpop - returns the popped element to the end of the list it is taken from, so that the element is not lost
+// when the client is gone or the version is not incremented. The context of the client may be done already.
+func (r *Redis) unpop(key, element string, left bool) error {
+	ctx := context.Background()
+	return r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		if left {
+			return c.LPush(ctx, key, element).Err()
+		}
+		return c.RPush(ctx, key, element).Err()
+	})
+}
+
+// unblockInterval - CLIENT UNBLOCK is repeated until the blocking command returns, because the context may be
+// done before redis receives the command
+const unblockInterval = 100 * time.Millisecond
+
+// blocking - executes the blocking command on the dedicated connection. Redis does not notice that the client
+// of the server is gone, so that the command is interrupted by CLIENT UNBLOCK when the context is done, instead
+// of holding the connection until the timeout. The connection is returned to the pool by Close.
+func (r *Redis) blocking(ctx context.Context, command func(c *redis.Conn) error) error {
+	conn := r.client.Conn(ctx)
+	for _, hook := range r.connHooks {
+		conn.AddHook(hook)
+	}
+	defer conn.Close()
+
+	id, err := conn.ClientID(ctx).Result()
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+		}
+
+		ticker := time.NewTicker(unblockInterval)
+		defer ticker.Stop()
+		for {
+			_ = r.client.ClientUnblock(context.Background(), id).Err()
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	err = command(conn)
+	close(done)
+	<-stopped
+
+	return err
+}
+
+// lmoveArgs - ARGV of lmoveScript
+func lmoveArgs(from, to string) []interface{} {
+	return []interface{}{strings.ToUpper(from[:1]) + "POP", strings.ToUpper(to[:1]) + "PUSH"}
+}
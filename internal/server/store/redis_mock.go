@@ -2,30 +2,114 @@ package store
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+
 	"github.com/go-redis/redismock/v8"
 )
 
+// mockPersistenceInfo - persistence section of INFO after successful background saving
+const mockPersistenceInfo = "# Persistence\r\nloading:0\r\nrdb_bgsave_in_progress:0\r\nrdb_last_save_time:1609019059\r\n" +
+	"rdb_last_bgsave_status:ok\r\nrdb_last_bgsave_time_sec:2\r\n"
+
+// matchHashArgs - go-redis flattens the map of HSET and HMSET in random order, so that field/value pairs
+// are compared regardless of the order
+func matchHashArgs(expected, actual []interface{}) error {
+	if len(expected) != len(actual) || len(actual) < 2 || !reflect.DeepEqual(expected[:2], actual[:2]) {
+		return fmt.Errorf("Unexpected command %v", actual)
+	}
+
+	pairs := func(args []interface{}) map[interface{}]interface{} {
+		res := make(map[interface{}]interface{}, len(args)/2)
+		for i := 0; i+1 < len(args); i += 2 {
+			res[args[i]] = args[i+1]
+		}
+		return res
+	}
+	if !reflect.DeepEqual(pairs(expected[2:]), pairs(actual[2:])) {
+		return fmt.Errorf("Unexpected command %v", actual)
+	}
+
+	return nil
+}
+
+// txHook - redismock has no expectations of MULTI, EXEC, WATCH and UNWATCH, so that they are skipped and the
+// commands of the transaction are matched one by one
+type txHook struct {
+	mock *redis.Client
+}
+
+func (h txHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	switch cmd.Name() {
+	case "multi", "exec", "watch", "unwatch":
+		return ctx, nil
+	}
+
+	return ctx, h.mock.Process(ctx, cmd)
+}
+
+func (h txHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h txHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	// errors are set to the commands, like redis replies to each command of EXEC
+	for _, cmd := range cmds {
+		_, _ = h.BeforeProcess(ctx, cmd)
+	}
+
+	return ctx, nil
+}
+
+func (h txHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+// newClientMock - redismock.NewClientMock, which accepts transactions
+func newClientMock() (*redis.Client, redismock.ClientMock) {
+	r, mock := newRedisMock()
+	return r.client, mock
+}
+
+// newRedisMock - Redis with the client of newClientMock, dedicated connections of blocking commands are served
+// by the mock too
+func newRedisMock() (*Redis, redismock.ClientMock) {
+	db, mock := redismock.NewClientMock()
+	hook := txHook{mock: db}
+
+	// MaxRetries -2 avoids executing commands on the redis server, like redismock does
+	client := redis.NewClient(&redis.Options{MaxRetries: -2})
+	client.AddHook(hook)
+
+	return &Redis{client: client, connHooks: []redis.Hook{hook}}, mock
+}
+
+// expectVersion - versionScript executed after the write
+func expectVersion(mock redismock.ClientMock, key string) *redismock.ExpectedCmd {
+	return mock.ExpectEval(versionScript, versionKeys(key))
+}
+
 // RedisMock ...
 type RedisMock struct {
 	client *Redis
 	mock   redismock.ClientMock
+	// redismock has no pub/sub, so that subscribers are served by in-process broker
+	pubsub *broker
 }
 
 // NewMock - helper to init redis mock
 func NewMock() *RedisMock {
-	db, mock := redismock.NewClientMock()
+	client, mock := newRedisMock()
 
-	client := &Redis{
-		client: db,
-	}
 	return &RedisMock{
 		client: client,
 		mock:   mock,
+		pubsub: newBroker(),
 	}
 }
 
@@ -34,17 +118,42 @@ func (r *RedisMock) Save(ctx context.Context) error {
 	return nil
 }
 
+// BGSave ...
+func (r *RedisMock) BGSave(ctx context.Context) error {
+	r.mock.ExpectBgSave().SetVal("Background saving started")
+	return r.client.BGSave(ctx)
+}
+
+// SaveStatus ...
+func (r *RedisMock) SaveStatus(ctx context.Context) (*models.SaveStatus, error) {
+	r.mock.ExpectInfo("persistence").SetVal(mockPersistenceInfo)
+	return r.client.SaveStatus(ctx)
+}
+
+// Info ...
+func (r *RedisMock) Info(ctx context.Context, section string) (map[string]string, error) {
+	if section == "" {
+		r.mock.ExpectInfo().SetVal(mockPersistenceInfo)
+	} else {
+		r.mock.ExpectInfo(section).SetVal(mockPersistenceInfo)
+	}
+
+	return r.client.Info(ctx, section)
+}
+
 // SetHash ...
-func (r *RedisMock) SetHash(ctx context.Context, key string, value map[string]interface{}, ttl int) error {
-	r.mock.ExpectHMSet(key, value).SetVal(true)
-	err := r.client.SetHash(ctx, key, value, ttl)
+func (r *RedisMock) SetHash(ctx context.Context, key string, value map[string]interface{}, exp models.Expiration) error {
+	r.mock.CustomMatch(matchHashArgs).ExpectHMSet(key, value).SetVal(true)
+	expectVersion(r.mock, key).SetVal(int64(1))
+	err := r.client.SetHash(ctx, key, value, exp)
 	return err
 }
 
 // SetString ...
-func (r *RedisMock) SetString(ctx context.Context, key, value string, ttl int) (string, error) {
-	r.mock.ExpectSet(key, value, time.Duration(ttl)).SetVal("OK")
-	res, err := r.client.SetString(ctx, key, value, ttl)
+func (r *RedisMock) SetString(ctx context.Context, key, value string, exp models.Expiration) (string, error) {
+	r.mock.ExpectSet(key, value, exp.TTL.Duration()).SetVal("OK")
+	expectVersion(r.mock, key).SetVal(int64(1))
+	res, err := r.client.SetString(ctx, key, value, exp)
 	if err != nil {
 		return "", err
 	}
@@ -53,61 +162,19 @@ func (r *RedisMock) SetString(ctx context.Context, key, value string, ttl int) (
 }
 
 // SetList ...
-func (r *RedisMock) SetList(ctx context.Context, key string, value []interface{}, ttl int) error {
+func (r *RedisMock) SetList(ctx context.Context, key string, value []interface{}, exp models.Expiration) error {
 	strSlice := make([]string, len(value))
 	for i, val := range value {
-		element := models.ListElement{}
-		switch val.(type) {
-		case float64:
-			element.Dtype = "float64"
-			element.Data = fmt.Sprintf("%.5f", val.(float64))
-			serialized, err := json.Marshal(element)
-			if err != nil {
-				return err
-			}
-			strSlice[i] = string(serialized)
-		case int64:
-			element.Dtype = "int64"
-			element.Data = fmt.Sprintf("%d", val.(int64))
-			serialized, err := json.Marshal(element)
-			if err != nil {
-				return err
-			}
-			strSlice[i] = string(serialized)
-		case int:
-			element.Dtype = "int"
-			element.Data = fmt.Sprintf("%d", val.(int))
-			serialized, err := json.Marshal(element)
-			if err != nil {
-				return err
-			}
-			strSlice[i] = string(serialized)
-		case map[string]interface{}:
-			serializedData, err := json.Marshal(val)
-			if err != nil {
-				return err
-			}
-
-			element.Dtype = "map"
-			element.Data = string(serializedData)
-			serialized, err := json.Marshal(element)
-			if err != nil {
-				return err
-			}
-			strSlice[i] = string(serialized)
-		default:
-			element.Dtype = "string"
-			element.Data = val.(string)
-			serialized, err := json.Marshal(element)
-			if err != nil {
-				return err
-			}
-			strSlice[i] = string(serialized)
+		serialized, err := encodeListElement(val)
+		if err != nil {
+			return err
 		}
+		strSlice[i] = serialized
 	}
 
 	r.mock.ExpectRPush(key, strSlice).SetVal(int64(len(value)))
-	err := r.client.SetList(ctx, key, value, ttl)
+	expectVersion(r.mock, key).SetVal(int64(1))
+	err := r.client.SetList(ctx, key, value, exp)
 	return err
 }
 
@@ -164,9 +231,64 @@ func (r *RedisMock) GetKeys(ctx context.Context, pattern string) ([]string, erro
 	return res, nil
 }
 
+// Type ...
+func (r *RedisMock) Type(ctx context.Context, key string) (string, error) {
+	r.mock.ExpectType(key).SetVal("hash")
+	return r.client.Type(ctx, key)
+}
+
+// TTL ...
+func (r *RedisMock) TTL(ctx context.Context, key string) (int64, error) {
+	r.mock.ExpectTTL(key).SetVal(time.Minute)
+	return r.client.TTL(ctx, key)
+}
+
+// PTTL ...
+func (r *RedisMock) PTTL(ctx context.Context, key string) (int64, error) {
+	r.mock.ExpectPTTL(key).SetVal(time.Minute)
+	return r.client.PTTL(ctx, key)
+}
+
+// Expire ...
+func (r *RedisMock) Expire(ctx context.Context, key string, opts models.ExpireOptions) (bool, error) {
+	if checkExpire(key, opts) == nil {
+		if opts.TTL > 0 {
+			r.mock.ExpectPExpire(key, opts.TTL.Duration()).SetVal(true)
+		} else {
+			r.mock.ExpectPExpireAt(key, time.Unix(0, expireOptionsAt(opts)*int64(time.Millisecond))).SetVal(true)
+		}
+		expectVersion(r.mock, key).SetVal(int64(2))
+	}
+	return r.client.Expire(ctx, key, opts)
+}
+
+// Persist ...
+func (r *RedisMock) Persist(ctx context.Context, key string) (bool, error) {
+	r.mock.ExpectPersist(key).SetVal(true)
+	expectVersion(r.mock, key).SetVal(int64(2))
+	return r.client.Persist(ctx, key)
+}
+
+// Exists ...
+func (r *RedisMock) Exists(ctx context.Context, keys []string) (int64, error) {
+	r.mock.ExpectExists(keys...).SetVal(int64(len(keys)))
+	return r.client.Exists(ctx, keys)
+}
+
+// Scan - redismock has no SCAN with TYPE, so that keys of the mock are returned without filtering by type
+func (r *RedisMock) Scan(ctx context.Context, cursor uint64, match string, count int64, keyType string) ([]string, uint64, error) {
+	if count < 0 {
+		return r.client.Scan(ctx, cursor, match, count, keyType)
+	}
+
+	r.mock.ExpectScan(cursor, match, count).SetVal([]string{"one", "two", "three"}, 0)
+	return r.client.Scan(ctx, cursor, match, count, "")
+}
+
 // Delete ...
 func (r *RedisMock) Delete(ctx context.Context, key string) (int64, error) {
 	r.mock.ExpectDel(key).SetVal(1)
+	expectVersion(r.mock, key).SetVal(int64(1))
 	res, err := r.client.Delete(ctx, key)
 	if err != nil {
 		return 0, err
@@ -186,12 +308,13 @@ func (r *RedisMock) HGet(ctx context.Context, key string, field string) (string,
 	return res, nil
 }
 
-// HSet ...
+// HSet - the value is expected encoded by HashValues, like the handler stores typed values
 func (r *RedisMock) HSet(ctx context.Context, key string, values map[string]interface{}) (int64, error) {
 	valueExp := map[string]interface{}{
-		"role": "admin",
+		"role": `{"Dtype":"string","Data":"admin"}`,
 	}
-	r.mock.ExpectHSet(key, valueExp).SetVal(2)
+	r.mock.CustomMatch(matchHashArgs).ExpectHSet(key, valueExp).SetVal(2)
+	expectVersion(r.mock, key).SetVal(int64(1))
 	res, err := r.client.HSet(ctx, key, values)
 	if err != nil {
 		return 0, err
@@ -200,6 +323,79 @@ func (r *RedisMock) HSet(ctx context.Context, key string, values map[string]inte
 	return res, nil
 }
 
+// HDel ...
+func (r *RedisMock) HDel(ctx context.Context, key string, fields []string) (int64, error) {
+	r.mock.ExpectHDel(key, fields...).SetVal(1)
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.HDel(ctx, key, fields)
+}
+
+// HExists - the mock hash has only field "role"
+func (r *RedisMock) HExists(ctx context.Context, key, field string) (bool, error) {
+	r.mock.ExpectHExists(key, field).SetVal(field == "role")
+	return r.client.HExists(ctx, key, field)
+}
+
+// HLen ...
+func (r *RedisMock) HLen(ctx context.Context, key string) (int64, error) {
+	r.mock.ExpectHLen(key).SetVal(1)
+	return r.client.HLen(ctx, key)
+}
+
+// HKeys ...
+func (r *RedisMock) HKeys(ctx context.Context, key string) ([]string, error) {
+	r.mock.ExpectHKeys(key).SetVal([]string{"role"})
+	return r.client.HKeys(ctx, key)
+}
+
+// HVals ...
+func (r *RedisMock) HVals(ctx context.Context, key string) ([]string, error) {
+	r.mock.ExpectHVals(key).SetVal([]string{"admin"})
+	return r.client.HVals(ctx, key)
+}
+
+// HMGet - the mock hash has only field "role"
+func (r *RedisMock) HMGet(ctx context.Context, key string, fields []string) ([]interface{}, error) {
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		if field == "role" {
+			values[i] = "admin"
+		}
+	}
+
+	r.mock.ExpectHMGet(key, fields...).SetVal(values)
+	return r.client.HMGet(ctx, key, fields)
+}
+
+// HSetNX - the mock hash has only field "role"
+func (r *RedisMock) HSetNX(ctx context.Context, key, field string, value interface{}) (bool, error) {
+	r.mock.ExpectHSetNX(key, field, value).SetVal(field != "role")
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.HSetNX(ctx, key, field, value)
+}
+
+// HIncrBy - the mock field is always 0 before the increment
+func (r *RedisMock) HIncrBy(ctx context.Context, key, field string, increment int64, raw bool) (int64, error) {
+	r.mock.ExpectEval(hincrScript, []string{key}, "HINCRBY", field, increment, hincrTyped(raw)).
+		SetVal(strconv.FormatInt(increment, 10))
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.HIncrBy(ctx, key, field, increment, raw)
+}
+
+// HIncrByFloat - the mock field is always 0 before the increment
+func (r *RedisMock) HIncrByFloat(ctx context.Context, key, field string, increment float64, raw bool) (float64, error) {
+	r.mock.ExpectEval(hincrScript, []string{key}, "HINCRBYFLOAT", field, increment, hincrTyped(raw)).
+		SetVal(strconv.FormatFloat(increment, 'g', -1, 64))
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.HIncrByFloat(ctx, key, field, increment, raw)
+}
+
+// HScan ...
+func (r *RedisMock) HScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	r.mock.ExpectHScan(key, cursor, match, count).SetVal([]string{"role", `"admin"`}, 0)
+	return r.client.HScan(ctx, key, cursor, match, count)
+}
+
 // LRange ...
 func (r *RedisMock) LRange(ctx context.Context, key string, start, stop int64) ([]interface{}, error) {
 	values := []string{
@@ -217,7 +413,8 @@ func (r *RedisMock) LRange(ctx context.Context, key string, start, stop int64) (
 
 // LSet ...
 func (r *RedisMock) LSet(ctx context.Context, key string, index int64, value interface{}) (string, error) {
-	r.mock.ExpectLSet(key, int64(index), value)
+	r.mock.ExpectLSet(key, int64(index), value).SetVal("OK")
+	expectVersion(r.mock, key).SetVal(int64(1))
 	res, err := r.client.LSet(ctx, key, index, value)
 	if err != nil {
 		return "", err
@@ -225,3 +422,808 @@ func (r *RedisMock) LSet(ctx context.Context, key string, index int64, value int
 
 	return res, nil
 }
+
+// LPush - the mock list is empty before the push
+func (r *RedisMock) LPush(ctx context.Context, key string, values []interface{}) (int64, error) {
+	if encoded, err := encodeListElements(values); err == nil {
+		r.mock.ExpectLPush(key, encoded).SetVal(int64(len(values)))
+		expectVersion(r.mock, key).SetVal(int64(1))
+	}
+	return r.client.LPush(ctx, key, values)
+}
+
+// RPush - the mock list is empty before the push
+func (r *RedisMock) RPush(ctx context.Context, key string, values []interface{}) (int64, error) {
+	if encoded, err := encodeListElements(values); err == nil {
+		r.mock.ExpectRPush(key, encoded).SetVal(int64(len(values)))
+		expectVersion(r.mock, key).SetVal(int64(1))
+	}
+	return r.client.RPush(ctx, key, values)
+}
+
+// LPop - the mock list holds one string "ivan"
+func (r *RedisMock) LPop(ctx context.Context, key string, count int64) ([]interface{}, error) {
+	r.mock.ExpectEval(popScript, versionKeys(key), "LPOP", count).SetVal(mockListReply())
+	return r.client.LPop(ctx, key, count)
+}
+
+// RPop - the mock list holds one string "ivan"
+func (r *RedisMock) RPop(ctx context.Context, key string, count int64) ([]interface{}, error) {
+	r.mock.ExpectEval(popScript, versionKeys(key), "RPOP", count).SetVal(mockListReply())
+	return r.client.RPop(ctx, key, count)
+}
+
+// LLen ...
+func (r *RedisMock) LLen(ctx context.Context, key string) (int64, error) {
+	r.mock.ExpectLLen(key).SetVal(1)
+	return r.client.LLen(ctx, key)
+}
+
+// LIndex ...
+func (r *RedisMock) LIndex(ctx context.Context, key string, index int64) (interface{}, error) {
+	r.mock.ExpectLIndex(key, index).SetVal(`{"Dtype":"string","Data":"ivan"}`)
+	return r.client.LIndex(ctx, key, index)
+}
+
+// LInsert - the pivot is always found in the mock list of one element
+func (r *RedisMock) LInsert(ctx context.Context, key string, before bool, pivot, value interface{}) (int64, error) {
+	op := "after"
+	if before {
+		op = "before"
+	}
+	encodedPivot, _ := encodeListElement(pivot)
+	encoded, _ := encodeListElement(value)
+	r.mock.ExpectLInsert(key, op, encodedPivot, encoded).SetVal(2)
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.LInsert(ctx, key, before, pivot, value)
+}
+
+// LRem ...
+func (r *RedisMock) LRem(ctx context.Context, key string, count int64, value interface{}) (int64, error) {
+	encoded, _ := encodeListElement(value)
+	r.mock.ExpectLRem(key, count, encoded).SetVal(1)
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.LRem(ctx, key, count, value)
+}
+
+// LTrim ...
+func (r *RedisMock) LTrim(ctx context.Context, key string, start, stop int64) error {
+	r.mock.ExpectLTrim(key, start, stop).SetVal("OK")
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.LTrim(ctx, key, start, stop)
+}
+
+// LPos - the value is always the first element of the mock list
+func (r *RedisMock) LPos(ctx context.Context, key string, value interface{}, args models.LPosArgs) ([]int64, error) {
+	encoded, _ := encodeListElement(value)
+	posArgs := redis.LPosArgs{Rank: lposRank(args), MaxLen: args.MaxLen}
+	if checkLPos(args) == nil {
+		if args.Count != nil {
+			r.mock.ExpectLPosCount(key, encoded, *args.Count, posArgs).SetVal([]int64{0})
+		} else {
+			r.mock.ExpectLPos(key, encoded, posArgs).SetVal(0)
+		}
+	}
+	return r.client.LPos(ctx, key, value, args)
+}
+
+// LMove - the mock source holds one string "ivan"
+func (r *RedisMock) LMove(ctx context.Context, source, destination, from, to string) (interface{}, error) {
+	if checkListEnds(from, to) == nil {
+		r.mock.ExpectEval(lmoveScript, versionKeys(source, destination), lmoveArgs(from, to)...).SetVal(`{"Dtype":"string","Data":"ivan"}`)
+	}
+	return r.client.LMove(ctx, source, destination, from, to)
+}
+
+// BLPop - the mock list holds one string "ivan"
+func (r *RedisMock) BLPop(ctx context.Context, keys []string, timeout time.Duration) (*models.BPopResult, error) {
+	r.mock.ExpectClientID().SetVal(1)
+	r.expectBPop(r.mock.ExpectBLPop(timeout, keys...), keys)
+	return r.client.BLPop(ctx, keys, timeout)
+}
+
+// BRPop - the mock list holds one string "ivan"
+func (r *RedisMock) BRPop(ctx context.Context, keys []string, timeout time.Duration) (*models.BPopResult, error) {
+	r.mock.ExpectClientID().SetVal(1)
+	r.expectBPop(r.mock.ExpectBRPop(timeout, keys...), keys)
+	return r.client.BRPop(ctx, keys, timeout)
+}
+
+// BLMove - the mock source holds one string "ivan", only right to left is expected
+func (r *RedisMock) BLMove(ctx context.Context, source, destination, from, to string, timeout time.Duration) (interface{}, error) {
+	if from == ListRight && to == ListLeft {
+		r.mock.ExpectClientID().SetVal(1)
+		r.mock.ExpectBRPopLPush(source, destination, timeout).SetVal(`{"Dtype":"string","Data":"ivan"}`)
+		expectVersion(r.mock, source).SetVal(int64(1))
+		expectVersion(r.mock, destination).SetVal(int64(1))
+	}
+	return r.client.BLMove(ctx, source, destination, from, to, timeout)
+}
+
+// expectBPop - the element is popped from the first key
+func (r *RedisMock) expectBPop(cmd *redismock.ExpectedStringSlice, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	cmd.SetVal([]string{keys[0], `{"Dtype":"string","Data":"ivan"}`})
+	expectVersion(r.mock, keys[0]).SetVal(int64(1))
+}
+
+func mockListReply() []interface{} {
+	return []interface{}{`{"Dtype":"string","Data":"ivan"}`}
+}
+
+// IncrBy - the mock counter is always 0 before the increment
+func (r *RedisMock) IncrBy(ctx context.Context, key string, increment int64) (int64, error) {
+	r.mock.ExpectIncrBy(key, increment).SetVal(increment)
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.IncrBy(ctx, key, increment)
+}
+
+// IncrByFloat ...
+func (r *RedisMock) IncrByFloat(ctx context.Context, key string, increment float64) (float64, error) {
+	r.mock.ExpectIncrByFloat(key, increment).SetVal(increment)
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.IncrByFloat(ctx, key, increment)
+}
+
+// SetArgs - the mock key holds "lapshin" before SET
+func (r *RedisMock) SetArgs(ctx context.Context, key, value string, opts models.SetOptions) (string, error) {
+	if checkSet(key, opts) == nil {
+		reply := "OK"
+		if opts.Get {
+			reply = "lapshin"
+		}
+		r.mock.ExpectEval(setScript, versionKeys(key), setArgs(value, opts)...).SetVal(reply)
+	}
+	return r.client.SetArgs(ctx, key, value, opts)
+}
+
+// GetSet ...
+func (r *RedisMock) GetSet(ctx context.Context, key, value string) (string, error) {
+	r.mock.ExpectGetSet(key, value).SetVal("lapshin")
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.GetSet(ctx, key, value)
+}
+
+// GetDel ...
+func (r *RedisMock) GetDel(ctx context.Context, key string) (string, error) {
+	r.mock.ExpectEval(getDelScript, versionKeys(key)).SetVal("lapshin")
+	return r.client.GetDel(ctx, key)
+}
+
+// GetEx ...
+func (r *RedisMock) GetEx(ctx context.Context, key string, opts models.GetExOptions) (string, error) {
+	if checkGetEx(key, opts) == nil {
+		r.mock.ExpectEval(getExScript, versionKeys(key), getExArgs(opts)...).SetVal("lapshin")
+	}
+	return r.client.GetEx(ctx, key, opts)
+}
+
+// Append - the mock key holds "lapshin" before APPEND
+func (r *RedisMock) Append(ctx context.Context, key, value string) (int64, error) {
+	r.mock.ExpectAppend(key, value).SetVal(int64(len("lapshin" + value)))
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.Append(ctx, key, value)
+}
+
+// StrLen ...
+func (r *RedisMock) StrLen(ctx context.Context, key string) (int64, error) {
+	r.mock.ExpectStrLen(key).SetVal(int64(len("lapshin")))
+	return r.client.StrLen(ctx, key)
+}
+
+// GetRange ...
+func (r *RedisMock) GetRange(ctx context.Context, key string, start, end int64) (string, error) {
+	r.mock.ExpectGetRange(key, start, end).SetVal("lap")
+	return r.client.GetRange(ctx, key, start, end)
+}
+
+// SetRange ...
+func (r *RedisMock) SetRange(ctx context.Context, key string, offset int64, value string) (int64, error) {
+	if checkSetRange(offset, value) == nil {
+		r.mock.ExpectSetRange(key, offset, value).SetVal(offset + int64(len(value)))
+		expectVersion(r.mock, key).SetVal(int64(1))
+	}
+	return r.client.SetRange(ctx, key, offset, value)
+}
+
+// SAdd ...
+func (r *RedisMock) SAdd(ctx context.Context, key string, members []string) (int64, error) {
+	r.mock.ExpectSAdd(key, stringArgs(members)...).SetVal(int64(len(members)))
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.SAdd(ctx, key, members)
+}
+
+// SRem ...
+func (r *RedisMock) SRem(ctx context.Context, key string, members []string) (int64, error) {
+	r.mock.ExpectSRem(key, stringArgs(members)...).SetVal(1)
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.SRem(ctx, key, members)
+}
+
+// SMembers ...
+func (r *RedisMock) SMembers(ctx context.Context, key string) ([]string, error) {
+	r.mock.ExpectSMembers(key).SetVal([]string{"user", "admin"})
+	return r.client.SMembers(ctx, key)
+}
+
+// SIsMember ...
+func (r *RedisMock) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	r.mock.ExpectSIsMember(key, member).SetVal(true)
+	return r.client.SIsMember(ctx, key, member)
+}
+
+// SCard ...
+func (r *RedisMock) SCard(ctx context.Context, key string) (int64, error) {
+	r.mock.ExpectSCard(key).SetVal(2)
+	return r.client.SCard(ctx, key)
+}
+
+// SInter ...
+func (r *RedisMock) SInter(ctx context.Context, keys []string) ([]string, error) {
+	r.mock.ExpectSInter(keys...).SetVal([]string{"admin"})
+	return r.client.SInter(ctx, keys)
+}
+
+// SUnion ...
+func (r *RedisMock) SUnion(ctx context.Context, keys []string) ([]string, error) {
+	r.mock.ExpectSUnion(keys...).SetVal([]string{"user", "admin", "guest"})
+	return r.client.SUnion(ctx, keys)
+}
+
+// SDiff ...
+func (r *RedisMock) SDiff(ctx context.Context, keys []string) ([]string, error) {
+	r.mock.ExpectSDiff(keys...).SetVal([]string{"user"})
+	return r.client.SDiff(ctx, keys)
+}
+
+// SInterStore ...
+func (r *RedisMock) SInterStore(ctx context.Context, destination string, keys []string) (int64, error) {
+	r.mock.ExpectSInterStore(destination, keys...).SetVal(1)
+	expectVersion(r.mock, destination).SetVal(int64(1))
+	return r.client.SInterStore(ctx, destination, keys)
+}
+
+// SUnionStore ...
+func (r *RedisMock) SUnionStore(ctx context.Context, destination string, keys []string) (int64, error) {
+	r.mock.ExpectSUnionStore(destination, keys...).SetVal(3)
+	expectVersion(r.mock, destination).SetVal(int64(1))
+	return r.client.SUnionStore(ctx, destination, keys)
+}
+
+// SDiffStore ...
+func (r *RedisMock) SDiffStore(ctx context.Context, destination string, keys []string) (int64, error) {
+	r.mock.ExpectSDiffStore(destination, keys...).SetVal(1)
+	expectVersion(r.mock, destination).SetVal(int64(1))
+	return r.client.SDiffStore(ctx, destination, keys)
+}
+
+// SScan ...
+func (r *RedisMock) SScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	r.mock.ExpectSScan(key, cursor, match, count).SetVal([]string{"user", "admin"}, 0)
+	return r.client.SScan(ctx, key, cursor, match, count)
+}
+
+// ZAdd - go-redis has no GT and LT options, so that the reply is canned for them
+func (r *RedisMock) ZAdd(ctx context.Context, key string, members []models.ZMember, opts models.ZAddOptions) (int64, error) {
+	if err := checkZAdd(key, members, opts, false); err != nil {
+		return 0, err
+	}
+
+	values := mockZ(members)
+	switch {
+	case opts.GT || opts.LT:
+		return int64(len(members)), nil
+	case opts.NX && opts.CH:
+		r.mock.ExpectZAddNXCh(key, values...).SetVal(int64(len(members)))
+	case opts.XX && opts.CH:
+		r.mock.ExpectZAddXXCh(key, values...).SetVal(int64(len(members)))
+	case opts.NX:
+		r.mock.ExpectZAddNX(key, values...).SetVal(int64(len(members)))
+	case opts.XX:
+		r.mock.ExpectZAddXX(key, values...).SetVal(0)
+	case opts.CH:
+		r.mock.ExpectZAddCh(key, values...).SetVal(int64(len(members)))
+	default:
+		r.mock.ExpectZAdd(key, values...).SetVal(int64(len(members)))
+	}
+
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.ZAdd(ctx, key, members, opts)
+}
+
+// ZAddIncr ...
+func (r *RedisMock) ZAddIncr(ctx context.Context, key string, member models.ZMember, opts models.ZAddOptions) (float64, error) {
+	if err := checkZAdd(key, []models.ZMember{member}, opts, true); err != nil {
+		return 0, err
+	}
+
+	value := mockZ([]models.ZMember{member})[0]
+	switch {
+	case opts.GT || opts.LT || opts.CH:
+		return member.Score, nil
+	case opts.NX:
+		r.mock.ExpectZIncrNX(key, value).SetVal(member.Score)
+	case opts.XX:
+		r.mock.ExpectZIncrXX(key, value).RedisNil()
+	default:
+		r.mock.ExpectZIncr(key, value).SetVal(member.Score)
+	}
+
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.ZAddIncr(ctx, key, member, opts)
+}
+
+// ZRem ...
+func (r *RedisMock) ZRem(ctx context.Context, key string, members []string) (int64, error) {
+	r.mock.ExpectZRem(key, stringArgs(members)...).SetVal(1)
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.ZRem(ctx, key, members)
+}
+
+// ZScore ...
+func (r *RedisMock) ZScore(ctx context.Context, key, member string) (float64, error) {
+	r.mock.ExpectZScore(key, member).SetVal(1.5)
+	return r.client.ZScore(ctx, key, member)
+}
+
+// ZRank ...
+func (r *RedisMock) ZRank(ctx context.Context, key, member string) (int64, error) {
+	r.mock.ExpectZRank(key, member).SetVal(1)
+	return r.client.ZRank(ctx, key, member)
+}
+
+// ZRange ...
+func (r *RedisMock) ZRange(ctx context.Context, key string, query models.ZRangeQuery) ([]models.ZMember, error) {
+	by, err := checkZRange(query)
+	if err != nil || key == "" {
+		return r.client.ZRange(ctx, key, query)
+	}
+
+	values := []redis.Z{{Score: 1, Member: "ivan"}, {Score: 2.5, Member: "petr"}}
+	switch by {
+	case ZRangeByIndex:
+		start, _ := strconv.ParseInt(query.Start, 10, 64)
+		stop, _ := strconv.ParseInt(query.Stop, 10, 64)
+		if query.Rev {
+			r.mock.ExpectZRevRangeWithScores(key, start, stop).SetVal(values)
+		} else {
+			r.mock.ExpectZRangeWithScores(key, start, stop).SetVal(values)
+		}
+	case ZRangeByScore:
+		if query.Rev {
+			r.mock.ExpectZRevRangeByScoreWithScores(key, zrangeBy(query.Stop, query.Start, query)).SetVal(values)
+		} else {
+			r.mock.ExpectZRangeByScoreWithScores(key, zrangeBy(query.Start, query.Stop, query)).SetVal(values)
+		}
+	case ZRangeByLex:
+		members := []string{"ivan", "petr"}
+		if query.Rev {
+			r.mock.ExpectZRevRangeByLex(key, zrangeBy(query.Stop, query.Start, query)).SetVal(members)
+		} else {
+			r.mock.ExpectZRangeByLex(key, zrangeBy(query.Start, query.Stop, query)).SetVal(members)
+		}
+		for _, z := range values {
+			r.mock.ExpectZScore(key, z.Member.(string)).SetVal(z.Score)
+		}
+	}
+
+	return r.client.ZRange(ctx, key, query)
+}
+
+// ZPopMin ...
+func (r *RedisMock) ZPopMin(ctx context.Context, key string, count int64) ([]models.ZMember, error) {
+	r.mock.ExpectZPopMin(key, count).SetVal([]redis.Z{{Score: 1, Member: "ivan"}})
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.ZPopMin(ctx, key, count)
+}
+
+// ZPopMax ...
+func (r *RedisMock) ZPopMax(ctx context.Context, key string, count int64) ([]models.ZMember, error) {
+	r.mock.ExpectZPopMax(key, count).SetVal([]redis.Z{{Score: 2.5, Member: "petr"}})
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.ZPopMax(ctx, key, count)
+}
+
+// ZUnion ...
+func (r *RedisMock) ZUnion(ctx context.Context, store models.ZStore) ([]models.ZMember, error) {
+	if aggregate, err := checkZStore(store); err == nil {
+		r.mock.ExpectEval(zcombineScript, []string{zcombineKey}, zstoreArgs("ZUNIONSTORE", store, aggregate)...).
+			SetVal([]interface{}{"ivan", "1", "petr", "2.5"})
+	}
+	return r.client.ZUnion(ctx, store)
+}
+
+// ZInter ...
+func (r *RedisMock) ZInter(ctx context.Context, store models.ZStore) ([]models.ZMember, error) {
+	if aggregate, err := checkZStore(store); err == nil {
+		r.mock.ExpectEval(zcombineScript, []string{zcombineKey}, zstoreArgs("ZINTERSTORE", store, aggregate)...).
+			SetVal([]interface{}{"petr", "2.5"})
+	}
+	return r.client.ZInter(ctx, store)
+}
+
+// ZScan ...
+func (r *RedisMock) ZScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	r.mock.ExpectZScan(key, cursor, match, count).SetVal([]string{"user", "1.5"}, 0)
+	return r.client.ZScan(ctx, key, cursor, match, count)
+}
+
+func mockZ(members []models.ZMember) []*redis.Z {
+	res := make([]*redis.Z, len(members))
+	for i, member := range members {
+		res[i] = &redis.Z{Score: member.Score, Member: member.Member}
+	}
+
+	return res
+}
+
+// mockXMessages - entries returned by stream commands, values are encoded the same way as by XAdd
+var mockXMessages = []redis.XMessage{
+	{
+		ID: "1609019628218-0",
+		Values: map[string]interface{}{
+			"name": `{"Dtype":"string","Data":"ivan"}`,
+			"age":  `{"Dtype":"int","Data":"25"}`,
+		},
+	},
+}
+
+// XAdd - go-redis can't send MAXLEN 0 and MINID, so that the reply is canned for them
+func (r *RedisMock) XAdd(ctx context.Context, key, id string, fields map[string]interface{}, trim models.XTrim) (string, error) {
+	if err := checkXAdd(key, id, fields, trim); err != nil {
+		return r.client.XAdd(ctx, key, id, fields, trim)
+	}
+
+	values, err := streamFields(fields)
+	if err != nil {
+		return "", err
+	}
+
+	added := id
+	if added == "" || added == "*" {
+		added = "1609019628218-0"
+	}
+
+	switch {
+	case trim.MinID != "", trim.MaxLen != nil && *trim.MaxLen == 0:
+		return added, nil
+	default:
+		args := &redis.XAddArgs{Stream: key, ID: id, Values: values}
+		if trim.MaxLen != nil {
+			args.MaxLen = *trim.MaxLen
+		}
+		r.mock.ExpectXAdd(args).SetVal(added)
+		expectVersion(r.mock, key).SetVal(int64(1))
+	}
+
+	return r.client.XAdd(ctx, key, id, fields, trim)
+}
+
+// mockJSONDocument - redismock can't expect commands of modules, so that replies of JSON.* are canned
+const mockJSONDocument = `{"age":21,"name":"ivan","tags":["admin"]}`
+
+// JSONSet ...
+func (r *RedisMock) JSONSet(ctx context.Context, key, path, value string, opts models.JSONSetOptions) (string, error) {
+	if _, _, err := checkJSONSet(key, path, value, opts); err != nil {
+		return "", err
+	}
+
+	return "OK", nil
+}
+
+// JSONGet - the mock document is returned for any path
+func (r *RedisMock) JSONGet(ctx context.Context, key string, paths []string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("Empty key")
+	}
+
+	return mockJSONDocument, nil
+}
+
+// JSONDel ...
+func (r *RedisMock) JSONDel(ctx context.Context, key, path string) (int64, error) {
+	if _, err := parseJSONPath(path); err != nil || key == "" {
+		return r.client.JSONDel(ctx, key, path)
+	}
+
+	return 1, nil
+}
+
+// JSONArrAppend - the mock array has one element
+func (r *RedisMock) JSONArrAppend(ctx context.Context, key, path string, values []string) (interface{}, error) {
+	if _, _, err := checkJSONArrAppend(key, path, values); err != nil {
+		return nil, err
+	}
+
+	return int64(1 + len(values)), nil
+}
+
+// JSONNumIncrBy - the mock number is always 0 before the increment
+func (r *RedisMock) JSONNumIncrBy(ctx context.Context, key, path, increment string) (string, error) {
+	_, number, err := checkJSONNumIncrBy(key, path, increment)
+	if err != nil {
+		return "", err
+	}
+
+	return number.String(), nil
+}
+
+// JSONObjKeys ...
+func (r *RedisMock) JSONObjKeys(ctx context.Context, key, path string) (interface{}, error) {
+	if _, err := parseJSONPath(path); err != nil || key == "" {
+		return r.client.JSONObjKeys(ctx, key, path)
+	}
+
+	return []interface{}{"age", "name", "tags"}, nil
+}
+
+// XRange ...
+func (r *RedisMock) XRange(ctx context.Context, key string, query models.XRangeQuery) ([]models.StreamEntry, error) {
+	query = xrangeDefaults(query, false)
+	start, okStart := redisStreamBound(query.Start, false)
+	end, okEnd := redisStreamBound(query.End, true)
+	if checkXRange(key, query.Start, query.End) == nil && okStart && okEnd {
+		if query.Count > 0 {
+			r.mock.ExpectXRangeN(key, start, end, query.Count).SetVal(mockXMessages)
+		} else {
+			r.mock.ExpectXRange(key, start, end).SetVal(mockXMessages)
+		}
+	}
+
+	return r.client.XRange(ctx, key, query)
+}
+
+// XRevRange ...
+func (r *RedisMock) XRevRange(ctx context.Context, key string, query models.XRangeQuery) ([]models.StreamEntry, error) {
+	query = xrangeDefaults(query, true)
+	start, okStart := redisStreamBound(query.Start, true)
+	end, okEnd := redisStreamBound(query.End, false)
+	if checkXRange(key, query.End, query.Start) == nil && okStart && okEnd {
+		if query.Count > 0 {
+			r.mock.ExpectXRevRangeN(key, start, end, query.Count).SetVal(mockXMessages)
+		} else {
+			r.mock.ExpectXRevRange(key, start, end).SetVal(mockXMessages)
+		}
+	}
+
+	return r.client.XRevRange(ctx, key, query)
+}
+
+// XLen ...
+func (r *RedisMock) XLen(ctx context.Context, key string) (int64, error) {
+	if key != "" {
+		r.mock.ExpectXLen(key).SetVal(1)
+	}
+	return r.client.XLen(ctx, key)
+}
+
+// XTrim - the reply is canned for MINID which go-redis can't send
+func (r *RedisMock) XTrim(ctx context.Context, key string, trim models.XTrim) (int64, error) {
+	if checkXTrim(key, trim, true) != nil {
+		return r.client.XTrim(ctx, key, trim)
+	}
+	if trim.MaxLen == nil {
+		return 1, nil
+	}
+
+	r.mock.ExpectXTrim(key, *trim.MaxLen).SetVal(1)
+	expectVersion(r.mock, key).SetVal(int64(1))
+	return r.client.XTrim(ctx, key, trim)
+}
+
+// XRead ...
+func (r *RedisMock) XRead(ctx context.Context, query models.XReadQuery) ([]models.XStream, error) {
+	if checkXRead(query.Keys, query.IDs, false) == nil {
+		r.mock.ExpectXRead(&redis.XReadArgs{
+			Streams: append(append([]string{}, query.Keys...), query.IDs...),
+			Count:   query.Count,
+			Block:   -1,
+		}).SetVal([]redis.XStream{{Stream: query.Keys[0], Messages: mockXMessages}})
+	}
+
+	return r.client.XRead(ctx, query)
+}
+
+// XGroupCreate ...
+func (r *RedisMock) XGroupCreate(ctx context.Context, key, group, id string, mkstream bool) error {
+	if id == "" {
+		id = "$"
+	}
+
+	switch {
+	case checkXGroupCreate(key, group, id) != nil:
+	case mkstream:
+		r.mock.ExpectXGroupCreateMkStream(key, group, id).SetVal("OK")
+	default:
+		r.mock.ExpectXGroupCreate(key, group, id).SetVal("OK")
+	}
+
+	return r.client.XGroupCreate(ctx, key, group, id, mkstream)
+}
+
+// XReadGroup ...
+func (r *RedisMock) XReadGroup(ctx context.Context, args models.XReadGroupRequest) ([]models.XStream, error) {
+	if ids, err := checkXReadGroup(args); err == nil {
+		r.mock.ExpectXReadGroup(&redis.XReadGroupArgs{
+			Group:    args.Group,
+			Consumer: args.Consumer,
+			Streams:  append(append([]string{}, args.Keys...), ids...),
+			Count:    args.Count,
+			Block:    -1,
+			NoAck:    args.NoAck,
+		}).SetVal([]redis.XStream{{Stream: args.Keys[0], Messages: mockXMessages}})
+	}
+
+	return r.client.XReadGroup(ctx, args)
+}
+
+// XAck ...
+func (r *RedisMock) XAck(ctx context.Context, key, group string, ids []string) (int64, error) {
+	if checkXAck(key, group, ids) == nil {
+		r.mock.ExpectXAck(key, group, ids...).SetVal(int64(len(ids)))
+	}
+	return r.client.XAck(ctx, key, group, ids)
+}
+
+// XPending ...
+func (r *RedisMock) XPending(ctx context.Context, key, group string) (*models.XPending, error) {
+	if key != "" && group != "" {
+		r.mock.ExpectXPending(key, group).SetVal(&redis.XPending{
+			Count:     1,
+			Lower:     "1609019628218-0",
+			Higher:    "1609019628218-0",
+			Consumers: map[string]int64{"ivan": 1},
+		})
+	}
+	return r.client.XPending(ctx, key, group)
+}
+
+// XPendingExt ...
+func (r *RedisMock) XPendingExt(ctx context.Context, key string, query models.XPendingQuery) ([]models.XPendingEntry, error) {
+	query = xpendingDefaults(query)
+	start, okStart := redisStreamBound(query.Start, false)
+	end, okEnd := redisStreamBound(query.End, true)
+	if checkXPendingExt(key, query) == nil && okStart && okEnd {
+		r.mock.ExpectXPendingExt(&redis.XPendingExtArgs{
+			Stream:   key,
+			Group:    query.Group,
+			Start:    start,
+			End:      end,
+			Count:    query.Count,
+			Consumer: query.Consumer,
+		}).SetVal([]redis.XPendingExt{{ID: "1609019628218-0", Consumer: "ivan", Idle: time.Second, RetryCount: 1}})
+	}
+
+	return r.client.XPendingExt(ctx, key, query)
+}
+
+// XClaim ...
+func (r *RedisMock) XClaim(ctx context.Context, key string, claim models.XClaim) ([]models.StreamEntry, error) {
+	if checkXClaim(key, claim) == nil {
+		r.mock.ExpectXClaim(xclaimArgs(key, claim)).SetVal(mockXMessages)
+	}
+	return r.client.XClaim(ctx, key, claim)
+}
+
+// XAutoClaim - go-redis has no XAUTOCLAIM, so that the reply is canned
+func (r *RedisMock) XAutoClaim(ctx context.Context, key string, claim models.XAutoClaim) (*models.XAutoClaimResult, error) {
+	if claim := xautoclaimDefaults(claim); checkXAutoClaim(key, claim) == nil {
+		return &models.XAutoClaimResult{Next: "0-0", Entries: xmessages(mockXMessages)}, nil
+	}
+	return r.client.XAutoClaim(ctx, key, claim)
+}
+
+// Publish - the message is sent to subscribers of the in-process broker, redis replies with number of them
+func (r *RedisMock) Publish(ctx context.Context, channel, message string) (int64, error) {
+	if channel != "" {
+		r.mock.ExpectPublish(channel, message).SetVal(r.pubsub.publish(channel, message))
+	}
+	return r.client.Publish(ctx, channel, message)
+}
+
+// Subscribe ...
+func (r *RedisMock) Subscribe(ctx context.Context, channels, patterns []string) (Subscription, error) {
+	sub, err := r.pubsub.subscribe(channels, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// Tx - commands are executed one by one by the mock, so that each of them sets its own expectations
+func (r *RedisMock) Tx(ctx context.Context, watch []string, commands []models.TxCommand) ([]models.TxResult, error) {
+	calls, err := prepareTx(watch, commands)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]models.TxResult, len(calls))
+	for i, call := range calls {
+		res[i] = txResult(call.exec(ctx, r))
+	}
+
+	return res, nil
+}
+
+// SetNotifyKeyspaceEvents ...
+func (r *RedisMock) SetNotifyKeyspaceEvents(ctx context.Context, value string) error {
+	if _, err := parseNotifyFlags(value); err == nil {
+		r.mock.ExpectConfigSet("notify-keyspace-events", value).SetVal("OK")
+	}
+	return r.client.SetNotifyKeyspaceEvents(ctx, value)
+}
+
+// NotifyKeyspaceEvents ...
+func (r *RedisMock) NotifyKeyspaceEvents(ctx context.Context) (string, error) {
+	r.mock.ExpectConfigGet("notify-keyspace-events").SetVal([]interface{}{"notify-keyspace-events", "AKE"})
+	return r.client.NotifyKeyspaceEvents(ctx)
+}
+
+// Version - every key of the mock has version 1
+func (r *RedisMock) Version(ctx context.Context, key string) (int64, error) {
+	r.mock.ExpectExists(key).SetVal(1)
+	r.mock.ExpectGet(versionKey(key)).SetVal("1")
+	return r.client.Version(ctx, key)
+}
+
+// SetHashCAS - the mock has no concurrent writes, so that the version is simply compared before the write
+func (r *RedisMock) SetHashCAS(ctx context.Context, key string, value map[string]interface{}, exp models.Expiration, version int64) (int64, error) {
+	return r.cas(ctx, key, version, func() error {
+		return r.SetHash(ctx, key, value, exp)
+	})
+}
+
+// SetStringCAS ...
+func (r *RedisMock) SetStringCAS(ctx context.Context, key, value string, exp models.Expiration, version int64) (int64, error) {
+	return r.cas(ctx, key, version, func() error {
+		_, err := r.SetString(ctx, key, value, exp)
+		return err
+	})
+}
+
+// SetListCAS ...
+func (r *RedisMock) SetListCAS(ctx context.Context, key string, value []interface{}, exp models.Expiration, version int64) (int64, error) {
+	return r.cas(ctx, key, version, func() error {
+		return r.SetList(ctx, key, value, exp)
+	})
+}
+
+// HSetCAS ...
+func (r *RedisMock) HSetCAS(ctx context.Context, key string, values map[string]interface{}, version int64) (int64, int64, error) {
+	var added int64
+	newVersion, err := r.cas(ctx, key, version, func() error {
+		var err error
+		added, err = r.HSet(ctx, key, values)
+		return err
+	})
+
+	return added, newVersion, err
+}
+
+// LSetCAS ...
+func (r *RedisMock) LSetCAS(ctx context.Context, key string, index int64, value interface{}, version int64) (int64, error) {
+	return r.cas(ctx, key, version, func() error {
+		_, err := r.LSet(ctx, key, index, value)
+		return err
+	})
+}
+
+func (r *RedisMock) cas(ctx context.Context, key string, version int64, write func() error) (int64, error) {
+	current, err := r.Version(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if current != version {
+		return 0, ErrVersionMismatch
+	}
+
+	if err := write(); err != nil {
+		return 0, err
+	}
+
+	return current + 1, nil
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+)
+
+// SetPubSubBuffer - number of messages kept for the subscriber, the subscriber is dropped when it is exceeded
+func (r *Redis) SetPubSubBuffer(buffer int) error {
+	if buffer < 1 {
+		return fmt.Errorf("Buffer of subscribers has to be positive")
+	}
+
+	r.pubsubBuffer = buffer
+	return nil
+}
+
+// Publish - sends the message to subscribers of the channel and matching patterns, returns number of receivers
+func (r *Redis) Publish(ctx context.Context, channel, message string) (int64, error) {
+	if channel == "" {
+		return 0, fmt.Errorf("Empty channel")
+	}
+
+	res, err := r.client.Publish(ctx, channel, message).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	return res, nil
+}
+
+// Subscribe - subscription to channels and patterns on its own connection to redis, it has to be closed
+// by the client. Messages are read from the connection in background.
+func (r *Redis) Subscribe(ctx context.Context, channels, patterns []string) (Subscription, error) {
+	if err := checkSubscribe(channels, patterns); err != nil {
+		return nil, err
+	}
+
+	pubsub := r.client.Subscribe(ctx)
+	if len(channels) > 0 {
+		if err := pubsub.Subscribe(ctx, channels...); err != nil {
+			pubsub.Close()
+			return nil, err
+		}
+	}
+	if len(patterns) > 0 {
+		if err := pubsub.PSubscribe(ctx, patterns...); err != nil {
+			pubsub.Close()
+			return nil, err
+		}
+	}
+
+	// the first reply confirms that the connection is established
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	buffer := r.pubsubBuffer
+	if buffer == 0 {
+		buffer = DefaultPubSubBuffer
+	}
+
+	sub := newSubscription(buffer)
+	sub.onClose = func() {
+		pubsub.Close()
+	}
+
+	messages := pubsub.Channel()
+	go func() {
+		for message := range messages {
+			if !sub.deliver(&models.Message{Channel: message.Channel, Pattern: message.Pattern, Payload: message.Payload}) {
+				pubsub.Close()
+				sub.finish(ErrSlowSubscriber)
+				return
+			}
+		}
+
+		sub.finish(nil)
+	}()
+
+	return sub, nil
+}
+
+// SetNotifyKeyspaceEvents - classes of keyspace events published by redis, e.g. "KEA", empty value disables them
+func (r *Redis) SetNotifyKeyspaceEvents(ctx context.Context, value string) error {
+	if _, err := parseNotifyFlags(value); err != nil {
+		return err
+	}
+
+	return r.client.ConfigSet(ctx, "notify-keyspace-events", value).Err()
+}
+
+// NotifyKeyspaceEvents - value of notify-keyspace-events of redis
+func (r *Redis) NotifyKeyspaceEvents(ctx context.Context) (string, error) {
+	res, err := r.client.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		return "", err
+	}
+
+	if len(res) != 2 {
+		return "", fmt.Errorf("Unexpected reply of CONFIG GET: %v", res)
+	}
+
+	value, _ := res[1].(string)
+	return value, nil
+}
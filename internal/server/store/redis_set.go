@@ -0,0 +1,213 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// SAdd - adds members to the set, returns number of added members
+func (r *Redis) SAdd(ctx context.Context, key string, members []string) (int64, error) {
+	if key == "" || len(members) == 0 {
+		return 0, fmt.Errorf("Empty key or members")
+	}
+
+	var cmd *redis.IntCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.SAdd(ctx, key, stringArgs(members)...)
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// SRem - removes members from the set, returns number of removed members
+func (r *Redis) SRem(ctx context.Context, key string, members []string) (int64, error) {
+	if key == "" || len(members) == 0 {
+		return 0, fmt.Errorf("Empty key or members")
+	}
+
+	var cmd *redis.IntCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.SRem(ctx, key, stringArgs(members)...)
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// SMembers - members of the set sorted in lexicographical order
+func (r *Redis) SMembers(ctx context.Context, key string) ([]string, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+
+	res, err := r.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(res)
+	return res, nil
+}
+
+// SIsMember ...
+func (r *Redis) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	if key == "" {
+		return false, fmt.Errorf("Empty key")
+	}
+
+	res, err := r.client.SIsMember(ctx, key, member).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return res, nil
+}
+
+// SCard - number of members of the set
+func (r *Redis) SCard(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	res, err := r.client.SCard(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	return res, nil
+}
+
+// SInter - members which belong to all sets
+func (r *Redis) SInter(ctx context.Context, keys []string) ([]string, error) {
+	if err := checkKeys(keys); err != nil {
+		return nil, err
+	}
+
+	res, err := r.client.SInter(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(res)
+	return res, nil
+}
+
+// SUnion - members which belong to any of sets
+func (r *Redis) SUnion(ctx context.Context, keys []string) ([]string, error) {
+	if err := checkKeys(keys); err != nil {
+		return nil, err
+	}
+
+	res, err := r.client.SUnion(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(res)
+	return res, nil
+}
+
+// SDiff - members of the first set which do not belong to other sets
+func (r *Redis) SDiff(ctx context.Context, keys []string) ([]string, error) {
+	if err := checkKeys(keys); err != nil {
+		return nil, err
+	}
+
+	res, err := r.client.SDiff(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(res)
+	return res, nil
+}
+
+// SInterStore - stores intersection of sets in destination, returns its cardinality
+func (r *Redis) SInterStore(ctx context.Context, destination string, keys []string) (int64, error) {
+	if err := checkKeys(append([]string{destination}, keys...)); err != nil {
+		return 0, err
+	}
+
+	var cmd *redis.IntCmd
+	err := r.write(ctx, destination, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.SInterStore(ctx, destination, keys...)
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// SUnionStore - stores union of sets in destination, returns its cardinality
+func (r *Redis) SUnionStore(ctx context.Context, destination string, keys []string) (int64, error) {
+	if err := checkKeys(append([]string{destination}, keys...)); err != nil {
+		return 0, err
+	}
+
+	var cmd *redis.IntCmd
+	err := r.write(ctx, destination, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.SUnionStore(ctx, destination, keys...)
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// SDiffStore - stores difference of sets in destination, returns its cardinality
+func (r *Redis) SDiffStore(ctx context.Context, destination string, keys []string) (int64, error) {
+	if err := checkKeys(append([]string{destination}, keys...)); err != nil {
+		return 0, err
+	}
+
+	var cmd *redis.IntCmd
+	err := r.write(ctx, destination, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.SDiffStore(ctx, destination, keys...)
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// checkKeys - commands working with several keys need at least one key and all keys have to be non empty
+func checkKeys(keys []string) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("Empty keys")
+	}
+
+	for _, key := range keys {
+		if key == "" {
+			return fmt.Errorf("Empty key")
+		}
+	}
+
+	return nil
+}
+
+// stringArgs - go-redis accepts members of sets as interface{}
+func stringArgs(values []string) []interface{} {
+	res := make([]interface{}, len(values))
+	for i, val := range values {
+		res[i] = val
+	}
+
+	return res
+}
@@ -0,0 +1,627 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	errStreamID           = errors.New("ERR Invalid stream ID specified as stream command argument")
+	errXAddIDZero         = errors.New("ERR The ID specified in XADD must be greater than 0-0")
+	errXAddIDSmall        = errors.New("ERR The ID specified in XADD is equal or smaller than the target stream top item")
+	errXSetIDSmall        = errors.New("ERR The ID specified in XSETID is smaller than the target stream top item")
+	errStreamExhausted    = errors.New("ERR The stream has exhausted the last possible ID, unable to add more items")
+	errXTrimMaxLenMinID   = errors.New("ERR syntax error, MAXLEN and MINID options at the same time are not compatible")
+	errXTrimStrategy      = errors.New("ERR syntax error, MAXLEN or MINID has to be specified")
+	errXTrimNegative      = errors.New("ERR The MAXLEN argument must be >= 0.")
+	errXReadUnbalanced    = errors.New("ERR Unbalanced XREAD list of streams: for each stream key an ID or '$' must be specified.")
+	errXReadGroupBalance  = errors.New("ERR Unbalanced XREADGROUP list of streams: for each stream key an ID or '>' must be specified.")
+	errXReadGroupID       = errors.New("ERR The > ID can be specified only when calling XREADGROUP using the GROUP <group> <consumer> option.")
+	errXGroupNoKey        = errors.New("ERR The XGROUP subcommand requires the key to exist. Note that for CREATE you may want to use the MKSTREAM option to create an empty stream automatically.")
+	errBusyGroup          = errors.New("BUSYGROUP Consumer Group name already exists")
+	errStreamKeysRequired = errors.New("ERR at least 1 stream key is needed")
+	errStreamIDsRequired  = errors.New("ERR at least 1 id is needed")
+)
+
+// errNoGroup - error of commands applied to missing key or consumer group
+func errNoGroup(key, group, command string) error {
+	if command == "XREADGROUP" {
+		return fmt.Errorf("NOGROUP No such key '%s' or consumer group '%s' in XREADGROUP with GROUP option", key, group)
+	}
+
+	return fmt.Errorf("NOGROUP No such key '%s' or consumer group '%s'", key, group)
+}
+
+// XAdd - adds the entry to the stream, returns its id. The id is generated by redis unless it is given.
+func (r *Redis) XAdd(ctx context.Context, key, id string, fields map[string]interface{}, trim models.XTrim) (string, error) {
+	if err := checkXAdd(key, id, fields, trim); err != nil {
+		return "", err
+	}
+
+	values, err := streamFields(fields)
+	if err != nil {
+		return "", err
+	}
+
+	// go-redis has no MINID option of redis 6.2, so that the command is sent as it is
+	cmd := redis.NewStringCmd(ctx, xaddArgs(key, id, values, trim)...)
+	err = r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		return c.Process(ctx, cmd)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return cmd.Val(), nil
+}
+
+// XRange - entries between start and end ordered by id
+func (r *Redis) XRange(ctx context.Context, key string, query models.XRangeQuery) ([]models.StreamEntry, error) {
+	return r.xrange(ctx, key, xrangeDefaults(query, false), false)
+}
+
+// XRevRange - entries between start and end in reverse order, start is the highest bound
+func (r *Redis) XRevRange(ctx context.Context, key string, query models.XRangeQuery) ([]models.StreamEntry, error) {
+	return r.xrange(ctx, key, xrangeDefaults(query, true), true)
+}
+
+// XLen - number of entries of the stream
+func (r *Redis) XLen(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	res, err := r.client.XLen(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	return res, nil
+}
+
+// XTrim - removes the oldest entries, returns number of removed entries
+func (r *Redis) XTrim(ctx context.Context, key string, trim models.XTrim) (int64, error) {
+	if err := checkXTrim(key, trim, true); err != nil {
+		return 0, err
+	}
+
+	var cmd *redis.IntCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		if trim.MaxLen != nil {
+			cmd = c.XTrim(ctx, key, *trim.MaxLen)
+		} else {
+			cmd = redis.NewIntCmd(ctx, "xtrim", key, "minid", trim.MinID)
+			_ = c.Process(ctx, cmd)
+		}
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// XRead - entries of streams with ids greater than the given ones, streams without such entries are skipped
+func (r *Redis) XRead(ctx context.Context, query models.XReadQuery) ([]models.XStream, error) {
+	if err := checkXRead(query.Keys, query.IDs, false); err != nil {
+		return nil, err
+	}
+
+	res, err := r.client.XRead(ctx, &redis.XReadArgs{
+		Streams: append(append([]string{}, query.Keys...), query.IDs...),
+		Count:   query.Count,
+		Block:   -1,
+	}).Result()
+	if err == redis.Nil {
+		return []models.XStream{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return xstreams(res), nil
+}
+
+// XGroupCreate - creates consumer group which starts after id, "$" is the last id of the stream
+func (r *Redis) XGroupCreate(ctx context.Context, key, group, id string, mkstream bool) error {
+	if id == "" {
+		id = "$"
+	}
+	if err := checkXGroupCreate(key, group, id); err != nil {
+		return err
+	}
+
+	var err error
+	if mkstream {
+		_, err = r.client.XGroupCreateMkStream(ctx, key, group, id).Result()
+	} else {
+		_, err = r.client.XGroupCreate(ctx, key, group, id).Result()
+	}
+
+	return err
+}
+
+// XReadGroup - with ">" entries never delivered to the group are read and added to pending entries list of
+// the consumer, other ids read entries pending for the consumer
+func (r *Redis) XReadGroup(ctx context.Context, args models.XReadGroupRequest) ([]models.XStream, error) {
+	ids, err := checkXReadGroup(args)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    args.Group,
+		Consumer: args.Consumer,
+		Streams:  append(append([]string{}, args.Keys...), ids...),
+		Count:    args.Count,
+		Block:    -1,
+		NoAck:    args.NoAck,
+	}).Result()
+	if err == redis.Nil {
+		return []models.XStream{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return xstreams(res), nil
+}
+
+// XAck - removes entries from pending entries list of the group, returns number of acknowledged entries
+func (r *Redis) XAck(ctx context.Context, key, group string, ids []string) (int64, error) {
+	if err := checkXAck(key, group, ids); err != nil {
+		return 0, err
+	}
+
+	res, err := r.client.XAck(ctx, key, group, ids...).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	return res, nil
+}
+
+// XPending - summary of pending entries of the group
+func (r *Redis) XPending(ctx context.Context, key, group string) (*models.XPending, error) {
+	if key == "" || group == "" {
+		return nil, fmt.Errorf("Empty key or group")
+	}
+
+	res, err := r.client.XPending(ctx, key, group).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := &models.XPending{
+		Count:     res.Count,
+		Lower:     res.Lower,
+		Higher:    res.Higher,
+		Consumers: res.Consumers,
+	}
+	if pending.Consumers == nil {
+		pending.Consumers = map[string]int64{}
+	}
+
+	return pending, nil
+}
+
+// XPendingExt - pending entries of the group between start and end, optionally of the consumer
+func (r *Redis) XPendingExt(ctx context.Context, key string, query models.XPendingQuery) ([]models.XPendingEntry, error) {
+	query = xpendingDefaults(query)
+	if err := checkXPendingExt(key, query); err != nil {
+		return nil, err
+	}
+
+	start, okStart := redisStreamBound(query.Start, false)
+	end, okEnd := redisStreamBound(query.End, true)
+	if !okStart || !okEnd {
+		return []models.XPendingEntry{}, nil
+	}
+
+	res, err := r.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   key,
+		Group:    query.Group,
+		Start:    start,
+		End:      end,
+		Count:    query.Count,
+		Consumer: query.Consumer,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.XPendingEntry, len(res))
+	for i, pending := range res {
+		entries[i] = models.XPendingEntry{
+			ID:         pending.ID,
+			Consumer:   pending.Consumer,
+			Idle:       int64(pending.Idle / time.Millisecond),
+			RetryCount: pending.RetryCount,
+		}
+	}
+
+	return entries, nil
+}
+
+// XClaim - transfers pending entries idle for at least min idle milliseconds to the consumer, returns claimed entries
+func (r *Redis) XClaim(ctx context.Context, key string, claim models.XClaim) ([]models.StreamEntry, error) {
+	if err := checkXClaim(key, claim); err != nil {
+		return nil, err
+	}
+
+	res, err := r.client.XClaim(ctx, xclaimArgs(key, claim)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return xmessages(res), nil
+}
+
+// XAutoClaim - the same as XClaim, but count pending entries starting from start are checked. The id to continue
+// with is returned, it is "0-0" when all pending entries are checked.
+func (r *Redis) XAutoClaim(ctx context.Context, key string, claim models.XAutoClaim) (*models.XAutoClaimResult, error) {
+	claim = xautoclaimDefaults(claim)
+	if err := checkXAutoClaim(key, claim); err != nil {
+		return nil, err
+	}
+
+	// go-redis has no XAUTOCLAIM of redis 6.2, so that the command is sent as it is
+	cmd := redis.NewSliceCmd(ctx, xautoclaimArgs(key, claim)...)
+	if err := r.client.Process(ctx, cmd); err != nil {
+		return nil, err
+	}
+
+	return xautoclaimResult(cmd.Val())
+}
+
+// xrange - XRANGE and XREVRANGE, exclusive bounds are converted to inclusive ones, so that the range which is
+// empty because of them is not requested
+func (r *Redis) xrange(ctx context.Context, key string, query models.XRangeQuery, rev bool) ([]models.StreamEntry, error) {
+	start, end := query.Start, query.End
+	if rev {
+		start, end = end, start
+	}
+	if err := checkXRange(key, start, end); err != nil {
+		return nil, err
+	}
+
+	start, okStart := redisStreamBound(start, false)
+	end, okEnd := redisStreamBound(end, true)
+	if !okStart || !okEnd {
+		return []models.StreamEntry{}, nil
+	}
+
+	var cmd *redis.XMessageSliceCmd
+	switch {
+	case rev && query.Count > 0:
+		cmd = r.client.XRevRangeN(ctx, key, end, start, query.Count)
+	case rev:
+		cmd = r.client.XRevRange(ctx, key, end, start)
+	case query.Count > 0:
+		cmd = r.client.XRangeN(ctx, key, start, end, query.Count)
+	default:
+		cmd = r.client.XRange(ctx, key, start, end)
+	}
+
+	res, err := cmd.Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return xmessages(res), nil
+}
+
+// checkXAdd - empty id or "*" means that the id is generated
+func checkXAdd(key, id string, fields map[string]interface{}, trim models.XTrim) error {
+	if key == "" || len(fields) == 0 {
+		return fmt.Errorf("Empty key or fields")
+	}
+
+	if id != "" && id != "*" {
+		if _, err := parseStreamID(id, 0); err != nil {
+			return err
+		}
+	}
+
+	return checkXTrim(key, trim, false)
+}
+
+// checkXTrim - only one strategy of trimming is allowed, with required one of them has to be set
+func checkXTrim(key string, trim models.XTrim, required bool) error {
+	if key == "" {
+		return fmt.Errorf("Empty key")
+	}
+
+	switch {
+	case trim.MaxLen != nil && trim.MinID != "":
+		return errXTrimMaxLenMinID
+	case trim.MaxLen != nil && *trim.MaxLen < 0:
+		return errXTrimNegative
+	case trim.MinID != "":
+		_, err := parseStreamID(trim.MinID, 0)
+		return err
+	case required && trim.MaxLen == nil:
+		return errXTrimStrategy
+	}
+
+	return nil
+}
+
+func checkXRange(key, start, end string) error {
+	if key == "" {
+		return fmt.Errorf("Empty key")
+	}
+
+	if _, _, err := parseStreamBound(start, false); err != nil {
+		return err
+	}
+	_, _, err := parseStreamBound(end, true)
+	return err
+}
+
+// checkXRead - every key has its id, ">" is allowed only for consumer groups and "$" only without them
+func checkXRead(keys, ids []string, group bool) error {
+	if len(keys) == 0 {
+		return errStreamKeysRequired
+	}
+	if err := checkKeys(keys); err != nil {
+		return err
+	}
+
+	if len(keys) != len(ids) {
+		if group {
+			return errXReadGroupBalance
+		}
+		return errXReadUnbalanced
+	}
+
+	for _, id := range ids {
+		switch {
+		case id == ">" && group, id == "$" && !group:
+			continue
+		case id == ">":
+			return errXReadGroupID
+		}
+
+		if _, err := parseStreamID(id, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkXReadGroup - returns ids of streams, ">" is used for all the streams if ids are not given
+func checkXReadGroup(args models.XReadGroupRequest) ([]string, error) {
+	if args.Group == "" || args.Consumer == "" {
+		return nil, fmt.Errorf("Empty group or consumer")
+	}
+
+	ids := args.IDs
+	if len(ids) == 0 {
+		ids = make([]string, len(args.Keys))
+		for i := range ids {
+			ids[i] = ">"
+		}
+	}
+
+	return ids, checkXRead(args.Keys, ids, true)
+}
+
+func checkXGroupCreate(key, group, id string) error {
+	if key == "" || group == "" {
+		return fmt.Errorf("Empty key or group")
+	}
+	if id == "$" {
+		return nil
+	}
+
+	_, err := parseStreamID(id, 0)
+	return err
+}
+
+func checkXAck(key, group string, ids []string) error {
+	if key == "" || group == "" {
+		return fmt.Errorf("Empty key or group")
+	}
+
+	return checkStreamIDs(ids)
+}
+
+func checkXPendingExt(key string, query models.XPendingQuery) error {
+	if key == "" || query.Group == "" {
+		return fmt.Errorf("Empty key or group")
+	}
+	if query.Count < 0 {
+		return errNegative
+	}
+
+	return checkXRange(key, query.Start, query.End)
+}
+
+func checkXClaim(key string, claim models.XClaim) error {
+	if key == "" || claim.Group == "" || claim.Consumer == "" {
+		return fmt.Errorf("Empty key, group or consumer")
+	}
+	if claim.MinIdle < 0 {
+		return errNegative
+	}
+
+	return checkStreamIDs(claim.IDs)
+}
+
+func checkXAutoClaim(key string, claim models.XAutoClaim) error {
+	if key == "" || claim.Group == "" || claim.Consumer == "" {
+		return fmt.Errorf("Empty key, group or consumer")
+	}
+	if claim.MinIdle < 0 || claim.Count < 0 {
+		return errNegative
+	}
+
+	_, err := parseStreamID(claim.Start, 0)
+	return err
+}
+
+func checkStreamIDs(ids []string) error {
+	if len(ids) == 0 {
+		return errStreamIDsRequired
+	}
+
+	for _, id := range ids {
+		if _, err := parseStreamID(id, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// xrangeDefaults - the whole stream is the default range, with rev start is the highest bound
+func xrangeDefaults(query models.XRangeQuery, rev bool) models.XRangeQuery {
+	low, high := "-", "+"
+	if rev {
+		low, high = high, low
+	}
+
+	if query.Start == "" {
+		query.Start = low
+	}
+	if query.End == "" {
+		query.End = high
+	}
+
+	return query
+}
+
+// xpendingDefaults - 10 pending entries of the whole stream by default
+func xpendingDefaults(query models.XPendingQuery) models.XPendingQuery {
+	if query.Start == "" {
+		query.Start = "-"
+	}
+	if query.End == "" {
+		query.End = "+"
+	}
+	if query.Count == 0 {
+		query.Count = 10
+	}
+
+	return query
+}
+
+// xautoclaimDefaults - the same defaults as XAUTOCLAIM has
+func xautoclaimDefaults(claim models.XAutoClaim) models.XAutoClaim {
+	if claim.Start == "" {
+		claim.Start = "0-0"
+	}
+	if claim.Count == 0 {
+		claim.Count = 100
+	}
+
+	return claim
+}
+
+// redisStreamBound - exclusive bound is converted to inclusive one, false is returned if nothing is left in the range
+func redisStreamBound(bound string, end bool) (string, bool) {
+	if bound == "" || bound[0] != '(' {
+		return bound, true
+	}
+
+	id, ok, _ := parseStreamBound(bound, end)
+	return id.String(), ok
+}
+
+// xaddArgs - XADD key [MAXLEN n|MINID id] id field value [field value ...], in the same order as go-redis sends them
+func xaddArgs(key, id string, values []string, trim models.XTrim) []interface{} {
+	args := []interface{}{"xadd", key}
+	switch {
+	case trim.MaxLen != nil:
+		args = append(args, "maxlen", *trim.MaxLen)
+	case trim.MinID != "":
+		args = append(args, "minid", trim.MinID)
+	}
+
+	if id == "" {
+		id = "*"
+	}
+	args = append(args, id)
+
+	return append(args, stringArgs(values)...)
+}
+
+func xclaimArgs(key string, claim models.XClaim) *redis.XClaimArgs {
+	return &redis.XClaimArgs{
+		Stream:   key,
+		Group:    claim.Group,
+		Consumer: claim.Consumer,
+		MinIdle:  time.Duration(claim.MinIdle) * time.Millisecond,
+		Messages: claim.IDs,
+	}
+}
+
+// xautoclaimArgs - XAUTOCLAIM key group consumer min-idle-time start COUNT count
+func xautoclaimArgs(key string, claim models.XAutoClaim) []interface{} {
+	return []interface{}{"xautoclaim", key, claim.Group, claim.Consumer, claim.MinIdle, claim.Start, "count", claim.Count}
+}
+
+// xautoclaimResult - reply of XAUTOCLAIM is the next id and claimed entries, entries deleted from the stream are nil
+func xautoclaimResult(reply []interface{}) (*models.XAutoClaimResult, error) {
+	if len(reply) < 2 {
+		return nil, fmt.Errorf("Unexpected reply of XAUTOCLAIM: %v", reply)
+	}
+
+	next, _ := reply[0].(string)
+	result := &models.XAutoClaimResult{Next: next, Entries: []models.StreamEntry{}}
+
+	entries, _ := reply[1].([]interface{})
+	for _, raw := range entries {
+		entry, ok := raw.([]interface{})
+		if !ok || len(entry) != 2 {
+			continue
+		}
+
+		id, _ := entry[0].(string)
+		values, _ := entry[1].([]interface{})
+		fields := make([]string, len(values))
+		for i, value := range values {
+			fields[i] = fmt.Sprint(value)
+		}
+		result.Entries = append(result.Entries, streamEntryModel(id, fields))
+	}
+
+	return result, nil
+}
+
+func xmessages(messages []redis.XMessage) []models.StreamEntry {
+	res := make([]models.StreamEntry, len(messages))
+	for i, message := range messages {
+		res[i] = models.StreamEntry{ID: message.ID}
+		if message.Values == nil {
+			continue
+		}
+
+		res[i].Fields = make(map[string]interface{}, len(message.Values))
+		for field, value := range message.Values {
+			res[i].Fields[field] = decodeStreamValue(fmt.Sprint(value))
+		}
+	}
+
+	return res
+}
+
+func xstreams(streams []redis.XStream) []models.XStream {
+	res := make([]models.XStream, len(streams))
+	for i, stream := range streams {
+		res[i] = models.XStream{
+			Stream:  stream.Stream,
+			Entries: xmessages(stream.Messages),
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,325 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// maxStringSize - the same limit of the string as proto-max-bulk-len of redis
+const maxStringSize = 512 * 1024 * 1024
+
+var (
+	errOverflow    = errors.New("ERR increment or decrement would overflow")
+	errIncrNaN     = errors.New("ERR increment would produce NaN or Infinity")
+	errSyntax      = errors.New("ERR syntax error")
+	errStringSize  = errors.New("ERR string exceeds maximum allowed size (512MB)")
+	errOffset      = errors.New("ERR offset is out of range")
+	errExpireSet   = errors.New("ERR invalid expire time in set")
+	errExpireGetEx = errors.New("ERR invalid expire time in getex")
+)
+
+// IsNotNumber - checks if the counter is not incremented because the key or the field of the hash holds a string
+// which is not a number
+func IsNotNumber(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch err.Error() {
+	case errNotInteger.Error(), errNotFloat.Error(), errHashNotInteger.Error(), errHashNotFloat.Error():
+		return true
+	}
+
+	return false
+}
+
+// IsOverflow - checks if the counter is not incremented because the result is out of range
+func IsOverflow(err error) bool {
+	return err != nil && (err.Error() == errOverflow.Error() || err.Error() == errIncrNaN.Error())
+}
+
+// IncrBy ...
+func (r *Redis) IncrBy(ctx context.Context, key string, increment int64) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	var cmd *redis.IntCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.IncrBy(ctx, key, increment)
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// IncrByFloat ...
+func (r *Redis) IncrByFloat(ctx context.Context, key string, increment float64) (float64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	var cmd *redis.FloatCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.IncrByFloat(ctx, key, increment)
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// setScript - redis 6.0 has no GET, EXAT and PXAT options of SET, so that the previous value is read and
+// expiration time is set within the script. ARGV are the value, "1" for GET, unix time in milliseconds or ""
+// and options of SET. Errors are returned as is, so that WRONGTYPE is not wrapped by the script error.
+const setScript = versionFunc + `local old = false
+if ARGV[2] == '1' then
+	old = redis.pcall('GET', KEYS[1])
+	if type(old) == 'table' and old.err then return old end
+end
+local res = redis.pcall('SET', KEYS[1], ARGV[1], unpack(ARGV, 4))
+if type(res) == 'table' and res.err then return res end
+if res then
+	if ARGV[3] ~= '' then redis.call('PEXPIREAT', KEYS[1], ARGV[3]) end
+	version(KEYS[1], KEYS[2])
+end
+if ARGV[2] == '1' then return old end
+return res`
+
+// getDelScript - redis 6.0 has no GETDEL
+const getDelScript = versionFunc + `local value = redis.pcall('GET', KEYS[1])
+if type(value) == 'table' and value.err then return value end
+if value then
+	redis.call('DEL', KEYS[1])
+	version(KEYS[1], KEYS[2])
+end
+return value`
+
+// getExScript - redis 6.0 has no GETEX. ARGV are the command changing expiration of the key and its arguments.
+const getExScript = versionFunc + `local value = redis.pcall('GET', KEYS[1])
+if type(value) == 'table' and value.err then return value end
+if value and ARGV[1] then
+	redis.call(ARGV[1], KEYS[1], unpack(ARGV, 2))
+	version(KEYS[1], KEYS[2])
+end
+return value`
+
+// SetArgs ...
+func (r *Redis) SetArgs(ctx context.Context, key, value string, opts models.SetOptions) (string, error) {
+	if err := checkSet(key, opts); err != nil {
+		return "", err
+	}
+
+	return r.client.Eval(ctx, setScript, versionKeys(key), setArgs(value, opts)...).Text()
+}
+
+// GetSet ...
+func (r *Redis) GetSet(ctx context.Context, key, value string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("Empty key")
+	}
+
+	var cmd *redis.StringCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.GetSet(ctx, key, value)
+		if err := cmd.Err(); err != redis.Nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return cmd.Result()
+}
+
+// GetDel ...
+func (r *Redis) GetDel(ctx context.Context, key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("Empty key")
+	}
+
+	return r.client.Eval(ctx, getDelScript, versionKeys(key)).Text()
+}
+
+// GetEx ...
+func (r *Redis) GetEx(ctx context.Context, key string, opts models.GetExOptions) (string, error) {
+	if err := checkGetEx(key, opts); err != nil {
+		return "", err
+	}
+
+	return r.client.Eval(ctx, getExScript, versionKeys(key), getExArgs(opts)...).Text()
+}
+
+// Append ...
+func (r *Redis) Append(ctx context.Context, key, value string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	var cmd *redis.IntCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.Append(ctx, key, value)
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// StrLen ...
+func (r *Redis) StrLen(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	return r.client.StrLen(ctx, key).Result()
+}
+
+// GetRange ...
+func (r *Redis) GetRange(ctx context.Context, key string, start, end int64) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("Empty key")
+	}
+
+	return r.client.GetRange(ctx, key, start, end).Result()
+}
+
+// SetRange ...
+func (r *Redis) SetRange(ctx context.Context, key string, offset int64, value string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+	if err := checkSetRange(offset, value); err != nil {
+		return 0, err
+	}
+
+	var cmd *redis.IntCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.SetRange(ctx, key, offset, value)
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// setArgs - ARGV of setScript
+func setArgs(value string, opts models.SetOptions) []interface{} {
+	get, at := "0", ""
+	if opts.Get {
+		get = "1"
+	}
+	if opts.ExAt > 0 {
+		at = strconv.FormatInt(opts.ExAt*1000, 10)
+	}
+	if opts.PxAt > 0 {
+		at = strconv.FormatInt(opts.PxAt, 10)
+	}
+	if opts.ExpireAt != nil {
+		at = strconv.FormatInt(unixMs(*opts.ExpireAt), 10)
+	}
+
+	args := []interface{}{value, get, at}
+	switch {
+	case opts.NX:
+		args = append(args, "NX")
+	case opts.XX:
+		args = append(args, "XX")
+	}
+	switch {
+	case opts.KeepTTL:
+		args = append(args, "KEEPTTL")
+	case opts.TTL > 0:
+		args = append(args, "PX", int64(opts.TTL.Duration()/time.Millisecond))
+	}
+
+	return args
+}
+
+// getExArgs - ARGV of getExScript
+func getExArgs(opts models.GetExOptions) []interface{} {
+	switch {
+	case opts.Persist:
+		return []interface{}{"PERSIST"}
+	case opts.TTL > 0:
+		return []interface{}{"PEXPIRE", int64(opts.TTL.Duration() / time.Millisecond)}
+	case opts.ExpireAt != nil:
+		return []interface{}{"PEXPIREAT", unixMs(*opts.ExpireAt)}
+	case opts.ExAt > 0:
+		return []interface{}{"PEXPIREAT", opts.ExAt * 1000}
+	case opts.PxAt > 0:
+		return []interface{}{"PEXPIREAT", opts.PxAt}
+	}
+
+	return nil
+}
+
+// checkSet - options of SET are exclusive the same way as in redis: NX with XX, one kind of expiration
+func checkSet(key string, opts models.SetOptions) error {
+	if key == "" {
+		return fmt.Errorf("Empty key")
+	}
+	if invalidExpiration(opts.Expiration) || opts.ExAt < 0 || opts.PxAt < 0 {
+		return errExpireSet
+	}
+	if opts.NX && opts.XX {
+		return errSyntax
+	}
+	if expirations(opts.TTL > 0, opts.ExpireAt != nil, opts.ExAt > 0, opts.PxAt > 0, opts.KeepTTL) > 1 {
+		return errSyntax
+	}
+
+	return nil
+}
+
+// checkGetEx - GETEX accepts one kind of expiration or PERSIST
+func checkGetEx(key string, opts models.GetExOptions) error {
+	if key == "" {
+		return fmt.Errorf("Empty key")
+	}
+	if invalidExpiration(opts.Expiration) || opts.ExAt < 0 || opts.PxAt < 0 {
+		return errExpireGetEx
+	}
+	if expirations(opts.TTL > 0, opts.ExpireAt != nil, opts.ExAt > 0, opts.PxAt > 0, opts.Persist) > 1 {
+		return errSyntax
+	}
+
+	return nil
+}
+
+// checkSetRange - the string may not grow beyond the limit
+func checkSetRange(offset int64, value string) error {
+	if offset < 0 || offset+int64(len(value)) > maxStringSize {
+		return errOffset
+	}
+
+	return nil
+}
+
+func expirations(options ...bool) int {
+	count := 0
+	for _, set := range options {
+		if set {
+			count++
+		}
+	}
+
+	return count
+}
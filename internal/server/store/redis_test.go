@@ -2,14 +2,19 @@ package store
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
-	"github.com/go-redis/redismock/v8"
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestSetList(t *testing.T) {
-	db, mock := redismock.NewClientMock()
+	db, mock := newClientMock()
 	client := Redis{
 		client: db,
 	}
@@ -30,7 +35,7 @@ func TestSetList(t *testing.T) {
 			ttl:  10,
 			valuesExp: []interface{}{
 				`{"Dtype":"string","Data":"Ivan"}`,
-				`{"Dtype":"float64","Data":"195.50000"}`,
+				`{"Dtype":"float64","Data":"195.5"}`,
 				`{"Dtype":"int","Data":"21"}`,
 				`{"Dtype":"map","Data":"{\"name\":\"Ivan\"}"}`,
 			},
@@ -82,7 +87,10 @@ func TestSetList(t *testing.T) {
 	for _, tc := range tCases {
 		t.Run(tc.name, func(t *testing.T) {
 			mock.ExpectRPush(tc.key, tc.valuesExp...).SetVal(3)
-			err := client.SetList(context.Background(), tc.key, tc.values, 0)
+			if !tc.isError {
+				expectVersion(mock, tc.key).SetVal(int64(1))
+			}
+			err := client.SetList(context.Background(), tc.key, tc.values, models.Expiration{})
 
 			if tc.isError {
 				assert.Error(t, err)
@@ -94,7 +102,7 @@ func TestSetList(t *testing.T) {
 }
 
 func TestSetHash(t *testing.T) {
-	db, mock := redismock.NewClientMock()
+	db, mock := newClientMock()
 	client := Redis{
 		client: db,
 	}
@@ -127,8 +135,9 @@ func TestSetHash(t *testing.T) {
 
 	for _, tc := range tCases {
 		t.Run(tc.name, func(t *testing.T) {
-			mock.ExpectHMSet(tc.key, tc.valuesExp).SetVal(true)
-			err := client.SetHash(context.Background(), tc.key, tc.values, 0)
+			mock.CustomMatch(matchHashArgs).ExpectHMSet(tc.key, tc.valuesExp).SetVal(true)
+			expectVersion(mock, tc.key).SetVal(int64(1))
+			err := client.SetHash(context.Background(), tc.key, tc.values, models.Expiration{})
 
 			if tc.isError {
 				assert.Error(t, err)
@@ -140,7 +149,7 @@ func TestSetHash(t *testing.T) {
 }
 
 func TestSetString(t *testing.T) {
-	db, mock := redismock.NewClientMock()
+	db, mock := newClientMock()
 	client := Redis{
 		client: db,
 	}
@@ -149,12 +158,13 @@ func TestSetString(t *testing.T) {
 	valuesExp := "Lapshin"
 
 	mock.ExpectSet(key, valuesExp, 0).SetVal("OK")
-	_, err := client.SetString(context.Background(), key, valuesExp, 0)
+	expectVersion(mock, key).SetVal(int64(1))
+	_, err := client.SetString(context.Background(), key, valuesExp, models.Expiration{})
 	assert.NoError(t, err)
 }
 
 func TestGetKeys(t *testing.T) {
-	db, mock := redismock.NewClientMock()
+	db, mock := newClientMock()
 	client := Redis{
 		client: db,
 	}
@@ -166,19 +176,20 @@ func TestGetKeys(t *testing.T) {
 }
 
 func TestDel(t *testing.T) {
-	db, mock := redismock.NewClientMock()
+	db, mock := newClientMock()
 	client := Redis{
 		client: db,
 	}
 
 	key := "Ivan"
 	mock.ExpectDel(key).SetVal(1)
+	expectVersion(mock, key).SetVal(int64(1))
 	_, err := client.Delete(context.Background(), key)
 	assert.NoError(t, err)
 }
 
 func TestGetString(t *testing.T) {
-	db, mock := redismock.NewClientMock()
+	db, mock := newClientMock()
 	client := Redis{
 		client: db,
 	}
@@ -191,7 +202,7 @@ func TestGetString(t *testing.T) {
 }
 
 func TestGetHash(t *testing.T) {
-	db, mock := redismock.NewClientMock()
+	db, mock := newClientMock()
 	client := Redis{
 		client: db,
 	}
@@ -207,7 +218,7 @@ func TestGetHash(t *testing.T) {
 }
 
 func TestGetList(t *testing.T) {
-	db, mock := redismock.NewClientMock()
+	db, mock := newClientMock()
 	client := Redis{
 		client: db,
 	}
@@ -223,7 +234,7 @@ func TestGetList(t *testing.T) {
 }
 
 func TestHGet(t *testing.T) {
-	db, mock := redismock.NewClientMock()
+	db, mock := newClientMock()
 	client := Redis{
 		client: db,
 	}
@@ -237,7 +248,7 @@ func TestHGet(t *testing.T) {
 }
 
 func TestHSet(t *testing.T) {
-	db, mock := redismock.NewClientMock()
+	db, mock := newClientMock()
 	client := Redis{
 		client: db,
 	}
@@ -249,14 +260,15 @@ func TestHSet(t *testing.T) {
 		"age":      "21",
 	}
 
-	mock.ExpectHSet(key, value).SetVal(3)
+	mock.CustomMatch(matchHashArgs).ExpectHSet(key, value).SetVal(3)
+	expectVersion(mock, key).SetVal(int64(1))
 
 	_, err := client.HSet(context.Background(), key, value)
 	assert.NoError(t, err)
 }
 
 func TestLRange(t *testing.T) {
-	db, mock := redismock.NewClientMock()
+	db, mock := newClientMock()
 	client := Redis{
 		client: db,
 	}
@@ -272,7 +284,7 @@ func TestLRange(t *testing.T) {
 }
 
 func TestLSet(t *testing.T) {
-	db, mock := redismock.NewClientMock()
+	db, mock := newClientMock()
 	client := Redis{
 		client: db,
 	}
@@ -287,3 +299,472 @@ func TestLSet(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func TestSAdd(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+
+	key := "roles"
+	members := []string{"admin", "user"}
+	mock.ExpectSAdd(key, "admin", "user").SetVal(2)
+	expectVersion(mock, key).SetVal(int64(1))
+
+	res, err := client.SAdd(context.Background(), key, members)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), res)
+
+	_, err = client.SAdd(context.Background(), key, nil)
+	assert.Error(t, err)
+}
+
+func TestSInterStore(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+
+	mock.ExpectSInterStore("common", "roles:1", "roles:2").SetVal(1)
+	expectVersion(mock, "common").SetVal(int64(1))
+
+	res, err := client.SInterStore(context.Background(), "common", []string{"roles:1", "roles:2"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), res)
+
+	_, err = client.SInterStore(context.Background(), "", []string{"roles:1"})
+	assert.Error(t, err)
+}
+
+func TestZAdd(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+
+	members := []models.ZMember{{Member: "ivan", Score: 1.5}, {Member: "petr", Score: 2}}
+	mock.ExpectZAddNXCh("scores", &redis.Z{Score: 1.5, Member: "ivan"}, &redis.Z{Score: 2, Member: "petr"}).SetVal(2)
+	expectVersion(mock, "scores").SetVal(int64(1))
+	mock.ExpectZIncrXX("scores", &redis.Z{Score: 1, Member: "ivan"}).SetVal(2.5)
+	expectVersion(mock, "scores").SetVal(int64(2))
+
+	res, err := client.ZAdd(context.Background(), "scores", members, models.ZAddOptions{NX: true, CH: true})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), res)
+
+	score, err := client.ZAddIncr(context.Background(), "scores", models.ZMember{Member: "ivan", Score: 1}, models.ZAddOptions{XX: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, score)
+
+	_, err = client.ZAdd(context.Background(), "scores", members, models.ZAddOptions{GT: true, LT: true})
+	assert.Equal(t, errZAddGTLTNX, err)
+	_, err = client.ZAdd(context.Background(), "", members, models.ZAddOptions{})
+	assert.Error(t, err)
+}
+
+func TestZRange(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+
+	mock.ExpectZRevRangeByScoreWithScores("scores", &redis.ZRangeBy{Min: "(1", Max: "+inf", Offset: 1, Count: -1}).
+		SetVal([]redis.Z{{Score: 2, Member: "petr"}})
+	mock.ExpectZRangeByLex("scores", &redis.ZRangeBy{Min: "[a", Max: "+"}).SetVal([]string{"ivan"})
+	mock.ExpectZScore("scores", "ivan").SetVal(1.5)
+
+	res, err := client.ZRange(context.Background(), "scores", models.ZRangeQuery{Start: "+inf", Stop: "(1", By: "SCORE", Rev: true, Offset: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ZMember{{Member: "petr", Score: 2}}, res)
+
+	res, err = client.ZRange(context.Background(), "scores", models.ZRangeQuery{Start: "[a", Stop: "+", By: ZRangeByLex})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ZMember{{Member: "ivan", Score: 1.5}}, res)
+
+	_, err = client.ZRange(context.Background(), "scores", models.ZRangeQuery{Start: "0", Stop: "a"})
+	assert.Equal(t, errNotInteger, err)
+}
+
+func TestZUnion(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+
+	store := models.ZStore{Keys: []string{"a", "b"}, Weights: []float64{1, 2}}
+	mock.ExpectEval(zcombineScript, []string{zcombineKey}, "ZUNIONSTORE", 2, "a", "b", "WEIGHTS", 1.0, 2.0, "AGGREGATE", "sum").
+		SetVal([]interface{}{"ivan", "1", "petr", "4.5"})
+
+	res, err := client.ZUnion(context.Background(), store)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ZMember{{Member: "ivan", Score: 1}, {Member: "petr", Score: 4.5}}, res)
+
+	_, err = client.ZInter(context.Background(), models.ZStore{Keys: []string{"a"}, Aggregate: "avg"})
+	assert.Equal(t, errAggregate, err)
+}
+
+func TestXAdd(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+
+	maxLen := int64(10)
+	mock.ExpectXAdd(&redis.XAddArgs{
+		Stream: "events",
+		MaxLen: 10,
+		ID:     "*",
+		Values: []string{"age", `{"Dtype":"int","Data":"25"}`, "name", `{"Dtype":"string","Data":"ivan"}`},
+	}).SetVal("1609019628218-0")
+	expectVersion(mock, "events").SetVal(int64(1))
+	// go-redis can't send MINID, so that the command with the same length and name is expected
+	minID := []interface{}{"xadd", "events", "minid", "5", "6-1", "name", `{"Dtype":"string","Data":"petr"}`}
+	mock.CustomMatch(func(expected, actual []interface{}) error {
+		if !reflect.DeepEqual(minID, actual) {
+			return fmt.Errorf("Unexpected command %v", actual)
+		}
+		return nil
+	}).ExpectXAdd(&redis.XAddArgs{Stream: "events", MaxLen: 1, ID: "6-1", Values: []string{"name", "petr"}}).SetVal("6-1")
+	expectVersion(mock, "events").SetVal(int64(2))
+
+	id, err := client.XAdd(context.Background(), "events", "", map[string]interface{}{"name": "ivan", "age": 25}, models.XTrim{MaxLen: &maxLen})
+	assert.NoError(t, err)
+	assert.Equal(t, "1609019628218-0", id)
+
+	id, err = client.XAdd(context.Background(), "events", "6-1", map[string]interface{}{"name": "petr"}, models.XTrim{MinID: "5"})
+	assert.NoError(t, err)
+	assert.Equal(t, "6-1", id)
+
+	_, err = client.XAdd(context.Background(), "events", "", nil, models.XTrim{})
+	assert.Error(t, err)
+	_, err = client.XAdd(context.Background(), "events", "", map[string]interface{}{"name": "ivan"}, models.XTrim{MaxLen: &maxLen, MinID: "5"})
+	assert.Equal(t, errXTrimMaxLenMinID, err)
+}
+
+func TestXRange(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+
+	messages := []redis.XMessage{{ID: "5-1", Values: map[string]interface{}{"name": `{"Dtype":"string","Data":"ivan"}`, "raw": "value"}}}
+	mock.ExpectXRangeN("events", "5-1", "+", 1).SetVal(messages)
+	mock.ExpectXRevRange("events", "5-18446744073709551614", "-").SetVal([]redis.XMessage{})
+
+	res, err := client.XRange(context.Background(), "events", models.XRangeQuery{Start: "(5-0", Count: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.StreamEntry{{ID: "5-1", Fields: map[string]interface{}{"name": "ivan", "raw": "value"}}}, res)
+
+	res, err = client.XRevRange(context.Background(), "events", models.XRangeQuery{Start: "(5"})
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+	// nothing is less than 0-0
+	res, err = client.XRange(context.Background(), "events", models.XRangeQuery{End: "(0-0"})
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+	_, err = client.XRange(context.Background(), "events", models.XRangeQuery{Start: "a"})
+	assert.Equal(t, errStreamID, err)
+}
+
+func TestXAutoClaimResult(t *testing.T) {
+	res, err := xautoclaimResult([]interface{}{"5-2", []interface{}{
+		[]interface{}{"5-0", []interface{}{"name", `{"Dtype":"string","Data":"ivan"}`}},
+		nil,
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.XAutoClaimResult{
+		Next:    "5-2",
+		Entries: []models.StreamEntry{{ID: "5-0", Fields: map[string]interface{}{"name": "ivan"}}},
+	}, res)
+
+	_, err = xautoclaimResult([]interface{}{"0-0"})
+	assert.Error(t, err)
+}
+
+func TestPublish(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+
+	mock.ExpectPublish("news", "hello").SetVal(2)
+
+	receivers, err := client.Publish(context.Background(), "news", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), receivers)
+
+	_, err = client.Publish(context.Background(), "", "hello")
+	assert.Error(t, err)
+	assert.Error(t, client.SetPubSubBuffer(-1))
+	_, err = client.Subscribe(context.Background(), []string{""}, nil)
+	assert.Error(t, err)
+}
+
+func TestNotifyKeyspaceEvents(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+
+	mock.ExpectConfigSet("notify-keyspace-events", "KEA").SetVal("OK")
+	mock.ExpectConfigGet("notify-keyspace-events").SetVal([]interface{}{"notify-keyspace-events", "AKE"})
+
+	assert.NoError(t, client.SetNotifyKeyspaceEvents(context.Background(), "KEA"))
+	value, err := client.NotifyKeyspaceEvents(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "AKE", value)
+
+	assert.Error(t, client.SetNotifyKeyspaceEvents(context.Background(), "KEq"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIncrBy(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+
+	mock.ExpectIncrBy("counter", 5).SetVal(5)
+	expectVersion(mock, "counter").SetVal(int64(1))
+	res, err := client.IncrBy(context.Background(), "counter", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), res)
+
+	// version is not changed by the failed increment
+	mock.ExpectIncrByFloat("name", 1.5).SetErr(errNotFloat)
+	_, err = client.IncrByFloat(context.Background(), "name", 1.5)
+	assert.True(t, IsNotNumber(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStringOperations(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+	ctx := context.Background()
+
+	opts := models.SetOptions{NX: true, Expiration: models.Expiration{TTL: models.TTL(time.Minute)}}
+	mock.ExpectEval(setScript, versionKeys("lock"), "1", "0", "", "NX", "PX", int64(60000)).
+		SetVal("OK")
+	res, err := client.SetArgs(ctx, "lock", "1", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", res)
+
+	mock.ExpectEval(setScript, versionKeys("lock"), "2", "1", "5000").RedisNil()
+	_, err = client.SetArgs(ctx, "lock", "2", models.SetOptions{Get: true, PxAt: 5000})
+	assert.Equal(t, redis.Nil, err)
+
+	_, err = client.SetArgs(ctx, "lock", "2", models.SetOptions{NX: true, XX: true})
+	assert.Equal(t, errSyntax, err)
+
+	mock.ExpectEval(getExScript, versionKeys("lock"), "PERSIST").SetVal("1")
+	res, err = client.GetEx(ctx, "lock", models.GetExOptions{Persist: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", res)
+
+	mock.ExpectEval(getDelScript, versionKeys("lock")).RedisNil()
+	_, err = client.GetDel(ctx, "lock")
+	assert.Equal(t, redis.Nil, err)
+
+	// version is incremented even if there was no previous value
+	mock.ExpectGetSet("name", "ivan").RedisNil()
+	expectVersion(mock, "name").SetVal(int64(1))
+	_, err = client.GetSet(ctx, "name", "ivan")
+	assert.Equal(t, redis.Nil, err)
+
+	mock.ExpectAppend("name", "!").SetVal(5)
+	expectVersion(mock, "name").SetVal(int64(2))
+	length, err := client.Append(ctx, "name", "!")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), length)
+
+	mock.ExpectSetRange("name", 4, "?").SetVal(5)
+	expectVersion(mock, "name").SetVal(int64(3))
+	length, err = client.SetRange(ctx, "name", 4, "?")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), length)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListOperations(t *testing.T) {
+	client, mock := newRedisMock()
+	ctx := context.Background()
+
+	encoded := []string{`{"Dtype":"int64","Data":"1"}`, `{"Dtype":"string","Data":"ivan"}`}
+	mock.ExpectLPush("list", encoded).SetVal(2)
+	expectVersion(mock, "list").SetVal(int64(1))
+	length, err := client.LPush(ctx, "list", []interface{}{int64(1), "ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), length)
+
+	mock.ExpectEval(popScript, versionKeys("list"), "RPOP", int64(2)).
+		SetVal([]interface{}{encoded[0], encoded[1]})
+	popped, err := client.RPop(ctx, "list", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(1), "ivan"}, popped)
+
+	mock.ExpectEval(popScript, versionKeys("list"), "LPOP", int64(1)).RedisNil()
+	_, err = client.LPop(ctx, "list", 1)
+	assert.Equal(t, redis.Nil, err)
+
+	mock.ExpectLInsert("list", "before", encoded[1], encoded[0]).SetVal(-1)
+	expectVersion(mock, "list").SetVal(int64(2))
+	length, err = client.LInsert(ctx, "list", true, "ivan", int64(1))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), length)
+
+	mock.ExpectLPos("list", encoded[1], redis.LPosArgs{Rank: -1}).RedisNil()
+	rank := int64(-1)
+	positions, err := client.LPos(ctx, "list", "ivan", models.LPosArgs{Rank: &rank})
+	assert.NoError(t, err)
+	assert.Empty(t, positions)
+
+	// COUNT 0 returns all matches, RANK 0 is rejected before the command is sent
+	count := int64(0)
+	mock.ExpectLPosCount("list", encoded[1], 0, redis.LPosArgs{Rank: 1}).SetVal([]int64{1, 3})
+	positions, err = client.LPos(ctx, "list", "ivan", models.LPosArgs{Count: &count})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 3}, positions)
+
+	_, err = client.LPos(ctx, "list", "ivan", models.LPosArgs{Rank: &count})
+	assert.Equal(t, errRankZero, err)
+
+	mock.ExpectEval(lmoveScript, versionKeys("a", "b"), "RPOP", "LPUSH").
+		SetVal(encoded[0])
+	el, err := client.LMove(ctx, "a", "b", ListRight, ListLeft)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), el)
+
+	mock.ExpectClientID().SetVal(1)
+	mock.ExpectBLPop(time.Second, "a", "b").SetVal([]string{"b", encoded[1]})
+	expectVersion(mock, "b").SetVal(int64(3))
+	res, err := client.BLPop(ctx, []string{"a", "b"}, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.BPopResult{Key: "b", Value: "ivan"}, res)
+
+	mock.ExpectClientID().SetVal(1)
+	mock.ExpectBRPop(time.Second, "a").RedisNil()
+	_, err = client.BRPop(ctx, []string{"a"}, time.Second)
+	assert.Equal(t, redis.Nil, err)
+
+	mock.ExpectClientID().SetVal(1)
+	mock.ExpectBRPopLPush("a", "b", 0).SetVal(encoded[0])
+	expectVersion(mock, "a").SetVal(int64(1))
+	expectVersion(mock, "b").SetVal(int64(4))
+	el, err = client.BLMove(ctx, "a", "b", ListRight, ListLeft, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), el)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBlockingPopDisconnect(t *testing.T) {
+	client, mock := newRedisMock()
+	encoded := `{"Dtype":"string","Data":"ivan"}`
+
+	// the client is disconnected while redis pops the element, so that it is pushed back to the same end
+	ctx, cancel := context.WithCancel(context.Background())
+	mock.ExpectClientID().SetVal(1)
+	mock.CustomMatch(func(expected, actual []interface{}) error {
+		cancel()
+		return nil
+	}).ExpectBLPop(0, "a").SetVal([]string{"a", encoded})
+	mock.ExpectLPush("a", encoded).SetVal(1)
+	expectVersion(mock, "a").SetVal(int64(2))
+	_, err := client.BLPop(ctx, []string{"a"}, 0)
+	assert.Equal(t, context.Canceled, err)
+
+	// the element is moved back if the version can't be incremented
+	mock.ExpectClientID().SetVal(1)
+	mock.ExpectBRPop(0, "a").SetVal([]string{"a", encoded})
+	expectVersion(mock, "a").SetErr(errors.New("LOADING Redis is loading the dataset in memory"))
+	mock.ExpectRPush("a", encoded).SetVal(1)
+	expectVersion(mock, "a").SetVal(int64(3))
+	_, err = client.BRPop(context.Background(), []string{"a"}, 0)
+	assert.EqualError(t, err, "LOADING Redis is loading the dataset in memory")
+
+	ctx, cancel = context.WithCancel(context.Background())
+	mock.ExpectClientID().SetVal(1)
+	mock.CustomMatch(func(expected, actual []interface{}) error {
+		cancel()
+		return nil
+	}).ExpectBRPopLPush("a", "b", 0).SetVal(encoded)
+	mock.ExpectEval(lmoveScript, versionKeys("b", "a"), "LPOP", "RPUSH").SetVal(encoded)
+	_, err = client.BLMove(ctx, "a", "b", ListRight, ListLeft, 0)
+	assert.Equal(t, context.Canceled, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHashOperations(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+	ctx := context.Background()
+
+	mock.ExpectHDel("user", "name", "age").SetVal(2)
+	expectVersion(mock, "user").SetVal(int64(2))
+	removed, err := client.HDel(ctx, "user", []string{"name", "age"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+
+	mock.ExpectHMGet("user", "role", "missing").SetVal([]interface{}{"admin", nil})
+	values, err := client.HMGet(ctx, "user", []string{"role", "missing"})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"admin", nil}, values)
+
+	mock.ExpectHSetNX("user", "role", "guest").SetVal(false)
+	expectVersion(mock, "user").SetVal(int64(3))
+	set, err := client.HSetNX(ctx, "user", "role", "guest")
+	assert.NoError(t, err)
+	assert.False(t, set)
+
+	// EXEC does not roll back the transaction, so that the version is incremented after the failed write
+	mock.ExpectEval(hincrScript, []string{"user"}, "HINCRBY", "visits", int64(2), "").SetErr(errHashNotInteger)
+	expectVersion(mock, "user").SetVal(int64(4))
+	_, err = client.HIncrBy(ctx, "user", "visits", 2, true)
+	assert.True(t, IsNotNumber(err))
+
+	// typed values are incremented by the script, which returns the result as text
+	mock.ExpectEval(hincrScript, []string{"user"}, "HINCRBYFLOAT", "balance", 1.5, "typed").SetVal("3")
+	expectVersion(mock, "user").SetVal(int64(5))
+	balance, err := client.HIncrByFloat(ctx, "user", "balance", 1.5, false)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(3), balance)
+
+	_, err = client.HDel(ctx, "user", nil)
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJSONOperations(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+	ctx := context.Background()
+
+	// arguments are rejected before anything is sent to RedisJSON
+	_, err := client.JSONSet(ctx, "doc", "$", `{"a":1}`, models.JSONSetOptions{NX: true, XX: true})
+	assert.Equal(t, errSyntax, err)
+
+	_, err = client.JSONSet(ctx, "doc", "$", `{"a":`, models.JSONSetOptions{})
+	assert.True(t, IsInvalidArgument(err))
+
+	_, err = client.JSONGet(ctx, "doc", []string{"$.a[?(@.b)]"})
+	assert.True(t, IsInvalidArgument(err))
+
+	_, err = client.JSONArrAppend(ctx, "doc", "$.a", nil)
+	assert.Error(t, err)
+
+	_, err = client.JSONNumIncrBy(ctx, "doc", "$.a", "one")
+	assert.Equal(t, errJSONNumber, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
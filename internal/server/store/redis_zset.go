@@ -0,0 +1,449 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// Kinds of ranges of ZRange
+const (
+	ZRangeByIndex = "index"
+	ZRangeByScore = "score"
+	ZRangeByLex   = "lex"
+)
+
+var (
+	errZAddNXXX     = errors.New("ERR XX and NX options at the same time are not compatible")
+	errZAddGTLTNX   = errors.New("ERR GT, LT, and/or NX options at the same time are not compatible")
+	errZAddIncr     = errors.New("ERR INCR option supports a single increment-element pair")
+	errNotFloat     = errors.New("ERR value is not a valid float")
+	errNaN          = errors.New("ERR resulting score is not a number (NaN)")
+	errMinMaxFloat  = errors.New("ERR min or max is not a float")
+	errMinMaxLex    = errors.New("ERR min or max not valid string range item")
+	errZRangeLimit  = errors.New("ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX")
+	errZRangeBy     = errors.New("ERR syntax error, range has to be by index, score or lex")
+	errNotInteger   = errors.New("ERR value is not an integer or out of range")
+	errNegative     = errors.New("ERR value is out of range, must be positive")
+	errWeights      = errors.New("ERR syntax error, number of weights has to be equal to number of keys")
+	errAggregate    = errors.New("ERR syntax error, aggregate has to be sum, min or max")
+	errZStoreNoKeys = errors.New("ERR at least 1 input key is needed for ZUNION/ZINTER")
+)
+
+// zcombineScript - redis 6.0 has no ZUNION and ZINTER, so the result is stored to temporary key and read
+// within the script. ARGV are ZUNIONSTORE or ZINTERSTORE followed by its arguments without destination.
+const zcombineScript = `redis.call(ARGV[1], KEYS[1], unpack(ARGV, 2))
+local res = redis.call('ZRANGE', KEYS[1], 0, -1, 'WITHSCORES')
+redis.call('DEL', KEYS[1])
+return res`
+
+// zcombineKey - temporary key used by zcombineScript
+const zcombineKey = "zset:combine:tmp"
+
+// ZAdd - adds members to the sorted set or updates their scores, returns number of added members
+// or number of changed members with CH option
+func (r *Redis) ZAdd(ctx context.Context, key string, members []models.ZMember, opts models.ZAddOptions) (int64, error) {
+	if err := checkZAdd(key, members, opts, false); err != nil {
+		return 0, err
+	}
+
+	cmd := redis.NewIntCmd(ctx, zaddArgs(key, members, opts, false)...)
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		return c.Process(ctx, cmd)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// ZAddIncr - increments score of the member like ZADD with INCR, returns the new score.
+// redis.Nil is returned when the member is not updated because of the options.
+func (r *Redis) ZAddIncr(ctx context.Context, key string, member models.ZMember, opts models.ZAddOptions) (float64, error) {
+	members := []models.ZMember{member}
+	if err := checkZAdd(key, members, opts, true); err != nil {
+		return 0, err
+	}
+
+	cmd := redis.NewFloatCmd(ctx, zaddArgs(key, members, opts, true)...)
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		return c.Process(ctx, cmd)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// ZRem - removes members from the sorted set, returns number of removed members
+func (r *Redis) ZRem(ctx context.Context, key string, members []string) (int64, error) {
+	if key == "" || len(members) == 0 {
+		return 0, fmt.Errorf("Empty key or members")
+	}
+
+	var cmd *redis.IntCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.ZRem(ctx, key, stringArgs(members)...)
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return cmd.Val(), nil
+}
+
+// ZScore - score of the member, redis.Nil is returned if there is no such member
+func (r *Redis) ZScore(ctx context.Context, key, member string) (float64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	res, err := r.client.ZScore(ctx, key, member).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	return res, nil
+}
+
+// ZRank - 0-based position of the member ordered by score, redis.Nil is returned if there is no such member
+func (r *Redis) ZRank(ctx context.Context, key, member string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	res, err := r.client.ZRank(ctx, key, member).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	return res, nil
+}
+
+// ZRange - members with scores between start and stop by index, score or lex
+func (r *Redis) ZRange(ctx context.Context, key string, query models.ZRangeQuery) ([]models.ZMember, error) {
+	if key == "" {
+		return nil, fmt.Errorf("Empty key")
+	}
+
+	by, err := checkZRange(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []redis.Z
+	switch by {
+	case ZRangeByIndex:
+		start, _ := strconv.ParseInt(query.Start, 10, 64)
+		stop, _ := strconv.ParseInt(query.Stop, 10, 64)
+		if query.Rev {
+			res, err = r.client.ZRevRangeWithScores(ctx, key, start, stop).Result()
+		} else {
+			res, err = r.client.ZRangeWithScores(ctx, key, start, stop).Result()
+		}
+	case ZRangeByScore:
+		if query.Rev {
+			res, err = r.client.ZRevRangeByScoreWithScores(ctx, key, zrangeBy(query.Stop, query.Start, query)).Result()
+		} else {
+			res, err = r.client.ZRangeByScoreWithScores(ctx, key, zrangeBy(query.Start, query.Stop, query)).Result()
+		}
+	case ZRangeByLex:
+		return r.zrangeByLex(ctx, key, query)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return zmembers(res), nil
+}
+
+// zrangeByLex - ZRANGEBYLEX has no WITHSCORES, so scores are requested separately
+func (r *Redis) zrangeByLex(ctx context.Context, key string, query models.ZRangeQuery) ([]models.ZMember, error) {
+	var members []string
+	var err error
+	if query.Rev {
+		members, err = r.client.ZRevRangeByLex(ctx, key, zrangeBy(query.Stop, query.Start, query)).Result()
+	} else {
+		members, err = r.client.ZRangeByLex(ctx, key, zrangeBy(query.Start, query.Stop, query)).Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]models.ZMember, len(members))
+	if len(members) == 0 {
+		return res, nil
+	}
+
+	cmds, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, member := range members {
+			pipe.ZScore(ctx, key, member)
+		}
+		return nil
+	})
+	// member may be removed between the commands
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	for i, member := range members {
+		res[i] = models.ZMember{
+			Member: member,
+			Score:  cmds[i].(*redis.FloatCmd).Val(),
+		}
+	}
+
+	return res, nil
+}
+
+// ZPopMin - removes and returns count members with the lowest scores
+func (r *Redis) ZPopMin(ctx context.Context, key string, count int64) ([]models.ZMember, error) {
+	if err := checkZPop(key, count); err != nil {
+		return nil, err
+	}
+
+	var cmd *redis.ZSliceCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.ZPopMin(ctx, key, count)
+		return cmd.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return zmembers(cmd.Val()), nil
+}
+
+// ZPopMax - removes and returns count members with the highest scores
+func (r *Redis) ZPopMax(ctx context.Context, key string, count int64) ([]models.ZMember, error) {
+	if err := checkZPop(key, count); err != nil {
+		return nil, err
+	}
+
+	var cmd *redis.ZSliceCmd
+	err := r.write(ctx, key, models.Expiration{}, func(c redis.Pipeliner) error {
+		cmd = c.ZPopMax(ctx, key, count)
+		return cmd.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return zmembers(cmd.Val()), nil
+}
+
+// ZUnion - members of all sorted sets, scores are aggregated
+func (r *Redis) ZUnion(ctx context.Context, store models.ZStore) ([]models.ZMember, error) {
+	return r.zcombine(ctx, "ZUNIONSTORE", store)
+}
+
+// ZInter - members which belong to all sorted sets, scores are aggregated
+func (r *Redis) ZInter(ctx context.Context, store models.ZStore) ([]models.ZMember, error) {
+	return r.zcombine(ctx, "ZINTERSTORE", store)
+}
+
+func (r *Redis) zcombine(ctx context.Context, command string, store models.ZStore) ([]models.ZMember, error) {
+	aggregate, err := checkZStore(store)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.client.Eval(ctx, zcombineScript, []string{zcombineKey}, zstoreArgs(command, store, aggregate)...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	pairs, ok := res.([]interface{})
+	if !ok || len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("Unexpected reply of %s: %v", command, res)
+	}
+
+	members := make([]models.ZMember, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		member, _ := pairs[i].(string)
+		score, _ := pairs[i+1].(string)
+
+		value, err := strconv.ParseFloat(score, 64)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, models.ZMember{Member: member, Score: value})
+	}
+
+	return members, nil
+}
+
+// checkZAdd - the same restrictions of options as in redis
+func checkZAdd(key string, members []models.ZMember, opts models.ZAddOptions, incr bool) error {
+	if key == "" || len(members) == 0 {
+		return fmt.Errorf("Empty key or members")
+	}
+
+	switch {
+	case opts.NX && opts.XX:
+		return errZAddNXXX
+	case (opts.GT && opts.LT) || ((opts.GT || opts.LT) && opts.NX):
+		return errZAddGTLTNX
+	case incr && len(members) != 1:
+		return errZAddIncr
+	}
+
+	for _, member := range members {
+		if math.IsNaN(member.Score) {
+			return errNotFloat
+		}
+	}
+
+	return nil
+}
+
+// zaddArgs - ZADD key [INCR] [NX|XX] [GT|LT] [CH] score member [score member ...], options are in the same
+// order as go-redis sends them
+func zaddArgs(key string, members []models.ZMember, opts models.ZAddOptions, incr bool) []interface{} {
+	args := []interface{}{"zadd", key}
+	if incr {
+		args = append(args, "incr")
+	}
+	switch {
+	case opts.NX:
+		args = append(args, "nx")
+	case opts.XX:
+		args = append(args, "xx")
+	}
+	switch {
+	case opts.GT:
+		args = append(args, "gt")
+	case opts.LT:
+		args = append(args, "lt")
+	}
+	if opts.CH {
+		args = append(args, "ch")
+	}
+
+	for _, member := range members {
+		args = append(args, member.Score, member.Member)
+	}
+
+	return args
+}
+
+// checkZRange - validates bounds of the range, returns its kind
+func checkZRange(query models.ZRangeQuery) (string, error) {
+	by := strings.ToLower(query.By)
+	if by == "" {
+		by = ZRangeByIndex
+	}
+
+	switch by {
+	case ZRangeByIndex:
+		if query.Offset != 0 || query.Count != 0 {
+			return "", errZRangeLimit
+		}
+		for _, bound := range []string{query.Start, query.Stop} {
+			if _, err := strconv.ParseInt(bound, 10, 64); err != nil {
+				return "", errNotInteger
+			}
+		}
+	case ZRangeByScore:
+		if _, err := parseScoreRange(query.Start, query.Stop); err != nil {
+			return "", err
+		}
+	case ZRangeByLex:
+		if _, err := parseLexRange(query.Start, query.Stop); err != nil {
+			return "", err
+		}
+	default:
+		return "", errZRangeBy
+	}
+
+	return by, nil
+}
+
+// zrangeBy - LIMIT with zero count returns nothing in redis, so that zero count means no limit here
+func zrangeBy(min, max string, query models.ZRangeQuery) *redis.ZRangeBy {
+	opt := &redis.ZRangeBy{
+		Min:    min,
+		Max:    max,
+		Offset: query.Offset,
+		Count:  query.Count,
+	}
+	if opt.Offset != 0 && opt.Count == 0 {
+		opt.Count = -1
+	}
+
+	return opt
+}
+
+func checkZPop(key string, count int64) error {
+	if key == "" {
+		return fmt.Errorf("Empty key")
+	}
+	if count < 0 {
+		return errNegative
+	}
+
+	return nil
+}
+
+// checkZStore - validates weights and aggregate, returns aggregate in lower case
+func checkZStore(store models.ZStore) (string, error) {
+	if len(store.Keys) == 0 {
+		return "", errZStoreNoKeys
+	}
+	if err := checkKeys(store.Keys); err != nil {
+		return "", err
+	}
+
+	if len(store.Weights) != 0 && len(store.Weights) != len(store.Keys) {
+		return "", errWeights
+	}
+
+	aggregate := strings.ToLower(store.Aggregate)
+	switch aggregate {
+	case "":
+		return "sum", nil
+	case "sum", "min", "max":
+		return aggregate, nil
+	}
+
+	return "", errAggregate
+}
+
+// zstoreArgs - arguments of ZUNIONSTORE and ZINTERSTORE after destination
+func zstoreArgs(command string, store models.ZStore, aggregate string) []interface{} {
+	args := []interface{}{command, len(store.Keys)}
+	for _, key := range store.Keys {
+		args = append(args, key)
+	}
+
+	if len(store.Weights) != 0 {
+		args = append(args, "WEIGHTS")
+		for _, weight := range store.Weights {
+			args = append(args, weight)
+		}
+	}
+
+	return append(args, "AGGREGATE", aggregate)
+}
+
+func zmembers(values []redis.Z) []models.ZMember {
+	res := make([]models.ZMember, len(values))
+	for i, z := range values {
+		res[i] = models.ZMember{
+			Member: fmt.Sprint(z.Member),
+			Score:  z.Score,
+		}
+	}
+
+	return res
+}
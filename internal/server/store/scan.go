@@ -0,0 +1,367 @@
+package store
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// defaultScanCount - number of elements examined by one call of SCAN when COUNT is not given, the same as in redis
+const defaultScanCount = 10
+
+// scanHash - position of the element in the order of iteration. Native engine has no buckets of hash table
+// to iterate like redis, so that elements are visited in the order of their hashes and the cursor is the hash
+// of the next element: it does not depend on elements added or removed between calls, and every element
+// present from the start to the end of the iteration is returned at least once.
+func scanHash(name string) uint64 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return uint64(h.Sum32())
+}
+
+// scanCandidate - name visited by SCAN together with its hash
+type scanCandidate struct {
+	hash uint64
+	name string
+}
+
+// less - order of iteration, names with the same hash are ordered by name
+func (c scanCandidate) less(o scanCandidate) bool {
+	if c.hash != o.hash {
+		return c.hash < o.hash
+	}
+	return c.name < o.name
+}
+
+// scanHeap - max-heap of the candidates of the page, the top is the last candidate
+type scanHeap []scanCandidate
+
+func (h scanHeap) Len() int            { return len(h) }
+func (h scanHeap) Less(i, j int) bool  { return h[j].less(h[i]) }
+func (h scanHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *scanHeap) Push(x interface{}) { *h = append(*h, x.(scanCandidate)) }
+func (h *scanHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// scanPage - up to count names starting from the cursor and the cursor of the next page, 0 when the iteration
+// is complete. Names with the same hash are never split between pages, so the page may be a bit longer than count.
+// Names are visited by each without copying or sorting all of them: only count candidates are kept while the
+// names are walked, and the second walk adds names with the hash of the last candidate and finds the next cursor.
+func scanPage(each func(visit func(name string)), cursor uint64, count int64) ([]string, uint64) {
+	h := scanHeap{}
+	each(func(name string) {
+		c := scanCandidate{hash: scanHash(name), name: name}
+		switch {
+		case c.hash < cursor:
+		case int64(len(h)) < count:
+			heap.Push(&h, c)
+		case c.less(h[0]):
+			h[0] = c
+			heap.Fix(&h, 0)
+		}
+	})
+	if len(h) == 0 {
+		return []string{}, 0
+	}
+
+	last := h[0].hash
+	page := make([]scanCandidate, 0, len(h))
+	for _, c := range h {
+		if c.hash != last {
+			page = append(page, c)
+		}
+	}
+
+	var next uint64
+	each(func(name string) {
+		hash := scanHash(name)
+		switch {
+		case hash == last:
+			page = append(page, scanCandidate{hash: hash, name: name})
+		case hash > last && (next == 0 || hash < next):
+			next = hash
+		}
+	})
+
+	sort.Slice(page, func(i, j int) bool {
+		return page[i].less(page[j])
+	})
+	res := make([]string, 0, len(page))
+	for _, c := range page {
+		res = append(res, c.name)
+	}
+
+	return res, next
+}
+
+// checkScanCount - COUNT has to be positive, 0 is replaced by the default value
+func checkScanCount(count int64) (int64, error) {
+	switch {
+	case count < 0:
+		return 0, errSyntax
+	case count == 0:
+		return defaultScanCount, nil
+	}
+
+	return count, nil
+}
+
+// Scan - page of keys starting from the cursor, 0 - from the beginning. Keys are filtered by pattern and
+// data type after count keys are examined, so that the page may be empty while the returned cursor is not 0.
+// The page is read from the index of keys ordered by scanHash, in the same order as scanPage visits names.
+func (n *Native) Scan(ctx context.Context, cursor uint64, match string, count int64, keyType string) ([]string, uint64, error) {
+	count, err := checkScanCount(count)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer n.lock(ctx)()
+
+	res := []string{}
+	var examined int64
+	var last float64
+	node := n.keys.first(func(node *skiplistNode) bool {
+		return node.score < float64(cursor)
+	})
+	// keys with the same hash are never split between pages
+	for node != nil && (examined < count || node.score == last) {
+		key := node.member
+		// the expired key is removed from the index by peek, so the next node is taken before
+		next := node.level[0].forward
+		if e := n.peek(key); e != nil {
+			examined++
+			last = node.score
+			if (match == "" || matchPattern(match, key)) && (keyType == "" || strings.EqualFold(e.typeName(), keyType)) {
+				res = append(res, key)
+			}
+		}
+		node = next
+	}
+	// the cursor is the hash of the next key which is not expired
+	for node != nil {
+		next := node.level[0].forward
+		if n.peek(node.member) != nil {
+			break
+		}
+		node = next
+	}
+
+	if node == nil {
+		return res, 0, nil
+	}
+
+	return res, uint64(node.score), nil
+}
+
+// GetKeysLimit - keys matching the pattern found by one walk of the keyspace, which stops after limit keys,
+// 0 - no limit. Keys are returned in the order of SCAN.
+func (n *Native) GetKeysLimit(ctx context.Context, pattern string, limit int) ([]string, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("Empty pattern")
+	}
+
+	defer n.lock(ctx)()
+
+	res := []string{}
+	for node := n.keys.header.level[0].forward; node != nil; {
+		key := node.member
+		next := node.level[0].forward
+		if n.peek(key) != nil && matchPattern(pattern, key) {
+			res = append(res, key)
+			if len(res) == limit {
+				break
+			}
+		}
+		node = next
+	}
+
+	return res, nil
+}
+
+// HScan - page of fields of the hash, the result holds field and value pairs
+func (n *Native) HScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if key == "" {
+		return nil, 0, fmt.Errorf("Empty key")
+	}
+
+	count, err := checkScanCount(count)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer n.lock(ctx)()
+
+	hash, err := n.hash(key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page, next := scanPage(func(visit func(string)) {
+		for field := range hash {
+			visit(field)
+		}
+	}, cursor, count)
+	res := []string{}
+	for _, field := range scanMatch(page, match) {
+		res = append(res, field, hash[field])
+	}
+
+	return res, next, nil
+}
+
+// SScan - page of members of the set
+func (n *Native) SScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if key == "" {
+		return nil, 0, fmt.Errorf("Empty key")
+	}
+
+	count, err := checkScanCount(count)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer n.lock(ctx)()
+
+	set, err := n.members(key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page, next := scanPage(func(visit func(string)) {
+		for member := range set {
+			visit(member)
+		}
+	}, cursor, count)
+	return scanMatch(page, match), next, nil
+}
+
+// ZScan - page of members of the sorted set, the result holds member and score pairs
+func (n *Native) ZScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if key == "" {
+		return nil, 0, fmt.Errorf("Empty key")
+	}
+
+	count, err := checkScanCount(count)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer n.lock(ctx)()
+
+	z, err := n.zset(key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page, next := scanPage(func(visit func(string)) {
+		for member := range z.dict {
+			visit(member)
+		}
+	}, cursor, count)
+	res := []string{}
+	for _, member := range scanMatch(page, match) {
+		res = append(res, member, strconv.FormatFloat(z.dict[member], 'g', -1, 64))
+	}
+
+	return res, next, nil
+}
+
+// scanMatch - names matching the pattern, all names if the pattern is empty
+func scanMatch(names []string, match string) []string {
+	if match == "" {
+		return names
+	}
+
+	res := []string{}
+	for _, name := range names {
+		if matchPattern(match, name) {
+			res = append(res, name)
+		}
+	}
+
+	return res
+}
+
+// Scan - SCAN of redis, TYPE requires redis 6.0
+func (r *Redis) Scan(ctx context.Context, cursor uint64, match string, count int64, keyType string) ([]string, uint64, error) {
+	if count < 0 {
+		return nil, 0, errSyntax
+	}
+
+	var cmd *redis.ScanCmd
+	if keyType == "" {
+		cmd = r.client.Scan(ctx, cursor, match, count)
+	} else {
+		// go-redis has no SCAN with TYPE
+		args := []interface{}{"scan", cursor}
+		if match != "" {
+			args = append(args, "match", match)
+		}
+		if count > 0 {
+			args = append(args, "count", count)
+		}
+		cmd = redis.NewScanCmd(ctx, r.client.Process, append(args, "type", keyType)...)
+		_ = r.client.Process(ctx, cmd)
+	}
+
+	keys, next, err := cmd.Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// versions of keys are not shown, see versionKey
+	res := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !strings.HasPrefix(key, versionPrefix) {
+			res = append(res, key)
+		}
+	}
+
+	return res, next, nil
+}
+
+// HScan ...
+func (r *Redis) HScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if key == "" {
+		return nil, 0, fmt.Errorf("Empty key")
+	}
+	if count < 0 {
+		return nil, 0, errSyntax
+	}
+
+	return r.client.HScan(ctx, key, cursor, match, count).Result()
+}
+
+// SScan ...
+func (r *Redis) SScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if key == "" {
+		return nil, 0, fmt.Errorf("Empty key")
+	}
+	if count < 0 {
+		return nil, 0, errSyntax
+	}
+
+	return r.client.SScan(ctx, key, cursor, match, count).Result()
+}
+
+// ZScan ...
+func (r *Redis) ZScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if key == "" {
+		return nil, 0, fmt.Errorf("Empty key")
+	}
+	if count < 0 {
+		return nil, 0, errSyntax
+	}
+
+	return r.client.ZScan(ctx, key, cursor, match, count).Result()
+}
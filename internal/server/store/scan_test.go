@@ -0,0 +1,270 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// scanAll - keys returned by all pages of SCAN, write is called after every page
+func scanAll(t *testing.T, client *Native, match string, count int64, keyType string, write func()) []string {
+	res := []string{}
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(context.Background(), cursor, match, count, keyType)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, int64(len(keys)), count+1)
+		res = append(res, keys...)
+
+		if next == 0 {
+			break
+		}
+		assert.Greater(t, next, cursor)
+		cursor = next
+		if write != nil {
+			write()
+		}
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// eachName - walks the names like the engine walks its keys
+func eachName(names []string) func(func(string)) {
+	return func(visit func(string)) {
+		for _, name := range names {
+			visit(name)
+		}
+	}
+}
+
+func TestScanPage(t *testing.T) {
+	names := []string{}
+	for i := 0; i < 100; i++ {
+		names = append(names, fmt.Sprintf("key:%d", i))
+	}
+
+	seen := map[string]int{}
+	var cursor uint64
+	pages := 0
+	for {
+		page, next := scanPage(eachName(names), cursor, 7)
+		pages++
+		assert.LessOrEqual(t, len(page), 7)
+		for i, name := range page {
+			seen[name]++
+			assert.GreaterOrEqual(t, scanHash(name), cursor)
+			if i > 0 {
+				assert.Less(t, scanHash(page[i-1]), scanHash(name))
+			}
+			if next != 0 {
+				assert.Less(t, scanHash(name), next)
+			}
+		}
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+
+	assert.Len(t, seen, len(names))
+	for name, times := range seen {
+		assert.Equal(t, 1, times, name)
+	}
+	assert.GreaterOrEqual(t, pages, 100/8)
+
+	page, next := scanPage(eachName(nil), 0, 10)
+	assert.Empty(t, page)
+	assert.Equal(t, uint64(0), next)
+}
+
+func TestNativeScan(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	want := []string{}
+	for i := 0; i < 30; i++ {
+		key := fmt.Sprintf("user:%02d", i)
+		_, err := client.SetString(ctx, key, "ivan", models.Expiration{})
+		assert.NoError(t, err)
+		want = append(want, key)
+	}
+	assert.NoError(t, client.SetHash(ctx, "hash", map[string]interface{}{"role": "admin", "age": 25}, models.Expiration{}))
+	_, err := client.SAdd(ctx, "roles", []string{"admin", "user", "guest"})
+	assert.NoError(t, err)
+	_, err = client.ZAdd(ctx, "rating", []models.ZMember{{Member: "ivan", Score: 1.5}, {Member: "petr", Score: 2}}, models.ZAddOptions{})
+	assert.NoError(t, err)
+
+	type testCase struct {
+		name    string
+		match   string
+		count   int64
+		keyType string
+		want    []string
+	}
+
+	tCases := []testCase{
+		{
+			name:  "All keys",
+			count: 4,
+			want:  append(append([]string{}, want...), "hash", "rating", "roles"),
+		},
+		{
+			name:  "Match",
+			match: "user:1*",
+			want:  want[10:20],
+		},
+		{
+			name:    "Type",
+			count:   100,
+			keyType: "ZSET",
+			want:    []string{"rating"},
+		},
+		{
+			name:    "Match and type",
+			match:   "user:*",
+			keyType: "hash",
+			want:    []string{},
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			count := tc.count
+			if count == 0 {
+				count = defaultScanCount
+			}
+			keys := scanAll(t, client, tc.match, count, tc.keyType, nil)
+			sort.Strings(tc.want)
+			assert.Equal(t, tc.want, keys)
+		})
+	}
+
+	_, _, err = client.Scan(ctx, 0, "", -1, "")
+	assert.Equal(t, errSyntax, err)
+
+	fields, next, err := client.HScan(ctx, "hash", 0, "r*", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), next)
+	assert.Equal(t, []string{"role", "admin"}, fields)
+
+	members, _, err := client.SScan(ctx, "roles", 0, "", 10)
+	assert.NoError(t, err)
+	sort.Strings(members)
+	assert.Equal(t, []string{"admin", "guest", "user"}, members)
+
+	scores, _, err := client.ZScan(ctx, "rating", 0, "i*", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ivan", "1.5"}, scores)
+
+	members, next, err = client.SScan(ctx, "missing", 0, "", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, members)
+	assert.Equal(t, uint64(0), next)
+
+	_, _, err = client.HScan(ctx, "roles", 0, "", 10)
+	assert.Equal(t, ErrWrongType, err)
+}
+
+func TestNativeScanWrites(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	for i := 0; i < 50; i++ {
+		_, err := client.SetString(ctx, fmt.Sprintf("stable:%d", i), "value", models.Expiration{})
+		assert.NoError(t, err)
+		_, err = client.SetString(ctx, fmt.Sprintf("removed:%d", i), "value", models.Expiration{})
+		assert.NoError(t, err)
+	}
+
+	// keys existing during the whole iteration are returned despite of keys added and removed between pages
+	i := 0
+	keys := scanAll(t, client, "stable:*", 5, "", func() {
+		_, err := client.Delete(ctx, fmt.Sprintf("removed:%d", i))
+		assert.NoError(t, err)
+		_, err = client.SetString(ctx, fmt.Sprintf("added:%d", i), "value", models.Expiration{})
+		assert.NoError(t, err)
+		i++
+	})
+
+	assert.Len(t, keys, 50)
+}
+
+func TestNativeScanIndex(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	live := []string{}
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key:%d", i)
+		_, err := client.SetString(ctx, key, "value", models.Expiration{})
+		assert.NoError(t, err)
+
+		switch i % 4 {
+		case 1:
+			_, err = client.Delete(ctx, key)
+			assert.NoError(t, err)
+		case 2:
+			client.data[key].expireAt = nowMs() - 1
+		default:
+			live = append(live, key)
+		}
+	}
+
+	// pages read from the index are the same as pages of scanPage walking all keys
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, "", 7, "")
+		assert.NoError(t, err)
+		page, want := scanPage(eachName(live), cursor, 7)
+		assert.Equal(t, page, keys)
+		assert.Equal(t, want, next)
+
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+
+	// deleted and expired keys are removed from the index
+	assert.Equal(t, int64(len(live)), client.keys.length)
+	assert.Len(t, client.data, len(live))
+
+	keys, err := client.GetKeysLimit(ctx, "key:*", 10)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 10)
+
+	keys, err = client.GetKeys(ctx, "key:*")
+	assert.NoError(t, err)
+	sort.Strings(keys)
+	sort.Strings(live)
+	assert.Equal(t, live, keys)
+}
+
+func TestScan(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+	ctx := context.Background()
+
+	// versions of keys are not shown
+	mock.ExpectScan(0, "user:*", 10).SetVal([]string{"user:1", versionKey("user:1"), "user:2"}, 17)
+	keys, next, err := client.Scan(ctx, 0, "user:*", 10, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user:1", "user:2"}, keys)
+	assert.Equal(t, uint64(17), next)
+
+	_, _, err = client.Scan(ctx, 0, "", -1, "")
+	assert.Equal(t, errSyntax, err)
+
+	_, _, err = client.HScan(ctx, "", 0, "", 0)
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,222 @@
+package store
+
+import "math/rand"
+
+const (
+	// skiplistMaxLevel - enough for 2^64 elements with skiplistP = 1/4
+	skiplistMaxLevel = 32
+	// skiplistP - probability of the node to have the next level
+	skiplistP = 0.25
+)
+
+// skiplist - members of sorted set ordered by score and then by member, the same as zskiplist of redis.
+// Spans of links are used to find rank of the member and member by rank in logarithmic time.
+type skiplist struct {
+	header *skiplistNode
+	tail   *skiplistNode
+	length int64
+	level  int
+}
+
+type skiplistNode struct {
+	member   string
+	score    float64
+	backward *skiplistNode
+	level    []skiplistLevel
+}
+
+type skiplistLevel struct {
+	forward *skiplistNode
+	// number of nodes between this node and the forward one
+	span int64
+}
+
+// newSkiplist - helper to init skiplist
+func newSkiplist() *skiplist {
+	return &skiplist{
+		header: &skiplistNode{level: make([]skiplistLevel, skiplistMaxLevel)},
+		level:  1,
+	}
+}
+
+func randomLevel() int {
+	level := 1
+	for level < skiplistMaxLevel && rand.Float64() < skiplistP {
+		level++
+	}
+
+	return level
+}
+
+// less - order of nodes: by score, nodes with equal scores are ordered by member
+func (node *skiplistNode) less(score float64, member string) bool {
+	return node.score < score || (node.score == score && node.member < member)
+}
+
+// insert - adds the member, it must not be in the list
+func (sl *skiplist) insert(score float64, member string) *skiplistNode {
+	update := make([]*skiplistNode, skiplistMaxLevel)
+	rank := make([]int64, skiplistMaxLevel)
+
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		if i < sl.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.level[i].forward != nil && x.level[i].forward.less(score, member) {
+			rank[i] += x.level[i].span
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+
+	level := randomLevel()
+	if level > sl.level {
+		for i := sl.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = sl.header
+			update[i].level[i].span = sl.length
+		}
+		sl.level = level
+	}
+
+	x = &skiplistNode{
+		member: member,
+		score:  score,
+		level:  make([]skiplistLevel, level),
+	}
+	for i := 0; i < level; i++ {
+		x.level[i].forward = update[i].level[i].forward
+		update[i].level[i].forward = x
+
+		x.level[i].span = update[i].level[i].span - (rank[0] - rank[i])
+		update[i].level[i].span = rank[0] - rank[i] + 1
+	}
+
+	// untouched levels are one node longer
+	for i := level; i < sl.level; i++ {
+		update[i].level[i].span++
+	}
+
+	if update[0] != sl.header {
+		x.backward = update[0]
+	}
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x
+	} else {
+		sl.tail = x
+	}
+
+	sl.length++
+	return x
+}
+
+// delete - removes the member with the score, returns false if there is no such node
+func (sl *skiplist) delete(score float64, member string) bool {
+	update := make([]*skiplistNode, skiplistMaxLevel)
+
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && x.level[i].forward.less(score, member) {
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+
+	x = x.level[0].forward
+	if x == nil || x.score != score || x.member != member {
+		return false
+	}
+
+	sl.deleteNode(x, update)
+	return true
+}
+
+func (sl *skiplist) deleteNode(x *skiplistNode, update []*skiplistNode) {
+	for i := 0; i < sl.level; i++ {
+		if update[i].level[i].forward == x {
+			update[i].level[i].span += x.level[i].span - 1
+			update[i].level[i].forward = x.level[i].forward
+		} else {
+			update[i].level[i].span--
+		}
+	}
+
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x.backward
+	} else {
+		sl.tail = x.backward
+	}
+
+	for sl.level > 1 && sl.header.level[sl.level-1].forward == nil {
+		sl.level--
+	}
+	sl.length--
+}
+
+// rank - 1-based position of the member with the score, 0 if there is no such node
+func (sl *skiplist) rank(score float64, member string) int64 {
+	var rank int64
+
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil &&
+			(x.level[i].forward.less(score, member) || (x.level[i].forward.score == score && x.level[i].forward.member == member)) {
+			rank += x.level[i].span
+			x = x.level[i].forward
+		}
+
+		if x != sl.header && x.member == member {
+			return rank
+		}
+	}
+
+	return 0
+}
+
+// byRank - node at 1-based position, nil if rank is out of range
+func (sl *skiplist) byRank(rank int64) *skiplistNode {
+	var traversed int64
+
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && traversed+x.level[i].span <= rank {
+			traversed += x.level[i].span
+			x = x.level[i].forward
+		}
+
+		if traversed == rank && x != sl.header {
+			return x
+		}
+	}
+
+	return nil
+}
+
+// first - the first node which is not before the range, nil if there is no such node
+func (sl *skiplist) first(before func(node *skiplistNode) bool) *skiplistNode {
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && before(x.level[i].forward) {
+			x = x.level[i].forward
+		}
+	}
+
+	return x.level[0].forward
+}
+
+// last - the last node which is not after the range, nil if there is no such node
+func (sl *skiplist) last(after func(node *skiplistNode) bool) *skiplistNode {
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && !after(x.level[i].forward) {
+			x = x.level[i].forward
+		}
+	}
+
+	if x == sl.header {
+		return nil
+	}
+
+	return x
+}
@@ -0,0 +1,397 @@
+package store
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/Vysogota99/redis-implementation/internal/server/rdb"
+)
+
+// streamID - id of stream entry, unix time in milliseconds and sequence number
+type streamID struct {
+	ms, seq uint64
+}
+
+var maxStreamID = streamID{ms: math.MaxUint64, seq: math.MaxUint64}
+
+func (id streamID) String() string {
+	return strconv.FormatUint(id.ms, 10) + "-" + strconv.FormatUint(id.seq, 10)
+}
+
+func (id streamID) less(other streamID) bool {
+	return id.ms < other.ms || (id.ms == other.ms && id.seq < other.seq)
+}
+
+// incr - the next possible id, false is returned for the greatest id
+func (id streamID) incr() (streamID, bool) {
+	switch {
+	case id.seq < math.MaxUint64:
+		return streamID{ms: id.ms, seq: id.seq + 1}, true
+	case id.ms < math.MaxUint64:
+		return streamID{ms: id.ms + 1}, true
+	}
+
+	return id, false
+}
+
+// decr - the previous possible id, false is returned for 0-0
+func (id streamID) decr() (streamID, bool) {
+	switch {
+	case id.seq > 0:
+		return streamID{ms: id.ms, seq: id.seq - 1}, true
+	case id.ms > 0:
+		return streamID{ms: id.ms - 1, seq: math.MaxUint64}, true
+	}
+
+	return id, false
+}
+
+// parseStreamID - id is <ms>-<seq> or <ms>, missing sequence number is replaced by seq
+func parseStreamID(id string, seq uint64) (streamID, error) {
+	var res streamID
+	parts := strings.SplitN(id, "-", 2)
+
+	ms, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return res, errStreamID
+	}
+	res.ms, res.seq = ms, seq
+
+	if len(parts) == 2 {
+		if res.seq, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+			return res, errStreamID
+		}
+	}
+
+	return res, nil
+}
+
+// parseStreamBound - bound of XRANGE: "-", "+" or id, "(" before the id makes the bound exclusive.
+// Missing sequence number is 0 for start and the greatest one for end. False is returned if
+// the exclusive bound leaves nothing in the range.
+func parseStreamBound(bound string, end bool) (streamID, bool, error) {
+	switch bound {
+	case "-":
+		return streamID{}, true, nil
+	case "+":
+		return maxStreamID, true, nil
+	}
+
+	exclusive := strings.HasPrefix(bound, "(")
+	if exclusive {
+		bound = bound[1:]
+	}
+
+	var seq uint64
+	if end {
+		seq = math.MaxUint64
+	}
+	id, err := parseStreamID(bound, seq)
+	if err != nil || !exclusive {
+		return id, true, err
+	}
+
+	if end {
+		id, ok := id.decr()
+		return id, ok, nil
+	}
+	id, ok := id.incr()
+	return id, ok, nil
+}
+
+// streamEntry - fields of the entry are stored as pairs of field and encoded value
+type streamEntry struct {
+	id     streamID
+	fields []string
+}
+
+// size - estimated number of bytes used by the entry
+func (e streamEntry) size() int64 {
+	size := int64(elementOverhead)
+	for _, field := range e.fields {
+		size += int64(len(field))
+	}
+
+	return size
+}
+
+// streamPending - entry delivered to the consumer but not acknowledged, delivery time is unix time in milliseconds
+type streamPending struct {
+	consumer      string
+	deliveryTime  int64
+	deliveryCount int64
+}
+
+// streamGroup - consumer group, consumers are mapped to unix time in milliseconds of their last interaction
+type streamGroup struct {
+	lastID    streamID
+	pending   map[streamID]*streamPending
+	consumers map[string]int64
+}
+
+func newStreamGroup(lastID streamID) *streamGroup {
+	return &streamGroup{
+		lastID:    lastID,
+		pending:   make(map[streamID]*streamPending),
+		consumers: make(map[string]int64),
+	}
+}
+
+// pendingIDs - ids of pending entries in the order of the stream
+func (g *streamGroup) pendingIDs() []streamID {
+	ids := make([]streamID, 0, len(g.pending))
+	for id := range g.pending {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].less(ids[j])
+	})
+
+	return ids
+}
+
+// stream - entries ordered by id, the last id is kept after the entries are trimmed
+type stream struct {
+	entries []streamEntry
+	lastID  streamID
+	groups  map[string]*streamGroup
+}
+
+func newStream() *stream {
+	return &stream{
+		entries: []streamEntry{},
+		groups:  make(map[string]*streamGroup),
+	}
+}
+
+// clone - entries are not modified in place, so that only the slice is copied
+func (s *stream) clone() *stream {
+	res := &stream{
+		entries: append([]streamEntry{}, s.entries...),
+		lastID:  s.lastID,
+		groups:  make(map[string]*streamGroup, len(s.groups)),
+	}
+
+	for name, group := range s.groups {
+		g := newStreamGroup(group.lastID)
+		for id, pending := range group.pending {
+			p := *pending
+			g.pending[id] = &p
+		}
+		for consumer, seen := range group.consumers {
+			g.consumers[consumer] = seen
+		}
+		res.groups[name] = g
+	}
+
+	return res
+}
+
+// search - index of the first entry with id greater or equal than the given one
+func (s *stream) search(id streamID) int {
+	return sort.Search(len(s.entries), func(i int) bool {
+		return !s.entries[i].id.less(id)
+	})
+}
+
+// get - entry by id, false if there is no such entry
+func (s *stream) get(id streamID) (streamEntry, bool) {
+	i := s.search(id)
+	if i == len(s.entries) || s.entries[i].id != id {
+		return streamEntry{}, false
+	}
+
+	return s.entries[i], true
+}
+
+// rangeEntries - entries between start and end inclusive, from the end with rev. 0 count - no limit
+func (s *stream) rangeEntries(start, end streamID, count int64, rev bool) []streamEntry {
+	res := []streamEntry{}
+	if end.less(start) {
+		return res
+	}
+
+	from, to := s.search(start), s.search(end)
+	if to < len(s.entries) && s.entries[to].id == end {
+		to++
+	}
+
+	for i := from; i < to; i++ {
+		if count > 0 && int64(len(res)) >= count {
+			break
+		}
+
+		if rev {
+			res = append(res, s.entries[to-1-(i-from)])
+		} else {
+			res = append(res, s.entries[i])
+		}
+	}
+
+	return res
+}
+
+// trim - removes count entries from the head of the stream, returns removed entries
+func (s *stream) trim(count int) []streamEntry {
+	removed := s.entries[:count]
+	s.entries = append([]streamEntry{}, s.entries[count:]...)
+
+	return removed
+}
+
+// rdbStream - the stream in the format of RDB entries
+func (s *stream) rdbStream() *rdb.Stream {
+	res := &rdb.Stream{
+		Entries: make([]rdb.StreamEntry, len(s.entries)),
+		LastID:  rdbStreamID(s.lastID),
+		Groups:  make([]rdb.StreamGroup, 0, len(s.groups)),
+	}
+
+	for i, e := range s.entries {
+		res.Entries[i] = rdb.StreamEntry{ID: rdbStreamID(e.id), Fields: e.fields}
+	}
+
+	names := make([]string, 0, len(s.groups))
+	for name := range s.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		group := s.groups[name]
+		g := rdb.StreamGroup{
+			Name:      name,
+			LastID:    rdbStreamID(group.lastID),
+			Pending:   make([]rdb.StreamPending, 0, len(group.pending)),
+			Consumers: make([]rdb.StreamConsumer, 0, len(group.consumers)),
+		}
+
+		for _, id := range group.pendingIDs() {
+			pending := group.pending[id]
+			g.Pending = append(g.Pending, rdb.StreamPending{
+				ID:            rdbStreamID(id),
+				Consumer:      pending.consumer,
+				DeliveryTime:  pending.deliveryTime,
+				DeliveryCount: uint64(pending.deliveryCount),
+			})
+		}
+
+		consumers := make([]string, 0, len(group.consumers))
+		for consumer := range group.consumers {
+			consumers = append(consumers, consumer)
+		}
+		sort.Strings(consumers)
+		for _, consumer := range consumers {
+			g.Consumers = append(g.Consumers, rdb.StreamConsumer{Name: consumer, SeenTime: group.consumers[consumer]})
+		}
+
+		res.Groups = append(res.Groups, g)
+	}
+
+	return res
+}
+
+func rdbStreamID(id streamID) rdb.StreamID {
+	return rdb.StreamID{Ms: id.ms, Seq: id.seq}
+}
+
+// streamCommands - commands which restore the stream from RDB file. Empty stream is created by
+// the entry which is trimmed at once. Pending entries are restored by XCLAIM with FORCE, so that
+// consumers without pending entries are lost.
+func streamCommands(key string, s *rdb.Stream) [][]string {
+	var res [][]string
+
+	last := streamID{}
+	for _, e := range s.Entries {
+		last = streamID{ms: e.ID.Ms, seq: e.ID.Seq}
+		res = append(res, append([]string{"XADD", key, last.String()}, e.Fields...))
+	}
+
+	if len(s.Entries) == 0 {
+		last = streamID{ms: s.LastID.Ms, seq: s.LastID.Seq}
+		if last == (streamID{}) {
+			last.seq = 1
+		}
+		res = append(res,
+			[]string{"XADD", key, last.String(), "", ""},
+			[]string{"XTRIM", key, "MAXLEN", "0"},
+		)
+	}
+
+	if lastID := (streamID{ms: s.LastID.Ms, seq: s.LastID.Seq}); lastID != last {
+		res = append(res, []string{"XSETID", key, lastID.String()})
+	}
+
+	for _, group := range s.Groups {
+		lastID := streamID{ms: group.LastID.Ms, seq: group.LastID.Seq}
+		res = append(res, []string{"XGROUP", "CREATE", key, group.Name, lastID.String()})
+
+		for _, pending := range group.Pending {
+			id := streamID{ms: pending.ID.Ms, seq: pending.ID.Seq}
+			res = append(res, xclaimCommand(key, group.Name, pending.Consumer, id, &streamPending{
+				deliveryTime:  pending.DeliveryTime,
+				deliveryCount: int64(pending.DeliveryCount),
+			}))
+		}
+	}
+
+	return res
+}
+
+// xclaimCommand - XCLAIM which sets the pending entry as it is regardless of its current state
+func xclaimCommand(key, group, consumer string, id streamID, pending *streamPending) []string {
+	return []string{
+		"XCLAIM", key, group, consumer, "0", id.String(),
+		"TIME", strconv.FormatInt(pending.deliveryTime, 10),
+		"RETRYCOUNT", strconv.FormatInt(pending.deliveryCount, 10),
+		"FORCE", "JUSTID",
+	}
+}
+
+// streamFields - fields of the entry sorted by name, values are encoded the same way as elements of lists
+func streamFields(values map[string]interface{}) ([]string, error) {
+	names := make([]string, 0, len(values))
+	for field := range values {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	res := make([]string, 0, len(values)*2)
+	for _, field := range names {
+		encoded, err := encodeListElement(values[field])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, field, encoded)
+	}
+
+	return res, nil
+}
+
+// decodeStreamValue - values added by other clients are not encoded, they are returned as strings
+func decodeStreamValue(raw string) interface{} {
+	value, err := decodeListElement(raw)
+	if err != nil || value == nil {
+		return raw
+	}
+
+	return value
+}
+
+// streamEntryModel - nil fields mean that the pending entry is already removed from the stream
+func streamEntryModel(id string, fields []string) models.StreamEntry {
+	res := models.StreamEntry{ID: id}
+	if fields == nil {
+		return res
+	}
+
+	res.Fields = make(map[string]interface{}, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		res.Fields[fields[i]] = decodeStreamValue(fields[i+1])
+	}
+
+	return res
+}
@@ -0,0 +1,509 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrTxAborted - returned when watched keys are modified by other clients before the transaction is executed
+var ErrTxAborted = errors.New("ABORTED Transaction is discarded because watched keys are modified")
+
+// IsTxAborted - checks if the transaction is discarded because of WATCH
+func IsTxAborted(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "ABORTED ")
+}
+
+// txCommand - command which may be used in transaction
+type txCommand struct {
+	// number of arguments including the name of command, negative value means "at least"
+	arity int
+	// prepare - parses arguments, so that the transaction with invalid command is rejected as a whole
+	prepare func(args []string) (*txCall, error)
+}
+
+// txCall - prepared command of transaction
+type txCall struct {
+	// exec - executes the command by the engine, native engine holds its lock during the whole transaction
+	exec func(ctx context.Context, r RedisImpl) (interface{}, error)
+	// args - the same command sent to redis between MULTI and EXEC, elements of lists are already encoded
+	args []interface{}
+	// reply - converts reply of redis to the same result as exec returns, nil - reply is returned as is
+	reply func(reply interface{}) (interface{}, error)
+	// writes - keys modified by the command, redis increments their versions in the same MULTI/EXEC
+	writes []string
+}
+
+var txCommands map[string]txCommand
+
+func init() {
+	txCommands = map[string]txCommand{
+		"get":      {prepare: txGet, arity: 2},
+		"set":      {prepare: txSet, arity: 3},
+		"del":      {prepare: txDel, arity: -2},
+		"hget":     {prepare: txHGet, arity: 3},
+		"hgetall":  {prepare: txHGetAll, arity: 2},
+		"hset":     {prepare: txHSet, arity: -4},
+		"rpush":    {prepare: txRPush, arity: -3},
+		"lrange":   {prepare: txLRange, arity: 4},
+		"lset":     {prepare: txLSet, arity: 4},
+		"sadd":     {prepare: txSAdd, arity: -3},
+		"srem":     {prepare: txSRem, arity: -3},
+		"smembers": {prepare: txSMembers, arity: 2},
+		"zadd":     {prepare: txZAdd, arity: -4},
+		"zrem":     {prepare: txZRem, arity: -3},
+		"zscore":   {prepare: txZScore, arity: 3},
+	}
+}
+
+// prepareTx - checks watched keys and commands before anything is executed
+func prepareTx(watch []string, commands []models.TxCommand) ([]*txCall, error) {
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("Empty transaction")
+	}
+
+	for _, key := range watch {
+		if key == "" {
+			return nil, fmt.Errorf("Empty key")
+		}
+	}
+
+	calls := make([]*txCall, len(commands))
+	for i, command := range commands {
+		name := strings.ToLower(command.Command)
+		cmd, ok := txCommands[name]
+		if !ok {
+			return nil, fmt.Errorf("ERR unknown command '%s'", command.Command)
+		}
+
+		args := len(command.Args) + 1
+		if (cmd.arity > 0 && args != cmd.arity) || (cmd.arity < 0 && args < -cmd.arity) {
+			return nil, fmt.Errorf("ERR wrong number of arguments for '%s' command", name)
+		}
+
+		call, err := cmd.prepare(command.Args)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = call
+	}
+
+	return calls, nil
+}
+
+// txResult - redis.Nil means absence of value, so that it is not an error
+func txResult(value interface{}, err error) models.TxResult {
+	if err == redis.Nil {
+		return models.TxResult{}
+	}
+	if err != nil {
+		return models.TxResult{Error: err.Error()}
+	}
+
+	return models.TxResult{Result: value}
+}
+
+// Tx - executes commands atomically under one lock. Watched keys are only checked: WATCH of redis aborts the
+// transaction if the keys are modified between WATCH and EXEC, and other clients can't modify them while the
+// lock is held, so that the transaction of native engine is never aborted.
+func (n *Native) Tx(ctx context.Context, watch []string, commands []models.TxCommand) ([]models.TxResult, error) {
+	calls, err := prepareTx(watch, commands)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.unlock()
+
+	res := make([]models.TxResult, len(calls))
+	err = n.atomic(ctx, func(ctx context.Context) error {
+		for i, call := range calls {
+			res[i] = txResult(call.exec(ctx, n))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// atomic - executes commands of fn one by one under the lock held by the caller. The commands are written to
+// append only file between MULTI and EXEC, so that replay applies all or none of them.
+func (n *Native) atomic(ctx context.Context, fn func(ctx context.Context) error) error {
+	n.tx = &nativeTx{}
+	err := fn(context.WithValue(ctx, txKey{}, n))
+
+	multi := n.tx.multi
+	n.tx = nil
+	if multi {
+		if err := n.propagate("EXEC"); err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+// Tx - executes commands between MULTI and EXEC, watched keys are watched before MULTI
+func (r *Redis) Tx(ctx context.Context, watch []string, commands []models.TxCommand) ([]models.TxResult, error) {
+	calls, err := prepareTx(watch, commands)
+	if err != nil {
+		return nil, err
+	}
+
+	var cmds []redis.Cmder
+	err = r.client.Watch(ctx, func(tx *redis.Tx) error {
+		cmds, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, call := range calls {
+				pipe.Do(ctx, call.args...)
+			}
+			// versions follow all commands, so that they get time to live the keys have after the transaction
+			for _, key := range txWrites(calls) {
+				pipe.Eval(ctx, versionScript, versionKeys(key))
+			}
+			return nil
+		})
+		return err
+	}, watch...)
+
+	if err == redis.TxFailedErr {
+		return nil, ErrTxAborted
+	}
+
+	return txResults(calls, cmds, err)
+}
+
+// txWrites - keys modified by the commands of the transaction, every key is listed once
+func txWrites(calls []*txCall) []string {
+	seen := map[string]struct{}{}
+	res := []string{}
+	for _, call := range calls {
+		for _, key := range call.writes {
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				res = append(res, key)
+			}
+		}
+	}
+
+	return res
+}
+
+// txResults - errors of commands are returned as their results. Other errors, including errors of redis
+// returned before EXEC, e.g. rejected WATCH, fail the whole transaction. Replies following the replies of
+// the commands belong to the increments of versions.
+func txResults(calls []*txCall, cmds []redis.Cmder, err error) ([]models.TxResult, error) {
+	if _, ok := err.(redis.Error); err != nil && (!ok || len(cmds) < len(calls)) {
+		return nil, err
+	}
+
+	res := make([]models.TxResult, len(calls))
+	for i, call := range calls {
+		reply, err := cmds[i].(*redis.Cmd).Result()
+		if err == nil && call.reply != nil {
+			reply, err = call.reply(reply)
+		}
+		res[i] = txResult(reply, err)
+	}
+
+	return res, nil
+}
+
+// txKey - key of context of the transaction holding the lock of native engine
+type txKey struct{}
+
+// nativeTx - state of the transaction executed by native engine
+type nativeTx struct {
+	// MULTI is written to append only file before the first write of the transaction
+	multi bool
+}
+
+// lock - locks the engine unless the lock is already held by the transaction of the context, returns unlock
+func (n *Native) lock(ctx context.Context) func() {
+	if ctx != nil && ctx.Value(txKey{}) == n {
+		return func() {}
+	}
+
+	n.mu.Lock()
+	return n.unlock
+}
+
+func txGet(args []string) (*txCall, error) {
+	return &txCall{
+		exec: func(ctx context.Context, r RedisImpl) (interface{}, error) {
+			return r.GetString(ctx, args[0])
+		},
+		args: txArgs("get", args),
+	}, nil
+}
+
+func txSet(args []string) (*txCall, error) {
+	return &txCall{
+		exec: func(ctx context.Context, r RedisImpl) (interface{}, error) {
+			return r.SetString(ctx, args[0], args[1], models.Expiration{})
+		},
+		args:   txArgs("set", args),
+		writes: args[:1],
+	}, nil
+}
+
+func txDel(args []string) (*txCall, error) {
+	return &txCall{
+		exec: func(ctx context.Context, r RedisImpl) (interface{}, error) {
+			var deleted int64
+			for _, key := range args {
+				res, err := r.Delete(ctx, key)
+				if err != nil {
+					return nil, err
+				}
+				deleted += res
+			}
+			return deleted, nil
+		},
+		args:   txArgs("del", args),
+		writes: args,
+	}, nil
+}
+
+func txHGet(args []string) (*txCall, error) {
+	return &txCall{
+		exec: func(ctx context.Context, r RedisImpl) (interface{}, error) {
+			return r.HGet(ctx, args[0], args[1])
+		},
+		args: txArgs("hget", args),
+	}, nil
+}
+
+func txHGetAll(args []string) (*txCall, error) {
+	return &txCall{
+		exec: func(ctx context.Context, r RedisImpl) (interface{}, error) {
+			return r.GetHash(ctx, args[0])
+		},
+		args: txArgs("hgetall", args),
+		reply: func(reply interface{}) (interface{}, error) {
+			values, _ := reply.([]interface{})
+			res := make(map[string]string, len(values)/2)
+			for i := 0; i+1 < len(values); i += 2 {
+				res[fmt.Sprint(values[i])] = fmt.Sprint(values[i+1])
+			}
+			return res, nil
+		},
+	}, nil
+}
+
+func txHSet(args []string) (*txCall, error) {
+	if len(args)%2 != 1 {
+		return nil, fmt.Errorf("ERR wrong number of arguments for 'hset' command")
+	}
+
+	values := make(map[string]interface{}, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		values[args[i]] = args[i+1]
+	}
+
+	return &txCall{
+		exec: func(ctx context.Context, r RedisImpl) (interface{}, error) {
+			return r.HSet(ctx, args[0], values)
+		},
+		args:   append([]interface{}{"hset", args[0]}, hashArgs(values)...),
+		writes: args[:1],
+	}, nil
+}
+
+// txRPush - elements are strings, they are encoded the same way as by SetList
+func txRPush(args []string) (*txCall, error) {
+	values := make([]interface{}, len(args)-1)
+	encoded := make([]string, len(args)-1)
+	for i, arg := range args[1:] {
+		values[i] = arg
+		el, err := encodeListElement(arg)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = el
+	}
+
+	return &txCall{
+		exec: func(ctx context.Context, r RedisImpl) (interface{}, error) {
+			if err := r.SetList(ctx, args[0], values, models.Expiration{}); err != nil {
+				return nil, err
+			}
+
+			// SetList does not return length of the list, so it is requested separately
+			list, err := r.GetList(ctx, args[0])
+			if err != nil {
+				return nil, err
+			}
+			return int64(len(list)), nil
+		},
+		args:   txArgs("rpush", append([]string{args[0]}, encoded...)),
+		writes: args[:1],
+	}, nil
+}
+
+func txLRange(args []string) (*txCall, error) {
+	start, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInteger
+	}
+	stop, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return nil, errNotInteger
+	}
+
+	return &txCall{
+		exec: func(ctx context.Context, r RedisImpl) (interface{}, error) {
+			return r.LRange(ctx, args[0], start, stop)
+		},
+		args: txArgs("lrange", args),
+		reply: func(reply interface{}) (interface{}, error) {
+			return decodeListElements(txStrings(reply))
+		},
+	}, nil
+}
+
+func txLSet(args []string) (*txCall, error) {
+	index, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInteger
+	}
+
+	encoded, err := encodeListElement(args[2])
+	if err != nil {
+		return nil, err
+	}
+
+	return &txCall{
+		exec: func(ctx context.Context, r RedisImpl) (interface{}, error) {
+			return r.LSet(ctx, args[0], index, args[2])
+		},
+		args:   txArgs("lset", []string{args[0], args[1], encoded}),
+		writes: args[:1],
+	}, nil
+}
+
+func txSAdd(args []string) (*txCall, error) {
+	return &txCall{
+		exec: func(ctx context.Context, r RedisImpl) (interface{}, error) {
+			return r.SAdd(ctx, args[0], args[1:])
+		},
+		args:   txArgs("sadd", args),
+		writes: args[:1],
+	}, nil
+}
+
+func txSRem(args []string) (*txCall, error) {
+	return &txCall{
+		exec: func(ctx context.Context, r RedisImpl) (interface{}, error) {
+			return r.SRem(ctx, args[0], args[1:])
+		},
+		args:   txArgs("srem", args),
+		writes: args[:1],
+	}, nil
+}
+
+func txSMembers(args []string) (*txCall, error) {
+	return &txCall{
+		exec: func(ctx context.Context, r RedisImpl) (interface{}, error) {
+			return r.SMembers(ctx, args[0])
+		},
+		args: txArgs("smembers", args),
+		reply: func(reply interface{}) (interface{}, error) {
+			members := txStrings(reply)
+			sort.Strings(members)
+			return members, nil
+		},
+	}, nil
+}
+
+// txZAdd - ZADD key score member [score member ...] without options
+func txZAdd(args []string) (*txCall, error) {
+	if len(args)%2 != 1 {
+		return nil, fmt.Errorf("ERR syntax error")
+	}
+
+	members := make([]models.ZMember, 0, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		score, err := strconv.ParseFloat(args[i], 64)
+		if err != nil {
+			return nil, errNotFloat
+		}
+		members = append(members, models.ZMember{Member: args[i+1], Score: score})
+	}
+
+	return &txCall{
+		exec: func(ctx context.Context, r RedisImpl) (interface{}, error) {
+			return r.ZAdd(ctx, args[0], members, models.ZAddOptions{})
+		},
+		args:   txArgs("zadd", args),
+		writes: args[:1],
+	}, nil
+}
+
+func txZRem(args []string) (*txCall, error) {
+	return &txCall{
+		exec: func(ctx context.Context, r RedisImpl) (interface{}, error) {
+			return r.ZRem(ctx, args[0], args[1:])
+		},
+		args:   txArgs("zrem", args),
+		writes: args[:1],
+	}, nil
+}
+
+func txZScore(args []string) (*txCall, error) {
+	return &txCall{
+		exec: func(ctx context.Context, r RedisImpl) (interface{}, error) {
+			return r.ZScore(ctx, args[0], args[1])
+		},
+		args: txArgs("zscore", args),
+		reply: func(reply interface{}) (interface{}, error) {
+			return strconv.ParseFloat(fmt.Sprint(reply), 64)
+		},
+	}, nil
+}
+
+// txArgs - arguments of redis command
+func txArgs(name string, args []string) []interface{} {
+	res := make([]interface{}, 0, len(args)+1)
+	res = append(res, name)
+	for _, arg := range args {
+		res = append(res, arg)
+	}
+
+	return res
+}
+
+// txStrings - array reply of redis as strings
+func txStrings(reply interface{}) []string {
+	values, _ := reply.([]interface{})
+	res := make([]string, len(values))
+	for i, value := range values {
+		res[i] = fmt.Sprint(value)
+	}
+
+	return res
+}
+
+// hashArgs - flattens hash into field/value pairs sorted by field, so that the command is deterministic
+func hashArgs(values map[string]interface{}) []interface{} {
+	fields := make([]string, 0, len(values))
+	for field := range values {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	args := make([]interface{}, 0, len(values)*2)
+	for _, field := range fields {
+		args = append(args, field, values[field])
+	}
+
+	return args
+}
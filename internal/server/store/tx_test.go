@@ -0,0 +1,235 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrepareTx(t *testing.T) {
+	type testCase struct {
+		name     string
+		watch    []string
+		commands []models.TxCommand
+		wantErr  bool
+	}
+
+	tCases := []testCase{
+		{
+			name:     "Valid",
+			watch:    []string{"user"},
+			commands: []models.TxCommand{{Command: "SET", Args: []string{"user", "ivan"}}, {Command: "zadd", Args: []string{"scores", "1", "ivan"}}},
+		},
+		{name: "Empty transaction", wantErr: true},
+		{
+			name:     "Empty watched key",
+			watch:    []string{""},
+			commands: []models.TxCommand{{Command: "get", Args: []string{"user"}}},
+			wantErr:  true,
+		},
+		{
+			name:     "Unknown command",
+			commands: []models.TxCommand{{Command: "flushall"}},
+			wantErr:  true,
+		},
+		{
+			name:     "Wrong number of arguments",
+			commands: []models.TxCommand{{Command: "get", Args: []string{"user", "name"}}},
+			wantErr:  true,
+		},
+		{
+			name:     "Not a number",
+			commands: []models.TxCommand{{Command: "lrange", Args: []string{"list", "a", "1"}}},
+			wantErr:  true,
+		},
+		{
+			name:     "Score without member",
+			commands: []models.TxCommand{{Command: "zadd", Args: []string{"scores", "1", "ivan", "2"}}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls, err := prepareTx(tc.watch, tc.commands)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, calls, len(tc.commands))
+		})
+	}
+}
+
+// txRedisError - error replied by redis, go-redis does not export its type
+type txRedisError string
+
+func (e txRedisError) Error() string { return string(e) }
+
+func (txRedisError) RedisError() {}
+
+func TestTxResults(t *testing.T) {
+	calls, err := prepareTx(nil, []models.TxCommand{{Command: "get", Args: []string{"user"}}, {Command: "get", Args: []string{"name"}}})
+	assert.NoError(t, err)
+
+	// WATCH is rejected, so that there are no replies of commands
+	_, err = txResults(calls, nil, txRedisError("ERR WATCH inside MULTI is not allowed"))
+	assert.EqualError(t, err, "ERR WATCH inside MULTI is not allowed")
+
+	failed := redis.NewCmdResult(nil, txRedisError("WRONGTYPE Operation against a key holding the wrong kind of value"))
+	found := redis.NewCmdResult("ivan", nil)
+
+	res, err := txResults(calls, []redis.Cmder{failed, found}, failed.Err())
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TxResult{
+		{Error: "WRONGTYPE Operation against a key holding the wrong kind of value"},
+		{Result: "ivan"},
+	}, res)
+}
+
+func TestNativeTx(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	_, err := client.SetString(ctx, "name", "ivan", models.Expiration{})
+	assert.NoError(t, err)
+
+	res, err := client.Tx(ctx, []string{"name"}, []models.TxCommand{
+		{Command: "hset", Args: []string{"user", "name", "ivan"}},
+		{Command: "rpush", Args: []string{"list", "a", "b"}},
+		{Command: "hget", Args: []string{"name", "field"}},
+		{Command: "get", Args: []string{"missing"}},
+		{Command: "lrange", Args: []string{"list", "0", "-1"}},
+		{Command: "del", Args: []string{"name", "missing"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TxResult{
+		{Result: int64(1)},
+		{Result: int64(2)},
+		// failed command does not stop the following ones
+		{Error: "WRONGTYPE Operation against a key holding the wrong kind of value"},
+		{},
+		{Result: []interface{}{"a", "b"}},
+		{Result: int64(1)},
+	}, res)
+
+	// invalid transaction is rejected before anything is executed
+	_, err = client.Tx(ctx, nil, []models.TxCommand{
+		{Command: "set", Args: []string{"name", "petr"}},
+		{Command: "unknown"},
+	})
+	assert.Error(t, err)
+	_, err = client.GetString(ctx, "name")
+	assert.Equal(t, redis.Nil, err)
+}
+
+func TestRedisTxVersions(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+	ctx := context.Background()
+
+	mock.ExpectExists("user").SetVal(1)
+	mock.ExpectGet(versionKey("user")).SetVal("1")
+	version, err := client.Version(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), version)
+
+	// versions of written keys are incremented after the commands, read only commands keep them
+	mock.ExpectSet("user", "petr", 0).SetVal("OK")
+	mock.ExpectDel("name", "user").SetVal(1)
+	mock.ExpectGet("user").RedisNil()
+	expectVersion(mock, "user").SetVal(int64(2))
+	expectVersion(mock, "name").SetVal(int64(3))
+	res, err := client.Tx(ctx, nil, []models.TxCommand{
+		{Command: "set", Args: []string{"user", "petr"}},
+		{Command: "del", Args: []string{"name", "user"}},
+		{Command: "get", Args: []string{"user"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TxResult{{Result: "OK"}, {Result: int64(1)}, {}}, res)
+
+	// the version read before the transaction is stale
+	mock.ExpectExists("user").SetVal(1)
+	mock.ExpectGet(versionKey("user")).SetVal("2")
+	_, err = client.SetStringCAS(ctx, "user", "ivan", models.Expiration{}, version)
+	assert.True(t, IsVersionMismatch(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNativeTxAtomic(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := client.Tx(ctx, nil, []models.TxCommand{
+				{Command: "sadd", Args: []string{"a", "x"}},
+				{Command: "srem", Args: []string{"a", "x"}},
+			})
+			assert.NoError(t, err)
+			// other transactions can't observe the member between the commands
+			assert.Equal(t, []models.TxResult{{Result: int64(1)}, {Result: int64(1)}}, res)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAOFTx(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aof")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "appendonly.aof")
+	ctx := context.Background()
+
+	client := NewNative()
+	assert.NoError(t, client.OpenAOF(path, FsyncAlways))
+
+	// transaction without writes is not written
+	_, err = client.Tx(ctx, nil, []models.TxCommand{{Command: "get", Args: []string{"user"}}})
+	assert.NoError(t, err)
+	_, err = client.Tx(ctx, nil, []models.TxCommand{
+		{Command: "set", Args: []string{"user", "ivan"}},
+		{Command: "sadd", Args: []string{"roles", "admin"}},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, client.Close())
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "*1\r\n$5\r\nMULTI\r\n"))
+	assert.True(t, strings.HasSuffix(string(data), "*1\r\n$4\r\nEXEC\r\n"))
+
+	// transaction without EXEC is truncated
+	multi := strings.TrimSuffix(string(data), "*1\r\n$4\r\nEXEC\r\n")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("*3\r\n$3\r\nSET\r\n$4\r\nname\r\n$4\r\nPetr\r\n"+multi), 0644))
+
+	restored := NewNative()
+	assert.NoError(t, restored.OpenAOF(path, FsyncNo))
+
+	value, err := restored.GetString(ctx, "name")
+	assert.NoError(t, err)
+	assert.Equal(t, "Petr", value)
+	_, err = restored.GetString(ctx, "user")
+	assert.Equal(t, redis.Nil, err)
+	assert.NoError(t, restored.Close())
+
+	data, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "*3\r\n$3\r\nSET\r\n$4\r\nname\r\n$4\r\nPetr\r\n", string(data))
+}
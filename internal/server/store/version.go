@@ -0,0 +1,319 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrVersionMismatch - compare-and-set is rejected because the key is modified or deleted since the version
+// was read
+var ErrVersionMismatch = errors.New("PRECONDITION version of the key does not match")
+
+// IsVersionMismatch - checks if compare-and-set is rejected because of the version of the key
+func IsVersionMismatch(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "PRECONDITION ")
+}
+
+// versionPrefix - redis keeps version of the key in the separate key with the same time to live
+const versionPrefix = "__version__:"
+
+// versionCounter - versions are taken from the single counter, so that the version of the recreated key
+// continues to grow after the version of the deleted key is removed
+const versionCounter = versionPrefix
+
+// versionFunc - Lua function incrementing version of the key, KEYS of the script end with versionCounter.
+// The version gets time to live of the key and is removed together with the key, so that versions of
+// expired and deleted keys do not take the memory.
+const versionFunc = `local function version(key, vkey)
+	local v = redis.call('INCR', KEYS[#KEYS])
+	local ttl = redis.call('PTTL', key)
+	if ttl == -2 then
+		redis.call('DEL', vkey)
+	elseif ttl == -1 then
+		redis.call('SET', vkey, v)
+	else
+		redis.call('SET', vkey, v, 'PX', ttl)
+	end
+	return v
+end
+`
+
+// versionScript - increments version of the key after the write, KEYS are the key, its version and the counter
+const versionScript = versionFunc + `return version(KEYS[1], KEYS[2])`
+
+func versionKey(key string) string {
+	return versionPrefix + key
+}
+
+// versionKeys - KEYS of the scripts incrementing versions: the keys, their versions and versionCounter
+func versionKeys(keys ...string) []string {
+	res := make([]string, 0, 2*len(keys)+1)
+	res = append(res, keys...)
+	for _, key := range keys {
+		res = append(res, versionKey(key))
+	}
+
+	return append(res, versionCounter)
+}
+
+// Version - monotonic counter incremented by every write of the key, redis.Nil if there is no such key
+func (n *Native) Version(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	e := n.peek(key)
+	if e == nil {
+		return 0, redis.Nil
+	}
+
+	return e.version, nil
+}
+
+// SetHashCAS - SetHash if the key has the version, returns the new version
+func (n *Native) SetHashCAS(ctx context.Context, key string, value map[string]interface{}, exp models.Expiration, version int64) (int64, error) {
+	return n.cas(ctx, key, version, func(ctx context.Context) error {
+		return n.SetHash(ctx, key, value, exp)
+	})
+}
+
+// SetStringCAS - SetString if the key has the version, returns the new version
+func (n *Native) SetStringCAS(ctx context.Context, key, value string, exp models.Expiration, version int64) (int64, error) {
+	return n.cas(ctx, key, version, func(ctx context.Context) error {
+		_, err := n.SetString(ctx, key, value, exp)
+		return err
+	})
+}
+
+// SetListCAS - SetList if the key has the version, returns the new version
+func (n *Native) SetListCAS(ctx context.Context, key string, value []interface{}, exp models.Expiration, version int64) (int64, error) {
+	return n.cas(ctx, key, version, func(ctx context.Context) error {
+		return n.SetList(ctx, key, value, exp)
+	})
+}
+
+// HSetCAS - HSet if the key has the version, returns number of added fields and the new version
+func (n *Native) HSetCAS(ctx context.Context, key string, values map[string]interface{}, version int64) (int64, int64, error) {
+	var added int64
+	newVersion, err := n.cas(ctx, key, version, func(ctx context.Context) error {
+		var err error
+		added, err = n.HSet(ctx, key, values)
+		return err
+	})
+
+	return added, newVersion, err
+}
+
+// LSetCAS - LSet if the key has the version, returns the new version
+func (n *Native) LSetCAS(ctx context.Context, key string, index int64, value interface{}, version int64) (int64, error) {
+	return n.cas(ctx, key, version, func(ctx context.Context) error {
+		_, err := n.LSet(ctx, key, index, value)
+		return err
+	})
+}
+
+// cas - the version is compared and the write is executed under one lock. Missing key never matches the version.
+func (n *Native) cas(ctx context.Context, key string, version int64, write func(ctx context.Context) error) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	defer n.lock(ctx)()
+
+	e := n.peek(key)
+	if e == nil || e.version != version {
+		return 0, ErrVersionMismatch
+	}
+
+	if err := n.atomic(ctx, write); err != nil {
+		return 0, err
+	}
+
+	e = n.peek(key)
+	if e == nil {
+		// the key is removed by the write, e.g. because of negative time to live, so that it has no version
+		return 0, nil
+	}
+
+	return e.version, nil
+}
+
+// Version - 0 for the key which is never written by the server, e.g. by redis-cli
+func (r *Redis) Version(ctx context.Context, key string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	return r.version(ctx, r.client, key)
+}
+
+// SetHashCAS ...
+func (r *Redis) SetHashCAS(ctx context.Context, key string, value map[string]interface{}, exp models.Expiration, version int64) (int64, error) {
+	if key == "" || value == nil {
+		return 0, fmt.Errorf("Empty key or field")
+	}
+	if err := checkExpiration(exp); err != nil {
+		return 0, err
+	}
+
+	return r.cas(ctx, key, exp, version, func(c redis.Pipeliner) error {
+		return c.HMSet(ctx, key, value).Err()
+	})
+}
+
+// SetStringCAS ...
+func (r *Redis) SetStringCAS(ctx context.Context, key, value string, exp models.Expiration, version int64) (int64, error) {
+	if key == "" || value == "" {
+		return 0, fmt.Errorf("Empty key or field")
+	}
+	if err := checkExpiration(exp); err != nil {
+		return 0, err
+	}
+
+	return r.cas(ctx, key, models.Expiration{ExpireAt: exp.ExpireAt}, version, func(c redis.Pipeliner) error {
+		return c.Set(ctx, key, value, exp.TTL.Duration()).Err()
+	})
+}
+
+// SetListCAS ...
+func (r *Redis) SetListCAS(ctx context.Context, key string, value []interface{}, exp models.Expiration, version int64) (int64, error) {
+	if key == "" || value == nil {
+		return 0, fmt.Errorf("Empty key or field")
+	}
+	if err := checkExpiration(exp); err != nil {
+		return 0, err
+	}
+
+	strSlice, err := encodeListElements(value)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.cas(ctx, key, exp, version, func(c redis.Pipeliner) error {
+		return c.RPush(ctx, key, strSlice).Err()
+	})
+}
+
+// HSetCAS ...
+func (r *Redis) HSetCAS(ctx context.Context, key string, values map[string]interface{}, version int64) (int64, int64, error) {
+	if key == "" || values == nil {
+		return 0, 0, fmt.Errorf("Empty key of value")
+	}
+
+	var cmd *redis.IntCmd
+	newVersion, err := r.cas(ctx, key, models.Expiration{}, version, func(c redis.Pipeliner) error {
+		cmd = c.HSet(ctx, key, values)
+		return cmd.Err()
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return cmd.Val(), newVersion, nil
+}
+
+// LSetCAS ...
+func (r *Redis) LSetCAS(ctx context.Context, key string, index int64, value interface{}, version int64) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("Empty key")
+	}
+
+	valueToInsert, err := encodeListElement(value)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.cas(ctx, key, models.Expiration{}, version, func(c redis.Pipeliner) error {
+		return c.LSet(ctx, key, index, valueToInsert).Err()
+	})
+}
+
+// version - the key and its version are read by the same client, so that they may be watched by the transaction
+func (r *Redis) version(ctx context.Context, c redis.Cmdable, key string) (int64, error) {
+	exists, err := c.Exists(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		return 0, redis.Nil
+	}
+
+	version, err := c.Get(ctx, versionKey(key)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+
+	return version, err
+}
+
+// write - executes the write, PEXPIRE or PEXPIREAT if expiration is set and increment of the version in one
+// MULTI/EXEC, so that neither the key nor its version is left without time to live
+func (r *Redis) write(ctx context.Context, key string, exp models.Expiration, write func(c redis.Pipeliner) error) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		_, err := versioned(ctx, pipe, key, exp, write)
+		return err
+	})
+	return err
+}
+
+// incrVersion - blocking commands can not be executed in MULTI/EXEC, so that version is incremented after them
+func (r *Redis) incrVersion(ctx context.Context, key string) error {
+	return r.client.Eval(ctx, versionScript, versionKeys(key)).Err()
+}
+
+// cas - the key and its version are watched while the version is compared, then the write is executed in
+// MULTI/EXEC. The transaction aborted by the concurrent write means that the version does not match anymore.
+func (r *Redis) cas(ctx context.Context, key string, exp models.Expiration, version int64, write func(c redis.Pipeliner) error) (int64, error) {
+	var incr *redis.Cmd
+	err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := r.version(ctx, tx, key)
+		if err == redis.Nil || (err == nil && current != version) {
+			return ErrVersionMismatch
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			incr, err = versioned(ctx, pipe, key, exp, write)
+			return err
+		})
+		return err
+	}, key, versionKey(key))
+
+	if err == redis.TxFailedErr {
+		return 0, ErrVersionMismatch
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return incr.Int64()
+}
+
+// versioned - queues the write, PEXPIRE or PEXPIREAT if expiration is set and versionScript
+func versioned(ctx context.Context, pipe redis.Pipeliner, key string, exp models.Expiration, write func(c redis.Pipeliner) error) (*redis.Cmd, error) {
+	if err := write(pipe); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch {
+	case exp.TTL > 0:
+		err = pipe.PExpire(ctx, key, exp.TTL.Duration()).Err()
+	case exp.ExpireAt != nil:
+		err = pipe.PExpireAt(ctx, key, *exp.ExpireAt).Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return pipe.Eval(ctx, versionScript, versionKeys(key)), nil
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Vysogota99/redis-implementation/internal/server/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNativeVersion(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	_, err := client.Version(ctx, "user")
+	assert.Equal(t, redis.Nil, err)
+
+	assert.NoError(t, client.SetHash(ctx, "user", map[string]interface{}{"name": "ivan"}, models.Expiration{}))
+	created, err := client.Version(ctx, "user")
+	assert.NoError(t, err)
+
+	// reads and writes of other keys do not change the version
+	_, err = client.GetHash(ctx, "user")
+	assert.NoError(t, err)
+	_, err = client.SetString(ctx, "other", "value", models.Expiration{})
+	assert.NoError(t, err)
+	version, err := client.Version(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, created, version)
+
+	_, err = client.HSet(ctx, "user", map[string]interface{}{"age": 20})
+	assert.NoError(t, err)
+	modified, err := client.Version(ctx, "user")
+	assert.NoError(t, err)
+	assert.Greater(t, modified, created)
+
+	// the recreated key never gets the version it had before
+	_, err = client.Delete(ctx, "user")
+	assert.NoError(t, err)
+	assert.NoError(t, client.SetHash(ctx, "user", map[string]interface{}{"name": "ivan"}, models.Expiration{}))
+	recreated, err := client.Version(ctx, "user")
+	assert.NoError(t, err)
+	assert.Greater(t, recreated, modified)
+}
+
+func TestNativeCAS(t *testing.T) {
+	client := NewNative()
+	ctx := context.Background()
+
+	assert.NoError(t, client.SetList(ctx, "list", []interface{}{"a", int64(1)}, models.Expiration{}))
+	_, err := client.SetString(ctx, "name", "ivan", models.Expiration{})
+	assert.NoError(t, err)
+
+	type testCase struct {
+		name    string
+		key     string
+		stale   bool
+		write   func(key string, version int64) (int64, error)
+		wantErr bool
+	}
+
+	tCases := []testCase{
+		{
+			name: "String",
+			key:  "name",
+			write: func(key string, version int64) (int64, error) {
+				return client.SetStringCAS(ctx, key, "petr", models.Expiration{TTL: models.TTL(time.Minute)}, version)
+			},
+		},
+		{
+			name:  "Stale string",
+			key:   "name",
+			stale: true,
+			write: func(key string, version int64) (int64, error) {
+				return client.SetStringCAS(ctx, key, "oleg", models.Expiration{}, version)
+			},
+			wantErr: true,
+		},
+		{
+			name: "List element",
+			key:  "list",
+			write: func(key string, version int64) (int64, error) {
+				return client.LSetCAS(ctx, key, 1, int64(2), version)
+			},
+		},
+		{
+			name: "Hash of wrong type",
+			key:  "list",
+			write: func(key string, version int64) (int64, error) {
+				_, version, err := client.HSetCAS(ctx, key, map[string]interface{}{"name": "ivan"}, version)
+				return version, err
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			before, err := client.Version(ctx, tc.key)
+			assert.NoError(t, err)
+			version := before
+			if tc.stale {
+				version--
+			}
+
+			newVersion, err := tc.write(tc.key, version)
+			if tc.wantErr {
+				assert.Error(t, err)
+				current, err := client.Version(ctx, tc.key)
+				assert.NoError(t, err)
+				assert.Equal(t, before, current)
+				return
+			}
+
+			assert.NoError(t, err)
+			current, err := client.Version(ctx, tc.key)
+			assert.NoError(t, err)
+			assert.Equal(t, current, newVersion)
+			assert.Greater(t, newVersion, version)
+		})
+	}
+
+	// missing key never matches
+	_, err = client.SetHashCAS(ctx, "missing", map[string]interface{}{"name": "ivan"}, models.Expiration{}, 0)
+	assert.True(t, IsVersionMismatch(err))
+
+	value, err := client.GetString(ctx, "name")
+	assert.NoError(t, err)
+	assert.Equal(t, "petr", value)
+	list, err := client.GetList(ctx, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", int64(2)}, list)
+}
+
+func TestRedisVersion(t *testing.T) {
+	db, mock := newClientMock()
+	client := Redis{
+		client: db,
+	}
+
+	mock.ExpectExists("user").SetVal(1)
+	mock.ExpectGet(versionKey("user")).SetVal("5")
+	version, err := client.Version(context.Background(), "user")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), version)
+
+	// the key is written by another client of redis
+	mock.ExpectExists("user").SetVal(1)
+	mock.ExpectGet(versionKey("user")).RedisNil()
+	version, err = client.Version(context.Background(), "user")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), version)
+
+	mock.ExpectExists("missing").SetVal(0)
+	_, err = client.Version(context.Background(), "missing")
+	assert.Equal(t, redis.Nil, err)
+
+	mock.ExpectKeys("*").SetVal([]string{"user", versionKey("user")})
+	keys, err := client.GetKeys(context.Background(), "*")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user"}, keys)
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// zset - sorted set, scores of members are kept in map and members ordered by score in skiplist
+type zset struct {
+	dict map[string]float64
+	zsl  *skiplist
+}
+
+// newZSet - helper to init sorted set
+func newZSet() *zset {
+	return &zset{
+		dict: make(map[string]float64),
+		zsl:  newSkiplist(),
+	}
+}
+
+// clone - copies members, the order of nodes is the same
+func (z *zset) clone() *zset {
+	res := newZSet()
+	for x := z.zsl.header.level[0].forward; x != nil; x = x.level[0].forward {
+		res.dict[x.member] = x.score
+		res.zsl.insert(x.score, x.member)
+	}
+
+	return res
+}
+
+// add - adds the member or updates its score, returns true if the member is new
+func (z *zset) add(member string, score float64) bool {
+	current, ok := z.dict[member]
+	if ok {
+		if current != score {
+			z.zsl.delete(current, member)
+			z.zsl.insert(score, member)
+			z.dict[member] = score
+		}
+		return false
+	}
+
+	z.zsl.insert(score, member)
+	z.dict[member] = score
+	return true
+}
+
+// remove - returns false if there is no such member
+func (z *zset) remove(member string) bool {
+	score, ok := z.dict[member]
+	if !ok {
+		return false
+	}
+
+	z.zsl.delete(score, member)
+	delete(z.dict, member)
+	return true
+}
+
+// scores - members with their scores, the format of RDB entries
+func (z *zset) scores() map[string]float64 {
+	res := make(map[string]float64, len(z.dict))
+	for member, score := range z.dict {
+		res[member] = score
+	}
+
+	return res
+}
+
+// zrange - bounds of range of members, before and after tell if the node is out of the range
+type zrange interface {
+	before(node *skiplistNode) bool
+	after(node *skiplistNode) bool
+}
+
+// scoreRange - range of scores, bounds are inclusive unless they are exclusive
+type scoreRange struct {
+	min, max     float64
+	minex, maxex bool
+}
+
+func (r scoreRange) before(node *skiplistNode) bool {
+	return node.score < r.min || (r.minex && node.score == r.min)
+}
+
+func (r scoreRange) after(node *skiplistNode) bool {
+	return node.score > r.max || (r.maxex && node.score == r.max)
+}
+
+// parseScoreRange - bounds are numbers, -inf or +inf, "(" before the number makes the bound exclusive
+func parseScoreRange(min, max string) (scoreRange, error) {
+	var r scoreRange
+	var err error
+	if r.min, r.minex, err = parseScoreBound(min); err != nil {
+		return r, err
+	}
+	if r.max, r.maxex, err = parseScoreBound(max); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+func parseScoreBound(bound string) (float64, bool, error) {
+	exclusive := strings.HasPrefix(bound, "(")
+	if exclusive {
+		bound = bound[1:]
+	}
+
+	score, err := strconv.ParseFloat(bound, 64)
+	if err != nil || math.IsNaN(score) {
+		return 0, false, errMinMaxFloat
+	}
+
+	return score, exclusive, nil
+}
+
+// lexRange - range of members with the same score, "-" and "+" are the lowest and the highest strings
+type lexRange struct {
+	min, max       string
+	minex, maxex   bool
+	minInf, maxInf int
+}
+
+func (r lexRange) before(node *skiplistNode) bool {
+	switch r.minInf {
+	case -1:
+		return false
+	case 1:
+		return true
+	}
+
+	return node.member < r.min || (r.minex && node.member == r.min)
+}
+
+func (r lexRange) after(node *skiplistNode) bool {
+	switch r.maxInf {
+	case -1:
+		return true
+	case 1:
+		return false
+	}
+
+	return node.member > r.max || (r.maxex && node.member == r.max)
+}
+
+// parseLexRange - bounds start with "[" (inclusive) or "(" (exclusive), or are "-" or "+"
+func parseLexRange(min, max string) (lexRange, error) {
+	var r lexRange
+	var err error
+	if r.min, r.minex, r.minInf, err = parseLexBound(min); err != nil {
+		return r, err
+	}
+	if r.max, r.maxex, r.maxInf, err = parseLexBound(max); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+func parseLexBound(bound string) (string, bool, int, error) {
+	switch {
+	case bound == "-":
+		return "", false, -1, nil
+	case bound == "+":
+		return "", false, 1, nil
+	case strings.HasPrefix(bound, "["):
+		return bound[1:], false, 0, nil
+	case strings.HasPrefix(bound, "("):
+		return bound[1:], true, 0, nil
+	}
+
+	return "", false, 0, errMinMaxLex
+}